@@ -0,0 +1,56 @@
+package buildkitelogs
+
+import (
+	"regexp"
+	"strconv"
+)
+
+// ArtifactSummary describes the artifact uploads of a job
+type ArtifactSummary struct {
+	FileCount int      `json:"file_count"` // Files matched by the upload patterns
+	Patterns  []string `json:"patterns"`   // Upload patterns in the order they were reported
+}
+
+// artifactGroupPattern selects the groups the agent prints artifact uploads in
+const artifactGroupPattern = "uploading artifacts"
+
+// artifactFoundRegex matches the agent's report of files found for an upload pattern,
+// e.g. `Found 2 files that match "artifacts/*"`
+var artifactFoundRegex = regexp.MustCompile(`Found (\d+) files? that match (".*")`)
+
+// ArtifactSummary totals the files found for each artifact upload pattern in "Uploading artifacts" groups
+// Content is matched with ANSI sequences stripped. A log without artifact uploads returns an empty summary.
+func (pr *ParquetReader) ArtifactSummary() (ArtifactSummary, error) {
+	summary := ArtifactSummary{Patterns: []string{}}
+	seen := make(map[string]bool)
+
+	entries := FilterByGroupIter(pr.ReadColumnsIter([]string{"content", "group"}), artifactGroupPattern)
+	for entry, err := range entries {
+		if err != nil {
+			return ArtifactSummary{}, err
+		}
+
+		match := artifactFoundRegex.FindStringSubmatch(entry.CleanContent())
+		if match == nil {
+			continue
+		}
+
+		count, err := strconv.Atoi(match[1])
+		if err != nil {
+			continue
+		}
+		summary.FileCount += count
+
+		// The agent quotes the pattern with Go syntax
+		pattern, err := strconv.Unquote(match[2])
+		if err != nil {
+			pattern = match[2][1 : len(match[2])-1]
+		}
+		if !seen[pattern] {
+			seen[pattern] = true
+			summary.Patterns = append(summary.Patterns, pattern)
+		}
+	}
+
+	return summary, nil
+}
@@ -0,0 +1,52 @@
+package buildkitelogs
+
+import (
+	"os"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestArtifactSummary(t *testing.T) {
+	summary, err := NewParquetReader("testdata/bash-example.parquet").ArtifactSummary()
+	if err != nil {
+		t.Fatalf("ArtifactSummary() error = %v", err)
+	}
+	if summary.FileCount != 2 || !slices.Equal(summary.Patterns, []string{"artifacts/*"}) {
+		t.Errorf("ArtifactSummary() = %+v, want 2 files matching artifacts/*", summary)
+	}
+
+	// Reports outside an upload group are ignored, repeated patterns are listed once
+	testData := "\x1b_bk;t=1745322209921\x07--- Tests\n" +
+		"\x1b_bk;t=1745322209922\x07Found 9 files that match \"ignored/*\"\n" +
+		"\x1b_bk;t=1745322209923\x07~~~ Uploading artifacts\n" +
+		"\x1b_bk;t=1745322209924\x07\x1b[38;5;48mINFO\x1b[0m \x1b[0mFound 3 files that match \"logs/**/*.log\"\x1b[0m\n" +
+		"\x1b_bk;t=1745322209925\x07Found 1 file that match \"coverage.out\"\n" +
+		"\x1b_bk;t=1745322209926\x07~~~ Uploading artifacts\n" +
+		"\x1b_bk;t=1745322209927\x07Found 2 files that match \"logs/**/*.log\""
+
+	filename := "test_artifact_summary.parquet"
+	defer func() {
+		_ = os.Remove(filename)
+	}()
+	if err := ExportSeq2ToParquet(NewParser().All(strings.NewReader(testData)), filename); err != nil {
+		t.Fatalf("ExportSeq2ToParquet() error = %v", err)
+	}
+
+	summary, err = NewParquetReader(filename).ArtifactSummary()
+	if err != nil {
+		t.Fatalf("ArtifactSummary() error = %v", err)
+	}
+	if summary.FileCount != 6 || !slices.Equal(summary.Patterns, []string{"logs/**/*.log", "coverage.out"}) {
+		t.Errorf("ArtifactSummary() = %+v, want 6 files matching logs/**/*.log and coverage.out", summary)
+	}
+
+	// A log without an upload group has an empty summary
+	summary, err = NewParquetReader("testdata/bazel-bazel_build_32517_rocky-rocky-linux-8.parquet").ArtifactSummary()
+	if err != nil {
+		t.Fatalf("ArtifactSummary() error = %v", err)
+	}
+	if summary.FileCount != 0 || summary.Patterns == nil || len(summary.Patterns) != 0 {
+		t.Errorf("ArtifactSummary() = %+v, want an empty summary", summary)
+	}
+}
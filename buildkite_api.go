@@ -1,20 +1,62 @@
 package buildkitelogs
 
 import (
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
+	"net/url"
 	"runtime"
 	"strings"
+	"sync"
 	"time"
 )
 
+// DefaultBaseURL is the public Buildkite REST API endpoint
+const DefaultBaseURL = "https://api.buildkite.com/v2"
+
+// ClientOptions configures a BuildkiteAPIClient
+type ClientOptions struct {
+	// BaseURL overrides the API endpoint, e.g. for dedicated instances or proxies.
+	// It must be an absolute URL. Defaults to DefaultBaseURL when empty.
+	BaseURL string
+
+	// RequestsPerSecond limits how often the client makes API requests, waiting before a request
+	// when the limit has been reached. 0 leaves requests unlimited.
+	RequestsPerSecond float64
+
+	// Burst is how many requests can be made at once before RequestsPerSecond applies, defaults to 1
+	Burst int
+
+	// HTTPClient makes the API requests, e.g. with a Transport configured for a proxy or custom CA.
+	// Defaults to a client with a 30 second timeout. The User-Agent and auth headers are set either way.
+	HTTPClient *http.Client
+}
+
+// Job describes a job within a Buildkite build
+type Job struct {
+	ID    string `json:"id"`
+	Type  string `json:"type"`
+	Name  string `json:"name"`
+	State string `json:"state"`
+}
+
+// HasLog returns true if the job produces log output
+// Only script (command) jobs have logs, wait, block and trigger steps do not.
+func (j Job) HasLog() bool {
+	return j.Type == "script"
+}
+
 // BuildkiteAPIClient provides methods to interact with the Buildkite API
 type BuildkiteAPIClient struct {
 	apiToken  string
 	baseURL   string
 	userAgent string
 	client    *http.Client
+	limiter   *rateLimiter // nil when requests are unlimited
 }
 
 // NewBuildkiteAPIClient creates a new Buildkite API client
@@ -23,7 +65,7 @@ func NewBuildkiteAPIClient(apiToken, version string) *BuildkiteAPIClient {
 
 	return &BuildkiteAPIClient{
 		apiToken:  apiToken,
-		baseURL:   "https://api.buildkite.com/v2",
+		baseURL:   DefaultBaseURL,
 		userAgent: userAgent,
 		client: &http.Client{
 			Timeout: 30 * time.Second,
@@ -31,40 +73,313 @@ func NewBuildkiteAPIClient(apiToken, version string) *BuildkiteAPIClient {
 	}
 }
 
+// NewBuildkiteAPIClientWithOptions creates a new Buildkite API client using the provided options
+func NewBuildkiteAPIClientWithOptions(apiToken, version string, opts ClientOptions) (*BuildkiteAPIClient, error) {
+	client := NewBuildkiteAPIClient(apiToken, version)
+
+	if opts.BaseURL != "" {
+		baseURL, err := url.Parse(opts.BaseURL)
+		if err != nil {
+			return nil, fmt.Errorf("invalid base URL %q: %w", opts.BaseURL, err)
+		}
+		if !baseURL.IsAbs() || baseURL.Host == "" {
+			return nil, fmt.Errorf("invalid base URL %q: must be an absolute URL", opts.BaseURL)
+		}
+		client.baseURL = strings.TrimSuffix(opts.BaseURL, "/")
+	}
+
+	if opts.RequestsPerSecond < 0 || opts.Burst < 0 {
+		return nil, fmt.Errorf("invalid rate limit: requests per second and burst must not be negative")
+	}
+	if opts.RequestsPerSecond > 0 {
+		client.limiter = newRateLimiter(opts.RequestsPerSecond, opts.Burst)
+	}
+
+	if opts.HTTPClient != nil {
+		client.client = opts.HTTPClient
+	}
+
+	return client, nil
+}
+
 // GetJobLog fetches the log output for a specific job
 // org: organization slug
 // pipeline: pipeline slug
 // build: build number or UUID
 // job: job ID
 func (c *BuildkiteAPIClient) GetJobLog(org, pipeline, build, job string) (io.ReadCloser, error) {
+	return c.GetJobLogContext(context.Background(), org, pipeline, build, job)
+}
+
+// GetJobLogContext fetches the log output for a specific job, aborting when ctx is cancelled
+// The context also applies while the returned body is being read. A log split over pages linked with
+// Link rel="next" headers is read as one body, each page being fetched when the previous one is exhausted.
+func (c *BuildkiteAPIClient) GetJobLogContext(ctx context.Context, org, pipeline, build, job string) (io.ReadCloser, error) {
 	if c.apiToken == "" {
 		return nil, fmt.Errorf("API token is required")
 	}
 
-	url := fmt.Sprintf("%s/organizations/%s/pipelines/%s/builds/%s/jobs/%s/log",
+	logURL := fmt.Sprintf("%s/organizations/%s/pipelines/%s/builds/%s/jobs/%s/log",
 		c.baseURL, org, pipeline, build, job)
 
-	req, err := http.NewRequest("GET", url, nil)
+	body, header, err := c.get(ctx, logURL, "text/plain")
+	if err != nil {
+		return nil, err
+	}
+
+	next := nextPageURL(header.Get("Link"))
+	if next == "" {
+		return body, nil
+	}
+	return &pagedBody{ctx: ctx, client: c, accept: "text/plain", body: body, next: next}, nil
+}
+
+// pagedBody concatenates the pages of a paginated response, fetching each page as the previous is read
+type pagedBody struct {
+	ctx    context.Context
+	client *BuildkiteAPIClient
+	accept string
+	body   io.ReadCloser // Page being read, nil once closed or after a failed fetch
+	next   string        // URL of the following page, "" on the last page
+	err    error         // Error fetching a page, returned by every later Read
+}
+
+// Read reads from the current page, moving on to the next page at the end of each one
+func (p *pagedBody) Read(buf []byte) (int, error) {
+	for {
+		if p.err != nil {
+			return 0, p.err
+		}
+		if p.body == nil {
+			return 0, io.ErrClosedPipe
+		}
+
+		n, err := p.body.Read(buf)
+		if err != io.EOF || p.next == "" {
+			return n, err
+		}
+
+		// The page is exhausted, close it and fetch the next before returning what was read
+		_ = p.body.Close()
+		body, header, fetchErr := p.client.get(p.ctx, p.next, p.accept)
+		p.body = body
+		if fetchErr != nil {
+			p.err = fmt.Errorf("failed to fetch next page: %w", fetchErr)
+			return n, p.err
+		}
+		p.next = nextPageURL(header.Get("Link"))
+
+		if n > 0 {
+			return n, nil
+		}
+	}
+}
+
+// Close closes the page currently being read
+func (p *pagedBody) Close() error {
+	if p.body == nil {
+		return nil
+	}
+	err := p.body.Close()
+	p.body = nil
+	return err
+}
+
+// StreamJobLogToParquet fetches a job's log and streams it into a Parquet file without buffering
+// Job provenance metadata and the job_id column are filled in unless already set in opts.
+func (c *BuildkiteAPIClient) StreamJobLogToParquet(org, pipeline, build, job, outputPath string, opts ParquetOptions) error {
+	return c.StreamJobLogToParquetContext(context.Background(), org, pipeline, build, job, outputPath, opts)
+}
+
+// StreamJobLogToParquetContext streams a job's log into a Parquet file, aborting when ctx is cancelled
+func (c *BuildkiteAPIClient) StreamJobLogToParquetContext(ctx context.Context, org, pipeline, build, job, outputPath string, opts ParquetOptions) error {
+	body, err := c.GetJobLogContext(ctx, org, pipeline, build, job)
+	if err != nil {
+		return err
+	}
+	defer body.Close()
+
+	if opts.Metadata == nil {
+		opts.Metadata = JobMetadata(org, pipeline, build, job)
+	}
+	if opts.JobID == "" {
+		opts.JobID = job
+	}
+
+	// The export only needs each line's size, not its bytes
+	parser := NewParserWithOptions(ParserOptions{DropRawLine: true})
+	return ExportSeq2ToParquetWithOptions(parser.All(body), outputPath, opts)
+}
+
+// JobSpec identifies a job log to export and the Parquet file to write it to
+type JobSpec struct {
+	Org        string
+	Pipeline   string
+	Build      string
+	Job        string
+	OutputPath string
+	Options    ParquetOptions
+}
+
+// ExportBuilds streams the logs of the jobs into their Parquet files, at most concurrency at a time
+// Combine with ClientOptions.RequestsPerSecond to stay under Buildkite's API limits.
+func (c *BuildkiteAPIClient) ExportBuilds(specs []JobSpec, concurrency int) error {
+	return c.ExportBuildsContext(context.Background(), specs, concurrency)
+}
+
+// ExportBuildsContext exports the jobs' logs like ExportBuilds, aborting when ctx is cancelled
+// A failed job doesn't stop the others, the errors of every failed job are joined in the order of specs.
+func (c *BuildkiteAPIClient) ExportBuildsContext(ctx context.Context, specs []JobSpec, concurrency int) error {
+	concurrency = max(concurrency, 1)
+
+	errs := make([]error, len(specs))
+	next := make(chan int)
+
+	var wg sync.WaitGroup
+	for range min(concurrency, len(specs)) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range next {
+				spec := specs[i]
+				if err := c.StreamJobLogToParquetContext(ctx, spec.Org, spec.Pipeline, spec.Build, spec.Job, spec.OutputPath, spec.Options); err != nil {
+					errs[i] = fmt.Errorf("job %s: %w", spec.Job, err)
+				}
+			}
+		}()
+	}
+
+	// Jobs not started before cancellation fail with the context error
+	for i := range specs {
+		if err := ctx.Err(); err != nil {
+			errs[i] = fmt.Errorf("job %s: %w", specs[i].Job, err)
+			continue
+		}
+		next <- i
+	}
+	close(next)
+	wg.Wait()
+
+	return errors.Join(errs...)
+}
+
+// GetBuildJobs fetches the jobs in a build so their logs can be fetched with GetJobLog
+// org: organization slug
+// pipeline: pipeline slug
+// build: build number
+func (c *BuildkiteAPIClient) GetBuildJobs(org, pipeline, build string) ([]Job, error) {
+	return c.GetBuildJobsContext(context.Background(), org, pipeline, build)
+}
+
+// GetBuildJobsContext fetches the jobs in a build, aborting when ctx is cancelled
+// Paginated responses are followed via the Link header until every job has been read.
+func (c *BuildkiteAPIClient) GetBuildJobsContext(ctx context.Context, org, pipeline, build string) ([]Job, error) {
+	if c.apiToken == "" {
+		return nil, fmt.Errorf("API token is required")
+	}
+
+	buildURL := fmt.Sprintf("%s/organizations/%s/pipelines/%s/builds/%s",
+		c.baseURL, org, pipeline, build)
+
+	var jobs []Job
+	for pageURL := buildURL; pageURL != ""; {
+		body, header, err := c.get(ctx, pageURL, "application/json")
+		if err != nil {
+			return nil, err
+		}
+
+		var page struct {
+			Jobs []Job `json:"jobs"`
+		}
+		err = json.NewDecoder(body).Decode(&page)
+		body.Close()
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode build response: %w", err)
+		}
+
+		jobs = append(jobs, page.Jobs...)
+		pageURL = nextPageURL(header.Get("Link"))
+	}
+
+	return jobs, nil
+}
+
+// get performs an authenticated GET request, returning the (decompressed) body and response headers
+// The request waits for the client's rate limiter, if any.
+func (c *BuildkiteAPIClient) get(ctx context.Context, requestURL, accept string) (io.ReadCloser, http.Header, error) {
+	if err := c.limiter.wait(ctx); err != nil {
+		return nil, nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "GET", requestURL, nil)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %w", err)
+		return nil, nil, fmt.Errorf("failed to create request: %w", err)
 	}
 
 	// Set headers
 	req.Header.Set("Authorization", "Bearer "+c.apiToken)
-	req.Header.Set("Accept", "text/plain")
+	req.Header.Set("Accept", accept)
 	req.Header.Set("User-Agent", c.userAgent)
+	req.Header.Set("Accept-Encoding", "gzip")
 
 	resp, err := c.client.Do(req)
 	if err != nil {
-		return nil, fmt.Errorf("failed to make request: %w", err)
+		return nil, nil, fmt.Errorf("failed to make request: %w", err)
 	}
 
 	if resp.StatusCode != http.StatusOK {
 		resp.Body.Close()
-		return nil, fmt.Errorf("API request failed with status %d: %s", resp.StatusCode, resp.Status)
+		return nil, nil, fmt.Errorf("API request failed with status %d: %s", resp.StatusCode, resp.Status)
+	}
+
+	// Setting Accept-Encoding disables the transport's transparent decompression
+	if strings.EqualFold(resp.Header.Get("Content-Encoding"), "gzip") {
+		gz, err := gzip.NewReader(resp.Body)
+		if err != nil {
+			resp.Body.Close()
+			return nil, nil, fmt.Errorf("failed to decompress response: %w", err)
+		}
+		return &gzipReadCloser{Reader: gz, body: resp.Body}, resp.Header, nil
 	}
 
-	return resp.Body, nil
+	return resp.Body, resp.Header, nil
+}
+
+// nextPageURL extracts the rel="next" URL from a Link header, returning "" on the last page
+func nextPageURL(link string) string {
+	for _, part := range strings.Split(link, ",") {
+		segments := strings.Split(part, ";")
+		if len(segments) < 2 {
+			continue
+		}
+
+		target := strings.TrimSpace(segments[0])
+		if !strings.HasPrefix(target, "<") || !strings.HasSuffix(target, ">") {
+			continue
+		}
+
+		for _, param := range segments[1:] {
+			if strings.TrimSpace(param) == `rel="next"` {
+				return strings.Trim(target, "<>")
+			}
+		}
+	}
+	return ""
+}
+
+// gzipReadCloser decompresses a response body, closing both the gzip reader and the body
+type gzipReadCloser struct {
+	*gzip.Reader
+	body io.ReadCloser
+}
+
+// Close closes the gzip reader and the underlying response body
+func (g *gzipReadCloser) Close() error {
+	gzErr := g.Reader.Close()
+	if err := g.body.Close(); err != nil {
+		return err
+	}
+	return gzErr
 }
 
 // ValidateAPIParams validates that all required API parameters are provided
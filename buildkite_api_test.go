@@ -1,11 +1,20 @@
 package buildkitelogs
 
 import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"errors"
 	"fmt"
+	"io"
 	"net/http"
 	"net/http/httptest"
+	"os"
 	"runtime"
+	"strings"
+	"sync/atomic"
 	"testing"
+	"time"
 )
 
 func TestValidateAPIParams(t *testing.T) {
@@ -164,3 +173,568 @@ func TestUserAgentHeaderSet(t *testing.T) {
 		t.Errorf("Expected User-Agent %q, got %q", expectedUserAgent, capturedUserAgent)
 	}
 }
+
+func TestGetJobLogContext_Canceled(t *testing.T) {
+	// Create a test server that never responds until the client goes away
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-r.Context().Done()
+	}))
+	defer server.Close()
+
+	client := NewBuildkiteAPIClient("test-token", "test")
+	client.baseURL = server.URL
+
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(50*time.Millisecond, cancel)
+
+	_, err := client.GetJobLogContext(ctx, "org", "pipeline", "build", "job")
+	if err == nil {
+		t.Fatal("Expected error when context is cancelled")
+	}
+
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected error to wrap context.Canceled, got %v", err)
+	}
+}
+
+func TestGetJobLog_Gzip(t *testing.T) {
+	payload := "\x1b_bk;t=1745322209921\x07~~~ Running global environment hook\n"
+
+	var compressed bytes.Buffer
+	gz := gzip.NewWriter(&compressed)
+	_, _ = gz.Write([]byte(payload))
+	_ = gz.Close()
+
+	var capturedEncoding string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		capturedEncoding = r.Header.Get("Accept-Encoding")
+		w.Header().Set("Content-Encoding", "gzip")
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write(compressed.Bytes())
+	}))
+	defer server.Close()
+
+	client := NewBuildkiteAPIClient("test-token", "test")
+	client.baseURL = server.URL
+
+	body, err := client.GetJobLog("org", "pipeline", "build", "job")
+	if err != nil {
+		t.Fatalf("GetJobLog failed: %v", err)
+	}
+
+	data, err := io.ReadAll(body)
+	if err != nil {
+		t.Fatalf("Failed to read body: %v", err)
+	}
+
+	if err := body.Close(); err != nil {
+		t.Errorf("Close failed: %v", err)
+	}
+
+	if capturedEncoding != "gzip" {
+		t.Errorf("Expected Accept-Encoding %q, got %q", "gzip", capturedEncoding)
+	}
+
+	if string(data) != payload {
+		t.Errorf("Expected decompressed payload %q, got %q", payload, string(data))
+	}
+}
+
+func TestGetJobLog_Uncompressed(t *testing.T) {
+	payload := "plain log content\n"
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write([]byte(payload))
+	}))
+	defer server.Close()
+
+	client := NewBuildkiteAPIClient("test-token", "test")
+	client.baseURL = server.URL
+
+	body, err := client.GetJobLog("org", "pipeline", "build", "job")
+	if err != nil {
+		t.Fatalf("GetJobLog failed: %v", err)
+	}
+	defer body.Close()
+
+	data, err := io.ReadAll(body)
+	if err != nil {
+		t.Fatalf("Failed to read body: %v", err)
+	}
+
+	if string(data) != payload {
+		t.Errorf("Expected payload %q, got %q", payload, string(data))
+	}
+}
+
+func TestNewBuildkiteAPIClientWithOptions(t *testing.T) {
+	tests := []struct {
+		name        string
+		baseURL     string
+		expectedURL string
+		expectError bool
+	}{
+		{
+			name:        "default",
+			baseURL:     "",
+			expectedURL: DefaultBaseURL,
+		},
+		{
+			name:        "custom",
+			baseURL:     "https://buildkite.example.com/api/v2",
+			expectedURL: "https://buildkite.example.com/api/v2",
+		},
+		{
+			name:        "trailing_slash",
+			baseURL:     "http://proxy.internal:8080/v2/",
+			expectedURL: "http://proxy.internal:8080/v2",
+		},
+		{
+			name:        "relative",
+			baseURL:     "/v2",
+			expectError: true,
+		},
+		{
+			name:        "missing_host",
+			baseURL:     "https:///v2",
+			expectError: true,
+		},
+		{
+			name:        "unparseable",
+			baseURL:     "http://[::1",
+			expectError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client, err := NewBuildkiteAPIClientWithOptions("test-token", "test", ClientOptions{BaseURL: tt.baseURL})
+
+			if tt.expectError {
+				if err == nil {
+					t.Errorf("Expected error for base URL %q", tt.baseURL)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("Expected no error but got: %v", err)
+			}
+			if client.baseURL != tt.expectedURL {
+				t.Errorf("Expected base URL %q, got %q", tt.expectedURL, client.baseURL)
+			}
+		})
+	}
+}
+
+func TestGetJobLog_CustomBaseURL(t *testing.T) {
+	var capturedPath string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		capturedPath = r.URL.Path
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	client, err := NewBuildkiteAPIClientWithOptions("test-token", "test", ClientOptions{BaseURL: server.URL + "/api/v2/"})
+	if err != nil {
+		t.Fatalf("NewBuildkiteAPIClientWithOptions failed: %v", err)
+	}
+
+	body, err := client.GetJobLog("org", "pipeline", "123", "job")
+	if err != nil {
+		t.Fatalf("GetJobLog failed: %v", err)
+	}
+	_ = body.Close()
+
+	expectedPath := "/api/v2/organizations/org/pipelines/pipeline/builds/123/jobs/job/log"
+	if capturedPath != expectedPath {
+		t.Errorf("Expected path %q, got %q", expectedPath, capturedPath)
+	}
+}
+
+func TestGetBuildJobs_Pagination(t *testing.T) {
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/organizations/org/pipelines/pipeline/builds/123" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		if r.URL.Query().Get("page") == "2" {
+			_, _ = w.Write([]byte(`{"jobs":[{"id":"job-3","type":"script","name":"deploy","state":"failed"}]}`))
+			return
+		}
+
+		w.Header().Set("Link", fmt.Sprintf(`<%s/organizations/org/pipelines/pipeline/builds/123?page=2>; rel="next", <%s/organizations/org/pipelines/pipeline/builds/123?page=2>; rel="last"`, server.URL, server.URL))
+		_, _ = w.Write([]byte(`{"jobs":[{"id":"job-1","type":"script","name":"test","state":"passed"},{"id":"job-2","type":"waiter","state":"passed"}]}`))
+	}))
+	defer server.Close()
+
+	client := NewBuildkiteAPIClient("test-token", "test")
+	client.baseURL = server.URL
+
+	jobs, err := client.GetBuildJobs("org", "pipeline", "123")
+	if err != nil {
+		t.Fatalf("GetBuildJobs failed: %v", err)
+	}
+
+	expected := []Job{
+		{ID: "job-1", Type: "script", Name: "test", State: "passed"},
+		{ID: "job-2", Type: "waiter", State: "passed"},
+		{ID: "job-3", Type: "script", Name: "deploy", State: "failed"},
+	}
+	if len(jobs) != len(expected) {
+		t.Fatalf("Expected %d jobs, got %d: %+v", len(expected), len(jobs), jobs)
+	}
+	for i := range expected {
+		if jobs[i] != expected[i] {
+			t.Errorf("Job %d: expected %+v, got %+v", i, expected[i], jobs[i])
+		}
+	}
+
+	if !jobs[0].HasLog() || jobs[1].HasLog() {
+		t.Error("Expected only script jobs to have logs")
+	}
+}
+
+func TestGetBuildJobs_NoToken(t *testing.T) {
+	client := NewBuildkiteAPIClient("", "test")
+
+	if _, err := client.GetBuildJobs("org", "pipeline", "123"); err == nil {
+		t.Error("Expected error when API token is empty")
+	}
+}
+
+func TestNextPageURL(t *testing.T) {
+	tests := []struct {
+		name     string
+		link     string
+		expected string
+	}{
+		{
+			name:     "empty",
+			link:     "",
+			expected: "",
+		},
+		{
+			name:     "next_and_last",
+			link:     `<https://api.buildkite.com/v2/builds?page=2>; rel="next", <https://api.buildkite.com/v2/builds?page=5>; rel="last"`,
+			expected: "https://api.buildkite.com/v2/builds?page=2",
+		},
+		{
+			name:     "last_page",
+			link:     `<https://api.buildkite.com/v2/builds?page=1>; rel="first", <https://api.buildkite.com/v2/builds?page=4>; rel="prev"`,
+			expected: "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := nextPageURL(tt.link); got != tt.expected {
+				t.Errorf("Expected %q, got %q", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestStreamJobLogToParquet(t *testing.T) {
+	payload := "\x1b_bk;t=1745322209921\x07~~~ Running tests\n" +
+		"\x1b_bk;t=1745322209922\x07$ make test\n" +
+		"\x1b_bk;t=1745322209923\x07ok\n"
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write([]byte(payload))
+	}))
+	defer server.Close()
+
+	client := NewBuildkiteAPIClient("test-token", "test")
+	client.baseURL = server.URL
+
+	filename := "test_stream_job_log.parquet"
+	defer func() {
+		_ = os.Remove(filename)
+	}()
+
+	if err := client.StreamJobLogToParquet("org", "pipeline", "123", "job-1", filename, ParquetOptions{}); err != nil {
+		t.Fatalf("StreamJobLogToParquet failed: %v", err)
+	}
+
+	var entries []ParquetLogEntry
+	for entry, err := range ReadParquetFileIter(filename) {
+		if err != nil {
+			t.Fatalf("ReadParquetFileIter failed: %v", err)
+		}
+		entries = append(entries, entry)
+	}
+
+	if len(entries) != 3 {
+		t.Fatalf("Expected 3 entries, got %d", len(entries))
+	}
+	if entries[0].Timestamp != 1745322209921 || !entries[0].IsGroup {
+		t.Errorf("Unexpected first entry: %+v", entries[0])
+	}
+	if entries[1].Content != "$ make test" || !entries[1].IsCommand {
+		t.Errorf("Unexpected second entry: %+v", entries[1])
+	}
+	for i, entry := range entries {
+		if entry.JobID != "job-1" {
+			t.Errorf("Entry %d: JobID = %q, want %q", i, entry.JobID, "job-1")
+		}
+	}
+
+	info, err := NewParquetReader(filename).GetFileInfo()
+	if err != nil {
+		t.Fatalf("GetFileInfo failed: %v", err)
+	}
+	if info.Metadata[MetadataBuild] != "123" || info.Metadata[MetadataJob] != "job-1" {
+		t.Errorf("Unexpected metadata: %v", info.Metadata)
+	}
+}
+
+func TestStreamJobLogToParquet_RequestFailed(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer server.Close()
+
+	client := NewBuildkiteAPIClient("test-token", "test")
+	client.baseURL = server.URL
+
+	filename := "test_stream_job_log_failed.parquet"
+	defer func() {
+		_ = os.Remove(filename)
+	}()
+
+	if err := client.StreamJobLogToParquet("org", "pipeline", "123", "job-1", filename, ParquetOptions{}); err == nil {
+		t.Fatal("Expected error for failed request")
+	}
+
+	if _, err := os.Stat(filename); !os.IsNotExist(err) {
+		t.Error("Output file should not be created when the request fails")
+	}
+}
+
+func TestNewBuildkiteAPIClientWithOptions_RateLimit(t *testing.T) {
+	client, err := NewBuildkiteAPIClientWithOptions("test-token", "test", ClientOptions{})
+	if err != nil {
+		t.Fatalf("NewBuildkiteAPIClientWithOptions failed: %v", err)
+	}
+	if client.limiter != nil {
+		t.Error("Expected no rate limiter by default")
+	}
+
+	client, err = NewBuildkiteAPIClientWithOptions("test-token", "test", ClientOptions{RequestsPerSecond: 5})
+	if err != nil {
+		t.Fatalf("NewBuildkiteAPIClientWithOptions failed: %v", err)
+	}
+	if client.limiter == nil || client.limiter.burst != 1 {
+		t.Errorf("Expected a rate limiter with a burst of 1, got %+v", client.limiter)
+	}
+
+	if _, err := NewBuildkiteAPIClientWithOptions("test-token", "test", ClientOptions{RequestsPerSecond: -1}); err == nil {
+		t.Error("Expected error for a negative rate limit")
+	}
+}
+
+func TestExportBuilds(t *testing.T) {
+	payload := "\x1b_bk;t=1745322209921\x07~~~ Running tests\n" +
+		"\x1b_bk;t=1745322209922\x07ok\n"
+
+	var inFlight, maxInFlight atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := inFlight.Add(1)
+		defer inFlight.Add(-1)
+		for {
+			m := maxInFlight.Load()
+			if n <= m || maxInFlight.CompareAndSwap(m, n) {
+				break
+			}
+		}
+		time.Sleep(20 * time.Millisecond)
+
+		if strings.HasSuffix(r.URL.Path, "/jobs/missing/log") {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write([]byte(payload))
+	}))
+	defer server.Close()
+
+	client := NewBuildkiteAPIClient("test-token", "test")
+	client.baseURL = server.URL
+
+	var specs []JobSpec
+	for _, job := range []string{"job-1", "job-2", "missing", "job-3", "job-4"} {
+		filename := "test_export_builds_" + job + ".parquet"
+		defer func() {
+			_ = os.Remove(filename)
+		}()
+		specs = append(specs, JobSpec{Org: "org", Pipeline: "pipeline", Build: "123", Job: job, OutputPath: filename})
+	}
+
+	err := client.ExportBuilds(specs, 2)
+	if err == nil || !strings.Contains(err.Error(), "job missing:") {
+		t.Fatalf("ExportBuilds() error = %v, want the missing job's error", err)
+	}
+	if got := maxInFlight.Load(); got > 2 {
+		t.Errorf("ExportBuilds() made %d concurrent requests, want at most 2", got)
+	}
+
+	for _, spec := range specs {
+		if spec.Job == "missing" {
+			continue
+		}
+		entries := readAllParquetEntries(t, spec.OutputPath)
+		if len(entries) != 2 || entries[0].JobID != spec.Job {
+			t.Errorf("%s: unexpected entries %+v", spec.OutputPath, entries)
+		}
+	}
+}
+
+func TestExportBuilds_Cancelled(t *testing.T) {
+	client := NewBuildkiteAPIClient("test-token", "test")
+	client.baseURL = "http://127.0.0.1:0"
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	specs := []JobSpec{{Org: "org", Pipeline: "pipeline", Build: "123", Job: "job-1", OutputPath: "test_export_builds_cancelled.parquet"}}
+	if err := client.ExportBuildsContext(ctx, specs, 1); !errors.Is(err, context.Canceled) {
+		t.Errorf("ExportBuildsContext() error = %v, want %v", err, context.Canceled)
+	}
+}
+
+// roundTripFunc adapts a function to an http.RoundTripper
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+func TestNewBuildkiteAPIClientWithOptions_HTTPClient(t *testing.T) {
+	var capturedUserAgent, capturedAuth string
+	httpClient := &http.Client{
+		Transport: roundTripFunc(func(req *http.Request) (*http.Response, error) {
+			capturedUserAgent = req.Header.Get("User-Agent")
+			capturedAuth = req.Header.Get("Authorization")
+			return &http.Response{
+				StatusCode: http.StatusOK,
+				Header:     http.Header{},
+				Body:       io.NopCloser(strings.NewReader("test log content")),
+				Request:    req,
+			}, nil
+		}),
+	}
+
+	client, err := NewBuildkiteAPIClientWithOptions("test-token", "v1.2.3", ClientOptions{HTTPClient: httpClient})
+	if err != nil {
+		t.Fatalf("NewBuildkiteAPIClientWithOptions failed: %v", err)
+	}
+
+	body, err := client.GetJobLog("org", "pipeline", "build", "job")
+	if err != nil {
+		t.Fatalf("GetJobLog failed: %v", err)
+	}
+	defer body.Close()
+
+	content, err := io.ReadAll(body)
+	if err != nil {
+		t.Fatalf("Failed to read body: %v", err)
+	}
+	if string(content) != "test log content" {
+		t.Errorf("Expected content from the injected client, got %q", content)
+	}
+
+	expectedUserAgent := fmt.Sprintf("buildkite-logs-parquet/v1.2.3 (Go; %s; %s)", runtime.GOOS, runtime.GOARCH)
+	if capturedUserAgent != expectedUserAgent {
+		t.Errorf("Expected User-Agent %q, got %q", expectedUserAgent, capturedUserAgent)
+	}
+	if capturedAuth != "Bearer test-token" {
+		t.Errorf("Expected Authorization header to be set, got %q", capturedAuth)
+	}
+
+	client, err = NewBuildkiteAPIClientWithOptions("test-token", "v1.2.3", ClientOptions{})
+	if err != nil {
+		t.Fatalf("NewBuildkiteAPIClientWithOptions failed: %v", err)
+	}
+	if client.client.Timeout != 30*time.Second {
+		t.Errorf("Expected default client with a 30s timeout, got %v", client.client.Timeout)
+	}
+}
+
+func TestGetJobLog_Pagination(t *testing.T) {
+	const logPath = "/organizations/org/pipelines/pipeline/builds/123/jobs/job-1/log"
+
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != logPath {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+
+		switch r.URL.Query().Get("page") {
+		case "":
+			w.Header().Set("Link", fmt.Sprintf(`<%s%s?page=2>; rel="next"`, server.URL, logPath))
+			_, _ = w.Write([]byte("first line\nsecond "))
+		case "2":
+			// Later pages may be compressed independently of the first
+			w.Header().Set("Content-Encoding", "gzip")
+			gz := gzip.NewWriter(w)
+			_, _ = gz.Write([]byte("line\nthird line\n"))
+			_ = gz.Close()
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	client := NewBuildkiteAPIClient("test-token", "test")
+	client.baseURL = server.URL
+
+	body, err := client.GetJobLog("org", "pipeline", "123", "job-1")
+	if err != nil {
+		t.Fatalf("GetJobLog failed: %v", err)
+	}
+	defer body.Close()
+
+	content, err := io.ReadAll(body)
+	if err != nil {
+		t.Fatalf("Failed to read body: %v", err)
+	}
+	if string(content) != "first line\nsecond line\nthird line\n" {
+		t.Errorf("Expected the pages concatenated, got %q", content)
+	}
+}
+
+func TestGetJobLog_PaginationFailed(t *testing.T) {
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("page") == "2" {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Link", fmt.Sprintf(`<%s%s?page=2>; rel="next"`, server.URL, r.URL.Path))
+		_, _ = w.Write([]byte("first page\n"))
+	}))
+	defer server.Close()
+
+	client := NewBuildkiteAPIClient("test-token", "test")
+	client.baseURL = server.URL
+
+	body, err := client.GetJobLog("org", "pipeline", "123", "job-1")
+	if err != nil {
+		t.Fatalf("GetJobLog failed: %v", err)
+	}
+	defer body.Close()
+
+	content, err := io.ReadAll(body)
+	if err == nil || !strings.Contains(err.Error(), "status 500") {
+		t.Errorf("Expected the failed page to be reported, got %v", err)
+	}
+	if string(content) != "first page\n" {
+		t.Errorf("Expected the first page to be read, got %q", content)
+	}
+}
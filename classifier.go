@@ -0,0 +1,111 @@
+package buildkitelogs
+
+import "strings"
+
+// EntryFlags is the set of types a log entry was classified as
+type EntryFlags uint8
+
+const (
+	FlagCommand  EntryFlags = 1 << iota // Command execution, e.g. "$ make test"
+	FlagGroup                           // Group header starting a new section
+	FlagProgress                        // Terminal progress update
+	FlagError                           // Error message
+	FlagWarning                         // Warning message, ignored if FlagError is also set
+)
+
+// Has returns true if every flag in flag is set
+func (f EntryFlags) Has(flag EntryFlags) bool {
+	return f&flag == flag
+}
+
+// Classifier decides the types of a log entry, see ParserOptions.Classifier
+// Classify is called once per line, after the timestamp and content are parsed but before the entry
+// is assigned to a group, so a FlagGroup result starts a new group with the entry as its header.
+type Classifier interface {
+	Classify(entry *LogEntry) EntryFlags
+}
+
+// DefaultClassifier is the built-in heuristic classifier
+//
+//   - Commands start with "$ "
+//   - Group headers start with "~~~", "---" or "+++"
+//   - Progress updates contain an erase-in-line sequence ([K) and git progress text
+//   - Errors and warnings match the Severity patterns
+//
+// Custom classifiers can embed it and add flags to the result of its Classify method.
+type DefaultClassifier struct {
+	Severity *SeverityPatterns // Patterns for FlagError and FlagWarning, nil uses DefaultSeverityPatterns
+}
+
+// Classify returns the flags the built-in heuristics assign to the entry
+func (c DefaultClassifier) Classify(entry *LogEntry) EntryFlags {
+	clean := entry.CleanContent()
+
+	var flags EntryFlags
+	if isCommandContent(clean) {
+		flags |= FlagCommand
+	}
+	if isGroupContent(clean) {
+		flags |= FlagGroup
+	}
+	if isProgressContent(entry.Content, clean) {
+		flags |= FlagProgress
+	}
+
+	severity := c.Severity
+	if severity == nil {
+		severity = defaultSeverityPatterns
+	}
+	if matchesSeverity(entry.Content, clean, severity.Error, severity.ErrorColors) {
+		flags |= FlagError
+	} else if matchesSeverity(entry.Content, clean, severity.Warning, severity.WarningColors) {
+		flags |= FlagWarning
+	}
+
+	return flags
+}
+
+// isCommandContent returns true if the ANSI-stripped content looks like a command execution
+func isCommandContent(clean string) bool {
+	return strings.HasPrefix(clean, "$ ")
+}
+
+// isGroupContent returns true if the ANSI-stripped content looks like a group header
+func isGroupContent(clean string) bool {
+	return strings.HasPrefix(clean, "~~~") || strings.HasPrefix(clean, "---") || strings.HasPrefix(clean, "+++")
+}
+
+// isProgressContent returns true if the content looks like a progress update
+// Progress lines are identified by [K (erase-in-line) sequences anywhere in the content,
+// but more conservatively than before - looking for the specific pattern where [K
+// appears in contexts that indicate terminal progress updates
+func isProgressContent(content, clean string) bool {
+	// Look for [K sequences in the content
+	if !strings.Contains(content, "[K") {
+		return false
+	}
+
+	// Additional validation: should be git progress-related content
+	return strings.Contains(clean, "objects") ||
+		strings.Contains(clean, "deltas") ||
+		strings.Contains(clean, "%")
+}
+
+// matchesSeverity checks the raw content for color patterns and the start of the clean content for text patterns
+// Anchoring the text keeps mentions such as "0 error: none" or "-WARNING flag" from matching.
+func matchesSeverity(content, clean string, textPatterns, colorPatterns []string) bool {
+	for _, pattern := range colorPatterns {
+		if strings.Contains(content, pattern) {
+			return true
+		}
+	}
+
+	clean = strings.TrimSpace(clean)
+	for _, pattern := range textPatterns {
+		if strings.HasPrefix(clean, pattern) {
+			return true
+		}
+	}
+
+	return false
+}
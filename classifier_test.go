@@ -0,0 +1,122 @@
+package buildkitelogs
+
+import (
+	"os"
+	"strings"
+	"testing"
+)
+
+// goTestClassifier treats go test "=== RUN" lines as group headers and "--- FAIL" lines as errors
+type goTestClassifier struct {
+	DefaultClassifier
+}
+
+func (c goTestClassifier) Classify(entry *LogEntry) EntryFlags {
+	clean := entry.CleanContent()
+	switch {
+	case strings.HasPrefix(clean, "=== RUN"):
+		return FlagGroup
+	case strings.HasPrefix(clean, "--- FAIL"):
+		return FlagError
+	case strings.HasPrefix(clean, "--- PASS"):
+		return 0
+	}
+	return c.DefaultClassifier.Classify(entry)
+}
+
+func TestParserClassifier(t *testing.T) {
+	testData := "\x1b_bk;t=1745322209921\x07$ go test ./...\n" +
+		"\x1b_bk;t=1745322209922\x07=== RUN   TestA\n" +
+		"\x1b_bk;t=1745322209923\x07--- PASS: TestA (0.00s)\n" +
+		"\x1b_bk;t=1745322209924\x07=== RUN   TestB\n" +
+		"\x1b_bk;t=1745322209925\x07--- FAIL: TestB (0.01s)"
+
+	parser := NewParserWithOptions(ParserOptions{Classifier: goTestClassifier{}})
+
+	var entries []*LogEntry
+	for entry, err := range parser.All(strings.NewReader(testData)) {
+		if err != nil {
+			t.Fatalf("Parse error: %v", err)
+		}
+		entries = append(entries, entry)
+	}
+
+	expected := []struct {
+		flags EntryFlags
+		group string
+	}{
+		{FlagCommand, ""},
+		{FlagGroup, "=== RUN   TestA"},
+		{0, "=== RUN   TestA"},
+		{FlagGroup, "=== RUN   TestB"},
+		{FlagError, "=== RUN   TestB"},
+	}
+	if len(entries) != len(expected) {
+		t.Fatalf("Expected %d entries, got %d", len(expected), len(entries))
+	}
+	for i, want := range expected {
+		entry := entries[i]
+		if entry.Flags() != want.flags {
+			t.Errorf("Entry %d: Flags() = %b, want %b", i, entry.Flags(), want.flags)
+		}
+		if entry.Group != want.group {
+			t.Errorf("Entry %d: Group = %q, want %q", i, entry.Group, want.group)
+		}
+		if entry.IsGroup() != want.flags.Has(FlagGroup) || entry.IsError() != want.flags.Has(FlagError) || entry.IsCommand() != want.flags.Has(FlagCommand) {
+			t.Errorf("Entry %d: Is* methods disagree with flags %b", i, want.flags)
+		}
+	}
+
+	// The Parquet columns come from the classifier too
+	filename := "test_classifier.parquet"
+	defer func() {
+		_ = os.Remove(filename)
+	}()
+	if err := ExportToParquet(entries, filename); err != nil {
+		t.Fatalf("ExportToParquet() error = %v", err)
+	}
+	for i, entry := range readAllParquetEntries(t, filename) {
+		if entry.IsGroup != expected[i].flags.Has(FlagGroup) || entry.IsError != expected[i].flags.Has(FlagError) {
+			t.Errorf("Row %d: is_group = %t, is_error = %t, want flags %b", i, entry.IsGroup, entry.IsError, expected[i].flags)
+		}
+	}
+}
+
+func TestDefaultClassifier(t *testing.T) {
+	tests := []struct {
+		content string
+		flags   EntryFlags
+	}{
+		{"$ make test", FlagCommand},
+		{"~~~ Running global environment hook", FlagGroup},
+		{"+++ :test_tube: Tests", FlagGroup},
+		{"remote: Counting objects:  50% (1/2)\x1b[K", FlagProgress},
+		{"🚨 Error: build failed", FlagError},
+		{"\x1b[33mWARNING\x1b[0m deprecated flag", FlagWarning},
+		{"\x1b[31mFAILED\x1b[0m with WARNING", FlagError},
+		{"just output", 0},
+	}
+
+	for _, tt := range tests {
+		entry, err := NewByteParser().ParseLine(tt.content)
+		if err != nil {
+			t.Fatalf("ParseLine(%q) error = %v", tt.content, err)
+		}
+
+		// Entries not from a Parser are classified on demand with the same heuristics
+		if got := entry.Flags(); got != tt.flags {
+			t.Errorf("Flags(%q) = %b, want %b", tt.content, got, tt.flags)
+		}
+
+		parsed, err := NewParser().ParseLine(tt.content)
+		if err != nil {
+			t.Fatalf("ParseLine(%q) error = %v", tt.content, err)
+		}
+		if got := parsed.Flags(); got != tt.flags {
+			t.Errorf("Parser Flags(%q) = %b, want %b", tt.content, got, tt.flags)
+		}
+		if parsed.IsWarning() != tt.flags.Has(FlagWarning) {
+			t.Errorf("IsWarning(%q) = %t", tt.content, parsed.IsWarning())
+		}
+	}
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"fmt"
+	"os"
+)
+
+// ANSI styles used for colorized text output
+const (
+	styleDim   = "\x1b[2m"
+	styleBold  = "\x1b[1m"
+	styleCyan  = "\x1b[36m"
+	styleRed   = "\x1b[31m"
+	styleReset = "\x1b[0m"
+)
+
+// colorizer styles text output, returning text unchanged when colors are disabled
+type colorizer struct {
+	enabled bool
+}
+
+// newColorizer resolves a -color mode: "always", "never", or "auto" to color only when out is a terminal
+// Auto mode also honours the NO_COLOR convention.
+func newColorizer(mode string, out *os.File) (colorizer, error) {
+	switch mode {
+	case "always":
+		return colorizer{enabled: true}, nil
+	case "never":
+		return colorizer{}, nil
+	case "", "auto":
+		return colorizer{enabled: os.Getenv("NO_COLOR") == "" && isTerminal(out)}, nil
+	default:
+		return colorizer{}, fmt.Errorf("unknown color mode: %s (supported: auto, always, never)", mode)
+	}
+}
+
+// isTerminal returns true if the file is a character device such as a terminal
+func isTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	if err != nil {
+		return false
+	}
+	return info.Mode()&os.ModeCharDevice != 0
+}
+
+// timestamp dims a timestamp
+func (c colorizer) timestamp(text string) string {
+	return c.style(styleDim, text)
+}
+
+// content styles entry content by type: errors red, group headers bold and commands cyan
+func (c colorizer) content(text string, isGroup, isCommand, isError bool) string {
+	switch {
+	case !c.enabled:
+		return text
+	case isError:
+		return c.style(styleRed, text)
+	case isGroup:
+		return c.style(styleBold, text)
+	case isCommand:
+		return c.style(styleCyan, text)
+	default:
+		return text
+	}
+}
+
+// style wraps text in an ANSI style when colors are enabled
+func (c colorizer) style(style, text string) string {
+	if !c.enabled {
+		return text
+	}
+	return style + text + styleReset
+}
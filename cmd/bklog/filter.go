@@ -0,0 +1,126 @@
+package main
+
+import (
+	"fmt"
+	"time"
+
+	buildkitelogs "github.com/wolfeidau/buildkite-logs-parquet"
+)
+
+// entryFilter selects the entries the parse command outputs or exports
+type entryFilter struct {
+	// Entry type: command, group or progress, empty for all
+	Type string
+	// Inclusive time bounds, zero when unset
+	Since time.Time
+	Until time.Time
+}
+
+// active returns true if the filter excludes anything
+func (f entryFilter) active() bool {
+	return f.Type != "" || !f.Since.IsZero() || !f.Until.IsZero()
+}
+
+// hasTimeBounds returns true if -since or -until is set
+func (f entryFilter) hasTimeBounds() bool {
+	return !f.Since.IsZero() || !f.Until.IsZero()
+}
+
+// timeBound is a -since or -until value: an absolute time, or a duration back from the last timestamp in the log
+type timeBound struct {
+	at  time.Time
+	ago time.Duration
+}
+
+// parseTimeBound parses an RFC3339 time or a duration such as 5m
+func parseTimeBound(value string) (timeBound, error) {
+	if value == "" {
+		return timeBound{}, nil
+	}
+
+	if ago, err := time.ParseDuration(value); err == nil {
+		if ago < 0 {
+			return timeBound{}, fmt.Errorf("invalid time bound %q: duration must not be negative", value)
+		}
+		return timeBound{ago: ago}, nil
+	}
+
+	at, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return timeBound{}, fmt.Errorf("invalid time bound %q: expected an RFC3339 time or a duration such as 5m", value)
+	}
+	return timeBound{at: at}, nil
+}
+
+// relative returns true if the bound is a duration back from the last timestamp
+func (b timeBound) relative() bool {
+	return b.at.IsZero() && b.ago > 0
+}
+
+// resolve returns the bound as a time, measuring relative bounds back from last
+func (b timeBound) resolve(last time.Time) time.Time {
+	if b.relative() {
+		return last.Add(-b.ago)
+	}
+	return b.at
+}
+
+// lastTimestamp parses the whole log file at path to find its latest timestamp, before the real pass opens it
+// again. Stdin and API sources can only be read once, so relative bounds only work with a local file.
+// Implausible timestamps are ignored when validate is set, as the real pass drops them.
+func lastTimestamp(path string, validate bool) (time.Time, error) {
+	if path == "" || path == "-" {
+		return time.Time{}, fmt.Errorf("relative -since/-until durations need a local -file, use an RFC3339 time for stdin or API sources")
+	}
+
+	reader, err := buildkitelogs.OpenLogFile(path)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("failed to open file: %w", err)
+	}
+	defer reader.Close()
+
+	parser := buildkitelogs.NewParserWithOptions(buildkitelogs.ParserOptions{
+		ValidateTimestamps: validate,
+		DropRawLine:        true,
+		ReuseEntries:       true,
+	})
+
+	var last time.Time
+	for entry, err := range parser.All(reader) {
+		if err != nil {
+			continue // The real pass reports parse errors
+		}
+		if entry.Timestamp.After(last) {
+			last = entry.Timestamp
+		}
+	}
+
+	return last, nil
+}
+
+// newEntryFilter builds the filter for the parse command, resolving relative time bounds against the log
+func newEntryFilter(config *Config) (entryFilter, error) {
+	filter := entryFilter{Type: config.Filter}
+
+	since, err := parseTimeBound(config.Since)
+	if err != nil {
+		return entryFilter{}, err
+	}
+	until, err := parseTimeBound(config.Until)
+	if err != nil {
+		return entryFilter{}, err
+	}
+
+	var last time.Time
+	if since.relative() || until.relative() {
+		last, err = lastTimestamp(config.FilePath, config.ValidateTimestamps)
+		if err != nil {
+			return entryFilter{}, err
+		}
+	}
+
+	filter.Since = since.resolve(last)
+	filter.Until = until.resolve(last)
+
+	return filter, nil
+}
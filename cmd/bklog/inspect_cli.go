@@ -0,0 +1,184 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+
+	buildkitelogs "github.com/wolfeidau/buildkite-logs-parquet"
+)
+
+// InspectConfig holds configuration for the inspect command
+type InspectConfig struct {
+	ParquetFile string
+	Format      string // "text" or "json"
+	TimeFormat  string // -time-format value
+}
+
+// inspectResult is everything inspect reports about a file
+type inspectResult struct {
+	File string `json:"file"`
+	*buildkitelogs.ParquetFileInfo
+	Schema  *schemaInfo                 `json:"schema"`
+	Columns []buildkitelogs.ColumnStats `json:"columns"`
+	// First and last timestamp from the timestamp column statistics, absent if the file has none
+	FirstTimestamp *time.Time `json:"first_timestamp,omitempty"`
+	LastTimestamp  *time.Time `json:"last_timestamp,omitempty"`
+	// Number of distinct group names, absent unless the file is a compatible log file
+	DistinctGroups *int `json:"distinct_groups,omitempty"`
+}
+
+// runInspect prints the schema and per-column statistics of a Parquet file
+// Everything but the distinct group count comes from the file footer, so files from other tools
+// that our readers reject can still be inspected.
+func runInspect(out io.Writer, config *InspectConfig) error {
+	if config.Format != "text" && config.Format != "json" {
+		return fmt.Errorf("unknown format: %s (supported: text, json)", config.Format)
+	}
+	times, err := parseTimeFormat(config.TimeFormat)
+	if err != nil {
+		return err
+	}
+
+	reader := buildkitelogs.NewParquetReader(config.ParquetFile)
+
+	info, err := reader.GetFileInfo()
+	if err != nil {
+		return fmt.Errorf("failed to get file info: %w", err)
+	}
+
+	schema, err := readSchemaInfo(reader)
+	if err != nil {
+		return err
+	}
+
+	columns, err := reader.ColumnStats()
+	if err != nil {
+		return fmt.Errorf("failed to read column statistics: %w", err)
+	}
+
+	result := inspectResult{
+		File:            config.ParquetFile,
+		ParquetFileInfo: info,
+		Schema:          schema,
+		Columns:         columns,
+	}
+	result.FirstTimestamp, result.LastTimestamp = timestampRange(columns)
+
+	if schema.Error == "" {
+		groups, err := reader.DistinctGroups()
+		if err != nil {
+			return fmt.Errorf("failed to read groups: %w", err)
+		}
+		count := len(groups)
+		result.DistinctGroups = &count
+	}
+
+	if config.Format == "json" {
+		encoder := json.NewEncoder(out)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(newJSONInspectResult(result, times))
+	}
+
+	return formatInspectText(out, result, times)
+}
+
+// jsonInspectResult is the inspect result as written to JSON output, with its timestamps in the -time-format
+type jsonInspectResult struct {
+	inspectResult
+	FirstTimestamp any `json:"first_timestamp,omitempty"`
+	LastTimestamp  any `json:"last_timestamp,omitempty"`
+}
+
+// newJSONInspectResult converts the inspect result for JSON output
+func newJSONInspectResult(result inspectResult, times timeFormat) jsonInspectResult {
+	converted := jsonInspectResult{inspectResult: result}
+	if result.FirstTimestamp != nil {
+		converted.FirstTimestamp = times.jsonValue(*result.FirstTimestamp, result.FirstTimestamp)
+		converted.LastTimestamp = times.jsonValue(*result.LastTimestamp, result.LastTimestamp)
+	}
+	return converted
+}
+
+// timestampRange returns the min and max of the timestamp column statistics as times
+func timestampRange(columns []buildkitelogs.ColumnStats) (*time.Time, *time.Time) {
+	for _, column := range columns {
+		if column.Name != "timestamp" || !column.HasMinMax {
+			continue
+		}
+
+		minMs, minErr := strconv.ParseInt(column.Min, 10, 64)
+		maxMs, maxErr := strconv.ParseInt(column.Max, 10, 64)
+		if minErr != nil || maxErr != nil {
+			return nil, nil
+		}
+
+		first, last := time.UnixMilli(minMs).UTC(), time.UnixMilli(maxMs).UTC()
+		return &first, &last
+	}
+	return nil, nil
+}
+
+// formatInspectText writes the inspect result as text
+func formatInspectText(out io.Writer, result inspectResult, times timeFormat) error {
+	fmt.Fprintf(out, "File:           %s\n", result.File)
+	fmt.Fprintf(out, "Rows:           %d\n", result.RowCount)
+	fmt.Fprintf(out, "Row Groups:     %d\n", result.NumRowGroups)
+	fmt.Fprintf(out, "File Size:      %d bytes (%s)\n", result.FileSize, humanizeBytes(result.FileSize))
+	fmt.Fprintf(out, "Log Schema:     v%d\n", result.SchemaVersion)
+	if result.Schema.Error != "" {
+		fmt.Fprintf(out, "Compatible:     no (%s)\n", result.Schema.Error)
+	} else {
+		fmt.Fprintf(out, "Compatible:     yes\n")
+	}
+	if result.FirstTimestamp != nil {
+		fmt.Fprintf(out, "Timestamps:     %s to %s\n",
+			times.format(*result.FirstTimestamp, textTimeLayout),
+			times.format(*result.LastTimestamp, textTimeLayout))
+	}
+	if result.DistinctGroups != nil {
+		fmt.Fprintf(out, "Groups:         %d distinct\n", *result.DistinctGroups)
+	}
+
+	fmt.Fprintf(out, "\nSchema:\n")
+	for _, column := range result.Schema.Columns {
+		fmt.Fprintf(out, "  %-16s %s\n", column.Name, column.Type)
+	}
+
+	fmt.Fprintf(out, "\nColumn Statistics:\n")
+	fmt.Fprintf(out, "  %-16s %-10s %8s %10s %-24s %-24s %s\n", "COLUMN", "PHYSICAL", "NULLS", "SIZE", "MIN", "MAX", "LOGICAL")
+	for _, column := range result.Columns {
+		nulls := "-"
+		if column.HasNullCount {
+			nulls = strconv.FormatInt(column.NullCount, 10)
+		}
+		logical := column.LogicalType
+		if logical == "" {
+			logical = "-"
+		}
+		minValue, maxValue := "-", "-"
+		if column.HasMinMax {
+			minValue, maxValue = statValue(column, column.Min), statValue(column, column.Max)
+		}
+		fmt.Fprintf(out, "  %-16s %-10s %8s %10s %-24s %-24s %s\n",
+			truncateString(column.Name, 16),
+			column.PhysicalType,
+			nulls,
+			humanizeBytes(column.CompressedSize),
+			truncateString(minValue, 24),
+			truncateString(maxValue, 24),
+			logical)
+	}
+
+	return nil
+}
+
+// statValue formats a min or max for the statistics table, quoting strings so control characters show
+func statValue(column buildkitelogs.ColumnStats, value string) string {
+	if column.PhysicalType == "BYTE_ARRAY" || column.PhysicalType == "FIXED_LEN_BYTE_ARRAY" {
+		return strconv.Quote(value)
+	}
+	return value
+}
@@ -0,0 +1,54 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+)
+
+// jsonArrayWriter streams values as an indented JSON array, one element at a time
+// The output matches encoding a whole slice with SetIndent("", "  "), without holding the slice in memory.
+type jsonArrayWriter struct {
+	w     io.Writer
+	buf   bytes.Buffer
+	enc   *json.Encoder
+	count int
+}
+
+func newJSONArrayWriter(w io.Writer) *jsonArrayWriter {
+	a := &jsonArrayWriter{w: w}
+	a.enc = json.NewEncoder(&a.buf)
+	a.enc.SetIndent("  ", "  ")
+	return a
+}
+
+// Write encodes v as the next element of the array
+func (a *jsonArrayWriter) Write(v any) error {
+	a.buf.Reset()
+	if err := a.enc.Encode(v); err != nil {
+		return err
+	}
+
+	sep := ",\n  "
+	if a.count == 0 {
+		sep = "[\n  "
+	}
+	a.count++
+
+	if _, err := io.WriteString(a.w, sep); err != nil {
+		return err
+	}
+	// Drop the newline the encoder adds, the separator or Close provides it
+	_, err := a.w.Write(bytes.TrimSuffix(a.buf.Bytes(), []byte("\n")))
+	return err
+}
+
+// Close ends the array, writing an empty array if nothing was written
+func (a *jsonArrayWriter) Close() error {
+	end := "\n]\n"
+	if a.count == 0 {
+		end = "[]\n"
+	}
+	_, err := io.WriteString(a.w, end)
+	return err
+}
@@ -1,11 +1,16 @@
 package main
 
 import (
-	"encoding/json"
+	"bufio"
+	"context"
+	"errors"
 	"flag"
 	"fmt"
 	"io"
+	"iter"
 	"os"
+	"os/signal"
+	"time"
 
 	buildkitelogs "github.com/wolfeidau/buildkite-logs-parquet"
 )
@@ -22,21 +27,59 @@ type Config struct {
 	ShowSummary bool
 	ShowGroups  bool
 	ParquetFile string
+	// Only include entries within these bounds, as RFC3339 times or durations back from the last timestamp
+	Since string
+	Until string
+	// Write text or JSON output to this file instead of stdout
+	OutputFile string
+	// Synthesize timestamps for lines without one
+	SynthesizeTimestamps bool
+	// Drop timestamps outside the plausible range
+	ValidateTimestamps bool
+	// Keep only the final state of runs of progress updates in Parquet exports
+	CollapseProgress bool
+	// Leave blank lines out of Parquet exports
+	SkipBlankLines bool
+	// Keep blank lines with a timestamp when skipping blank lines
+	KeepTimestampedBlankLines bool
+	// Track the enclosing "~~~" group of each entry
+	ParentGroups bool
+	// Collapse runs of identical lines in the same group
+	DedupeConsecutive bool
+	// Flag lines that only change the previous line's trailing percentage as progress
+	PercentProgress bool
+	// Write the Parquet timestamp column as a timestamp logical type
+	LogicalTimestamps bool
+	// Write a Bloom filter of the group column in Parquet exports
+	BloomGroups bool
+	// Truncate content longer than this many bytes in Parquet exports, 0 keeps it all
+	MaxContentLength int
+	// Store content without ANSI codes in Parquet exports
+	StripANSIOnWrite bool
+	// Keep the original content in a raw_content column when stripping ANSI codes on write
+	RawContent bool
+	// Colorize text output: auto, always or never
+	Color string
+	// Timestamp format of text and JSON output: default, rfc3339, epoch-ms, epoch-s or a Go layout
+	TimeFormat string
 	// Buildkite API parameters
 	Organization string
 	Pipeline     string
 	Build        string
 	Job          string
+	APIURL       string
 }
 
 type ProcessingSummary struct {
-	TotalEntries    int
+	buildkitelogs.Summary
 	FilteredEntries int
 	BytesProcessed  int64
-	EntriesWithTime int
-	Commands        int
-	Sections        int
-	Progress        int
+	// Progress rows dropped by -collapse-progress
+	CollapsedProgress int
+	// Blank rows dropped by -skip-blank
+	SkippedBlankLines int
+	// Lines that failed to parse and were left out of the Parquet export
+	SkippedLines int
 }
 
 func main() {
@@ -52,6 +95,10 @@ func main() {
 		handleParseCommand()
 	case "query":
 		handleQueryCommand()
+	case "inspect":
+		handleInspectCommand()
+	case "verify":
+		handleVerifyCommand()
 	case "version", "-v", "--version":
 		fmt.Printf("bklog version %s\n", version)
 		return
@@ -69,6 +116,8 @@ func printUsage() {
 	fmt.Println("Subcommands:")
 	fmt.Println("  parse     Parse Buildkite log files and export to various formats")
 	fmt.Println("  query     Query Parquet log files")
+	fmt.Println("  inspect   Show the schema and column statistics of a Parquet file")
+	fmt.Println("  verify    Check a log round-trips through Parquet without losing anything")
 	fmt.Println("  version   Show version information")
 	fmt.Println("  help      Show this help message")
 	fmt.Println("")
@@ -79,24 +128,43 @@ func handleParseCommand() {
 	var config Config
 
 	parseFlags := flag.NewFlagSet("parse", flag.ExitOnError)
-	parseFlags.StringVar(&config.FilePath, "file", "", "Path to Buildkite log file (use this OR API parameters)")
+	parseFlags.StringVar(&config.FilePath, "file", "", "Path to Buildkite log file, or - to read from stdin (use this OR API parameters)")
 	parseFlags.BoolVar(&config.OutputJSON, "json", false, "Output as JSON")
 	parseFlags.BoolVar(&config.StripANSI, "strip-ansi", false, "Strip ANSI escape sequences from output")
 	parseFlags.StringVar(&config.Filter, "filter", "", "Filter entries by type: command, progress, group")
+	parseFlags.StringVar(&config.Since, "since", "", "Only include entries at or after this RFC3339 time, or a duration before the last timestamp (e.g. 5m)")
+	parseFlags.StringVar(&config.Until, "until", "", "Only include entries at or before this RFC3339 time, or a duration before the last timestamp (e.g. 1m)")
 	parseFlags.BoolVar(&config.ShowSummary, "summary", false, "Show processing summary at the end")
 	parseFlags.BoolVar(&config.ShowGroups, "groups", false, "Show group/section information")
+	parseFlags.StringVar(&config.Color, "color", "auto", "Colorize text output: auto (when stdout is a terminal), always, never")
+	parseFlags.StringVar(&config.TimeFormat, "time-format", "default", "Timestamp format of text and JSON output: default, rfc3339, epoch-ms, epoch-s or a Go layout such as 15:04:05.000")
+	parseFlags.StringVar(&config.OutputFile, "out", "", "Write text or JSON output to a file instead of stdout")
 	parseFlags.StringVar(&config.ParquetFile, "parquet", "", "Export to Parquet file (e.g., output.parquet)")
+	parseFlags.BoolVar(&config.ParentGroups, "parent-groups", false, "Record the enclosing \"~~~\" group of \"---\" and \"+++\" groups as parent_group")
+	parseFlags.BoolVar(&config.LogicalTimestamps, "logical-timestamps", false, "Write the timestamp column as a Parquet TIMESTAMP instead of int64 milliseconds, for DuckDB and Spark (for Parquet export)")
+	parseFlags.BoolVar(&config.BloomGroups, "bloom-groups", false, "Write a Bloom filter of group names so files without a group can be ruled out cheaply (for Parquet export)")
+	parseFlags.IntVar(&config.MaxContentLength, "max-content-length", 0, "Truncate content longer than this many bytes, 0 keeps it all (for Parquet export)")
+	parseFlags.BoolVar(&config.StripANSIOnWrite, "strip-ansi-on-write", false, "Store content with ANSI escape sequences stripped (for Parquet export)")
+	parseFlags.BoolVar(&config.RawContent, "raw-content", false, "With -strip-ansi-on-write, keep the original content in a raw_content column (for Parquet export)")
+	parseFlags.BoolVar(&config.CollapseProgress, "collapse-progress", false, "Keep only the last of each run of consecutive progress updates (for Parquet export)")
+	parseFlags.BoolVar(&config.SkipBlankLines, "skip-blank", false, "Leave out lines that are empty or only whitespace once ANSI codes are stripped (for Parquet export)")
+	parseFlags.BoolVar(&config.KeepTimestampedBlankLines, "keep-timestamped-blank", false, "With -skip-blank, keep blank lines that have a timestamp so timing gaps stay visible (for Parquet export)")
+	parseFlags.BoolVar(&config.PercentProgress, "percent-progress", false, "Flag lines that repeat the previous line with only a changed trailing percentage (e.g. \"Downloading... 20%\") as progress")
+	parseFlags.BoolVar(&config.DedupeConsecutive, "dedupe", false, "Collapse runs of identical consecutive lines in the same group into one entry with a repeat count")
+	parseFlags.BoolVar(&config.SynthesizeTimestamps, "synthesize-timestamps", false, "Give lines without a timestamp synthetic, increasing ones so they keep their order")
+	parseFlags.BoolVar(&config.ValidateTimestamps, "validate-timestamps", false, "Treat timestamps before 2000 or after 2100 as corrupt and drop them")
 	// Buildkite API parameters
 	parseFlags.StringVar(&config.Organization, "org", "", "Buildkite organization slug (for API)")
 	parseFlags.StringVar(&config.Pipeline, "pipeline", "", "Buildkite pipeline slug (for API)")
 	parseFlags.StringVar(&config.Build, "build", "", "Buildkite build number or UUID (for API)")
 	parseFlags.StringVar(&config.Job, "job", "", "Buildkite job ID (for API)")
+	parseFlags.StringVar(&config.APIURL, "api-url", os.Getenv("BUILDKITE_API_URL"), "Buildkite API base URL (for API, defaults to "+buildkitelogs.DefaultBaseURL+")")
 
 	parseFlags.Usage = func() {
 		fmt.Printf("Usage: %s parse [options]\n\n", os.Args[0])
 		fmt.Println("Parse Buildkite log files from local files or API and export to various formats.")
 		fmt.Println("\nYou must provide either:")
-		fmt.Println("  -file <path>     Local log file")
+		fmt.Println("  -file <path>     Local log file, or - for stdin")
 		fmt.Println("  OR API params:   -org -pipeline -build -job")
 		fmt.Println("\nFor API usage, set BUILDKITE_API_TOKEN environment variable.")
 		fmt.Println("\nOptions:")
@@ -106,6 +174,9 @@ func handleParseCommand() {
 		fmt.Printf("  %s parse -file buildkite.log -strip-ansi\n", os.Args[0])
 		fmt.Printf("  %s parse -file buildkite.log -filter command -json\n", os.Args[0])
 		fmt.Printf("  %s parse -file buildkite.log -parquet output.parquet -summary\n", os.Args[0])
+		fmt.Printf("  %s parse -file buildkite.log -json -out entries.json -summary\n", os.Args[0])
+		fmt.Printf("  %s parse -file buildkite.log -since 5m\n", os.Args[0])
+		fmt.Printf("  cat buildkite.log | %s parse -file - -parquet output.parquet\n", os.Args[0])
 		fmt.Printf("\n  # API:\n")
 		fmt.Printf("  %s parse -org myorg -pipeline mypipe -build 123 -job abc-def -json\n", os.Args[0])
 		fmt.Printf("  %s parse -org myorg -pipeline mypipe -build 123 -job abc-def -parquet logs.parquet\n", os.Args[0])
@@ -131,6 +202,12 @@ func handleParseCommand() {
 		os.Exit(1)
 	}
 
+	if config.OutputFile != "" && config.ParquetFile != "" {
+		fmt.Fprintf(os.Stderr, "Error: Cannot use -out with -parquet, -out is for text or JSON output\n\n")
+		parseFlags.Usage()
+		os.Exit(1)
+	}
+
 	// If using API, validate all required parameters are present
 	if hasAPIParams {
 		if err := buildkitelogs.ValidateAPIParams(config.Organization, config.Pipeline, config.Build, config.Job); err != nil {
@@ -151,13 +228,32 @@ func handleQueryCommand() {
 
 	queryFlags := flag.NewFlagSet("query", flag.ExitOnError)
 	queryFlags.StringVar(&config.ParquetFile, "file", "", "Path to Parquet log file (required)")
-	queryFlags.StringVar(&config.Operation, "op", "list-groups", "Query operation: list-groups, by-group, info, tail, seek")
-	queryFlags.StringVar(&config.GroupName, "group", "", "Group name to filter by (for by-group operation)")
-	queryFlags.StringVar(&config.Format, "format", "text", "Output format: text, json")
+	queryFlags.StringVar(&config.Operation, "op", "list-groups", "Query operation: list-groups, group-timing, by-group, info, head, tail, seek, seek-time, grep, filter, top-commands, artifacts, gaps")
+	queryFlags.StringVar(&config.GroupName, "group", "", "Group name to filter by, or a comma-separated list of names (for by-group operation)")
+	queryFlags.BoolVar(&config.ExactGroup, "exact", false, "Match -group exactly, skipping row groups that can't contain it (for by-group operation)")
+	queryFlags.StringVar(&config.SortBy, "sort", "first-seen", "Sort groups by: first-seen, entries, commands, duration, name, index, bytes (for list-groups operation)")
+	queryFlags.BoolVar(&config.SortDesc, "desc", false, "Sort groups in descending order (for list-groups operation)")
+	queryFlags.BoolVar(&config.Tree, "tree", false, "Show sub-groups indented under their parent \"~~~\" group (for list-groups operation)")
+	queryFlags.BoolVar(&config.Emoji, "emoji", false, "Show leading emoji shortcodes in group names, such as :package:, as emoji (for list-groups operation)")
+	queryFlags.BoolVar(&config.CleanNames, "clean-names", false, "Show group names without the ~~~/---/+++ marker and leading emoji shortcode (for list-groups text output)")
+	queryFlags.BoolVar(&config.GroupByJob, "by-job", false, "List groups separately for each job (for list-groups operation)")
+	queryFlags.StringVar(&config.EntryType, "type", "", "Entry type to show: command, group, progress, error (for filter operation)")
+	queryFlags.StringVar(&config.Where, "where", "", "Expression selecting entries, e.g. 'is_command && group~=\"test\"' (for filter operation)")
+	queryFlags.StringVar(&config.Pattern, "pattern", "", "Content pattern to match (for grep operation)")
+	queryFlags.BoolVar(&config.UseRegex, "regex", false, "Treat -pattern as a regular expression (for grep operation)")
+	queryFlags.BoolVar(&config.IgnoreCase, "i", false, "Match -pattern case-insensitively (for grep operation)")
+	queryFlags.StringVar(&config.Format, "format", "text", "Output format: text, json, jsonl, csv")
+	queryFlags.StringVar(&config.Color, "color", "auto", "Colorize text output: auto (when stdout is a terminal), always, never")
+	queryFlags.StringVar(&config.TimeFormat, "time-format", "default", "Timestamp format of every output: default, rfc3339, epoch-ms, epoch-s or a Go layout such as 15:04:05.000")
 	queryFlags.BoolVar(&config.ShowStats, "stats", true, "Show query statistics")
 	queryFlags.IntVar(&config.LimitEntries, "limit", 0, "Limit number of entries returned (0 = no limit, enables early termination)")
-	queryFlags.IntVar(&config.TailLines, "tail", 10, "Number of lines to show from end (for tail operation)")
+	queryFlags.IntVar(&config.HeadLines, "n", 10, "Number of lines to show from start (for head operation), or commands to report (for top-commands operation)")
+	queryFlags.IntVar(&config.TailLines, "tail", 10, "Number of lines to show from end (for tail operation), or of the matching group when given (for by-group operation)")
+	queryFlags.BoolVar(&config.Follow, "follow", false, "Keep printing rows as they are appended, until Ctrl-C (for tail operation)")
 	queryFlags.Int64Var(&config.SeekToRow, "seek", 0, "Row number to seek to (0-based, for seek operation)")
+	queryFlags.StringVar(&config.SeekTime, "time", "", "RFC3339 time to seek to, e.g. 2025-04-22T11:43:30Z (for seek-time operation)")
+	queryFlags.DurationVar(&config.Threshold, "threshold", 30*time.Second, "Report pauses longer than this between timestamped entries, e.g. 30s or 5m (for gaps operation)")
+	queryFlags.BoolVar(&config.ShowSchema, "schema", false, "Show column names and types and check compatibility (for info operation)")
 
 	queryFlags.Usage = func() {
 		fmt.Printf("Usage: %s query -file <parquet-file> [options]\n\n", os.Args[0])
@@ -166,23 +262,52 @@ func handleQueryCommand() {
 		queryFlags.PrintDefaults()
 		fmt.Println("\nOperations:")
 		fmt.Println("  list-groups  List all groups with statistics")
-		fmt.Println("  by-group     Show entries for a specific group")
+		fmt.Println("  group-timing List groups by duration, slowest first")
+		fmt.Println("  by-group     Show entries for a specific group, or only its last -tail entries")
 		fmt.Println("  info         Show file metadata (row count, file size, etc.)")
+		fmt.Println("  head         Show first N entries from the file")
 		fmt.Println("  tail         Show last N entries from the file")
 		fmt.Println("  seek         Start reading from a specific row number")
+		fmt.Println("  seek-time    Start reading from the first entry at or after a time")
+		fmt.Println("  grep         Show entries whose content matches a pattern")
+		fmt.Println("  filter       Show entries of a specific type or matching a -where expression")
+		fmt.Println("  top-commands Show the most frequently run commands")
+		fmt.Println("  artifacts    Summarize artifact uploads: files found and upload patterns")
+		fmt.Println("  gaps         Find pauses longer than -threshold between timestamped entries, e.g. where a build hung")
 		fmt.Println("\nExamples:")
 		fmt.Printf("  %s query -file logs.parquet -op list-groups\n", os.Args[0])
+		fmt.Printf("  %s query -file logs.parquet -op list-groups -sort entries -desc\n", os.Args[0])
+		fmt.Printf("  %s query -file logs.parquet -op group-timing\n", os.Args[0])
 		fmt.Printf("  %s query -file logs.parquet -op by-group -group \"Running tests\"\n", os.Args[0])
+		fmt.Printf("  %s query -file logs.parquet -op by-group -group \"checkout,tests\"\n", os.Args[0])
+		fmt.Printf("  %s query -file logs.parquet -op by-group -group \"command\" -tail 50\n", os.Args[0])
 		fmt.Printf("  %s query -file logs.parquet -op info\n", os.Args[0])
+		fmt.Printf("  %s query -file logs.parquet -op head -n 20\n", os.Args[0])
 		fmt.Printf("  %s query -file logs.parquet -op tail -tail 20\n", os.Args[0])
+		fmt.Printf("  %s query -file logs.parquet -op tail -follow\n", os.Args[0])
 		fmt.Printf("  %s query -file logs.parquet -op seek -seek 1000 -limit 50\n", os.Args[0])
+		fmt.Printf("  %s query -file logs.parquet -op seek-time -time 2025-04-22T11:43:00Z -limit 50\n", os.Args[0])
+		fmt.Printf("  %s query -file logs.parquet -op grep -pattern \"error\" -i -limit 20\n", os.Args[0])
+		fmt.Printf("  %s query -file logs.parquet -op filter -type command\n", os.Args[0])
+		fmt.Printf("  %s query -file logs.parquet -op filter -where 'is_command && group~=\"test\"'\n", os.Args[0])
+		fmt.Printf("  %s query -file logs.parquet -op top-commands -n 20\n", os.Args[0])
+		fmt.Printf("  %s query -file logs.parquet -op artifacts -format json\n", os.Args[0])
+		fmt.Printf("  %s query -file logs.parquet -op gaps -threshold 2m\n", os.Args[0])
 		fmt.Printf("  %s query -file logs.parquet -op list-groups -format json\n", os.Args[0])
+		fmt.Printf("  %s query -file logs.parquet -op grep -pattern \"error\" -format jsonl | jq .content\n", os.Args[0])
 	}
 
 	if err := queryFlags.Parse(os.Args[2:]); err != nil {
 		os.Exit(1)
 	}
 
+	// The tail operation defaults -tail, by-group only trims to the last entries when it is given
+	queryFlags.Visit(func(f *flag.Flag) {
+		if f.Name == "tail" {
+			config.GroupTail = config.TailLines
+		}
+	})
+
 	if config.ParquetFile == "" {
 		queryFlags.Usage()
 		os.Exit(1)
@@ -196,26 +321,86 @@ func handleQueryCommand() {
 
 // runQuery is now implemented in query_cli.go using the library package
 
+func handleInspectCommand() {
+	var config InspectConfig
+
+	inspectFlags := flag.NewFlagSet("inspect", flag.ExitOnError)
+	inspectFlags.StringVar(&config.ParquetFile, "file", "", "Path to Parquet file (required)")
+	inspectFlags.StringVar(&config.Format, "format", "text", "Output format: text, json")
+	inspectFlags.StringVar(&config.TimeFormat, "time-format", "default", "Timestamp format of the first and last timestamps: default, rfc3339, epoch-ms, epoch-s or a Go layout such as 15:04:05.000")
+
+	inspectFlags.Usage = func() {
+		fmt.Printf("Usage: %s inspect -file <parquet-file> [options]\n\n", os.Args[0])
+		fmt.Println("Show the schema and per-column statistics of a Parquet file, to check files from other tools.")
+		fmt.Println("\nOptions:")
+		inspectFlags.PrintDefaults()
+		fmt.Println("\nExamples:")
+		fmt.Printf("  %s inspect -file logs.parquet\n", os.Args[0])
+		fmt.Printf("  %s inspect -file logs.parquet -format json\n", os.Args[0])
+	}
+
+	if err := inspectFlags.Parse(os.Args[2:]); err != nil {
+		os.Exit(1)
+	}
+
+	if config.ParquetFile == "" {
+		inspectFlags.Usage()
+		os.Exit(1)
+	}
+
+	if err := runInspect(os.Stdout, &config); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+func handleVerifyCommand() {
+	var config VerifyConfig
+
+	verifyFlags := flag.NewFlagSet("verify", flag.ExitOnError)
+	verifyFlags.StringVar(&config.FilePath, "file", "", "Path to Buildkite log file (required)")
+
+	verifyFlags.Usage = func() {
+		fmt.Printf("Usage: %s verify -file <log-file>\n\n", os.Args[0])
+		fmt.Println("Parse a log, export it to a temporary Parquet file and check every entry's content, timestamp")
+		fmt.Println("and group read back unchanged. Exits non-zero and shows the first mismatch if any differ.")
+		fmt.Println("\nOptions:")
+		verifyFlags.PrintDefaults()
+		fmt.Println("\nExamples:")
+		fmt.Printf("  %s verify -file raw.log\n", os.Args[0])
+		fmt.Printf("  %s verify -file raw.log.gz\n", os.Args[0])
+	}
+
+	if err := verifyFlags.Parse(os.Args[2:]); err != nil {
+		os.Exit(1)
+	}
+
+	if config.FilePath == "" {
+		verifyFlags.Usage()
+		os.Exit(1)
+	}
+
+	err := runVerify(os.Stdout, &config)
+	if errors.Is(err, errRoundTripMismatch) {
+		os.Exit(1)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+}
+
 func runParse(config *Config) error {
 	var reader io.ReadCloser
 	var bytesProcessed int64
 
-	// Determine data source: file or API
+	// Determine data source: file, stdin or API
 	if config.FilePath != "" {
-		// Local file
-		file, err := os.Open(config.FilePath)
+		var err error
+		reader, bytesProcessed, err = openLocalInput(config.FilePath)
 		if err != nil {
-			return fmt.Errorf("failed to open file: %w", err)
+			return err
 		}
-		reader = file
-
-		// Get file size for bytes processed calculation
-		fileInfo, err := file.Stat()
-		if err != nil {
-			file.Close()
-			return fmt.Errorf("failed to get file info: %w", err)
-		}
-		bytesProcessed = fileInfo.Size()
 	} else {
 		// Buildkite API
 		apiToken := os.Getenv("BUILDKITE_API_TOKEN")
@@ -223,8 +408,18 @@ func runParse(config *Config) error {
 			return fmt.Errorf("BUILDKITE_API_TOKEN environment variable is required for API access")
 		}
 
-		client := buildkitelogs.NewBuildkiteAPIClient(apiToken, version)
-		logReader, err := client.GetJobLog(config.Organization, config.Pipeline, config.Build, config.Job)
+		// Ctrl-C aborts a slow download cleanly
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+		defer stop()
+
+		client, err := buildkitelogs.NewBuildkiteAPIClientWithOptions(apiToken, version, buildkitelogs.ClientOptions{
+			BaseURL: config.APIURL,
+		})
+		if err != nil {
+			return err
+		}
+
+		logReader, err := client.GetJobLogContext(ctx, config.Organization, config.Pipeline, config.Build, config.Job)
 		if err != nil {
 			return fmt.Errorf("failed to fetch logs from API: %w", err)
 		}
@@ -242,17 +437,64 @@ func runParse(config *Config) error {
 		BytesProcessed: bytesProcessed,
 	}
 
-	parser := buildkitelogs.NewParser()
+	filter, err := newEntryFilter(config)
+	if err != nil {
+		return err
+	}
+
+	parser := buildkitelogs.NewParserWithOptions(buildkitelogs.ParserOptions{
+		SynthesizeTimestamps:  config.SynthesizeTimestamps,
+		ValidateTimestamps:    config.ValidateTimestamps,
+		TrackParentGroups:     config.ParentGroups,
+		DedupeConsecutive:     config.DedupeConsecutive,
+		DetectPercentProgress: config.PercentProgress,
+		DropRawLine:           true, // Only the raw line sizes are used
+		// Text and JSON output handle each entry before the next, only the Parquet export keeps them
+		ReuseEntries: config.ParquetFile == "",
+	})
 
 	// Handle Parquet export if specified
 	if config.ParquetFile != "" {
-		err := exportToParquetSeq2(reader, parser, config.ParquetFile, config.Filter, summary)
+		var opts buildkitelogs.ParquetOptions
+		if config.FilePath == "" {
+			// Record which job the file came from when exporting from the API
+			opts.Metadata = buildkitelogs.JobMetadata(config.Organization, config.Pipeline, config.Build, config.Job)
+			opts.JobID = config.Job
+		}
+		opts.CollapseProgress = config.CollapseProgress
+		opts.SkipBlankLines = config.SkipBlankLines
+		opts.KeepTimestampedBlankLines = config.KeepTimestampedBlankLines
+		opts.LogicalTimestamps = config.LogicalTimestamps
+		opts.BloomFilterGroups = config.BloomGroups
+		opts.MaxContentBytes = config.MaxContentLength
+		opts.StripANSIOnWrite = config.StripANSIOnWrite
+		opts.KeepRawContent = config.RawContent
+
+		// Show a running count on an interactive stderr, it is kept quiet in scripts and CI
+		progress := &exportProgress{out: os.Stderr}
+		if isTerminal(os.Stderr) {
+			opts.OnProgress = progress.update
+		}
+
+		err := exportToParquetSeq2(reader, parser, config.ParquetFile, filter, opts, summary)
+		progress.done()
 		if err != nil {
 			return fmt.Errorf("failed to export to Parquet: %w", err)
 		}
 	} else {
 		// Regular output processing
-		err := outputSeq2(reader, parser, config.OutputJSON, config.Filter, config.StripANSI, config.ShowGroups, summary)
+		err := writeOutput(config.OutputFile, func(out io.Writer, outFile *os.File) error {
+			colors, err := newColorizer(config.Color, outFile)
+			if err != nil {
+				return err
+			}
+			times, err := parseTimeFormat(config.TimeFormat)
+			if err != nil {
+				return err
+			}
+
+			return outputSeq2(out, reader, parser, config.OutputJSON, filter, config.StripANSI, config.ShowGroups, colors, times, summary)
+		})
 		if err != nil {
 			return fmt.Errorf("failed to process data: %w", err)
 		}
@@ -265,44 +507,98 @@ func runParse(config *Config) error {
 	return nil
 }
 
-func outputSeq2(reader io.Reader, parser *buildkitelogs.Parser, outputJSON bool, filter string, stripANSI bool, showGroups bool, summary *ProcessingSummary) error {
+// exportProgress prints a count of the entries exported so far, rewriting the same line each time
+type exportProgress struct {
+	out   io.Writer
+	shown bool
+}
+
+// update shows the number of entries written, it is used as ParquetOptions.OnProgress
+func (p *exportProgress) update(entriesWritten int) {
+	_, _ = fmt.Fprintf(p.out, "\rExported %d entries", entriesWritten)
+	p.shown = true
+}
+
+// done ends the progress line so later output starts on its own line
+func (p *exportProgress) done() {
+	if p.shown {
+		_, _ = fmt.Fprintln(p.out)
+	}
+}
+
+// openLocalInput opens a local log file, or stdin when path is "-"
+// Gzipped files are decompressed. It also returns the number of bytes to be processed, which is -1 for
+// stdin as it isn't known up front, and the compressed size for gzipped files.
+func openLocalInput(path string) (io.ReadCloser, int64, error) {
+	if path == "-" {
+		// Wrapped so closing the input leaves stdin itself open
+		return io.NopCloser(os.Stdin), -1, nil
+	}
+
+	// Get file size for bytes processed calculation
+	fileInfo, err := os.Stat(path)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to open file: %w", err)
+	}
+
+	file, err := buildkitelogs.OpenLogFile(path)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to open file: %w", err)
+	}
+
+	return file, fileInfo.Size(), nil
+}
+
+// writeOutput runs output against stdout, or a buffered writer over the file at path when it is set
+// The file is passed along too so colors can be decided by whether it is a terminal.
+func writeOutput(path string, output func(out io.Writer, outFile *os.File) error) error {
+	if path == "" {
+		return output(os.Stdout, os.Stdout)
+	}
+
+	file, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("failed to create output file: %w", err)
+	}
+	defer file.Close()
+
+	buf := bufio.NewWriter(file)
+	if err := output(buf, file); err != nil {
+		return err
+	}
+	if err := buf.Flush(); err != nil {
+		return fmt.Errorf("failed to write output file: %w", err)
+	}
+
+	return file.Close()
+}
+
+func outputSeq2(out io.Writer, reader io.Reader, parser *buildkitelogs.Parser, outputJSON bool, filter entryFilter, stripANSI bool, showGroups bool, colors colorizer, times timeFormat, summary *ProcessingSummary) error {
 
 	if outputJSON {
-		return outputJSONSeq2(reader, parser, filter, stripANSI, showGroups, summary)
+		return outputJSONSeq2(out, reader, parser, filter, stripANSI, showGroups, times, summary)
 	}
-	return outputTextSeq2(reader, parser, filter, stripANSI, showGroups, summary)
+	return outputTextSeq2(out, reader, parser, filter, stripANSI, showGroups, colors, times, summary)
 }
 
-func outputJSONSeq2(reader io.Reader, parser *buildkitelogs.Parser, filter string, stripANSI bool, showGroups bool, summary *ProcessingSummary) error {
+func outputJSONSeq2(out io.Writer, reader io.Reader, parser *buildkitelogs.Parser, filter entryFilter, stripANSI bool, showGroups bool, times timeFormat, summary *ProcessingSummary) error {
 	type JSONEntry struct {
 		Timestamp string `json:"timestamp,omitempty"`
 		Content   string `json:"content"`
 		HasTime   bool   `json:"has_timestamp"`
 		Group     string `json:"group,omitempty"`
+		Repeats   int    `json:"repeat_count,omitempty"`
+		Suspect   bool   `json:"timestamp_suspect,omitempty"`
 	}
 
-	var jsonEntries []JSONEntry
+	entries := newJSONArrayWriter(out)
 
 	for entry, err := range parser.All(reader) {
 		if err != nil {
 			return fmt.Errorf("parse error: %w", err)
 		}
 
-		summary.TotalEntries++
-
-		// Update entry type counts
-		if entry.HasTimestamp() {
-			summary.EntriesWithTime++
-		}
-		if entry.IsCommand() {
-			summary.Commands++
-		}
-		if entry.IsGroup() {
-			summary.Sections++
-		}
-		if entry.IsProgress() {
-			summary.Progress++
-		}
+		summary.Add(entry)
 
 		if !shouldIncludeEntry(entry, filter) {
 			continue
@@ -318,45 +614,33 @@ func outputJSONSeq2(reader io.Reader, parser *buildkitelogs.Parser, filter strin
 		jsonEntry := JSONEntry{
 			Content: content,
 			HasTime: entry.HasTimestamp(),
+			Repeats: entry.RepeatCount,
+			Suspect: entry.TimestampSuspect,
 		}
 
 		if entry.HasTimestamp() {
-			jsonEntry.Timestamp = entry.Timestamp.Format("2006-01-02T15:04:05.000Z")
+			jsonEntry.Timestamp = times.format(entry.Timestamp, jsonTimeLayout)
 		}
 
 		if showGroups && entry.Group != "" {
 			jsonEntry.Group = entry.Group
 		}
 
-		jsonEntries = append(jsonEntries, jsonEntry)
+		if err := entries.Write(jsonEntry); err != nil {
+			return fmt.Errorf("failed to write output: %w", err)
+		}
 	}
 
-	encoder := json.NewEncoder(os.Stdout)
-	encoder.SetIndent("", "  ")
-	return encoder.Encode(jsonEntries)
+	return entries.Close()
 }
 
-func outputTextSeq2(reader io.Reader, parser *buildkitelogs.Parser, filter string, stripANSI bool, showGroups bool, summary *ProcessingSummary) error {
+func outputTextSeq2(out io.Writer, reader io.Reader, parser *buildkitelogs.Parser, filter entryFilter, stripANSI bool, showGroups bool, colors colorizer, times timeFormat, summary *ProcessingSummary) error {
 	for entry, err := range parser.All(reader) {
 		if err != nil {
 			return fmt.Errorf("parse error: %w", err)
 		}
 
-		summary.TotalEntries++
-
-		// Update entry type counts
-		if entry.HasTimestamp() {
-			summary.EntriesWithTime++
-		}
-		if entry.IsCommand() {
-			summary.Commands++
-		}
-		if entry.IsGroup() {
-			summary.Sections++
-		}
-		if entry.IsProgress() {
-			summary.Progress++
-		}
+		summary.Add(entry)
 
 		if !shouldIncludeEntry(entry, filter) {
 			continue
@@ -368,27 +652,49 @@ func outputTextSeq2(reader io.Reader, parser *buildkitelogs.Parser, filter strin
 		if stripANSI {
 			content = entry.CleanContent()
 		}
+		if colors.enabled {
+			content = colors.content(content, entry.IsGroup(), entry.IsCommand(), entry.IsError())
+		}
+
+		if entry.RepeatCount > 0 {
+			// Count of the line and its collapsed repeats
+			content += colors.style(styleDim, fmt.Sprintf(" (x%d)", entry.RepeatCount+1))
+		}
 
+		timestamp := ""
+		if entry.HasTimestamp() {
+			timestamp = colors.timestamp("["+times.format(entry.Timestamp, textTimeLayout)+"]") + " "
+		}
+
+		var err error
 		if showGroups && entry.Group != "" {
-			if entry.HasTimestamp() {
-				fmt.Printf("[%s] [%s] %s\n", entry.Timestamp.Format("2006-01-02 15:04:05.000"), entry.Group, content)
-			} else {
-				fmt.Printf("[%s] %s\n", entry.Group, content)
-			}
+			_, err = fmt.Fprintf(out, "%s[%s] %s\n", timestamp, entry.Group, content)
 		} else {
-			if entry.HasTimestamp() {
-				fmt.Printf("[%s] %s\n", entry.Timestamp.Format("2006-01-02 15:04:05.000"), content)
-			} else {
-				fmt.Printf("%s\n", content)
-			}
+			_, err = fmt.Fprintf(out, "%s%s\n", timestamp, content)
+		}
+		if err != nil {
+			return fmt.Errorf("failed to write output: %w", err)
 		}
 	}
 
 	return nil
 }
 
-func shouldIncludeEntry(entry *buildkitelogs.LogEntry, filter string) bool {
-	switch filter {
+// shouldIncludeEntry applies the type and time filters, entries without a timestamp are excluded when a time bound is set
+func shouldIncludeEntry(entry *buildkitelogs.LogEntry, filter entryFilter) bool {
+	if filter.hasTimeBounds() {
+		if !entry.HasTimestamp() {
+			return false
+		}
+		if !filter.Since.IsZero() && entry.Timestamp.Before(filter.Since) {
+			return false
+		}
+		if !filter.Until.IsZero() && entry.Timestamp.After(filter.Until) {
+			return false
+		}
+	}
+
+	switch filter.Type {
 	case "command":
 		return entry.IsCommand()
 	case "group", "section": // Support both for backward compatibility
@@ -400,21 +706,47 @@ func shouldIncludeEntry(entry *buildkitelogs.LogEntry, filter string) bool {
 	}
 }
 
-func exportToParquetSeq2(reader io.Reader, parser *buildkitelogs.Parser, filename string, filter string, summary *ProcessingSummary) error {
+// exportToParquetSeq2 streams parsed entries straight into the Parquet writer
+// Only one row group worth of entries is held in memory at a time, and the summary
+// counters are updated as entries flow through, so large API logs are never buffered.
+func exportToParquetSeq2(reader io.Reader, parser *buildkitelogs.Parser, filename string, filter entryFilter, opts buildkitelogs.ParquetOptions, summary *ProcessingSummary) error {
 	// Create filter function based on filter string
 	var filterFunc func(*buildkitelogs.LogEntry) bool
-	if filter != "" {
+	if filter.active() {
 		filterFunc = func(entry *buildkitelogs.LogEntry) bool {
 			return shouldIncludeEntry(entry, filter)
 		}
 	}
 
+	// Applied here so the summary can count what's dropped, the export options are cleared below
+	// before the sequence runs, so capture them now
+	collapseProgress, skipBlank, keepTimestampedBlank := opts.CollapseProgress, opts.SkipBlankLines, opts.KeepTimestampedBlankLines
+
 	// Create a sequence that counts entries for summary and handles errors
 	countingSeq := func(yield func(*buildkitelogs.LogEntry, error) bool) {
+		// Lines are counted as they are parsed, before any are collapsed or dropped, so warnings give source
+		// line numbers. Errors pass straight through both, so the count is the failing line when one arrives.
 		lineNum := 0
-		for entry, err := range parser.All(reader) {
-			lineNum++
+		var entries iter.Seq2[*buildkitelogs.LogEntry, error] = func(yield func(*buildkitelogs.LogEntry, error) bool) {
+			for entry, err := range parser.All(reader) {
+				lineNum++
+				if !yield(entry, err) {
+					return
+				}
+			}
+		}
+		if collapseProgress {
+			entries = buildkitelogs.CollapseProgress(entries, func(*buildkitelogs.LogEntry) {
+				summary.CollapsedProgress++
+			})
+		}
+		if skipBlank {
+			entries = buildkitelogs.DropBlankLines(entries, keepTimestampedBlank, func(*buildkitelogs.LogEntry) {
+				summary.SkippedBlankLines++
+			})
+		}
 
+		for entry, err := range entries {
 			// Handle parse errors - still count them but log warnings
 			if err != nil {
 				fmt.Fprintf(os.Stderr, "Warning: Error parsing line %d: %v\n", lineNum, err)
@@ -424,51 +756,59 @@ func exportToParquetSeq2(reader io.Reader, parser *buildkitelogs.Parser, filenam
 				continue
 			}
 
-			summary.TotalEntries++
-
-			// Update entry type counts
-			if entry.HasTimestamp() {
-				summary.EntriesWithTime++
-			}
-			if entry.IsCommand() {
-				summary.Commands++
-			}
-			if entry.IsGroup() {
-				summary.Sections++
-			}
-			if entry.IsProgress() {
-				summary.Progress++
-			}
+			summary.Add(entry)
 
 			// Apply filter if specified
-			if filterFunc == nil || filterFunc(entry) {
-				summary.FilteredEntries++
+			if filterFunc != nil && !filterFunc(entry) {
+				continue
 			}
 
-			// Always yield the entry for export consideration
+			summary.FilteredEntries++
+
 			if !yield(entry, nil) {
 				return
 			}
 		}
 	}
 
-	// Export using the Seq2 iterator with filtering
-	return buildkitelogs.ExportSeq2ToParquetWithFilter(countingSeq, filename, filterFunc)
+	// Export the filtered sequence using the Parquet options, progress is already collapsed and blank lines dropped
+	opts.CollapseProgress = false
+	opts.SkipBlankLines = false
+
+	// Malformed lines were warned about above, skip them rather than failing the export
+	opts.OnError = func(error) bool {
+		summary.SkippedLines++
+		return true
+	}
+	return buildkitelogs.ExportSeq2ToParquetWithOptions(countingSeq, filename, opts)
 }
 
 func printSummary(summary *ProcessingSummary) {
 	fmt.Printf("\n--- Processing Summary ---\n")
 	if summary.BytesProcessed >= 0 {
-		fmt.Printf("Bytes processed: %.1f KB\n", float64(summary.BytesProcessed)/1024)
+		fmt.Printf("Bytes processed: %s\n", humanizeBytes(summary.BytesProcessed))
 	} else {
-		fmt.Printf("Bytes processed: (API source - unknown)\n")
+		fmt.Printf("Bytes processed: (API or stdin source - unknown)\n")
 	}
 	fmt.Printf("Total entries: %d\n", summary.TotalEntries)
 	fmt.Printf("Entries with timestamps: %d\n", summary.EntriesWithTime)
+	if summary.SuspectTimestamps > 0 {
+		fmt.Printf("Suspect timestamps: %d\n", summary.SuspectTimestamps)
+	}
 	fmt.Printf("Commands: %d\n", summary.Commands)
 	fmt.Printf("Sections: %d\n", summary.Sections)
 	fmt.Printf("Progress updates: %d\n", summary.Progress)
-	fmt.Printf("Regular output: %d\n", summary.TotalEntries-summary.Commands-summary.Sections-summary.Progress)
+	fmt.Printf("Errors: %d\n", summary.Errors)
+	if summary.CollapsedProgress > 0 {
+		fmt.Printf("Progress updates collapsed: %d\n", summary.CollapsedProgress)
+	}
+	if summary.SkippedBlankLines > 0 {
+		fmt.Printf("Blank lines skipped: %d\n", summary.SkippedBlankLines)
+	}
+	fmt.Printf("Regular output: %d\n", summary.Regular())
+	if summary.SkippedLines > 0 {
+		fmt.Printf("Lines skipped (parse errors): %d\n", summary.SkippedLines)
+	}
 
 	if summary.FilteredEntries > 0 {
 		fmt.Printf("Exported %d entries to %s\n", summary.FilteredEntries, "Parquet file")
@@ -0,0 +1,492 @@
+package main
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	buildkitelogs "github.com/wolfeidau/buildkite-logs-parquet"
+)
+
+const testLog = "\x1b_bk;t=1745322209921\x07~~~ Running tests\n\x1b_bk;t=1745322209922\x07$ make test\n"
+
+func TestOpenLocalInputStdin(t *testing.T) {
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatalf("Failed to create pipe: %v", err)
+	}
+	defer r.Close()
+
+	oldStdin := os.Stdin
+	os.Stdin = r
+	defer func() { os.Stdin = oldStdin }()
+
+	go func() {
+		_, _ = w.Write([]byte(testLog))
+		w.Close()
+	}()
+
+	reader, bytesProcessed, err := openLocalInput("-")
+	if err != nil {
+		t.Fatalf("openLocalInput failed: %v", err)
+	}
+	if bytesProcessed != -1 {
+		t.Errorf("Expected unknown bytes processed (-1), got %d", bytesProcessed)
+	}
+
+	data, err := io.ReadAll(reader)
+	if err != nil {
+		t.Fatalf("Failed to read stdin: %v", err)
+	}
+	if string(data) != testLog {
+		t.Errorf("Unexpected data read from stdin: %q", data)
+	}
+
+	if err := reader.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+	if _, err := r.Stat(); err != nil {
+		t.Errorf("Expected stdin to stay open after Close, got %v", err)
+	}
+}
+
+func TestOutputSeq2Writer(t *testing.T) {
+	var text bytes.Buffer
+	err := outputSeq2(&text, strings.NewReader(testLog), buildkitelogs.NewParser(), false, entryFilter{Type: "command"}, false, true, colorizer{}, timeFormat{}, &ProcessingSummary{})
+	if err != nil {
+		t.Fatalf("text output failed: %v", err)
+	}
+	if !strings.HasSuffix(text.String(), "] [~~~ Running tests] $ make test\n") || strings.Count(text.String(), "\n") != 1 {
+		t.Errorf("Unexpected text output: %q", text.String())
+	}
+
+	var jsonOut bytes.Buffer
+	summary := &ProcessingSummary{}
+	err = outputSeq2(&jsonOut, strings.NewReader(testLog), buildkitelogs.NewParser(), true, entryFilter{}, false, false, colorizer{}, timeFormat{}, summary)
+	if err != nil {
+		t.Fatalf("JSON output failed: %v", err)
+	}
+
+	var entries []map[string]any
+	if err := json.Unmarshal(jsonOut.Bytes(), &entries); err != nil {
+		t.Fatalf("Invalid JSON output: %v", err)
+	}
+	if len(entries) != 2 || summary.FilteredEntries != 2 {
+		t.Errorf("Expected 2 JSON entries, got %d (filtered %d)", len(entries), summary.FilteredEntries)
+	}
+}
+
+func TestOutputSeq2TimeFormat(t *testing.T) {
+	times, err := parseTimeFormat("epoch-ms")
+	if err != nil {
+		t.Fatalf("parseTimeFormat() error = %v", err)
+	}
+
+	var text bytes.Buffer
+	err = outputSeq2(&text, strings.NewReader(testLog), buildkitelogs.NewParser(), false, entryFilter{}, false, false, colorizer{}, times, &ProcessingSummary{})
+	if err != nil {
+		t.Fatalf("text output failed: %v", err)
+	}
+	if want := "[1745322209921] ~~~ Running tests\n[1745322209922] $ make test\n"; text.String() != want {
+		t.Errorf("text output = %q, want %q", text.String(), want)
+	}
+
+	var jsonOut bytes.Buffer
+	err = outputSeq2(&jsonOut, strings.NewReader(testLog), buildkitelogs.NewParser(), true, entryFilter{}, false, false, colorizer{}, times, &ProcessingSummary{})
+	if err != nil {
+		t.Fatalf("JSON output failed: %v", err)
+	}
+
+	var entries []struct {
+		Timestamp string `json:"timestamp"`
+	}
+	if err := json.Unmarshal(jsonOut.Bytes(), &entries); err != nil {
+		t.Fatalf("Invalid JSON output: %v", err)
+	}
+	if len(entries) != 2 || entries[0].Timestamp != "1745322209921" || entries[1].Timestamp != "1745322209922" {
+		t.Errorf("Unexpected JSON timestamps: %+v", entries)
+	}
+}
+
+func TestWriteOutputFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "out.txt")
+
+	err := writeOutput(path, func(out io.Writer, outFile *os.File) error {
+		if outFile == os.Stdout {
+			t.Error("Expected the output file rather than stdout")
+		}
+		_, err := io.WriteString(out, "hello\n")
+		return err
+	})
+	if err != nil {
+		t.Fatalf("writeOutput failed: %v", err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("Failed to read output file: %v", err)
+	}
+	if string(data) != "hello\n" {
+		t.Errorf("Expected buffered output to be flushed to the file, got %q", data)
+	}
+}
+
+func TestJSONArrayWriterMatchesSliceEncoding(t *testing.T) {
+	values := []map[string]any{{"content": "a <b>", "n": 1}, {"content": "c"}}
+
+	for _, n := range []int{1, 2} {
+		var want bytes.Buffer
+		encoder := json.NewEncoder(&want)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(values[:n]); err != nil {
+			t.Fatal(err)
+		}
+
+		var got bytes.Buffer
+		array := newJSONArrayWriter(&got)
+		for _, v := range values[:n] {
+			if err := array.Write(v); err != nil {
+				t.Fatalf("Write failed: %v", err)
+			}
+		}
+		if err := array.Close(); err != nil {
+			t.Fatalf("Close failed: %v", err)
+		}
+
+		if got.String() != want.String() {
+			t.Errorf("With %d values got:\n%s\nwant:\n%s", n, got.String(), want.String())
+		}
+	}
+
+	var empty bytes.Buffer
+	if err := newJSONArrayWriter(&empty).Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+	if empty.String() != "[]\n" {
+		t.Errorf("Expected an empty array, got %q", empty.String())
+	}
+}
+
+func TestParseTimeBound(t *testing.T) {
+	last := time.Date(2025, 4, 22, 11, 43, 30, 0, time.UTC)
+
+	bound, err := parseTimeBound("5m")
+	if err != nil {
+		t.Fatalf("parseTimeBound failed: %v", err)
+	}
+	if !bound.relative() || !bound.resolve(last).Equal(last.Add(-5*time.Minute)) {
+		t.Errorf("Expected 5m to resolve to 5 minutes before the last timestamp, got %v", bound.resolve(last))
+	}
+
+	bound, err = parseTimeBound("2025-04-22T11:00:00Z")
+	if err != nil {
+		t.Fatalf("parseTimeBound failed: %v", err)
+	}
+	if bound.relative() || !bound.resolve(last).Equal(time.Date(2025, 4, 22, 11, 0, 0, 0, time.UTC)) {
+		t.Errorf("Expected an absolute bound, got %v", bound.resolve(last))
+	}
+
+	for _, value := range []string{"yesterday", "-5m"} {
+		if _, err := parseTimeBound(value); err == nil {
+			t.Errorf("Expected an error for %q", value)
+		}
+	}
+}
+
+func TestShouldIncludeEntryTimeBounds(t *testing.T) {
+	base := time.UnixMilli(1745322209921)
+	filter := entryFilter{Since: base, Until: base.Add(time.Second)}
+
+	tests := []struct {
+		name  string
+		entry buildkitelogs.LogEntry
+		want  bool
+	}{
+		{"at since", buildkitelogs.LogEntry{Timestamp: base}, true},
+		{"at until", buildkitelogs.LogEntry{Timestamp: base.Add(time.Second)}, true},
+		{"before since", buildkitelogs.LogEntry{Timestamp: base.Add(-time.Millisecond)}, false},
+		{"after until", buildkitelogs.LogEntry{Timestamp: base.Add(time.Second + time.Millisecond)}, false},
+		{"no timestamp", buildkitelogs.LogEntry{Content: "plain"}, false},
+	}
+
+	for _, tt := range tests {
+		if got := shouldIncludeEntry(&tt.entry, filter); got != tt.want {
+			t.Errorf("%s: shouldIncludeEntry() = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+
+	if !shouldIncludeEntry(&buildkitelogs.LogEntry{Content: "plain"}, entryFilter{}) {
+		t.Error("Expected entries without a timestamp to be included without time bounds")
+	}
+}
+
+func TestNewEntryFilterRelative(t *testing.T) {
+	dir := t.TempDir()
+	plain := filepath.Join(dir, "build.log")
+	if err := os.WriteFile(plain, []byte(testLog), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	var compressed bytes.Buffer
+	gz := gzip.NewWriter(&compressed)
+	_, _ = gz.Write([]byte(testLog))
+	if err := gz.Close(); err != nil {
+		t.Fatal(err)
+	}
+	gzipped := filepath.Join(dir, "build.log.gz")
+	if err := os.WriteFile(gzipped, compressed.Bytes(), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, path := range []string{plain, gzipped} {
+		t.Run(filepath.Base(path), func(t *testing.T) {
+			reader, _, err := openLocalInput(path)
+			if err != nil {
+				t.Fatalf("openLocalInput failed: %v", err)
+			}
+			defer reader.Close()
+
+			filter, err := newEntryFilter(&Config{FilePath: path, Since: "1ms"})
+			if err != nil {
+				t.Fatalf("newEntryFilter failed: %v", err)
+			}
+			if !filter.Since.Equal(time.UnixMilli(1745322209921)) {
+				t.Errorf("Expected since to be 1ms before the last timestamp, got %v", filter.Since)
+			}
+
+			// The real pass still reads the whole log
+			data, err := io.ReadAll(reader)
+			if err != nil || string(data) != testLog {
+				t.Errorf("Expected the whole log after the pre-pass, got %q (%v)", data, err)
+			}
+		})
+	}
+
+	for _, path := range []string{"-", ""} {
+		if _, err := newEntryFilter(&Config{FilePath: path, Since: "1ms"}); err == nil {
+			t.Errorf("Expected an error for a relative bound without a local file (path %q)", path)
+		}
+	}
+}
+
+func TestNewEntryFilterRelativeValidateTimestamps(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "build.log")
+	log := testLog + "\x1b_bk;t=7258118400000\x07corrupt in 2200\n"
+	if err := os.WriteFile(path, []byte(log), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	filter, err := newEntryFilter(&Config{FilePath: path, Since: "1ms", ValidateTimestamps: true})
+	if err != nil {
+		t.Fatalf("newEntryFilter failed: %v", err)
+	}
+	if !filter.Since.Equal(time.UnixMilli(1745322209921)) {
+		t.Errorf("Expected the implausible timestamp to be ignored, got since %v", filter.Since)
+	}
+
+	filter, err = newEntryFilter(&Config{FilePath: path, Since: "1ms"})
+	if err != nil {
+		t.Fatalf("newEntryFilter failed: %v", err)
+	}
+	if !filter.Since.Equal(time.UnixMilli(7258118400000 - 1)) {
+		t.Errorf("Expected the last timestamp without validation, got since %v", filter.Since)
+	}
+}
+
+func TestHumanizeBytes(t *testing.T) {
+	tests := []struct {
+		n    int64
+		want string
+	}{
+		{0, "0 B"},
+		{1023, "1023 B"},
+		{1024, "1.0 KB"},
+		{25000, "24.4 KB"},
+		{5 * 1024 * 1024, "5.0 MB"},
+		{3 << 30, "3.0 GB"},
+	}
+
+	for _, tt := range tests {
+		if got := humanizeBytes(tt.n); got != tt.want {
+			t.Errorf("humanizeBytes(%d) = %q, want %q", tt.n, got, tt.want)
+		}
+	}
+}
+
+func TestPrettyDuration(t *testing.T) {
+	tests := []struct {
+		d    time.Duration
+		want string
+	}{
+		{0, "0ms"},
+		{450 * time.Millisecond, "450ms"},
+		{12340 * time.Millisecond, "12.3s"},
+		{59960 * time.Millisecond, "1m00s"},
+		{4*time.Minute + 5*time.Second, "4m05s"},
+		{time.Hour + 2*time.Minute + 3*time.Second, "1h02m03s"},
+		{-2 * time.Second, "-2.0s"},
+	}
+
+	for _, tt := range tests {
+		if got := prettyDuration(tt.d); got != tt.want {
+			t.Errorf("prettyDuration(%v) = %q, want %q", tt.d, got, tt.want)
+		}
+	}
+}
+
+func TestRunInspect(t *testing.T) {
+	var out bytes.Buffer
+	if err := runInspect(&out, &InspectConfig{ParquetFile: "../../testdata/bash-example.parquet", Format: "text"}); err != nil {
+		t.Fatalf("runInspect() error = %v", err)
+	}
+	for _, want := range []string{"Rows:           212", "Compatible:     yes", "Timestamps:     2025-04-22", "Column Statistics:", "is_command"} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("Expected output to contain %q, got:\n%s", want, out.String())
+		}
+	}
+
+	out.Reset()
+	if err := runInspect(&out, &InspectConfig{ParquetFile: "../../testdata/bash-example.parquet", Format: "json"}); err != nil {
+		t.Fatalf("runInspect() error = %v", err)
+	}
+	var result struct {
+		RowCount       int64 `json:"row_count"`
+		DistinctGroups int   `json:"distinct_groups"`
+		Columns        []struct {
+			Name string `json:"name"`
+			Min  string `json:"min"`
+		} `json:"columns"`
+	}
+	if err := json.Unmarshal(out.Bytes(), &result); err != nil {
+		t.Fatalf("Invalid JSON output: %v", err)
+	}
+	if result.RowCount != 212 || result.DistinctGroups == 0 || len(result.Columns) == 0 || result.Columns[0].Name != "timestamp" {
+		t.Errorf("Unexpected JSON result: %+v", result)
+	}
+
+	out.Reset()
+	if err := runInspect(&out, &InspectConfig{ParquetFile: "../../testdata/bash-example.parquet", Format: "json", TimeFormat: "epoch-ms"}); err != nil {
+		t.Fatalf("runInspect() error = %v", err)
+	}
+	if !strings.Contains(out.String(), `"first_timestamp": 1745322209921`) {
+		t.Errorf("Expected the first timestamp in epoch milliseconds, got:\n%s", out.String())
+	}
+}
+
+func TestRunVerify(t *testing.T) {
+	var out bytes.Buffer
+	if err := runVerify(&out, &VerifyConfig{FilePath: "../../testdata/bash-example.log"}); err != nil {
+		t.Fatalf("runVerify() error = %v", err)
+	}
+	if !strings.Contains(out.String(), "Verified 212 entries") {
+		t.Errorf("Expected a summary of entries checked, got %q", out.String())
+	}
+
+	if err := runVerify(&out, &VerifyConfig{FilePath: "-"}); err == nil {
+		t.Error("Expected an error verifying stdin")
+	}
+}
+
+func TestExportProgress(t *testing.T) {
+	var out bytes.Buffer
+	progress := &exportProgress{out: &out}
+	progress.done()
+	if out.Len() != 0 {
+		t.Errorf("Expected no output before any progress, got %q", out.String())
+	}
+
+	progress.update(1000)
+	progress.update(1500)
+	progress.done()
+	if want := "\rExported 1000 entries\rExported 1500 entries\n"; out.String() != want {
+		t.Errorf("Expected %q, got %q", want, out.String())
+	}
+}
+
+func TestExportToParquetSeq2SkipBlankLines(t *testing.T) {
+	input := testLog + "\x1b_bk;t=1745322209923\x07\n\n   \nok\n"
+	output := filepath.Join(t.TempDir(), "blank.parquet")
+
+	summary := &ProcessingSummary{}
+	opts := buildkitelogs.ParquetOptions{SkipBlankLines: true, KeepTimestampedBlankLines: true}
+	if err := exportToParquetSeq2(strings.NewReader(input), buildkitelogs.NewParser(), output, entryFilter{}, opts, summary); err != nil {
+		t.Fatalf("exportToParquetSeq2() error = %v", err)
+	}
+
+	if summary.SkippedBlankLines != 2 {
+		t.Errorf("Expected 2 blank lines skipped, got %d", summary.SkippedBlankLines)
+	}
+	info, err := buildkitelogs.NewParquetReader(output).GetFileInfo()
+	if err != nil {
+		t.Fatalf("GetFileInfo() error = %v", err)
+	}
+	if info.RowCount != 4 {
+		t.Errorf("Expected 4 rows, got %d", info.RowCount)
+	}
+}
+
+func TestExportToParquetSeq2CollapseProgress(t *testing.T) {
+	input := testLog +
+		"\x1b_bk;t=1745322209923\x07Receiving objects:  10% (1/10)\x1b[K\n" +
+		"\x1b_bk;t=1745322209924\x07Receiving objects: 100% (10/10), done.\x1b[K\n"
+	output := filepath.Join(t.TempDir(), "collapse.parquet")
+
+	summary := &ProcessingSummary{}
+	opts := buildkitelogs.ParquetOptions{CollapseProgress: true}
+	if err := exportToParquetSeq2(strings.NewReader(input), buildkitelogs.NewParser(), output, entryFilter{}, opts, summary); err != nil {
+		t.Fatalf("exportToParquetSeq2() error = %v", err)
+	}
+
+	if summary.CollapsedProgress != 1 {
+		t.Errorf("Expected 1 progress update collapsed, got %d", summary.CollapsedProgress)
+	}
+	info, err := buildkitelogs.NewParquetReader(output).GetFileInfo()
+	if err != nil {
+		t.Fatalf("GetFileInfo() error = %v", err)
+	}
+	if info.RowCount != 3 {
+		t.Errorf("Expected 3 rows, got %d", info.RowCount)
+	}
+}
+
+func TestExportToParquetSeq2WarningLineNumbers(t *testing.T) {
+	input := "\x1b_bk;t=1745322209921\x07Receiving objects:  10% (1/10)\x1b[K\n" +
+		"\x1b_bk;t=1745322209922\x07Receiving objects:  50% (5/10)\x1b[K\n" +
+		"\n" +
+		"\x1b_bk;t=1745322209923\x07Receiving objects: 100% (10/10), done.\x1b[K\n" +
+		"\x1b_bk;t=17453222x9924\x07broken\n"
+	output := filepath.Join(t.TempDir(), "warnings.parquet")
+
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatalf("os.Pipe() error = %v", err)
+	}
+	stderr := os.Stderr
+	os.Stderr = w
+	defer func() { os.Stderr = stderr }()
+
+	summary := &ProcessingSummary{}
+	opts := buildkitelogs.ParquetOptions{CollapseProgress: true, SkipBlankLines: true}
+	exportErr := exportToParquetSeq2(strings.NewReader(input), buildkitelogs.NewParser(), output, entryFilter{}, opts, summary)
+	os.Stderr = stderr
+	_ = w.Close()
+	warnings, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatalf("Failed to read warnings: %v", err)
+	}
+	if exportErr != nil {
+		t.Fatalf("exportToParquetSeq2() error = %v", exportErr)
+	}
+
+	if !strings.Contains(string(warnings), "Error parsing line 5:") {
+		t.Errorf("Expected a warning for source line 5, got %q", warnings)
+	}
+	if summary.CollapsedProgress != 1 || summary.SkippedBlankLines != 1 || summary.SkippedLines != 1 {
+		t.Errorf("Unexpected summary: %+v", summary)
+	}
+}
@@ -1,9 +1,18 @@
 package main
 
 import (
+	"bufio"
+	"cmp"
+	"context"
+	"encoding/csv"
 	"encoding/json"
 	"fmt"
+	"iter"
 	"os"
+	"os/signal"
+	"regexp"
+	"sort"
+	"strconv"
 	"strings"
 	"time"
 
@@ -13,18 +22,61 @@ import (
 // QueryConfig holds configuration for CLI query operations
 type QueryConfig struct {
 	ParquetFile  string
-	Operation    string // "list-groups", "by-group", "info", "tail"
+	Operation    string // "list-groups", "group-timing", "by-group", "info", "head", "tail", "seek", "seek-time", "grep", "filter", "top-commands", "artifacts", "gaps"
 	GroupName    string
-	Format       string // "text", "json"
+	ExactGroup   bool   // Match GroupName exactly, skipping row groups using statistics
+	EntryType    string // Entry type (for filter operation)
+	Where        string // Predicate expression (for filter operation)
+	Pattern      string // Content pattern (for grep operation)
+	UseRegex     bool   // Treat Pattern as a regular expression
+	IgnoreCase   bool   // Match Pattern case-insensitively
+	Format       string // "text", "json", "jsonl", "csv"
+	Color        string // "auto", "always", "never" (for text format)
+	TimeFormat   string // "default", "rfc3339", "epoch-ms", "epoch-s" or a Go layout, for entry timestamps
 	ShowStats    bool
-	LimitEntries int   // Limit output entries (0 = no limit)
-	TailLines    int   // Number of lines to show from end (for tail operation)
-	SeekToRow    int64 // Row number to seek to (0-based)
+	GroupByJob   bool          // Group within each job (for list-groups operation)
+	SortBy       string        // Sort key (for list-groups operation)
+	SortDesc     bool          // Reverse the sort order (for list-groups operation)
+	Tree         bool          // Show sub-groups under their parent group (for list-groups operation)
+	Emoji        bool          // Show leading emoji shortcodes in group names as emoji (for list-groups operation)
+	CleanNames   bool          // Show group labels without markers and emoji shortcodes (for list-groups operation)
+	LimitEntries int           // Limit output entries (0 = no limit)
+	HeadLines    int           // Number of lines to show from start (for head operation), or commands to report (for top-commands)
+	TailLines    int           // Number of lines to show from end (for tail operation)
+	GroupTail    int           // Show only the last N matching entries, 0 shows them all (for by-group operation)
+	Follow       bool          // Keep printing appended rows (for tail operation)
+	SeekToRow    int64         // Row number to seek to (0-based)
+	SeekTime     string        // RFC3339 time to seek to (for seek-time operation)
+	Threshold    time.Duration // Shortest pause to report (for gaps operation)
+	ShowSchema   bool          // Print column names and types (for info operation)
+
+	colors colorizer  // Resolved from Color by runQuery
+	times  timeFormat // Resolved from TimeFormat by runQuery
 }
 
 // runQuery executes a query using streaming iterators
 func runQuery(config *QueryConfig) error {
+	colors, err := newColorizer(config.Color, os.Stdout)
+	if err != nil {
+		return err
+	}
+	config.colors = colors
+
+	times, err := parseTimeFormat(config.TimeFormat)
+	if err != nil {
+		return err
+	}
+	config.times = times
+
 	reader := buildkitelogs.NewParquetReader(config.ParquetFile)
+
+	// Reject unrelated Parquet files up front, info can still describe them
+	if config.Operation != "info" {
+		if err := reader.ValidateSchema(); err != nil {
+			return err
+		}
+	}
+
 	return runStreamingQuery(reader, config)
 }
 
@@ -35,17 +87,45 @@ func runStreamingQuery(reader *buildkitelogs.ParquetReader, config *QueryConfig)
 	switch config.Operation {
 	case "list-groups":
 		return streamListGroups(reader, config, start)
+	case "group-timing":
+		return streamGroupTiming(reader, config, start)
 	case "by-group":
 		if config.GroupName == "" {
 			return fmt.Errorf("group pattern is required for by-group operation")
 		}
+		if config.GroupTail < 0 {
+			return fmt.Errorf("-tail must be positive, got %d", config.GroupTail)
+		}
+		if config.GroupTail > 0 && config.LimitEntries > 0 {
+			return fmt.Errorf("-tail and -limit can't be combined for by-group, the last entries are only known once the whole group is read")
+		}
 		return streamByGroup(reader, config, start)
 	case "info":
 		return showFileInfo(reader, config)
+	case "head":
+		return headFile(reader, config, start)
 	case "tail":
 		return tailFile(reader, config, start)
 	case "seek":
 		return seekToRow(reader, config, start)
+	case "seek-time":
+		if config.SeekTime == "" {
+			return fmt.Errorf("time is required for seek-time operation")
+		}
+		return seekToTime(reader, config, start)
+	case "grep":
+		if config.Pattern == "" {
+			return fmt.Errorf("pattern is required for grep operation")
+		}
+		return streamGrep(reader, config, start)
+	case "filter":
+		return streamFilterByType(reader, config, start)
+	case "top-commands":
+		return streamTopCommands(reader, config, start)
+	case "artifacts":
+		return streamArtifacts(reader, config, start)
+	case "gaps":
+		return streamGaps(reader, config, start)
 	default:
 		return fmt.Errorf("unknown operation: %s", config.Operation)
 	}
@@ -53,116 +133,478 @@ func runStreamingQuery(reader *buildkitelogs.ParquetReader, config *QueryConfig)
 
 // streamListGroups handles list-groups operation using streaming
 func streamListGroups(reader *buildkitelogs.ParquetReader, config *QueryConfig, start time.Time) error {
-	// Use streaming iterator to build group statistics
-	groupMap := make(map[string]*buildkitelogs.GroupInfo)
+	if config.Format == "csv" {
+		return fmt.Errorf("csv format is not supported for list-groups")
+	}
+
+	less, err := groupSortLess(config.SortBy)
+	if err != nil {
+		return err
+	}
+
+	groups, totalEntries, err := collectGroupStats(reader, config)
+	if err != nil {
+		return err
+	}
+
+	sort.Slice(groups, func(i, j int) bool {
+		if config.SortDesc {
+			return less(groups[j], groups[i])
+		}
+		return less(groups[i], groups[j])
+	})
+
+	if config.Tree {
+		groups = groupTreeOrder(groups)
+	}
+
+	// Format output
+	queryTime := float64(time.Since(start).Nanoseconds()) / 1e6
+	return formatStreamingGroupsResult(groups, totalEntries, queryTime, config)
+}
+
+// groupTreeOrder moves each sub-group to follow its parent group, keeping the sort order among siblings
+// Sub-groups whose parent isn't in the list stay at the top level.
+func groupTreeOrder(groups []buildkitelogs.GroupInfo) []buildkitelogs.GroupInfo {
+	key := func(jobID, name string) string { return jobID + "\x00" + name }
+
+	present := make(map[string]bool, len(groups))
+	for _, group := range groups {
+		present[key(group.JobID, group.Name)] = true
+	}
+
+	var roots []buildkitelogs.GroupInfo
+	children := make(map[string][]buildkitelogs.GroupInfo)
+	for _, group := range groups {
+		parent := key(group.JobID, group.Parent)
+		if group.Parent != "" && present[parent] {
+			children[parent] = append(children[parent], group)
+			continue
+		}
+		roots = append(roots, group)
+	}
+
+	ordered := make([]buildkitelogs.GroupInfo, 0, len(groups))
+	for _, root := range roots {
+		ordered = append(ordered, root)
+		ordered = append(ordered, children[key(root.JobID, root.Name)]...)
+	}
+	return ordered
+}
+
+// groupSortLess returns the ordering for a list-groups sort key, ties are broken by name
+func groupSortLess(key string) (func(a, b buildkitelogs.GroupInfo) bool, error) {
+	var compare func(a, b buildkitelogs.GroupInfo) int
+	switch key {
+	case "", "first-seen":
+		compare = func(a, b buildkitelogs.GroupInfo) int { return a.FirstSeen.Compare(b.FirstSeen) }
+	case "entries":
+		compare = func(a, b buildkitelogs.GroupInfo) int { return cmp.Compare(a.EntryCount, b.EntryCount) }
+	case "commands":
+		compare = func(a, b buildkitelogs.GroupInfo) int { return cmp.Compare(a.Commands, b.Commands) }
+	case "duration":
+		compare = func(a, b buildkitelogs.GroupInfo) int { return cmp.Compare(a.Duration(), b.Duration()) }
+	case "name":
+		compare = func(a, b buildkitelogs.GroupInfo) int { return 0 }
+	case "index":
+		compare = func(a, b buildkitelogs.GroupInfo) int { return cmp.Compare(a.Index, b.Index) }
+	case "bytes":
+		compare = func(a, b buildkitelogs.GroupInfo) int { return cmp.Compare(a.TotalBytes, b.TotalBytes) }
+	default:
+		return nil, fmt.Errorf("unknown sort key: %s (supported: first-seen, entries, commands, duration, name, index, bytes)", key)
+	}
+
+	return func(a, b buildkitelogs.GroupInfo) bool {
+		if c := compare(a, b); c != 0 {
+			return c < 0
+		}
+		return a.Name < b.Name
+	}, nil
+}
+
+// streamGroupTiming handles group-timing operation, reporting groups slowest first
+func streamGroupTiming(reader *buildkitelogs.ParquetReader, config *QueryConfig, start time.Time) error {
+	if config.Format == "csv" {
+		return fmt.Errorf("csv format is not supported for group-timing")
+	}
+
+	groups, totalEntries, err := collectGroupStats(reader, config)
+	if err != nil {
+		return err
+	}
+
+	sort.SliceStable(groups, func(i, j int) bool {
+		return groups[i].Duration() > groups[j].Duration()
+	})
+
+	// Format output
+	queryTime := float64(time.Since(start).Nanoseconds()) / 1e6
+	return formatGroupTimingResult(groups, totalEntries, queryTime, config)
+}
+
+// collectGroupStats reads group statistics, returning them with the total number of entries
+func collectGroupStats(reader *buildkitelogs.ParquetReader, config *QueryConfig) ([]buildkitelogs.GroupInfo, int, error) {
+	var groups []buildkitelogs.GroupInfo
+	var err error
+	if config.GroupByJob {
+		groups, err = reader.GroupStatsByJob()
+	} else {
+		groups, err = reader.GroupStats()
+	}
+	if err != nil {
+		return nil, 0, err
+	}
+
 	totalEntries := 0
+	for _, group := range groups {
+		totalEntries += group.EntryCount
+	}
 
-	for entry, err := range reader.ReadEntriesIter() {
+	return groups, totalEntries, nil
+}
+
+// streamByGroup handles by-group operation using streaming with optional limiting
+func streamByGroup(reader *buildkitelogs.ParquetReader, config *QueryConfig, start time.Time) error {
+	results := newEntryResults(config)
+
+	totalEntries, matchedEntries, err := collectByGroup(reader, config, results)
+	if err != nil {
+		return err
+	}
+
+	if err := results.flush(); err != nil {
+		return err
+	}
+
+	// Format output
+	queryTime := float64(time.Since(start).Nanoseconds()) / 1e6
+	return formatStreamingEntriesResult(results.entries, totalEntries, matchedEntries, queryTime, config)
+}
+
+// collectByGroup adds entries in matching groups to results, counting matched and total entries in a single pass
+// Once the limit is reached the scan stops early, unless stats were requested in which case the rest of
+// the file is still counted. The total is zero when the file wasn't fully scanned. With GroupTail only the
+// last matches are kept, in a ring buffer so memory stays constant, and added once the scan is done.
+func collectByGroup(reader *buildkitelogs.ParquetReader, config *QueryConfig, results *entryResults) (int, int, error) {
+	totalEntries := 0
+	matchedEntries := 0
+	scannedAll := true
+
+	var last *entryRing
+	if config.GroupTail > 0 {
+		last = newEntryRing(config.GroupTail)
+	}
+
+	patterns := splitGroupPatterns(config.GroupName)
+	matches := buildkitelogs.FilterByGroupsIter(countEntries(reader.ReadEntriesIter(), &totalEntries), patterns)
+	if config.ExactGroup {
+		if len(patterns) > 1 {
+			return 0, 0, fmt.Errorf("-exact matches a single group, got %d", len(patterns))
+		}
+
+		// Skipped row groups are never read, so the total comes from the file metadata
+		info, err := reader.GetFileInfo()
 		if err != nil {
-			return fmt.Errorf("error reading entries: %w", err)
+			return 0, 0, fmt.Errorf("failed to get file info: %w", err)
 		}
+		totalEntries = int(info.RowCount)
+		matches = reader.FilterByGroupExactIter(config.GroupName)
+	}
 
-		totalEntries++
+	for entry, err := range matches {
+		if err != nil {
+			return 0, 0, fmt.Errorf("error filtering entries: %w", err)
+		}
+
+		// Apply limit if specified (early termination advantage)
+		if config.LimitEntries > 0 && matchedEntries >= config.LimitEntries {
+			if !config.ShowStats {
+				scannedAll = false
+				break
+			}
+			continue
+		}
 
-		groupName := entry.Group
-		if groupName == "" {
-			groupName = "<no group>"
+		matchedEntries++
+		if last != nil {
+			last.add(entry)
+			continue
 		}
+		if err := results.add(entry); err != nil {
+			return 0, 0, err
+		}
+	}
 
-		info, exists := groupMap[groupName]
-		if !exists {
-			entryTime := time.Unix(0, entry.Timestamp*int64(time.Millisecond))
-			info = &buildkitelogs.GroupInfo{
-				Name:      groupName,
-				FirstSeen: entryTime,
-				LastSeen:  entryTime,
+	if last != nil {
+		for _, entry := range last.ordered() {
+			if err := results.add(entry); err != nil {
+				return 0, 0, err
 			}
-			groupMap[groupName] = info
 		}
+	}
+
+	if !scannedAll {
+		totalEntries = 0
+	}
+
+	return totalEntries, matchedEntries, nil
+}
+
+// entryRing keeps the last entries added to it, up to a fixed number
+type entryRing struct {
+	entries []buildkitelogs.ParquetLogEntry
+	next    int // Index of the oldest entry, overwritten by the next add once full
+}
+
+// newEntryRing creates a ring keeping the last size entries
+func newEntryRing(size int) *entryRing {
+	return &entryRing{entries: make([]buildkitelogs.ParquetLogEntry, 0, size)}
+}
+
+// add keeps an entry, replacing the oldest one when the ring is full
+func (r *entryRing) add(entry buildkitelogs.ParquetLogEntry) {
+	if len(r.entries) < cap(r.entries) {
+		r.entries = append(r.entries, entry)
+		return
+	}
+	r.entries[r.next] = entry
+	r.next = (r.next + 1) % len(r.entries)
+}
+
+// ordered returns the kept entries, oldest first
+func (r *entryRing) ordered() []buildkitelogs.ParquetLogEntry {
+	return append(r.entries[r.next:len(r.entries):len(r.entries)], r.entries[:r.next]...)
+}
+
+// splitGroupPatterns splits a comma-separated -group value into its patterns, ignoring empty ones
+// A value without commas is a single pattern, matched exactly as given.
+func splitGroupPatterns(value string) []string {
+	if !strings.Contains(value, ",") {
+		return []string{value}
+	}
 
-		info.EntryCount++
+	var patterns []string
+	for _, pattern := range strings.Split(value, ",") {
+		if pattern = strings.TrimSpace(pattern); pattern != "" {
+			patterns = append(patterns, pattern)
+		}
+	}
+	return patterns
+}
 
-		entryTime := time.Unix(0, entry.Timestamp*int64(time.Millisecond))
-		if entryTime.Before(info.FirstSeen) {
-			info.FirstSeen = entryTime
+// countEntries wraps an entry iterator, counting the entries read into count
+func countEntries(entries iter.Seq2[buildkitelogs.ParquetLogEntry, error], count *int) iter.Seq2[buildkitelogs.ParquetLogEntry, error] {
+	return func(yield func(buildkitelogs.ParquetLogEntry, error) bool) {
+		for entry, err := range entries {
+			if err == nil {
+				*count++
+			}
+			if !yield(entry, err) {
+				return
+			}
 		}
-		if entryTime.After(info.LastSeen) {
-			info.LastSeen = entryTime
+	}
+}
+
+// streamGrep handles grep operation, streaming entries whose content matches a pattern
+func streamGrep(reader *buildkitelogs.ParquetReader, config *QueryConfig, start time.Time) error {
+	pattern, useRegex := grepPattern(config)
+
+	// Count entries as they are scanned so stats don't need a second pass
+	totalEntries := 0
+	scanned := countEntries(reader.ReadEntriesIter(), &totalEntries)
+
+	matches, err := buildkitelogs.FilterByContentIter(scanned, pattern, useRegex, true)
+	if err != nil {
+		return err
+	}
+
+	results := newEntryResults(config)
+	matchedEntries := 0
+	limited := false
+
+	for entry, err := range matches {
+		if err != nil {
+			return fmt.Errorf("error filtering entries: %w", err)
 		}
 
-		if entry.IsCommand {
-			info.Commands++
+		matchedEntries++
+		if err := results.add(entry); err != nil {
+			return err
 		}
-		if entry.IsProgress {
-			info.Progress++
+
+		// Apply limit if specified (early termination advantage)
+		if config.LimitEntries > 0 && matchedEntries >= config.LimitEntries {
+			limited = true
+			break
 		}
 	}
 
-	// Convert to slice and sort
-	groups := make([]buildkitelogs.GroupInfo, 0, len(groupMap))
-	for _, info := range groupMap {
-		groups = append(groups, *info)
+	// The total is only known when the whole file was scanned
+	if limited {
+		totalEntries = 0
 	}
 
-	// Sort by first seen time (simple sorting)
-	for i := 0; i < len(groups)-1; i++ {
-		for j := i + 1; j < len(groups); j++ {
-			if groups[j].FirstSeen.Before(groups[i].FirstSeen) {
-				groups[i], groups[j] = groups[j], groups[i]
-			}
-		}
+	if err := results.flush(); err != nil {
+		return err
 	}
 
 	// Format output
 	queryTime := float64(time.Since(start).Nanoseconds()) / 1e6
-	return formatStreamingGroupsResult(groups, totalEntries, queryTime, config)
+	return formatStreamingEntriesResult(results.entries, totalEntries, matchedEntries, queryTime, config)
 }
 
-// streamByGroup handles by-group operation using streaming with optional limiting
-func streamByGroup(reader *buildkitelogs.ParquetReader, config *QueryConfig, start time.Time) error {
-	var entries []buildkitelogs.ParquetLogEntry
+// streamFilterByType handles filter operation, streaming entries of a single type and/or matching a -where expression
+func streamFilterByType(reader *buildkitelogs.ParquetReader, config *QueryConfig, start time.Time) error {
+	matches, err := filterMatcher(config)
+	if err != nil {
+		return err
+	}
+
+	results := newEntryResults(config)
 	totalEntries := 0
 	matchedEntries := 0
+	limited := false
 
-	for entry, err := range reader.FilterByGroupIter(config.GroupName) {
+	for entry, err := range reader.ReadEntriesIter() {
 		if err != nil {
-			return fmt.Errorf("error filtering entries: %w", err)
+			return fmt.Errorf("error reading entries: %w", err)
 		}
 
 		totalEntries++
+
+		if !matches(&entry) {
+			continue
+		}
+
 		matchedEntries++
-		entries = append(entries, entry)
+		if err := results.add(entry); err != nil {
+			return err
+		}
 
 		// Apply limit if specified (early termination advantage)
 		if config.LimitEntries > 0 && matchedEntries >= config.LimitEntries {
+			limited = true
 			break
 		}
 	}
 
-	// Count total entries for stats if needed (requires separate iteration)
-	if config.ShowStats {
-		for _, _ = range reader.ReadEntriesIter() {
-			totalEntries++
-		}
+	// The total is only known when the whole file was scanned
+	if limited {
+		totalEntries = 0
+	}
+
+	if err := results.flush(); err != nil {
+		return err
 	}
 
 	// Format output
 	queryTime := float64(time.Since(start).Nanoseconds()) / 1e6
-	return formatStreamingEntriesResult(entries, totalEntries, matchedEntries, queryTime, config)
+	return formatStreamingEntriesResult(results.entries, totalEntries, matchedEntries, queryTime, config)
+}
+
+// filterMatcher combines the -type and -where options of the filter operation, requiring at least one
+func filterMatcher(config *QueryConfig) (wherePredicate, error) {
+	if config.Where == "" {
+		matchesType, err := entryTypeMatcher(config.EntryType)
+		if err != nil {
+			return nil, err
+		}
+		return func(entry *buildkitelogs.ParquetLogEntry) bool { return matchesType(*entry) }, nil
+	}
+
+	where, err := compileWhere(config.Where)
+	if err != nil {
+		return nil, fmt.Errorf("invalid -where expression: %w", err)
+	}
+	if config.EntryType == "" {
+		return where, nil
+	}
+
+	matchesType, err := entryTypeMatcher(config.EntryType)
+	if err != nil {
+		return nil, err
+	}
+	return func(entry *buildkitelogs.ParquetLogEntry) bool { return matchesType(*entry) && where(entry) }, nil
+}
+
+// entryTypeMatcher returns a predicate selecting entries whose type column is set
+func entryTypeMatcher(entryType string) (func(buildkitelogs.ParquetLogEntry) bool, error) {
+	switch entryType {
+	case "command":
+		return func(entry buildkitelogs.ParquetLogEntry) bool { return entry.IsCommand }, nil
+	case "group", "section": // Support both for consistency with parse -filter
+		return func(entry buildkitelogs.ParquetLogEntry) bool { return entry.IsGroup }, nil
+	case "progress":
+		return func(entry buildkitelogs.ParquetLogEntry) bool { return entry.IsProgress }, nil
+	case "error":
+		return func(entry buildkitelogs.ParquetLogEntry) bool { return entry.IsError }, nil
+	case "":
+		return nil, fmt.Errorf("type is required for filter operation (command, group, progress, error)")
+	default:
+		return nil, fmt.Errorf("unknown entry type: %s (supported: command, group, progress, error)", entryType)
+	}
+}
+
+// grepPattern converts the grep flags into a pattern for FilterByContentIter
+// Substring matching in the library is always case-insensitive, so case-sensitive
+// substrings are matched as escaped regular expressions instead.
+func grepPattern(config *QueryConfig) (string, bool) {
+	switch {
+	case config.UseRegex && config.IgnoreCase:
+		return "(?i)" + config.Pattern, true
+	case config.UseRegex:
+		return config.Pattern, true
+	case config.IgnoreCase:
+		return config.Pattern, false
+	default:
+		return regexp.QuoteMeta(config.Pattern), true
+	}
+}
+
+// jsonGroup is a group as written to JSON output, with its first and last seen times in the -time-format
+type jsonGroup struct {
+	buildkitelogs.GroupInfo
+	FirstSeen any `json:"first_seen"`
+	LastSeen  any `json:"last_seen"`
+}
+
+// newJSONGroup converts a group for JSON output
+func newJSONGroup(group buildkitelogs.GroupInfo, times timeFormat) jsonGroup {
+	return jsonGroup{
+		GroupInfo: group,
+		FirstSeen: times.jsonValue(group.FirstSeen, group.FirstSeen),
+		LastSeen:  times.jsonValue(group.LastSeen, group.LastSeen),
+	}
 }
 
 // formatStreamingGroupsResult formats groups output from streaming query
 func formatStreamingGroupsResult(groups []buildkitelogs.GroupInfo, totalEntries int, queryTime float64, config *QueryConfig) error {
+	jsonGroups := make([]jsonGroup, 0, len(groups))
+	for _, group := range groups {
+		jsonGroups = append(jsonGroups, newJSONGroup(group, config.times))
+	}
+
+	if config.Format == "jsonl" {
+		encoder := json.NewEncoder(os.Stdout)
+		for _, group := range jsonGroups {
+			if err := encoder.Encode(group); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
 	if config.Format == "json" {
 		result := struct {
-			Groups []buildkitelogs.GroupInfo `json:"groups"`
+			Groups []jsonGroup `json:"groups"`
 			Stats  struct {
 				TotalEntries int     `json:"total_entries"`
 				TotalGroups  int     `json:"total_groups"`
 				QueryTime    float64 `json:"query_time_ms"`
 			} `json:"stats,omitempty"`
 		}{
-			Groups: groups,
+			Groups: jsonGroups,
 		}
 
 		if config.ShowStats {
@@ -185,18 +627,43 @@ func formatStreamingGroupsResult(groups []buildkitelogs.GroupInfo, totalEntries
 	}
 
 	// Print table header
-	fmt.Printf("%-40s %8s %8s %8s %19s %19s\n",
-		"GROUP NAME", "ENTRIES", "COMMANDS", "PROGRESS", "FIRST SEEN", "LAST SEEN")
-	fmt.Println(strings.Repeat("-", 120))
+	separatorWidth := 137
+	if config.GroupByJob {
+		fmt.Printf("%-36s ", "JOB ID")
+		separatorWidth += 37
+	}
+	fmt.Printf("%5s %-40s %8s %8s %8s %10s %19s %19s\n",
+		"#", "GROUP NAME", "ENTRIES", "COMMANDS", "PROGRESS", "BYTES", "FIRST SEEN", "LAST SEEN")
+	fmt.Println(strings.Repeat("-", separatorWidth))
 
 	for _, group := range groups {
-		fmt.Printf("%-40s %8d %8d %8d %19s %19s\n",
-			truncateString(group.Name, 40),
+		if config.GroupByJob {
+			jobID := group.JobID
+			if jobID == "" {
+				jobID = "<no job>"
+			}
+			fmt.Printf("%-36s ", truncateString(jobID, 36))
+		}
+		index := "-"
+		if group.Index >= 0 {
+			index = strconv.Itoa(int(group.Index))
+		}
+		name := group.Name
+		if config.CleanNames {
+			name = buildkitelogs.GroupLabel(name)
+		}
+		if config.Tree && group.Parent != "" {
+			name = "  " + name
+		}
+		fmt.Printf("%5s %s %8d %8d %8d %10s %19s %19s\n",
+			index,
+			groupNameColumn(name, 40, config.Emoji),
 			group.EntryCount,
 			group.Commands,
 			group.Progress,
-			group.FirstSeen.Format("2006-01-02 15:04:05"),
-			group.LastSeen.Format("2006-01-02 15:04:05"))
+			humanizeBytes(group.TotalBytes),
+			config.times.format(group.FirstSeen, groupTimeLayout),
+			config.times.format(group.LastSeen, groupTimeLayout))
 	}
 
 	if config.ShowStats {
@@ -209,18 +676,492 @@ func formatStreamingGroupsResult(groups []buildkitelogs.GroupInfo, totalEntries
 	return nil
 }
 
+// groupNameColumn truncates and pads a group name to width columns, replacing a leading emoji
+// shortcode with its emoji when emoji is set
+func groupNameColumn(name string, width int, emoji bool) string {
+	if emoji {
+		shortcode, _ := buildkitelogs.SplitGroupEmoji(strings.TrimLeft(name, " "))
+		if symbol, ok := buildkitelogs.EmojiForShortcode(shortcode); ok {
+			i := strings.Index(name, shortcode)
+			head := name[:i]
+			// Emoji are displayed two columns wide
+			tail := truncateString(name[i+len(shortcode):], max(width-len(head)-2, 0))
+			return head + symbol + tail + strings.Repeat(" ", max(width-len(head)-2-len(tail), 0))
+		}
+	}
+
+	return fmt.Sprintf("%-*s", width, truncateString(name, width))
+}
+
+// groupTiming is a group with its elapsed duration, for group-timing output
+type groupTiming struct {
+	jsonGroup
+	DurationMs int64 `json:"duration_ms"`
+}
+
+// formatGroupTimingResult formats group-timing output
+func formatGroupTimingResult(groups []buildkitelogs.GroupInfo, totalEntries int, queryTime float64, config *QueryConfig) error {
+	timings := make([]groupTiming, 0, len(groups))
+	var buildStart, buildEnd time.Time
+	for _, group := range groups {
+		timings = append(timings, groupTiming{
+			jsonGroup:  newJSONGroup(group, config.times),
+			DurationMs: group.Duration().Milliseconds(),
+		})
+
+		if !group.FirstSeen.IsZero() && (buildStart.IsZero() || group.FirstSeen.Before(buildStart)) {
+			buildStart = group.FirstSeen
+		}
+		if group.LastSeen.After(buildEnd) {
+			buildEnd = group.LastSeen
+		}
+	}
+
+	// Wall-clock time from the first to the last timestamp in any group
+	wallClock := buildkitelogs.GroupInfo{FirstSeen: buildStart, LastSeen: buildEnd}.Duration()
+
+	if config.Format == "jsonl" {
+		encoder := json.NewEncoder(os.Stdout)
+		for _, timing := range timings {
+			if err := encoder.Encode(timing); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	if config.Format == "json" {
+		result := struct {
+			Groups      []groupTiming `json:"groups"`
+			WallClockMs int64         `json:"wall_clock_ms"`
+			Stats       struct {
+				TotalEntries int     `json:"total_entries"`
+				TotalGroups  int     `json:"total_groups"`
+				QueryTime    float64 `json:"query_time_ms"`
+			} `json:"stats,omitempty"`
+		}{
+			Groups:      timings,
+			WallClockMs: wallClock.Milliseconds(),
+		}
+
+		if config.ShowStats {
+			result.Stats.TotalEntries = totalEntries
+			result.Stats.TotalGroups = len(groups)
+			result.Stats.QueryTime = queryTime
+		}
+
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(result)
+	}
+
+	// Text format
+	fmt.Printf("Group timing (slowest first): %d groups\n\n", len(groups))
+
+	if len(groups) == 0 {
+		fmt.Println("No groups found.")
+		return nil
+	}
+
+	fmt.Printf("%-60s %12s %8s %19s\n", "GROUP NAME", "DURATION", "ENTRIES", "FIRST SEEN")
+	fmt.Println(strings.Repeat("-", 102))
+
+	for _, group := range groups {
+		firstSeen := "-"
+		if !group.FirstSeen.IsZero() {
+			firstSeen = config.times.format(group.FirstSeen, groupTimeLayout)
+		}
+
+		fmt.Printf("%-60s %12s %8d %19s\n",
+			truncateString(group.Name, 60),
+			prettyDuration(group.Duration()),
+			group.EntryCount,
+			firstSeen)
+	}
+
+	fmt.Printf("\nTotal wall-clock: %s\n", prettyDuration(wallClock))
+
+	if config.ShowStats {
+		fmt.Printf("\n--- Query Statistics (Streaming) ---\n")
+		fmt.Printf("Total entries: %d\n", totalEntries)
+		fmt.Printf("Total groups: %d\n", len(groups))
+		fmt.Printf("Query time: %.2f ms\n", queryTime)
+	}
+
+	return nil
+}
+
+// commandCount is how many times a command was run, for top-commands output
+type commandCount struct {
+	Command string `json:"command"`
+	Count   int    `json:"count"`
+}
+
+// topCommandsColumns are the columns read by top-commands
+var topCommandsColumns = []string{"content", "is_command"}
+
+// streamTopCommands handles top-commands operation, reporting the most frequently run commands
+func streamTopCommands(reader *buildkitelogs.ParquetReader, config *QueryConfig, start time.Time) error {
+	topN := config.HeadLines
+	if topN <= 0 {
+		topN = 10
+	}
+
+	counts, totalCommands, err := countCommands(reader.ReadColumnsIter(topCommandsColumns))
+	if err != nil {
+		return err
+	}
+
+	distinct := len(counts)
+	if len(counts) > topN {
+		counts = counts[:topN]
+	}
+
+	// Format output
+	queryTime := float64(time.Since(start).Nanoseconds()) / 1e6
+	return formatTopCommandsResult(counts, totalCommands, distinct, queryTime, config)
+}
+
+// countCommands counts each distinct command, returning them most frequent first with the total number of commands
+// Commands are compared with ANSI sequences and the "$ " prompt stripped, so the same command styled differently
+// is counted once. Ties are ordered by command.
+func countCommands(entries iter.Seq2[buildkitelogs.ParquetLogEntry, error]) ([]commandCount, int, error) {
+	counts := make(map[string]int)
+	total := 0
+
+	for entry, err := range entries {
+		if err != nil {
+			return nil, 0, fmt.Errorf("error reading entries: %w", err)
+		}
+
+		if !entry.IsCommand {
+			continue
+		}
+
+		command := strings.TrimSpace(strings.TrimPrefix(entry.CleanContent(), "$ "))
+		counts[command]++
+		total++
+	}
+
+	result := make([]commandCount, 0, len(counts))
+	for command, count := range counts {
+		result = append(result, commandCount{Command: command, Count: count})
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Count != result[j].Count {
+			return result[i].Count > result[j].Count
+		}
+		return result[i].Command < result[j].Command
+	})
+
+	return result, total, nil
+}
+
+// formatTopCommandsResult formats top-commands output
+func formatTopCommandsResult(counts []commandCount, totalCommands, distinct int, queryTime float64, config *QueryConfig) error {
+	switch config.Format {
+	case "json":
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(counts)
+	case "jsonl":
+		encoder := json.NewEncoder(os.Stdout)
+		for _, count := range counts {
+			if err := encoder.Encode(count); err != nil {
+				return err
+			}
+		}
+		return nil
+	case "csv":
+		writer := csv.NewWriter(os.Stdout)
+		if err := writer.Write([]string{"command", "count"}); err != nil {
+			return err
+		}
+		for _, count := range counts {
+			if err := writer.Write([]string{count.Command, strconv.Itoa(count.Count)}); err != nil {
+				return err
+			}
+		}
+		writer.Flush()
+		return writer.Error()
+	}
+
+	// Text format
+	fmt.Printf("Top commands: %d of %d distinct\n\n", len(counts), distinct)
+
+	if len(counts) == 0 {
+		fmt.Println("No commands found.")
+		return nil
+	}
+
+	fmt.Printf("%8s  %s\n", "COUNT", "COMMAND")
+	fmt.Println(strings.Repeat("-", 80))
+
+	for _, count := range counts {
+		fmt.Printf("%8d  %s\n", count.Count, count.Command)
+	}
+
+	if config.ShowStats {
+		fmt.Printf("\n--- Query Statistics (Streaming) ---\n")
+		fmt.Printf("Total commands: %d\n", totalCommands)
+		fmt.Printf("Distinct commands: %d\n", distinct)
+		fmt.Printf("Query time: %.2f ms\n", queryTime)
+	}
+
+	return nil
+}
+
+// streamArtifacts handles artifacts operation, summarising the artifact uploads of the job
+func streamArtifacts(reader *buildkitelogs.ParquetReader, config *QueryConfig, start time.Time) error {
+	summary, err := reader.ArtifactSummary()
+	if err != nil {
+		return fmt.Errorf("error reading entries: %w", err)
+	}
+
+	queryTime := float64(time.Since(start).Nanoseconds()) / 1e6
+	return formatArtifactsResult(summary, queryTime, config)
+}
+
+// formatArtifactsResult formats artifacts output
+func formatArtifactsResult(summary buildkitelogs.ArtifactSummary, queryTime float64, config *QueryConfig) error {
+	switch config.Format {
+	case "json", "jsonl":
+		encoder := json.NewEncoder(os.Stdout)
+		if config.Format == "json" {
+			encoder.SetIndent("", "  ")
+		}
+		return encoder.Encode(summary)
+	case "csv":
+		writer := csv.NewWriter(os.Stdout)
+		if err := writer.Write([]string{"file_count", "patterns"}); err != nil {
+			return err
+		}
+		if err := writer.Write([]string{strconv.Itoa(summary.FileCount), strings.Join(summary.Patterns, " ")}); err != nil {
+			return err
+		}
+		writer.Flush()
+		return writer.Error()
+	}
+
+	// Text format
+	if len(summary.Patterns) == 0 {
+		fmt.Println("No artifact uploads found.")
+	} else {
+		fmt.Printf("Artifact files: %d\n", summary.FileCount)
+		fmt.Printf("Patterns:\n")
+		for _, pattern := range summary.Patterns {
+			fmt.Printf("  %s\n", pattern)
+		}
+	}
+
+	if config.ShowStats {
+		fmt.Printf("\n--- Query Statistics (Streaming) ---\n")
+		fmt.Printf("Query time: %.2f ms\n", queryTime)
+	}
+
+	return nil
+}
+
+// gapResult is a pause between timestamped entries, for gaps output
+type gapResult struct {
+	DurationMs    int64     `json:"duration_ms"`
+	BeforeRow     int64     `json:"before_row"`
+	AfterRow      int64     `json:"after_row"`
+	BeforeLine    int64     `json:"before_line,omitempty"`
+	AfterLine     int64     `json:"after_line,omitempty"`
+	Start         time.Time `json:"start"`
+	End           time.Time `json:"end"`
+	BeforeGroup   string    `json:"before_group"`
+	BeforeContent string    `json:"before_content"`
+	AfterGroup    string    `json:"after_group"`
+	AfterContent  string    `json:"after_content"`
+}
+
+// jsonGap is a gap as written to JSON output, with its start and end in the -time-format
+type jsonGap struct {
+	gapResult
+	Start any `json:"start"`
+	End   any `json:"end"`
+}
+
+// newJSONGap converts a gap for JSON output
+func newJSONGap(gap gapResult, times timeFormat) jsonGap {
+	return jsonGap{gapResult: gap, Start: times.jsonValue(gap.Start, gap.Start), End: times.jsonValue(gap.End, gap.End)}
+}
+
+// newGapResult flattens a gap for output
+func newGapResult(gap buildkitelogs.TimeGap) gapResult {
+	return gapResult{
+		DurationMs:    gap.Duration().Milliseconds(),
+		BeforeRow:     gap.BeforeRow,
+		AfterRow:      gap.AfterRow,
+		BeforeLine:    gap.Before.LineNumber,
+		AfterLine:     gap.After.LineNumber,
+		Start:         time.UnixMilli(gap.Before.Timestamp).UTC(),
+		End:           time.UnixMilli(gap.After.Timestamp).UTC(),
+		BeforeGroup:   gap.Before.Group,
+		BeforeContent: gap.Before.Content,
+		AfterGroup:    gap.After.Group,
+		AfterContent:  gap.After.Content,
+	}
+}
+
+// gapsCSVHeader is the header row written for gaps csv output
+var gapsCSVHeader = []string{"duration_ms", "before_row", "after_row", "start", "end", "before_group", "before_content", "after_group", "after_content"}
+
+// streamGaps handles gaps operation, reporting pauses longer than the threshold between timestamped entries
+// Gaps are written as they are found for jsonl and csv output.
+func streamGaps(reader *buildkitelogs.ParquetReader, config *QueryConfig, start time.Time) error {
+	if config.Threshold <= 0 {
+		return fmt.Errorf("threshold must be positive for gaps operation")
+	}
+
+	var csvWriter *csv.Writer
+	switch config.Format {
+	case "csv":
+		csvWriter = csv.NewWriter(os.Stdout)
+		if err := csvWriter.Write(gapsCSVHeader); err != nil {
+			return err
+		}
+	case "jsonl":
+	case "text", "json":
+	default:
+		return fmt.Errorf("unknown format: %s", config.Format)
+	}
+	encoder := json.NewEncoder(os.Stdout)
+
+	var gaps []gapResult
+	var longest time.Duration
+	found := 0
+	for gap, err := range reader.GapsIter(config.Threshold) {
+		if err != nil {
+			return err
+		}
+		found++
+
+		result := newGapResult(gap)
+		longest = max(longest, gap.Duration())
+
+		switch config.Format {
+		case "jsonl":
+			if err := encoder.Encode(newJSONGap(result, config.times)); err != nil {
+				return err
+			}
+		case "csv":
+			err := csvWriter.Write([]string{
+				strconv.FormatInt(result.DurationMs, 10),
+				strconv.FormatInt(result.BeforeRow, 10),
+				strconv.FormatInt(result.AfterRow, 10),
+				config.times.format(result.Start, csvTimeLayout),
+				config.times.format(result.End, csvTimeLayout),
+				result.BeforeGroup,
+				result.BeforeContent,
+				result.AfterGroup,
+				result.AfterContent,
+			})
+			if err != nil {
+				return err
+			}
+		default:
+			gaps = append(gaps, result)
+		}
+
+		if config.LimitEntries > 0 && found >= config.LimitEntries {
+			break
+		}
+	}
+
+	if csvWriter != nil {
+		csvWriter.Flush()
+		return csvWriter.Error()
+	}
+	if config.Format == "jsonl" {
+		return nil
+	}
+
+	queryTime := float64(time.Since(start).Nanoseconds()) / 1e6
+	return formatGapsResult(gaps, longest, queryTime, config)
+}
+
+// formatGapsResult formats gaps output for text and json
+func formatGapsResult(gaps []gapResult, longest time.Duration, queryTime float64, config *QueryConfig) error {
+	if config.Format == "json" {
+		result := struct {
+			Gaps        []jsonGap `json:"gaps"`
+			ThresholdMs int64     `json:"threshold_ms"`
+			Stats       struct {
+				LongestMs int64   `json:"longest_ms"`
+				QueryTime float64 `json:"query_time_ms"`
+			} `json:"stats,omitempty"`
+		}{
+			Gaps:        make([]jsonGap, 0, len(gaps)),
+			ThresholdMs: config.Threshold.Milliseconds(),
+		}
+		for _, gap := range gaps {
+			result.Gaps = append(result.Gaps, newJSONGap(gap, config.times))
+		}
+
+		if config.ShowStats {
+			result.Stats.LongestMs = longest.Milliseconds()
+			result.Stats.QueryTime = queryTime
+		}
+
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(result)
+	}
+
+	// Text format
+	fmt.Printf("Gaps longer than %s: %d\n\n", prettyDuration(config.Threshold), len(gaps))
+
+	if len(gaps) == 0 {
+		fmt.Println("No gaps found.")
+	}
+
+	for i, gap := range gaps {
+		if i > 0 {
+			fmt.Println()
+		}
+		fmt.Printf("%s between rows %d and %d\n", prettyDuration(time.Duration(gap.DurationMs)*time.Millisecond), gap.BeforeRow, gap.AfterRow)
+		printGapSide(gap.Start, gap.BeforeLine, gap.BeforeGroup, gap.BeforeContent, config.colors, config.times)
+		printGapSide(gap.End, gap.AfterLine, gap.AfterGroup, gap.AfterContent, config.colors, config.times)
+	}
+
+	if config.ShowStats {
+		fmt.Printf("\n--- Query Statistics (Streaming) ---\n")
+		fmt.Printf("Longest gap: %s\n", prettyDuration(longest))
+		fmt.Printf("Query time: %.2f ms\n", queryTime)
+	}
+
+	return nil
+}
+
+// printGapSide prints the entry on one side of a gap with its line number and group
+func printGapSide(at time.Time, lineNumber int64, group, content string, colors colorizer, times timeFormat) {
+	fmt.Printf("  %s%s %s %s\n",
+		formatLineNumber(lineNumber),
+		colors.timestamp("["+times.format(at.Local(), textTimeLayout)+"]"),
+		colors.style(styleBold, "("+truncateString(group, 40)+")"),
+		content)
+}
+
 // formatStreamingEntriesResult formats entries output from streaming query
 func formatStreamingEntriesResult(entries []buildkitelogs.ParquetLogEntry, totalEntries, matchedEntries int, queryTime float64, config *QueryConfig) error {
+	if config.Format == "jsonl" || config.Format == "csv" {
+		return nil // Entries were already streamed as they were read
+	}
+
 	if config.Format == "json" {
 		result := struct {
-			Entries []buildkitelogs.ParquetLogEntry `json:"entries"`
+			Entries []jsonEntry `json:"entries"`
 			Stats   struct {
 				TotalEntries   int     `json:"total_entries"`
 				MatchedEntries int     `json:"matched_entries"`
 				QueryTime      float64 `json:"query_time_ms"`
 			} `json:"stats,omitempty"`
 		}{
-			Entries: entries,
+			Entries: jsonEntries(entries, config.times),
 		}
 
 		if config.ShowStats {
@@ -239,76 +1180,197 @@ func formatStreamingEntriesResult(entries []buildkitelogs.ParquetLogEntry, total
 	if config.LimitEntries > 0 && matchedEntries >= config.LimitEntries {
 		limitText = fmt.Sprintf(" (limited to %d)", config.LimitEntries)
 	}
-	fmt.Printf("Entries in group matching '%s': %d%s\n\n", config.GroupName, matchedEntries, limitText)
+	switch config.Operation {
+	case "grep":
+		fmt.Printf("Entries with content matching '%s': %d%s\n\n", config.Pattern, matchedEntries, limitText)
+	case "filter":
+		switch {
+		case config.Where != "" && config.EntryType != "":
+			fmt.Printf("Entries of type '%s' where %s: %d%s\n\n", config.EntryType, config.Where, matchedEntries, limitText)
+		case config.Where != "":
+			fmt.Printf("Entries where %s: %d%s\n\n", config.Where, matchedEntries, limitText)
+		default:
+			fmt.Printf("Entries of type '%s': %d%s\n\n", config.EntryType, matchedEntries, limitText)
+		}
+	default:
+		if config.GroupTail > 0 && matchedEntries > len(entries) {
+			limitText = fmt.Sprintf(" (last %d shown)", len(entries))
+		}
+		fmt.Printf("Entries in group matching '%s': %d%s\n\n", config.GroupName, matchedEntries, limitText)
+	}
 
 	if len(entries) == 0 {
-		fmt.Println("No entries found for the specified group.")
+		switch config.Operation {
+		case "grep":
+			fmt.Println("No entries found matching the specified pattern.")
+		case "filter":
+			fmt.Println("No entries found of the specified type.")
+		default:
+			fmt.Println("No entries found for the specified group.")
+		}
 		return nil
 	}
 
 	for _, entry := range entries {
-		timestamp := time.Unix(0, entry.Timestamp*int64(time.Millisecond))
+		fmt.Println(formatEntryLine(entry, config.colors, config.times))
+	}
+
+	if config.ShowStats {
+		fmt.Printf("\n--- Query Statistics (Streaming) ---\n")
+		if totalEntries > 0 {
+			fmt.Printf("Total entries: %d\n", totalEntries)
+		}
+		fmt.Printf("Matched entries: %d\n", matchedEntries)
+		fmt.Printf("Query time: %.2f ms\n", queryTime)
+	}
+
+	return nil
+}
+
+// showFileInfo displays metadata about the Parquet file
+func showFileInfo(reader *buildkitelogs.ParquetReader, config *QueryConfig) error {
+	if config.Format == "csv" {
+		return fmt.Errorf("csv format is not supported for info")
+	}
+
+	info, err := reader.GetFileInfo()
+	if err != nil {
+		return fmt.Errorf("failed to get file info: %w", err)
+	}
+
+	var schema *schemaInfo
+	if config.ShowSchema {
+		schema, err = readSchemaInfo(reader)
+		if err != nil {
+			return err
+		}
+	}
+
+	if config.Format == "json" || config.Format == "jsonl" {
+		output := struct {
+			*buildkitelogs.ParquetFileInfo
+			Schema *schemaInfo `json:"schema,omitempty"`
+		}{info, schema}
+
+		encoder := json.NewEncoder(os.Stdout)
+		if config.Format == "json" {
+			encoder.SetIndent("", "  ")
+		}
+		return encoder.Encode(output)
+	}
+
+	// Text format
+	fmt.Printf("Parquet File Information:\n")
+	fmt.Printf("  File:         %s\n", config.ParquetFile)
+	fmt.Printf("  Rows:         %d\n", info.RowCount)
+	fmt.Printf("  Columns:      %d\n", info.ColumnCount)
+	fmt.Printf("  File Size:    %d bytes (%s)\n", info.FileSize, humanizeBytes(info.FileSize))
+	fmt.Printf("  Row Groups:   %d\n", info.NumRowGroups)
+	fmt.Printf("  Log Schema:   v%d\n", info.SchemaVersion)
 
-		var markers []string
-		if entry.IsCommand {
-			markers = append(markers, "CMD")
-		}
-		if entry.IsGroup {
-			markers = append(markers, "GRP")
-		}
-		if entry.IsProgress {
-			markers = append(markers, "PROG")
+	if len(info.Metadata) > 0 {
+		keys := make([]string, 0, len(info.Metadata))
+		for key := range info.Metadata {
+			keys = append(keys, key)
 		}
+		sort.Strings(keys)
 
-		markerStr := ""
-		if len(markers) > 0 {
-			markerStr = fmt.Sprintf(" [%s]", strings.Join(markers, ","))
+		fmt.Printf("  Metadata:\n")
+		for _, key := range keys {
+			fmt.Printf("    %-12s %s\n", key+":", info.Metadata[key])
 		}
-
-		fmt.Printf("[%s]%s %s\n",
-			timestamp.Format("2006-01-02 15:04:05.000"),
-			markerStr,
-			entry.Content)
 	}
 
-	if config.ShowStats {
-		fmt.Printf("\n--- Query Statistics (Streaming) ---\n")
-		if totalEntries > 0 {
-			fmt.Printf("Total entries: %d\n", totalEntries)
+	if schema != nil {
+		fmt.Printf("  Schema:\n")
+		for _, column := range schema.Columns {
+			fmt.Printf("    %-16s %s\n", column.Name, column.Type)
+		}
+		if schema.Error != "" {
+			fmt.Printf("  Compatible:   no (%s)\n", schema.Error)
+		} else {
+			fmt.Printf("  Compatible:   yes\n")
 		}
-		fmt.Printf("Matched entries: %d\n", matchedEntries)
-		fmt.Printf("Query time: %.2f ms\n", queryTime)
 	}
 
 	return nil
 }
 
-// showFileInfo displays metadata about the Parquet file
-func showFileInfo(reader *buildkitelogs.ParquetReader, config *QueryConfig) error {
-	info, err := reader.GetFileInfo()
+// schemaInfo describes the columns of a Parquet file and whether it is a compatible log file
+type schemaInfo struct {
+	Columns []schemaColumn `json:"columns"`
+	Error   string         `json:"error,omitempty"`
+}
+
+// schemaColumn is the name and Arrow type of a column
+type schemaColumn struct {
+	Name string `json:"name"`
+	Type string `json:"type"`
+}
+
+// readSchemaInfo reads the schema of the file and validates it against the log schema
+func readSchemaInfo(reader *buildkitelogs.ParquetReader) (*schemaInfo, error) {
+	schema, err := reader.Schema()
 	if err != nil {
-		return fmt.Errorf("failed to get file info: %w", err)
+		return nil, fmt.Errorf("failed to read schema: %w", err)
 	}
 
-	if config.Format == "json" {
-		encoder := json.NewEncoder(os.Stdout)
-		encoder.SetIndent("", "  ")
-		return encoder.Encode(info)
+	info := &schemaInfo{}
+	for _, field := range schema.Fields() {
+		info.Columns = append(info.Columns, schemaColumn{Name: field.Name, Type: field.Type.String()})
 	}
 
-	// Text format
-	fmt.Printf("Parquet File Information:\n")
-	fmt.Printf("  File:         %s\n", config.ParquetFile)
-	fmt.Printf("  Rows:         %d\n", info.RowCount)
-	fmt.Printf("  Columns:      %d\n", info.ColumnCount)
-	fmt.Printf("  File Size:    %d bytes (%.2f MB)\n", info.FileSize, float64(info.FileSize)/(1024*1024))
-	fmt.Printf("  Row Groups:   %d\n", info.NumRowGroups)
+	if err := reader.ValidateSchema(); err != nil {
+		info.Error = err.Error()
+	}
 
-	return nil
+	return info, nil
+}
+
+// headFile shows the first N entries from the file
+func headFile(reader *buildkitelogs.ParquetReader, config *QueryConfig, start time.Time) error {
+	headLines := config.HeadLines
+	if headLines <= 0 {
+		headLines = 10 // Default to 10 lines
+	}
+
+	results := newEntryResults(config)
+	entriesRead := 0
+
+	for entry, err := range reader.ReadEntriesIter() {
+		if err != nil {
+			return fmt.Errorf("error reading entries: %w", err)
+		}
+
+		if err := results.add(entry); err != nil {
+			return err
+		}
+		entriesRead++
+
+		// Stop as soon as the requested lines have been read
+		if entriesRead >= headLines {
+			break
+		}
+	}
+
+	if err := results.flush(); err != nil {
+		return err
+	}
+
+	// Format output
+	queryTime := float64(time.Since(start).Nanoseconds()) / 1e6
+	return formatHeadResult(results.entries, int64(entriesRead), queryTime, config)
 }
 
+// followPollInterval is how often tail -follow checks the file for new rows
+const followPollInterval = time.Second
+
 // tailFile shows the last N entries from the file
 func tailFile(reader *buildkitelogs.ParquetReader, config *QueryConfig, start time.Time) error {
+	if config.Follow && config.Format != "text" && config.Format != "jsonl" {
+		return fmt.Errorf("-follow supports text and jsonl formats only")
+	}
+
 	// Get file info to calculate starting position
 	info, err := reader.GetFileInfo()
 	if err != nil {
@@ -326,7 +1388,7 @@ func tailFile(reader *buildkitelogs.ParquetReader, config *QueryConfig, start ti
 		startRow = 0
 	}
 
-	var entries []buildkitelogs.ParquetLogEntry
+	results := newEntryResults(config)
 	entriesRead := 0
 
 	for entry, err := range reader.SeekToRow(startRow) {
@@ -334,7 +1396,9 @@ func tailFile(reader *buildkitelogs.ParquetReader, config *QueryConfig, start ti
 			return fmt.Errorf("error reading entries: %w", err)
 		}
 
-		entries = append(entries, entry)
+		if err := results.add(entry); err != nil {
+			return err
+		}
 		entriesRead++
 
 		// Limit to requested tail lines
@@ -343,14 +1407,90 @@ func tailFile(reader *buildkitelogs.ParquetReader, config *QueryConfig, start ti
 		}
 	}
 
+	if err := results.flush(); err != nil {
+		return err
+	}
+
 	// Format output
 	queryTime := float64(time.Since(start).Nanoseconds()) / 1e6
-	return formatTailResult(entries, info.RowCount, int64(entriesRead), queryTime, config)
+	if err := formatTailResult(results.entries, info.RowCount, int64(entriesRead), queryTime, config); err != nil {
+		return err
+	}
+
+	if config.Follow {
+		return followFile(config.ParquetFile, config, info.RowCount)
+	}
+
+	return nil
+}
+
+// followFile polls the file and prints rows appended after fromRow until interrupted
+// New rows only become visible once the writer has flushed them and rewritten the
+// Parquet footer, so this suits writers that close and reopen files per row group.
+func followFile(filename string, config *QueryConfig, fromRow int64) error {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	encoder := json.NewEncoder(os.Stdout)
+
+	return pollNewRows(ctx, filename, fromRow, followPollInterval, func(entry buildkitelogs.ParquetLogEntry) error {
+		if config.Format == "jsonl" {
+			if err := encoder.Encode(newJSONEntry(entry, config.times)); err != nil {
+				return fmt.Errorf("failed to write entry: %w", err)
+			}
+			return nil
+		}
+		printNumberedEntries([]buildkitelogs.ParquetLogEntry{entry}, config.colors, config.times)
+		return nil
+	})
+}
+
+// pollNewRows calls fn with each row appended to the file after fromRow, checking every interval until ctx is done
+// The file is reopened on each poll so rows written since the last one are seen. Read errors are
+// retried on the next poll as the file may be mid-write, errors from fn stop polling.
+func pollNewRows(ctx context.Context, filename string, fromRow int64, interval time.Duration, fn func(buildkitelogs.ParquetLogEntry) error) error {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	lastRow := fromRow
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+
+		reader := buildkitelogs.NewParquetReader(filename)
+
+		info, err := reader.GetFileInfo()
+		if err != nil {
+			continue
+		}
+
+		if info.RowCount < lastRow {
+			lastRow = info.RowCount // The file was replaced with a shorter one
+		}
+		if info.RowCount == lastRow {
+			continue
+		}
+
+		for entry, err := range reader.SeekToRow(lastRow) {
+			if err != nil {
+				break // Resume from lastRow on the next poll
+			}
+
+			if err := fn(entry); err != nil {
+				return err
+			}
+			lastRow++
+		}
+	}
 }
 
 // seekToRow starts reading from a specific row
 func seekToRow(reader *buildkitelogs.ParquetReader, config *QueryConfig, start time.Time) error {
-	var entries []buildkitelogs.ParquetLogEntry
+	results := newEntryResults(config)
 	entriesRead := 0
 
 	for entry, err := range reader.SeekToRow(config.SeekToRow) {
@@ -358,7 +1498,9 @@ func seekToRow(reader *buildkitelogs.ParquetReader, config *QueryConfig, start t
 			return fmt.Errorf("error reading entries: %w", err)
 		}
 
-		entries = append(entries, entry)
+		if err := results.add(entry); err != nil {
+			return err
+		}
 		entriesRead++
 
 		// Apply limit if specified
@@ -367,27 +1509,68 @@ func seekToRow(reader *buildkitelogs.ParquetReader, config *QueryConfig, start t
 		}
 	}
 
+	if err := results.flush(); err != nil {
+		return err
+	}
+
 	// Format output
 	queryTime := float64(time.Since(start).Nanoseconds()) / 1e6
-	return formatSeekResult(entries, config.SeekToRow, int64(entriesRead), queryTime, config)
+	return formatSeekResult(results.entries, config.SeekToRow, int64(entriesRead), queryTime, config)
 }
 
-// formatTailResult formats tail command output
-func formatTailResult(entries []buildkitelogs.ParquetLogEntry, totalRows, entriesRead int64, queryTime float64, config *QueryConfig) error {
+// seekToTime starts reading from the first entry at or after a time
+func seekToTime(reader *buildkitelogs.ParquetReader, config *QueryConfig, start time.Time) error {
+	at, err := time.Parse(time.RFC3339Nano, config.SeekTime)
+	if err != nil {
+		return fmt.Errorf("invalid time %q: expected an RFC3339 time such as 2025-04-22T11:43:00Z", config.SeekTime)
+	}
+
+	results := newEntryResults(config)
+	entriesRead := 0
+
+	for entry, err := range reader.SeekToTimestamp(at) {
+		if err != nil {
+			return fmt.Errorf("error reading entries: %w", err)
+		}
+
+		if err := results.add(entry); err != nil {
+			return err
+		}
+		entriesRead++
+
+		// Apply limit if specified
+		if config.LimitEntries > 0 && entriesRead >= config.LimitEntries {
+			break
+		}
+	}
+
+	if err := results.flush(); err != nil {
+		return err
+	}
+
+	// Format output
+	queryTime := float64(time.Since(start).Nanoseconds()) / 1e6
+	return formatSeekTimeResult(results.entries, at, int64(entriesRead), queryTime, config)
+}
+
+// formatHeadResult formats head command output
+func formatHeadResult(entries []buildkitelogs.ParquetLogEntry, entriesRead int64, queryTime float64, config *QueryConfig) error {
+	if config.Format == "jsonl" || config.Format == "csv" {
+		return nil // Entries were already streamed as they were read
+	}
+
 	if config.Format == "json" {
 		result := struct {
-			Entries []buildkitelogs.ParquetLogEntry `json:"entries"`
+			Entries []jsonEntry `json:"entries"`
 			Stats   struct {
-				TotalRows    int64   `json:"total_rows"`
 				EntriesShown int64   `json:"entries_shown"`
 				QueryTime    float64 `json:"query_time_ms"`
 			} `json:"stats,omitempty"`
 		}{
-			Entries: entries,
+			Entries: jsonEntries(entries, config.times),
 		}
 
 		if config.ShowStats {
-			result.Stats.TotalRows = totalRows
 			result.Stats.EntriesShown = entriesRead
 			result.Stats.QueryTime = queryTime
 		}
@@ -398,33 +1581,53 @@ func formatTailResult(entries []buildkitelogs.ParquetLogEntry, totalRows, entrie
 	}
 
 	// Text format
-	fmt.Printf("Last %d entries:\n\n", entriesRead)
+	fmt.Printf("First %d entries:\n\n", entriesRead)
 
-	for _, entry := range entries {
-		timestamp := time.Unix(0, entry.Timestamp*int64(time.Millisecond))
+	printNumberedEntries(entries, config.colors, config.times)
 
-		var markers []string
-		if entry.IsCommand {
-			markers = append(markers, "CMD")
-		}
-		if entry.IsGroup {
-			markers = append(markers, "GRP")
-		}
-		if entry.IsProgress {
-			markers = append(markers, "PROG")
+	if config.ShowStats {
+		fmt.Printf("\n--- Head Statistics ---\n")
+		fmt.Printf("Entries shown: %d\n", entriesRead)
+		fmt.Printf("Query time: %.2f ms\n", queryTime)
+	}
+
+	return nil
+}
+
+// formatTailResult formats tail command output
+func formatTailResult(entries []buildkitelogs.ParquetLogEntry, totalRows, entriesRead int64, queryTime float64, config *QueryConfig) error {
+	if config.Format == "jsonl" || config.Format == "csv" {
+		return nil // Entries were already streamed as they were read
+	}
+
+	if config.Format == "json" {
+		result := struct {
+			Entries []jsonEntry `json:"entries"`
+			Stats   struct {
+				TotalRows    int64   `json:"total_rows"`
+				EntriesShown int64   `json:"entries_shown"`
+				QueryTime    float64 `json:"query_time_ms"`
+			} `json:"stats,omitempty"`
+		}{
+			Entries: jsonEntries(entries, config.times),
 		}
 
-		markerStr := ""
-		if len(markers) > 0 {
-			markerStr = fmt.Sprintf(" [%s]", strings.Join(markers, ","))
+		if config.ShowStats {
+			result.Stats.TotalRows = totalRows
+			result.Stats.EntriesShown = entriesRead
+			result.Stats.QueryTime = queryTime
 		}
 
-		fmt.Printf("[%s]%s %s\n",
-			timestamp.Format("2006-01-02 15:04:05.000"),
-			markerStr,
-			entry.Content)
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(result)
 	}
 
+	// Text format
+	fmt.Printf("Last %d entries:\n\n", entriesRead)
+
+	printNumberedEntries(entries, config.colors, config.times)
+
 	if config.ShowStats {
 		fmt.Printf("\n--- Tail Statistics ---\n")
 		fmt.Printf("Total rows in file: %d\n", totalRows)
@@ -437,16 +1640,20 @@ func formatTailResult(entries []buildkitelogs.ParquetLogEntry, totalRows, entrie
 
 // formatSeekResult formats seek command output
 func formatSeekResult(entries []buildkitelogs.ParquetLogEntry, startRow, entriesRead int64, queryTime float64, config *QueryConfig) error {
+	if config.Format == "jsonl" || config.Format == "csv" {
+		return nil // Entries were already streamed as they were read
+	}
+
 	if config.Format == "json" {
 		result := struct {
-			Entries []buildkitelogs.ParquetLogEntry `json:"entries"`
+			Entries []jsonEntry `json:"entries"`
 			Stats   struct {
 				StartRow     int64   `json:"start_row"`
 				EntriesShown int64   `json:"entries_shown"`
 				QueryTime    float64 `json:"query_time_ms"`
 			} `json:"stats,omitempty"`
 		}{
-			Entries: entries,
+			Entries: jsonEntries(entries, config.times),
 		}
 
 		if config.ShowStats {
@@ -467,34 +1674,62 @@ func formatSeekResult(entries []buildkitelogs.ParquetLogEntry, startRow, entries
 	}
 	fmt.Printf("Entries starting from row %d: %d%s\n\n", startRow, entriesRead, limitText)
 
-	for _, entry := range entries {
-		timestamp := time.Unix(0, entry.Timestamp*int64(time.Millisecond))
+	printNumberedEntries(entries, config.colors, config.times)
 
-		var markers []string
-		if entry.IsCommand {
-			markers = append(markers, "CMD")
-		}
-		if entry.IsGroup {
-			markers = append(markers, "GRP")
-		}
-		if entry.IsProgress {
-			markers = append(markers, "PROG")
+	if config.ShowStats {
+		fmt.Printf("\n--- Seek Statistics ---\n")
+		fmt.Printf("Start row: %d\n", startRow)
+		fmt.Printf("Entries shown: %d\n", entriesRead)
+		fmt.Printf("Query time: %.2f ms\n", queryTime)
+	}
+
+	return nil
+}
+
+// formatSeekTimeResult formats seek-time command output
+func formatSeekTimeResult(entries []buildkitelogs.ParquetLogEntry, at time.Time, entriesRead int64, queryTime float64, config *QueryConfig) error {
+	if config.Format == "jsonl" || config.Format == "csv" {
+		return nil // Entries were already streamed as they were read
+	}
+
+	if config.Format == "json" {
+		result := struct {
+			Entries []jsonEntry `json:"entries"`
+			Stats   struct {
+				StartTime    any     `json:"start_time"`
+				EntriesShown int64   `json:"entries_shown"`
+				QueryTime    float64 `json:"query_time_ms"`
+			} `json:"stats,omitempty"`
+		}{
+			Entries: jsonEntries(entries, config.times),
 		}
 
-		markerStr := ""
-		if len(markers) > 0 {
-			markerStr = fmt.Sprintf(" [%s]", strings.Join(markers, ","))
+		if config.ShowStats {
+			result.Stats.StartTime = config.times.jsonValue(at, at.Format(time.RFC3339Nano))
+			result.Stats.EntriesShown = entriesRead
+			result.Stats.QueryTime = queryTime
 		}
 
-		fmt.Printf("[%s]%s %s\n",
-			timestamp.Format("2006-01-02 15:04:05.000"),
-			markerStr,
-			entry.Content)
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(result)
+	}
+
+	// Text format
+	limitText := ""
+	if config.LimitEntries > 0 && entriesRead >= int64(config.LimitEntries) {
+		limitText = fmt.Sprintf(" (limited to %d)", config.LimitEntries)
+	}
+	fmt.Printf("Entries from %s: %d%s\n\n", config.times.format(at, time.RFC3339Nano), entriesRead, limitText)
+
+	if len(entries) == 0 {
+		fmt.Println("No entries at or after this time.")
 	}
+	printNumberedEntries(entries, config.colors, config.times)
 
 	if config.ShowStats {
 		fmt.Printf("\n--- Seek Statistics ---\n")
-		fmt.Printf("Start row: %d\n", startRow)
+		fmt.Printf("Start time: %s\n", config.times.format(at, time.RFC3339Nano))
 		fmt.Printf("Entries shown: %d\n", entriesRead)
 		fmt.Printf("Query time: %.2f ms\n", queryTime)
 	}
@@ -502,6 +1737,171 @@ func formatSeekResult(entries []buildkitelogs.ParquetLogEntry, startRow, entries
 	return nil
 }
 
+// jsonEntry is an entry as written to query JSON output, with its timestamp in the -time-format
+// By default it keeps the stored Unix milliseconds.
+type jsonEntry struct {
+	buildkitelogs.ParquetLogEntry
+	Timestamp any `json:"timestamp"`
+}
+
+// newJSONEntry converts an entry for JSON output
+func newJSONEntry(entry buildkitelogs.ParquetLogEntry, times timeFormat) jsonEntry {
+	return jsonEntry{
+		ParquetLogEntry: entry,
+		Timestamp:       times.jsonValue(time.UnixMilli(entry.Timestamp).UTC(), entry.Timestamp),
+	}
+}
+
+// jsonEntries converts entries for JSON output, keeping nil as nil
+func jsonEntries(entries []buildkitelogs.ParquetLogEntry, times timeFormat) []jsonEntry {
+	if entries == nil {
+		return nil
+	}
+	converted := make([]jsonEntry, 0, len(entries))
+	for _, entry := range entries {
+		converted = append(converted, newJSONEntry(entry, times))
+	}
+	return converted
+}
+
+// csvHeader is the header row written for csv output
+var csvHeader = []string{"timestamp", "group", "content", "is_command", "is_group", "is_progress"}
+
+// entryResults accumulates query results for formatting
+// For jsonl and csv output entries are written to stdout as they arrive instead of being buffered,
+// keeping memory use constant regardless of the number of matches.
+type entryResults struct {
+	entries   []buildkitelogs.ParquetLogEntry
+	writer    *bufio.Writer
+	encoder   *json.Encoder
+	csvWriter *csv.Writer
+	csvHeader bool       // Whether the csv header row has been written
+	times     timeFormat // Formats jsonl and csv timestamps
+}
+
+// newEntryResults creates an entryResults for the configured output format
+func newEntryResults(config *QueryConfig) *entryResults {
+	results := &entryResults{times: config.times}
+	switch config.Format {
+	case "jsonl":
+		results.writer = bufio.NewWriter(os.Stdout)
+		results.encoder = json.NewEncoder(results.writer)
+	case "csv":
+		results.csvWriter = csv.NewWriter(os.Stdout)
+	}
+	return results
+}
+
+// add records an entry, streaming it immediately for jsonl and csv output
+func (r *entryResults) add(entry buildkitelogs.ParquetLogEntry) error {
+	if r.encoder != nil {
+		if err := r.encoder.Encode(newJSONEntry(entry, r.times)); err != nil {
+			return fmt.Errorf("failed to write entry: %w", err)
+		}
+		return nil
+	}
+
+	if r.csvWriter != nil {
+		if err := r.writeCSVHeader(); err != nil {
+			return err
+		}
+		return r.writeCSV(entry)
+	}
+
+	r.entries = append(r.entries, entry)
+	return nil
+}
+
+// writeCSVHeader writes the csv header row once
+func (r *entryResults) writeCSVHeader() error {
+	if r.csvHeader {
+		return nil
+	}
+	r.csvHeader = true
+
+	if err := r.csvWriter.Write(csvHeader); err != nil {
+		return fmt.Errorf("failed to write csv header: %w", err)
+	}
+	return nil
+}
+
+// writeCSV writes an entry as a csv row
+func (r *entryResults) writeCSV(entry buildkitelogs.ParquetLogEntry) error {
+	timestamp := time.Unix(0, entry.Timestamp*int64(time.Millisecond)).UTC()
+	record := []string{
+		r.times.format(timestamp, csvTimeLayout),
+		entry.Group,
+		entry.Content,
+		strconv.FormatBool(entry.IsCommand),
+		strconv.FormatBool(entry.IsGroup),
+		strconv.FormatBool(entry.IsProgress),
+	}
+	if err := r.csvWriter.Write(record); err != nil {
+		return fmt.Errorf("failed to write entry: %w", err)
+	}
+	return nil
+}
+
+// flush writes any buffered streamed output
+func (r *entryResults) flush() error {
+	if r.csvWriter != nil {
+		// Results with no entries still get a header row
+		if err := r.writeCSVHeader(); err != nil {
+			return err
+		}
+		r.csvWriter.Flush()
+		return r.csvWriter.Error()
+	}
+	if r.writer == nil {
+		return nil
+	}
+	return r.writer.Flush()
+}
+
+// printNumberedEntries prints entries in text format prefixed with their source line numbers
+func printNumberedEntries(entries []buildkitelogs.ParquetLogEntry, colors colorizer, times timeFormat) {
+	for _, entry := range entries {
+		fmt.Printf("%s%s\n", formatLineNumber(entry.LineNumber), formatEntryLine(entry, colors, times))
+	}
+}
+
+// formatEntryLine formats an entry as a text line with its timestamp, type markers and content
+func formatEntryLine(entry buildkitelogs.ParquetLogEntry, colors colorizer, times timeFormat) string {
+	timestamp := time.Unix(0, entry.Timestamp*int64(time.Millisecond))
+
+	var markers []string
+	if entry.IsCommand {
+		markers = append(markers, "CMD")
+	}
+	if entry.IsGroup {
+		markers = append(markers, "GRP")
+	}
+	if entry.IsProgress {
+		markers = append(markers, "PROG")
+	}
+	if entry.IsError {
+		markers = append(markers, "ERR")
+	}
+
+	markerStr := ""
+	if len(markers) > 0 {
+		markerStr = fmt.Sprintf(" [%s]", strings.Join(markers, ","))
+	}
+
+	return fmt.Sprintf("%s%s %s",
+		colors.timestamp("["+times.format(timestamp, textTimeLayout)+"]"),
+		markerStr,
+		colors.content(entry.Content, entry.IsGroup, entry.IsCommand, entry.IsError))
+}
+
+// formatLineNumber returns a source line number prefix, or nothing for files without line numbers
+func formatLineNumber(lineNumber int64) string {
+	if lineNumber <= 0 {
+		return ""
+	}
+	return fmt.Sprintf("%6d ", lineNumber)
+}
+
 // truncateString truncates a string to the specified length
 func truncateString(s string, maxLen int) string {
 	if len(s) <= maxLen {
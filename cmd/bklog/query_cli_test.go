@@ -0,0 +1,513 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"iter"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	buildkitelogs "github.com/wolfeidau/buildkite-logs-parquet"
+)
+
+func TestCollectByGroupTotalEntries(t *testing.T) {
+	testFile := "../../testdata/bash-example.parquet"
+	reader := buildkitelogs.NewParquetReader(testFile)
+
+	info, err := reader.GetFileInfo()
+	if err != nil {
+		t.Fatalf("GetFileInfo failed: %v", err)
+	}
+
+	tests := []struct {
+		name          string
+		limit         int
+		showStats     bool
+		expectedTotal int
+		expectedMatch int
+	}{
+		{name: "all matches", showStats: true, expectedTotal: int(info.RowCount), expectedMatch: 2},
+		{name: "limited with stats", limit: 1, showStats: true, expectedTotal: int(info.RowCount), expectedMatch: 1},
+		{name: "limited without stats", limit: 1, expectedTotal: 0, expectedMatch: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := &QueryConfig{
+				ParquetFile:  testFile,
+				Operation:    "by-group",
+				GroupName:    "environment hook",
+				Format:       "json",
+				ShowStats:    tt.showStats,
+				LimitEntries: tt.limit,
+			}
+			results := newEntryResults(config)
+
+			totalEntries, matchedEntries, err := collectByGroup(reader, config, results)
+			if err != nil {
+				t.Fatalf("collectByGroup failed: %v", err)
+			}
+
+			if totalEntries != tt.expectedTotal {
+				t.Errorf("total_entries = %d, want %d", totalEntries, tt.expectedTotal)
+			}
+			if matchedEntries != tt.expectedMatch {
+				t.Errorf("matched_entries = %d, want %d", matchedEntries, tt.expectedMatch)
+			}
+			if len(results.entries) != tt.expectedMatch {
+				t.Errorf("Expected %d collected entries, got %d", tt.expectedMatch, len(results.entries))
+			}
+		})
+	}
+}
+
+func TestCollectByGroupTail(t *testing.T) {
+	reader := buildkitelogs.NewParquetReader("../../testdata/bash-example.parquet")
+
+	all := &QueryConfig{Operation: "by-group", GroupName: "Example tests", Format: "json"}
+	allResults := newEntryResults(all)
+	if _, _, err := collectByGroup(reader, all, allResults); err != nil {
+		t.Fatalf("collectByGroup failed: %v", err)
+	}
+	if len(allResults.entries) < 5 {
+		t.Fatalf("Expected at least 5 entries in the group, got %d", len(allResults.entries))
+	}
+
+	for _, tail := range []int{1, 3, len(allResults.entries), len(allResults.entries) + 5} {
+		config := &QueryConfig{Operation: "by-group", GroupName: "Example tests", Format: "json", GroupTail: tail}
+		results := newEntryResults(config)
+
+		_, matched, err := collectByGroup(reader, config, results)
+		if err != nil {
+			t.Fatalf("collectByGroup failed: %v", err)
+		}
+		if matched != len(allResults.entries) {
+			t.Errorf("tail %d: matched_entries = %d, want %d", tail, matched, len(allResults.entries))
+		}
+
+		want := allResults.entries[max(len(allResults.entries)-tail, 0):]
+		if len(results.entries) != len(want) {
+			t.Fatalf("tail %d: expected %d entries, got %d", tail, len(want), len(results.entries))
+		}
+		for i := range want {
+			if results.entries[i] != want[i] {
+				t.Errorf("tail %d: entry %d = %q, want %q", tail, i, results.entries[i].Content, want[i].Content)
+			}
+		}
+	}
+
+	config := &QueryConfig{Operation: "by-group", GroupName: "Example tests", Format: "json", GroupTail: 5, LimitEntries: 2}
+	if err := runStreamingQuery(reader, config); err == nil || !strings.Contains(err.Error(), "can't be combined") {
+		t.Errorf("Expected -tail with -limit to fail, got %v", err)
+	}
+}
+
+func TestEntryRing(t *testing.T) {
+	ring := newEntryRing(3)
+	contents := func() []string {
+		var got []string
+		for _, entry := range ring.ordered() {
+			got = append(got, entry.Content)
+		}
+		return got
+	}
+
+	for i, want := range []string{"a", "a b", "a b c", "b c d", "c d e", "d e f", "e f g"} {
+		ring.add(buildkitelogs.ParquetLogEntry{Content: string(rune('a' + i))})
+		if got := strings.Join(contents(), " "); got != want {
+			t.Errorf("After %d adds: %q, want %q", i+1, got, want)
+		}
+	}
+}
+
+func TestGroupTreeOrder(t *testing.T) {
+	groups := []buildkitelogs.GroupInfo{
+		{Name: "--- Build", Parent: "~~~ Script"},
+		{Name: "~~~ Checkout"},
+		{Name: "~~~ Script"},
+		{Name: "+++ Test", Parent: "~~~ Script"},
+		{Name: "--- Orphan", Parent: "~~~ Missing"},
+	}
+
+	var got []string
+	for _, group := range groupTreeOrder(groups) {
+		got = append(got, group.Name)
+	}
+
+	want := []string{"~~~ Checkout", "~~~ Script", "--- Build", "+++ Test", "--- Orphan"}
+	if strings.Join(got, "|") != strings.Join(want, "|") {
+		t.Errorf("groupTreeOrder() = %q, want %q", got, want)
+	}
+}
+
+func TestSplitGroupPatterns(t *testing.T) {
+	tests := []struct {
+		value string
+		want  []string
+	}{
+		{"Running tests", []string{"Running tests"}},
+		{" padded ", []string{" padded "}},
+		{"checkout,tests", []string{"checkout", "tests"}},
+		{"checkout, tests,,", []string{"checkout", "tests"}},
+	}
+
+	for _, tt := range tests {
+		got := splitGroupPatterns(tt.value)
+		if strings.Join(got, "|") != strings.Join(tt.want, "|") {
+			t.Errorf("splitGroupPatterns(%q) = %q, want %q", tt.value, got, tt.want)
+		}
+	}
+}
+
+func TestGroupNameColumn(t *testing.T) {
+	tests := []struct {
+		name  string
+		emoji bool
+		want  string
+	}{
+		{"~~~ Running script", false, "~~~ Running script  "},
+		{"--- :package: Build", false, "--- :package: Build "},
+		{"--- :package: Build", true, "--- \U0001F4E6 Build        "},
+		{"  --- :hammer: Example tests", true, "  --- \U0001F528 Example ..."},
+		{"--- :buildkite: Build", true, "--- :buildkite: B..."},
+	}
+
+	for _, tt := range tests {
+		if got := groupNameColumn(tt.name, 20, tt.emoji); got != tt.want {
+			t.Errorf("groupNameColumn(%q, %v) = %q, want %q", tt.name, tt.emoji, got, tt.want)
+		}
+	}
+}
+
+func TestCollectByGroupMultiplePatterns(t *testing.T) {
+	reader := buildkitelogs.NewParquetReader("../../testdata/bash-example.parquet")
+
+	count := func(groupName string) int {
+		t.Helper()
+		config := &QueryConfig{Operation: "by-group", GroupName: groupName, Format: "json", ShowStats: true}
+		_, matched, err := collectByGroup(reader, config, newEntryResults(config))
+		if err != nil {
+			t.Fatalf("collectByGroup(%q) failed: %v", groupName, err)
+		}
+		return matched
+	}
+
+	hook, script := count("environment hook"), count("running script")
+	if hook == 0 || script == 0 {
+		t.Fatalf("Expected both groups in the test file, got %d and %d entries", hook, script)
+	}
+	if both := count("environment hook,running script"); both != hook+script {
+		t.Errorf("Expected %d entries for both groups, got %d", hook+script, both)
+	}
+
+	config := &QueryConfig{Operation: "by-group", GroupName: "a,b", ExactGroup: true, Format: "json"}
+	if _, _, err := collectByGroup(reader, config, newEntryResults(config)); err == nil {
+		t.Error("Expected -exact with several groups to fail")
+	}
+}
+
+func TestFormatEntryLineColors(t *testing.T) {
+	entry := buildkitelogs.ParquetLogEntry{Timestamp: 0, Content: "$ make", IsCommand: true}
+	plain := formatEntryLine(entry, colorizer{}, timeFormat{})
+
+	if strings.Contains(plain, "\x1b") {
+		t.Errorf("Expected no escape sequences without colors, got %q", plain)
+	}
+	if !strings.HasSuffix(plain, "] [CMD] $ make") {
+		t.Errorf("Unexpected plain line %q", plain)
+	}
+
+	colored := formatEntryLine(entry, colorizer{enabled: true}, timeFormat{})
+	if !strings.Contains(colored, styleCyan+"$ make"+styleReset) || !strings.HasPrefix(colored, styleDim+"[") {
+		t.Errorf("Expected a dimmed timestamp and cyan command, got %q", colored)
+	}
+
+	entry.IsError = true
+	if colored := formatEntryLine(entry, colorizer{enabled: true}, timeFormat{}); !strings.Contains(colored, styleRed+"$ make") {
+		t.Errorf("Expected errors to take precedence over commands, got %q", colored)
+	}
+}
+
+func TestNewColorizer(t *testing.T) {
+	for mode, enabled := range map[string]bool{"always": true, "never": false} {
+		colors, err := newColorizer(mode, os.Stdout)
+		if err != nil {
+			t.Fatalf("newColorizer(%q) failed: %v", mode, err)
+		}
+		if colors.enabled != enabled {
+			t.Errorf("newColorizer(%q).enabled = %v, want %v", mode, colors.enabled, enabled)
+		}
+	}
+
+	if _, err := newColorizer("sometimes", os.Stdout); err == nil {
+		t.Error("Expected an error for an unknown mode")
+	}
+}
+
+func TestParseTimeFormat(t *testing.T) {
+	at := time.Date(2025, time.April, 22, 11, 43, 29, 921_000_000, time.FixedZone("AEST", 10*3600))
+
+	tests := []struct {
+		value string
+		want  string
+	}{
+		{"default", "2025-04-22 11:43:29.921"},
+		{"", "2025-04-22 11:43:29.921"},
+		{"rfc3339", "2025-04-22T11:43:29.921+10:00"},
+		{"epoch-ms", "1745286209921"},
+		{"epoch-s", "1745286209"},
+		{"15:04:05", "11:43:29"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			times, err := parseTimeFormat(tt.value)
+			if err != nil {
+				t.Fatalf("parseTimeFormat() error = %v", err)
+			}
+			if got := times.format(at, textTimeLayout); got != tt.want {
+				t.Errorf("format() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+
+	for _, value := range []string{"epoch-us", "iso", "unix"} {
+		if _, err := parseTimeFormat(value); err == nil {
+			t.Errorf("Expected parseTimeFormat(%q) to fail", value)
+		}
+	}
+}
+
+func TestJSONTimeFormat(t *testing.T) {
+	entry := buildkitelogs.ParquetLogEntry{Timestamp: 1745322209921, Content: "$ make", HasTime: true}
+	group := buildkitelogs.GroupInfo{Name: "~~~ Build", FirstSeen: time.UnixMilli(1745322209921).UTC()}
+
+	tests := []struct {
+		value     string
+		wantEntry string
+		wantGroup string
+	}{
+		{"default", `"timestamp":1745322209921`, `"first_seen":"2025-04-22T11:43:29.921Z","last_seen":"0001-01-01T00:00:00Z"`},
+		{"epoch-ms", `"timestamp":1745322209921`, `"first_seen":1745322209921,"last_seen":null`},
+		{"epoch-s", `"timestamp":1745322209`, `"first_seen":1745322209,"last_seen":null`},
+		{"15:04:05", `"timestamp":"11:43:29"`, `"first_seen":"11:43:29","last_seen":null`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			times, err := parseTimeFormat(tt.value)
+			if err != nil {
+				t.Fatalf("parseTimeFormat() error = %v", err)
+			}
+
+			data, err := json.Marshal(newJSONEntry(entry, times))
+			if err != nil {
+				t.Fatalf("json.Marshal() error = %v", err)
+			}
+			if !strings.Contains(string(data), tt.wantEntry) || strings.Count(string(data), `"timestamp"`) != 1 {
+				t.Errorf("Entry JSON = %s, want it to contain %s", data, tt.wantEntry)
+			}
+
+			data, err = json.Marshal(newJSONGroup(group, times))
+			if err != nil {
+				t.Fatalf("json.Marshal() error = %v", err)
+			}
+			if !strings.Contains(string(data), tt.wantGroup) {
+				t.Errorf("Group JSON = %s, want it to contain %s", data, tt.wantGroup)
+			}
+		})
+	}
+}
+
+func TestCountCommands(t *testing.T) {
+	entries := []buildkitelogs.ParquetLogEntry{
+		{Content: "\x1b[90m$\x1b[0m make test", IsCommand: true},
+		{Content: "$ make test", IsCommand: true},
+		{Content: "$ go vet ./...", IsCommand: true},
+		{Content: "$ ./build.sh", IsCommand: true},
+		{Content: "$ not a command"},
+	}
+
+	var seq iter.Seq2[buildkitelogs.ParquetLogEntry, error] = func(yield func(buildkitelogs.ParquetLogEntry, error) bool) {
+		for _, entry := range entries {
+			if !yield(entry, nil) {
+				return
+			}
+		}
+	}
+
+	counts, total, err := countCommands(seq)
+	if err != nil {
+		t.Fatalf("countCommands failed: %v", err)
+	}
+
+	if total != 4 {
+		t.Errorf("Expected 4 commands, got %d", total)
+	}
+
+	want := []commandCount{{"make test", 2}, {"./build.sh", 1}, {"go vet ./...", 1}}
+	if len(counts) != len(want) {
+		t.Fatalf("Expected %v, got %v", want, counts)
+	}
+	for i := range want {
+		if counts[i] != want[i] {
+			t.Errorf("counts[%d] = %v, want %v", i, counts[i], want[i])
+		}
+	}
+}
+
+func TestCompileWhere(t *testing.T) {
+	command := buildkitelogs.ParquetLogEntry{Timestamp: 1000, Content: "\x1b[90m$\x1b[0m make test", Group: "~~~ Running tests", HasTime: true, IsCommand: true}
+	output := buildkitelogs.ParquetLogEntry{Timestamp: 2000, Content: "ok", Group: "~~~ Running tests", HasTime: true}
+	header := buildkitelogs.ParquetLogEntry{Content: "--- Build", Group: "--- Build", IsGroup: true}
+
+	tests := []struct {
+		expr string
+		want []bool // Matches for command, output and header
+	}{
+		{`is_command`, []bool{true, false, false}},
+		{`!is_command`, []bool{false, true, true}},
+		{`is_command && group~="test"`, []bool{true, false, false}},
+		{`is_command || is_group`, []bool{true, false, true}},
+		{`group == "--- Build"`, []bool{false, false, true}},
+		{`content ~= "$ make"`, []bool{true, false, false}},
+		{`timestamp == 2000`, []bool{false, true, false}},
+		{`has_timestamp == false`, []bool{false, false, true}},
+		{`!(is_command || is_group) && group ~= "Running"`, []bool{false, true, false}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			predicate, err := compileWhere(tt.expr)
+			if err != nil {
+				t.Fatalf("compileWhere() error = %v", err)
+			}
+			for i, entry := range []buildkitelogs.ParquetLogEntry{command, output, header} {
+				if got := predicate(&entry); got != tt.want[i] {
+					t.Errorf("entry %d: got %v, want %v", i, got, tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestCompileWhereStructuredFields(t *testing.T) {
+	logfmt := buildkitelogs.ParquetLogEntry{Content: `level=error msg="waiting for lock"`}
+	json := buildkitelogs.ParquetLogEntry{Content: `{"level":"info","trace-id":"a1"}`}
+	prose := buildkitelogs.ParquetLogEntry{Content: "level=error is not structured"}
+
+	tests := []struct {
+		expr string
+		want []bool // Matches for logfmt, json and prose
+	}{
+		{`field.level == "error"`, []bool{true, false, false}},
+		{`field.level == ""`, []bool{false, false, true}},
+		{`field.msg ~= "lock" || field.trace-id == "a1"`, []bool{true, true, false}},
+		{`content ~= "level=error" && !(field.level == "error")`, []bool{false, false, true}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			predicate, err := compileWhere(tt.expr)
+			if err != nil {
+				t.Fatalf("compileWhere() error = %v", err)
+			}
+			for i, entry := range []buildkitelogs.ParquetLogEntry{logfmt, json, prose} {
+				if got := predicate(&entry); got != tt.want[i] {
+					t.Errorf("entry %d: got %v, want %v", i, got, tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestCompileWhereErrors(t *testing.T) {
+	tests := []struct {
+		expr    string
+		wantErr string
+	}{
+		{``, "position 1: expected a field name, got end of expression"},
+		{`is_command &&`, "position 14: expected a field name"},
+		{`job_id == "1"`, `unknown field "job_id"`},
+		{`group`, `position 6: expected "==" or "~=" after group`},
+		{`group == 1`, "expected a quoted string"},
+		{`timestamp ~= "1"`, `"~=" needs a string field`},
+		{`is_error == "yes"`, "expected true or false"},
+		{`(is_error`, `expected ")"`},
+		{`group == "test`, "unterminated string"},
+		{`is_error != true`, `position 11: unexpected "="`},
+		{`is_error is_group`, `position 10: unexpected "is_group"`},
+		{`field. == "x"`, `unknown field "field."`},
+		{`field.level`, `expected "==" or "~=" after field.level`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			_, err := compileWhere(tt.expr)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("compileWhere(%q) error = %v, want it to contain %q", tt.expr, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestPollNewRows(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "follow.parquet")
+
+	var entries []*buildkitelogs.LogEntry
+	writeRows := func(contents ...string) {
+		for _, content := range contents {
+			entries = append(entries, &buildkitelogs.LogEntry{
+				Timestamp:  time.UnixMilli(1745322209921 + int64(len(entries))),
+				Content:    content,
+				LineNumber: int64(len(entries) + 1),
+			})
+		}
+		if err := buildkitelogs.ExportToParquet(entries, path); err != nil {
+			t.Fatalf("ExportToParquet() error = %v", err)
+		}
+	}
+	writeRows("first", "second")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	seen := make(chan string)
+	done := make(chan error, 1)
+	go func() {
+		done <- pollNewRows(ctx, path, 2, 10*time.Millisecond, func(entry buildkitelogs.ParquetLogEntry) error {
+			select {
+			case seen <- entry.Content:
+			case <-ctx.Done():
+			}
+			return nil
+		})
+	}()
+
+	expect := func(want string) {
+		t.Helper()
+		select {
+		case got := <-seen:
+			if got != want {
+				t.Fatalf("pollNewRows() row = %q, want %q", got, want)
+			}
+		case <-ctx.Done():
+			t.Fatalf("pollNewRows() did not yield %q", want)
+		}
+	}
+
+	writeRows("third")
+	expect("third")
+
+	writeRows("fourth", "fifth")
+	expect("fourth")
+	expect("fifth")
+
+	cancel()
+	if err := <-done; err != nil {
+		t.Errorf("pollNewRows() error = %v", err)
+	}
+}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// Default timestamp layouts of each output, used unless -time-format chooses another
+const (
+	textTimeLayout  = "2006-01-02 15:04:05.000"
+	jsonTimeLayout  = "2006-01-02T15:04:05.000Z"
+	csvTimeLayout   = time.RFC3339Nano
+	groupTimeLayout = "2006-01-02 15:04:05" // Group first and last seen columns
+)
+
+// rfc3339MillisLayout is RFC 3339 with millisecond precision, matching the stored timestamps
+const rfc3339MillisLayout = "2006-01-02T15:04:05.000Z07:00"
+
+// timeFormat formats timestamps for output, as chosen by -time-format
+// Every timestamp parse, query and inspect print goes through it so the outputs can't drift apart.
+// The zero value keeps each output's default.
+type timeFormat struct {
+	epoch  time.Duration // Unit of an epoch format, zero for a layout
+	layout string        // Layout replacing the output's default, empty to keep it
+}
+
+// parseTimeFormat parses a -time-format value: default, rfc3339, epoch-ms, epoch-s or a Go time layout
+// A layout without any reference time elements, such as a misspelt name, is rejected.
+func parseTimeFormat(value string) (timeFormat, error) {
+	switch value {
+	case "", "default":
+		return timeFormat{}, nil
+	case "rfc3339":
+		return timeFormat{layout: rfc3339MillisLayout}, nil
+	case "epoch-ms":
+		return timeFormat{epoch: time.Millisecond}, nil
+	case "epoch-s":
+		return timeFormat{epoch: time.Second}, nil
+	}
+
+	// Any element of the reference time formats this differently from the layout itself
+	probe := time.Date(2001, time.March, 4, 7, 8, 9, 0, time.FixedZone("", 3600))
+	if probe.Format(value) == value {
+		return timeFormat{}, fmt.Errorf("invalid time format %q: use default, rfc3339, epoch-ms, epoch-s or a Go layout such as 15:04:05", value)
+	}
+	return timeFormat{layout: value}, nil
+}
+
+// format formats a timestamp, using defaultLayout unless another format was chosen
+// Layouts format t in its own location, epoch formats are integers.
+func (f timeFormat) format(t time.Time, defaultLayout string) string {
+	switch {
+	case f.epoch == time.Millisecond:
+		return strconv.FormatInt(t.UnixMilli(), 10)
+	case f.epoch == time.Second:
+		return strconv.FormatInt(t.Unix(), 10)
+	case f.layout != "":
+		return t.Format(f.layout)
+	default:
+		return t.Format(defaultLayout)
+	}
+}
+
+// jsonValue returns a timestamp for JSON output, defaultValue unless another format was chosen
+// Epoch formats are numbers and layouts strings. Zero times, which mean unknown, are null.
+func (f timeFormat) jsonValue(t time.Time, defaultValue any) any {
+	switch {
+	case f == timeFormat{}:
+		return defaultValue
+	case t.IsZero():
+		return nil
+	case f.epoch != 0:
+		return json.Number(f.format(t, ""))
+	default:
+		return t.Format(f.layout)
+	}
+}
@@ -0,0 +1,42 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+
+	buildkitelogs "github.com/wolfeidau/buildkite-logs-parquet"
+)
+
+// VerifyConfig holds configuration for the verify command
+type VerifyConfig struct {
+	FilePath string
+}
+
+// errRoundTripMismatch is returned by runVerify when the Parquet round trip changed an entry
+var errRoundTripMismatch = errors.New("parquet round trip is not lossless")
+
+// runVerify parses a log, exports it to Parquet and checks every entry reads back unchanged
+// The first mismatch is printed and errRoundTripMismatch returned, so the command exits non-zero.
+func runVerify(out io.Writer, config *VerifyConfig) error {
+	if config.FilePath == "-" {
+		return fmt.Errorf("verify reads the log twice, so it needs a file rather than stdin")
+	}
+
+	result, err := buildkitelogs.VerifyRoundTrip(config.FilePath)
+	if err != nil {
+		return err
+	}
+
+	if mismatch := result.Mismatch; mismatch != nil {
+		_, _ = fmt.Fprintf(out, "Mismatch in %s of entry %d", mismatch.Field, mismatch.Entry)
+		if mismatch.LineNumber > 0 {
+			_, _ = fmt.Fprintf(out, " (line %d)", mismatch.LineNumber)
+		}
+		_, _ = fmt.Fprintf(out, ":\n  parsed:     %q\n  round trip: %q\n", mismatch.Parsed, mismatch.RoundTrip)
+		return errRoundTripMismatch
+	}
+
+	_, _ = fmt.Fprintf(out, "Verified %d entries: Parquet round trip is lossless\n", result.Entries)
+	return nil
+}
@@ -0,0 +1,323 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	buildkitelogs "github.com/wolfeidau/buildkite-logs-parquet"
+)
+
+// wherePredicate reports whether an entry matches a compiled -where expression
+type wherePredicate func(entry *buildkitelogs.ParquetLogEntry) bool
+
+// whereField describes a field that can be used in a -where expression
+type whereField struct {
+	kind    whereKind
+	str     func(entry *buildkitelogs.ParquetLogEntry) string
+	integer func(entry *buildkitelogs.ParquetLogEntry) int64
+	boolean func(entry *buildkitelogs.ParquetLogEntry) bool
+}
+
+type whereKind int
+
+const (
+	whereString whereKind = iota
+	whereInt
+	whereBool
+)
+
+// whereFields are the fields a -where expression may refer to
+// Content is matched with ANSI sequences stripped, like grep.
+var whereFields = map[string]whereField{
+	"timestamp":     {kind: whereInt, integer: func(e *buildkitelogs.ParquetLogEntry) int64 { return e.Timestamp }},
+	"content":       {kind: whereString, str: func(e *buildkitelogs.ParquetLogEntry) string { return e.CleanContent() }},
+	"group":         {kind: whereString, str: func(e *buildkitelogs.ParquetLogEntry) string { return e.Group }},
+	"has_timestamp": {kind: whereBool, boolean: func(e *buildkitelogs.ParquetLogEntry) bool { return e.HasTime }},
+	"is_command":    {kind: whereBool, boolean: func(e *buildkitelogs.ParquetLogEntry) bool { return e.IsCommand }},
+	"is_group":      {kind: whereBool, boolean: func(e *buildkitelogs.ParquetLogEntry) bool { return e.IsGroup }},
+	"is_progress":   {kind: whereBool, boolean: func(e *buildkitelogs.ParquetLogEntry) bool { return e.IsProgress }},
+	"is_error":      {kind: whereBool, boolean: func(e *buildkitelogs.ParquetLogEntry) bool { return e.IsError }},
+}
+
+// structuredFieldPrefix prefixes a key of a structured log line in a -where expression, e.g. field.level
+const structuredFieldPrefix = "field."
+
+// structuredField returns the string field for a key extracted by ParquetLogEntry.Fields
+// Entries that aren't structured or lack the key have an empty value.
+func structuredField(key string) whereField {
+	return whereField{kind: whereString, str: func(e *buildkitelogs.ParquetLogEntry) string {
+		fields, _ := e.Fields()
+		return fields[key]
+	}}
+}
+
+// compileWhere parses a -where expression into a predicate, so the expression is parsed once per query
+//
+// The grammar is:
+//
+//	expr       = and { "||" and }
+//	and        = unary { "&&" unary }
+//	unary      = "!" unary | primary
+//	primary    = "(" expr ")" | field [ ( "==" | "~=" ) literal ]
+//
+// A boolean field on its own is true when the field is set. "~=" tests whether a string field contains
+// the literal. Literals are double-quoted strings, integers, true or false. A field named field.<key>
+// is the value of key in a logfmt or JSON line, see buildkitelogs.LogEntry.Fields.
+func compileWhere(expr string) (wherePredicate, error) {
+	tokens, err := lexWhere(expr)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &whereParser{tokens: tokens}
+	predicate, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if tok := p.peek(); tok.kind != tokEOF {
+		return nil, p.errorf(tok, "unexpected %s", tok)
+	}
+	return predicate, nil
+}
+
+type whereTokenKind int
+
+const (
+	tokEOF whereTokenKind = iota
+	tokIdent
+	tokString
+	tokNumber
+	tokOp
+)
+
+// whereToken is a lexed token of a -where expression with its byte offset
+type whereToken struct {
+	kind  whereTokenKind
+	text  string
+	value string // Unquoted value of a string literal
+	pos   int
+}
+
+func (t whereToken) String() string {
+	if t.kind == tokEOF {
+		return "end of expression"
+	}
+	return strconv.Quote(t.text)
+}
+
+// lexWhere splits a -where expression into tokens
+func lexWhere(expr string) ([]whereToken, error) {
+	var tokens []whereToken
+
+	for i := 0; i < len(expr); {
+		c := expr[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			i++
+		case c == '(' || c == ')':
+			tokens = append(tokens, whereToken{kind: tokOp, text: string(c), pos: i})
+			i++
+		case c == '!':
+			tokens = append(tokens, whereToken{kind: tokOp, text: "!", pos: i})
+			i++
+		case strings.HasPrefix(expr[i:], "=="), strings.HasPrefix(expr[i:], "~="),
+			strings.HasPrefix(expr[i:], "&&"), strings.HasPrefix(expr[i:], "||"):
+			tokens = append(tokens, whereToken{kind: tokOp, text: expr[i : i+2], pos: i})
+			i += 2
+		case c == '"':
+			end, err := quotedEnd(expr, i)
+			if err != nil {
+				return nil, err
+			}
+			value, err := strconv.Unquote(expr[i:end])
+			if err != nil {
+				return nil, fmt.Errorf("syntax error at position %d: invalid string %s", i+1, expr[i:end])
+			}
+			tokens = append(tokens, whereToken{kind: tokString, text: expr[i:end], value: value, pos: i})
+			i = end
+		case c == '-' || (c >= '0' && c <= '9'):
+			end := i + 1
+			for end < len(expr) && expr[end] >= '0' && expr[end] <= '9' {
+				end++
+			}
+			tokens = append(tokens, whereToken{kind: tokNumber, text: expr[i:end], pos: i})
+			i = end
+		case isIdentByte(c):
+			end := i + 1
+			for end < len(expr) && (isIdentByte(expr[end]) || isIdentTailByte(expr[end])) {
+				end++
+			}
+			tokens = append(tokens, whereToken{kind: tokIdent, text: expr[i:end], pos: i})
+			i = end
+		default:
+			return nil, fmt.Errorf("syntax error at position %d: unexpected %q", i+1, expr[i:i+1])
+		}
+	}
+
+	return append(tokens, whereToken{kind: tokEOF, pos: len(expr)}), nil
+}
+
+// quotedEnd returns the offset just past the closing quote of the string literal starting at start
+func quotedEnd(expr string, start int) (int, error) {
+	for i := start + 1; i < len(expr); i++ {
+		switch expr[i] {
+		case '\\':
+			i++
+		case '"':
+			return i + 1, nil
+		}
+	}
+	return 0, fmt.Errorf("syntax error at position %d: unterminated string", start+1)
+}
+
+func isIdentByte(c byte) bool {
+	return c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+// isIdentTailByte checks for the bytes allowed after the first in a field name, for field.<key>
+func isIdentTailByte(c byte) bool {
+	return c == '.' || c == '-' || (c >= '0' && c <= '9')
+}
+
+// whereParser is a recursive descent parser over lexed -where tokens
+type whereParser struct {
+	tokens []whereToken
+	pos    int
+}
+
+func (p *whereParser) peek() whereToken {
+	return p.tokens[p.pos]
+}
+
+func (p *whereParser) next() whereToken {
+	tok := p.tokens[p.pos]
+	if tok.kind != tokEOF {
+		p.pos++
+	}
+	return tok
+}
+
+// accept consumes the next token if it is the operator op
+func (p *whereParser) accept(op string) bool {
+	if tok := p.peek(); tok.kind == tokOp && tok.text == op {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+func (p *whereParser) errorf(tok whereToken, format string, args ...any) error {
+	return fmt.Errorf("syntax error at position %d: %s", tok.pos+1, fmt.Sprintf(format, args...))
+}
+
+func (p *whereParser) parseOr() (wherePredicate, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for p.accept("||") {
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		l := left
+		left = func(e *buildkitelogs.ParquetLogEntry) bool { return l(e) || right(e) }
+	}
+	return left, nil
+}
+
+func (p *whereParser) parseAnd() (wherePredicate, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	for p.accept("&&") {
+		right, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		l := left
+		left = func(e *buildkitelogs.ParquetLogEntry) bool { return l(e) && right(e) }
+	}
+	return left, nil
+}
+
+func (p *whereParser) parseUnary() (wherePredicate, error) {
+	if p.accept("!") {
+		operand, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return func(e *buildkitelogs.ParquetLogEntry) bool { return !operand(e) }, nil
+	}
+	return p.parsePrimary()
+}
+
+func (p *whereParser) parsePrimary() (wherePredicate, error) {
+	if p.accept("(") {
+		inner, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if !p.accept(")") {
+			return nil, p.errorf(p.peek(), "expected \")\", got %s", p.peek())
+		}
+		return inner, nil
+	}
+
+	tok := p.next()
+	if tok.kind != tokIdent {
+		return nil, p.errorf(tok, "expected a field name, got %s", tok)
+	}
+	field, ok := whereFields[tok.text]
+	if key, structured := strings.CutPrefix(tok.text, structuredFieldPrefix); structured && key != "" {
+		field, ok = structuredField(key), true
+	}
+	if !ok {
+		return nil, p.errorf(tok, "unknown field %q (supported: timestamp, content, group, has_timestamp, is_command, is_group, is_progress, is_error, field.<key>)", tok.text)
+	}
+
+	op := p.peek()
+	if op.kind != tokOp || (op.text != "==" && op.text != "~=") {
+		if field.kind != whereBool {
+			return nil, p.errorf(op, "expected \"==\" or \"~=\" after %s, got %s", tok.text, op)
+		}
+		return func(e *buildkitelogs.ParquetLogEntry) bool { return field.boolean(e) }, nil
+	}
+	p.next()
+
+	literal := p.next()
+	return p.comparison(tok.text, field, op, literal)
+}
+
+// comparison builds the predicate comparing a field to a literal, checking the literal's type
+func (p *whereParser) comparison(name string, field whereField, op, literal whereToken) (wherePredicate, error) {
+	if op.text == "~=" && field.kind != whereString {
+		return nil, p.errorf(op, "\"~=\" needs a string field, %s is not one", name)
+	}
+
+	switch field.kind {
+	case whereString:
+		if literal.kind != tokString {
+			return nil, p.errorf(literal, "expected a quoted string to compare with %s, got %s", name, literal)
+		}
+		want := literal.value
+		if op.text == "~=" {
+			return func(e *buildkitelogs.ParquetLogEntry) bool { return strings.Contains(field.str(e), want) }, nil
+		}
+		return func(e *buildkitelogs.ParquetLogEntry) bool { return field.str(e) == want }, nil
+	case whereInt:
+		want, err := strconv.ParseInt(literal.text, 10, 64)
+		if literal.kind != tokNumber || err != nil {
+			return nil, p.errorf(literal, "expected an integer to compare with %s, got %s", name, literal)
+		}
+		return func(e *buildkitelogs.ParquetLogEntry) bool { return field.integer(e) == want }, nil
+	default:
+		if literal.kind != tokIdent || (literal.text != "true" && literal.text != "false") {
+			return nil, p.errorf(literal, "expected true or false to compare with %s, got %s", name, literal)
+		}
+		want := literal.text == "true"
+		return func(e *buildkitelogs.ParquetLogEntry) bool { return field.boolean(e) == want }, nil
+	}
+}
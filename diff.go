@@ -0,0 +1,240 @@
+package buildkitelogs
+
+import (
+	"context"
+	"fmt"
+	"iter"
+)
+
+// DiffOp is how a line compares between the two logs of a diff
+type DiffOp int
+
+const (
+	DiffCommon  DiffOp = iota // Line is in both logs
+	DiffRemoved               // Line is only in the first log
+	DiffAdded                 // Line is only in the second log
+)
+
+// String returns the op name
+func (op DiffOp) String() string {
+	switch op {
+	case DiffCommon:
+		return "common"
+	case DiffRemoved:
+		return "removed"
+	case DiffAdded:
+		return "added"
+	default:
+		return fmt.Sprintf("DiffOp(%d)", int(op))
+	}
+}
+
+// MarshalText encodes the op by name, so it appears as "added" rather than 2 in JSON
+func (op DiffOp) MarshalText() ([]byte, error) {
+	return []byte(op.String()), nil
+}
+
+// DiffEntry is a single line of a diff between two Parquet log files
+type DiffEntry struct {
+	Op      DiffOp `json:"op"`
+	Group   string `json:"group"`
+	Content string `json:"content"`
+	LineA   int64  `json:"line_a"` // Line number in the first log, 0 for added lines or if not recorded
+	LineB   int64  `json:"line_b"` // Line number in the second log, 0 for removed lines or if not recorded
+}
+
+// maxDiffCells bounds the LCS table built for a pair of groups (or the group sequences)
+// Above it the differing middle of the pair is reported as removed then added rather than aligned.
+const maxDiffCells = 1 << 22
+
+// diffColumns are the columns read when diffing files
+var diffColumns = []string{"content", "group", "line_number"}
+
+// DiffParquet compares two Parquet log files line by line, for example two runs of the same pipeline
+// Groups are aligned by name in the order they appear, then the lines of each aligned pair are compared
+// by content with a longest common subsequence. Lines of groups only in one file are all added or removed.
+// Lines are compared including ANSI codes, but timestamps are ignored.
+//
+// The diff is heuristic: a group that moves relative to the others is reported as removed and added.
+// Only one group of each file is held in memory at a time, and each file is read twice.
+func DiffParquet(a, b string) iter.Seq2[DiffEntry, error] {
+	return func(yield func(DiffEntry, error) bool) {
+		runsA, err := readGroupRuns(fileSource(a))
+		if err != nil {
+			yield(DiffEntry{}, fmt.Errorf("failed to read groups from %s: %w", a, err))
+			return
+		}
+		runsB, err := readGroupRuns(fileSource(b))
+		if err != nil {
+			yield(DiffEntry{}, fmt.Errorf("failed to read groups from %s: %w", b, err))
+			return
+		}
+
+		nextA, stopA := iter.Pull2(readParquetFileStreamingIter(context.Background(), fileSource(a), 5000, streamOptions{columns: diffColumns}))
+		defer stopA()
+		nextB, stopB := iter.Pull2(readParquetFileStreamingIter(context.Background(), fileSource(b), 5000, streamOptions{columns: diffColumns}))
+		defer stopB()
+
+		steps := diffScript(len(runsA), len(runsB), func(i, j int) bool { return runsA[i].name == runsB[j].name })
+		for _, step := range steps {
+			var linesA, linesB []ParquetLogEntry
+			if step.op != DiffAdded {
+				if linesA, err = pullRun(nextA, runsA[step.a]); err != nil {
+					yield(DiffEntry{}, fmt.Errorf("failed to read %s: %w", a, err))
+					return
+				}
+			}
+			if step.op != DiffRemoved {
+				if linesB, err = pullRun(nextB, runsB[step.b]); err != nil {
+					yield(DiffEntry{}, fmt.Errorf("failed to read %s: %w", b, err))
+					return
+				}
+			}
+
+			if !diffGroup(linesA, linesB, yield) {
+				return
+			}
+		}
+	}
+}
+
+// groupRun is a run of consecutive rows belonging to the same group
+type groupRun struct {
+	name string
+	rows int
+}
+
+// readGroupRuns reads the group column and returns the runs of consecutive rows in each group
+// A group name can appear in more than one run if its rows are not contiguous.
+func readGroupRuns(src parquetSource) ([]groupRun, error) {
+	var runs []groupRun
+	for entry, err := range readParquetFileStreamingIter(context.Background(), src, 5000, streamOptions{columns: []string{"group"}}) {
+		if err != nil {
+			return nil, err
+		}
+		if len(runs) > 0 && runs[len(runs)-1].name == entry.Group {
+			runs[len(runs)-1].rows++
+			continue
+		}
+		runs = append(runs, groupRun{name: entry.Group, rows: 1})
+	}
+	return runs, nil
+}
+
+// pullRun reads the rows of the next group run
+func pullRun(next func() (ParquetLogEntry, error, bool), run groupRun) ([]ParquetLogEntry, error) {
+	entries := make([]ParquetLogEntry, 0, run.rows)
+	for len(entries) < run.rows {
+		entry, err, ok := next()
+		if !ok {
+			return nil, fmt.Errorf("file ended inside group %q, was it modified while diffing?", run.name)
+		}
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, entry)
+	}
+	return entries, nil
+}
+
+// diffGroup yields the line diff of a group in each file, either of which may be empty
+func diffGroup(a, b []ParquetLogEntry, yield func(DiffEntry, error) bool) bool {
+	for _, step := range diffScript(len(a), len(b), func(i, j int) bool { return a[i].Content == b[j].Content }) {
+		var entry DiffEntry
+		switch step.op {
+		case DiffCommon:
+			entry = DiffEntry{Op: DiffCommon, Group: a[step.a].Group, Content: a[step.a].Content, LineA: a[step.a].LineNumber, LineB: b[step.b].LineNumber}
+		case DiffRemoved:
+			entry = DiffEntry{Op: DiffRemoved, Group: a[step.a].Group, Content: a[step.a].Content, LineA: a[step.a].LineNumber}
+		case DiffAdded:
+			entry = DiffEntry{Op: DiffAdded, Group: b[step.b].Group, Content: b[step.b].Content, LineB: b[step.b].LineNumber}
+		}
+		if !yield(entry, nil) {
+			return false
+		}
+	}
+	return true
+}
+
+// diffStep is one step of an edit script, a and b index the sequences being compared
+type diffStep struct {
+	op   DiffOp
+	a, b int
+}
+
+// diffScript returns the edit script turning a sequence of length n into one of length m,
+// where equal reports whether element i of the first equals element j of the second
+// Removals are listed before additions where the two sequences differ.
+func diffScript(n, m int, equal func(i, j int) bool) []diffStep {
+	steps := make([]diffStep, 0, max(n, m))
+
+	// Common prefix and suffix are matched directly, which keeps the table small for similar logs
+	prefix := 0
+	for prefix < n && prefix < m && equal(prefix, prefix) {
+		steps = append(steps, diffStep{op: DiffCommon, a: prefix, b: prefix})
+		prefix++
+	}
+	suffix := 0
+	for suffix < n-prefix && suffix < m-prefix && equal(n-1-suffix, m-1-suffix) {
+		suffix++
+	}
+
+	steps = append(steps, lcsScript(prefix, n-suffix, prefix, m-suffix, equal)...)
+
+	for k := suffix; k > 0; k-- {
+		steps = append(steps, diffStep{op: DiffCommon, a: n - k, b: m - k})
+	}
+	return steps
+}
+
+// lcsScript returns the edit script for a[aStart:aEnd] and b[bStart:bEnd] from their longest common subsequence
+func lcsScript(aStart, aEnd, bStart, bEnd int, equal func(i, j int) bool) []diffStep {
+	n, m := aEnd-aStart, bEnd-bStart
+	var steps []diffStep
+
+	if n == 0 || m == 0 || n*m > maxDiffCells {
+		for i := aStart; i < aEnd; i++ {
+			steps = append(steps, diffStep{op: DiffRemoved, a: i})
+		}
+		for j := bStart; j < bEnd; j++ {
+			steps = append(steps, diffStep{op: DiffAdded, b: j})
+		}
+		return steps
+	}
+
+	// lengths[i][j] is the LCS length of a[aStart+i:aEnd] and b[bStart+j:bEnd]
+	width := m + 1
+	lengths := make([]int32, (n+1)*width)
+	for i := n - 1; i >= 0; i-- {
+		for j := m - 1; j >= 0; j-- {
+			if equal(aStart+i, bStart+j) {
+				lengths[i*width+j] = lengths[(i+1)*width+j+1] + 1
+			} else {
+				lengths[i*width+j] = max(lengths[(i+1)*width+j], lengths[i*width+j+1])
+			}
+		}
+	}
+
+	i, j := 0, 0
+	for i < n && j < m {
+		switch {
+		case equal(aStart+i, bStart+j):
+			steps = append(steps, diffStep{op: DiffCommon, a: aStart + i, b: bStart + j})
+			i++
+			j++
+		case lengths[(i+1)*width+j] >= lengths[i*width+j+1]:
+			steps = append(steps, diffStep{op: DiffRemoved, a: aStart + i})
+			i++
+		default:
+			steps = append(steps, diffStep{op: DiffAdded, b: bStart + j})
+			j++
+		}
+	}
+	for ; i < n; i++ {
+		steps = append(steps, diffStep{op: DiffRemoved, a: aStart + i})
+	}
+	for ; j < m; j++ {
+		steps = append(steps, diffStep{op: DiffAdded, b: bStart + j})
+	}
+	return steps
+}
@@ -0,0 +1,130 @@
+package buildkitelogs
+
+import (
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestDiffParquet(t *testing.T) {
+	// The second run has a flaky test failure, a retry, and lost its lint group
+	runA := "\x1b_bk;t=1745322209000\x07--- Setup\n" +
+		"\x1b_bk;t=1745322209001\x07$ make deps\n" +
+		"\x1b_bk;t=1745322209002\x07--- Lint\n" +
+		"\x1b_bk;t=1745322209003\x07$ make lint\n" +
+		"\x1b_bk;t=1745322209004\x07--- Tests\n" +
+		"\x1b_bk;t=1745322209005\x07$ make test\n" +
+		"\x1b_bk;t=1745322209006\x07ok pkg/a\n" +
+		"\x1b_bk;t=1745322209007\x07ok pkg/b\n" +
+		"\x1b_bk;t=1745322209008\x07done"
+	runB := "\x1b_bk;t=1745322300000\x07--- Setup\n" +
+		"\x1b_bk;t=1745322300001\x07$ make deps\n" +
+		"\x1b_bk;t=1745322300004\x07--- Tests\n" +
+		"\x1b_bk;t=1745322300005\x07$ make test\n" +
+		"\x1b_bk;t=1745322300006\x07ok pkg/a\n" +
+		"\x1b_bk;t=1745322300007\x07FAIL pkg/b\n" +
+		"\x1b_bk;t=1745322300008\x07retrying\n" +
+		"\x1b_bk;t=1745322300009\x07done"
+
+	fileA := "test_diff_a.parquet"
+	fileB := "test_diff_b.parquet"
+	defer func() {
+		_ = os.Remove(fileA)
+		_ = os.Remove(fileB)
+	}()
+
+	if err := ExportSeq2ToParquet(NewParser().All(strings.NewReader(runA)), fileA); err != nil {
+		t.Fatalf("ExportSeq2ToParquet() error = %v", err)
+	}
+	if err := ExportSeq2ToParquet(NewParser().All(strings.NewReader(runB)), fileB); err != nil {
+		t.Fatalf("ExportSeq2ToParquet() error = %v", err)
+	}
+
+	expected := []DiffEntry{
+		{Op: DiffCommon, Group: "--- Setup", Content: "--- Setup", LineA: 1, LineB: 1},
+		{Op: DiffCommon, Group: "--- Setup", Content: "$ make deps", LineA: 2, LineB: 2},
+		{Op: DiffRemoved, Group: "--- Lint", Content: "--- Lint", LineA: 3},
+		{Op: DiffRemoved, Group: "--- Lint", Content: "$ make lint", LineA: 4},
+		{Op: DiffCommon, Group: "--- Tests", Content: "--- Tests", LineA: 5, LineB: 3},
+		{Op: DiffCommon, Group: "--- Tests", Content: "$ make test", LineA: 6, LineB: 4},
+		{Op: DiffCommon, Group: "--- Tests", Content: "ok pkg/a", LineA: 7, LineB: 5},
+		{Op: DiffRemoved, Group: "--- Tests", Content: "ok pkg/b", LineA: 8},
+		{Op: DiffAdded, Group: "--- Tests", Content: "FAIL pkg/b", LineB: 6},
+		{Op: DiffAdded, Group: "--- Tests", Content: "retrying", LineB: 7},
+		{Op: DiffCommon, Group: "--- Tests", Content: "done", LineA: 9, LineB: 8},
+	}
+
+	var diff []DiffEntry
+	for entry, err := range DiffParquet(fileA, fileB) {
+		if err != nil {
+			t.Fatalf("DiffParquet() error = %v", err)
+		}
+		diff = append(diff, entry)
+	}
+
+	if len(diff) != len(expected) {
+		t.Fatalf("Expected %d diff entries, got %d: %+v", len(expected), len(diff), diff)
+	}
+	for i := range expected {
+		if diff[i] != expected[i] {
+			t.Errorf("Entry %d = %+v, want %+v", i, diff[i], expected[i])
+		}
+	}
+}
+
+func TestDiffParquetIdentical(t *testing.T) {
+	filename := "testdata/bash-example.parquet"
+
+	info, err := NewParquetReader(filename).GetFileInfo()
+	if err != nil {
+		t.Fatalf("GetFileInfo() error = %v", err)
+	}
+
+	var count int64
+	for entry, err := range DiffParquet(filename, filename) {
+		if err != nil {
+			t.Fatalf("DiffParquet() error = %v", err)
+		}
+		if entry.Op != DiffCommon {
+			t.Fatalf("Expected only common lines, got %s line %q", entry.Op, entry.Content)
+		}
+		count++
+	}
+	if count != info.RowCount {
+		t.Errorf("Expected %d common lines, got %d", info.RowCount, count)
+	}
+}
+
+func TestDiffScript(t *testing.T) {
+	tests := []struct {
+		name     string
+		a, b     string
+		expected string
+	}{
+		{name: "equal", a: "abc", b: "abc", expected: "=a=b=c"},
+		{name: "empty first", a: "", b: "ab", expected: "+a+b"},
+		{name: "empty second", a: "ab", b: "", expected: "-a-b"},
+		{name: "insert middle", a: "ac", b: "abc", expected: "=a+b=c"},
+		{name: "replace", a: "axc", b: "ayc", expected: "=a-x+y=c"},
+		{name: "interleaved", a: "abcabba", b: "cbabac", expected: "-a-b=c-a=b+a=b=a+c"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got strings.Builder
+			for _, step := range diffScript(len(tt.a), len(tt.b), func(i, j int) bool { return tt.a[i] == tt.b[j] }) {
+				switch step.op {
+				case DiffCommon:
+					got.WriteString("=" + tt.a[step.a:step.a+1])
+				case DiffRemoved:
+					got.WriteString("-" + tt.a[step.a:step.a+1])
+				case DiffAdded:
+					got.WriteString("+" + tt.b[step.b:step.b+1])
+				}
+			}
+			if got.String() != tt.expected {
+				t.Errorf("diffScript(%q, %q) = %s, want %s", tt.a, tt.b, got.String(), tt.expected)
+			}
+		})
+	}
+}
@@ -0,0 +1,125 @@
+package buildkitelogs
+
+import "strings"
+
+// groupEmoji maps common emoji shortcodes in group headers to Unicode emoji
+// Buildkite's custom emoji, such as :buildkite: or :docker:, have no Unicode equivalent and aren't mapped.
+var groupEmoji = map[string]string{
+	":+1:":                       "\U0001F44D",
+	":bar_chart:":                "\U0001F4CA",
+	":bug:":                      "\U0001F41B",
+	":building_construction:":    "\U0001F3D7\uFE0F",
+	":cat:":                      "\U0001F431",
+	":chart_with_upwards_trend:": "\U0001F4C8",
+	":clipboard:":                "\U0001F4CB",
+	":closed_lock_with_key:":     "\U0001F510",
+	":cloud:":                    "\u2601\uFE0F",
+	":construction:":             "\U0001F6A7",
+	":evergreen_tree:":           "\U0001F332",
+	":fire:":                     "\U0001F525",
+	":frame_with_picture:":       "\U0001F5BC\uFE0F",
+	":gear:":                     "\u2699\uFE0F",
+	":hammer:":                   "\U0001F528",
+	":hammer_and_wrench:":        "\U0001F6E0\uFE0F",
+	":hourglass:":                "\u231B",
+	":key:":                      "\U0001F511",
+	":lock:":                     "\U0001F512",
+	":mag:":                      "\U0001F50D",
+	":memo:":                     "\U0001F4DD",
+	":microscope:":               "\U0001F52C",
+	":package:":                  "\U0001F4E6",
+	":pencil:":                   "\U0001F4DD",
+	":rocket:":                   "\U0001F680",
+	":rotating_light:":           "\U0001F6A8",
+	":scissors:":                 "\u2702\uFE0F",
+	":seedling:":                 "\U0001F331",
+	":shield:":                   "\U0001F6E1\uFE0F",
+	":ship:":                     "\U0001F6A2",
+	":sparkles:":                 "\u2728",
+	":test_tube:":                "\U0001F9EA",
+	":warning:":                  "\u26A0\uFE0F",
+	":whale:":                    "\U0001F433",
+	":white_check_mark:":         "\u2705",
+	":wrench:":                   "\U0001F527",
+	":x:":                        "\u274C",
+	":zap:":                      "\u26A1",
+}
+
+// EmojiForShortcode returns the Unicode emoji for a shortcode such as ":package:"
+// Only a curated set of common emoji is known, ok is false for any other shortcode.
+func EmojiForShortcode(shortcode string) (emoji string, ok bool) {
+	emoji, ok = groupEmoji[shortcode]
+	return emoji, ok
+}
+
+// SplitGroupEmoji splits the emoji shortcode leading a group label, e.g. "~~~ :package: Build" is split
+// into ":package:" and "~~~ Build". A "~~~", "---" or "+++" header marker is kept in rest. Labels without
+// a leading shortcode are returned unchanged with an empty shortcode.
+func SplitGroupEmoji(label string) (shortcode, rest string) {
+	marker := ""
+	for _, prefix := range []string{"~~~ ", "--- ", "+++ "} {
+		if strings.HasPrefix(label, prefix) {
+			marker = prefix
+			break
+		}
+	}
+
+	text := label[len(marker):]
+	end := shortcodeEnd(text)
+	if end < 0 {
+		return "", label
+	}
+
+	return text[:end], marker + strings.TrimLeft(text[end:], " ")
+}
+
+// shortcodeEnd returns the length of the ":name:" shortcode at the start of text, or -1 if there isn't one
+// The shortcode must be followed by a space or the end of the text, so times such as "12:30:" don't match.
+func shortcodeEnd(text string) int {
+	if len(text) < 3 || text[0] != ':' {
+		return -1
+	}
+
+	letters := false
+	for i := 1; i < len(text); i++ {
+		c := text[i]
+		switch {
+		case c == ':':
+			if i == 1 || !letters || (i+1 < len(text) && text[i+1] != ' ') {
+				return -1
+			}
+			return i + 1
+		case c >= 'a' && c <= 'z', c == '_', c == '+':
+			letters = true
+		case c >= '0' && c <= '9', c == '-':
+		default:
+			return -1
+		}
+	}
+	return -1
+}
+
+// GroupEmoji splits the emoji shortcode leading the entry's group, see SplitGroupEmoji
+func (entry *LogEntry) GroupEmoji() (shortcode string, rest string) {
+	return SplitGroupEmoji(entry.Group)
+}
+
+// GroupLabel returns a group's human label, without its "~~~", "---" or "+++" marker and leading
+// emoji shortcode, e.g. "Running tests" from "+++ :hammer: Running tests"
+func GroupLabel(name string) string {
+	label := name
+	for _, marker := range []string{"~~~", "---", "+++"} {
+		if strings.HasPrefix(label, marker) {
+			label = label[len(marker):]
+			break
+		}
+	}
+
+	_, label = SplitGroupEmoji(strings.TrimLeft(label, " "))
+	return strings.TrimSpace(label)
+}
+
+// GroupLabel returns the human label of the entry's group, see GroupLabel
+func (entry *LogEntry) GroupLabel() string {
+	return GroupLabel(entry.Group)
+}
@@ -0,0 +1,85 @@
+package buildkitelogs
+
+import "testing"
+
+func TestSplitGroupEmoji(t *testing.T) {
+	tests := []struct {
+		label     string
+		shortcode string
+		rest      string
+	}{
+		{"~~~ :package: Build job checkout directory", ":package:", "~~~ Build job checkout directory"},
+		{"+++ :frame_with_picture: Inline image", ":frame_with_picture:", "+++ Inline image"},
+		{":hammer: Example tests", ":hammer:", "Example tests"},
+		{"--- :+1:", ":+1:", "--- "},
+		{"~~~ Running script", "", "~~~ Running script"},
+		{"~~~ Running :hammer: tests", "", "~~~ Running :hammer: tests"},
+		{"--- 12:30: started", "", "--- 12:30: started"},
+		{"--- :43: started", "", "--- :43: started"},
+		{"--- :foo:bar", "", "--- :foo:bar"},
+		{"", "", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.label, func(t *testing.T) {
+			shortcode, rest := SplitGroupEmoji(tt.label)
+			if shortcode != tt.shortcode || rest != tt.rest {
+				t.Errorf("SplitGroupEmoji(%q) = %q, %q, want %q, %q", tt.label, shortcode, rest, tt.shortcode, tt.rest)
+			}
+		})
+	}
+}
+
+func TestGroupEmoji(t *testing.T) {
+	parser := NewParser()
+	var entries []*LogEntry
+	for _, line := range []string{"--- :package: Build job checkout directory", "git checkout"} {
+		entry, err := parser.ParseLine(line)
+		if err != nil {
+			t.Fatalf("ParseLine() error = %v", err)
+		}
+		entries = append(entries, entry)
+	}
+
+	for _, entry := range entries {
+		shortcode, rest := entry.GroupEmoji()
+		if shortcode != ":package:" || rest != "--- Build job checkout directory" {
+			t.Errorf("GroupEmoji() = %q, %q for %q", shortcode, rest, entry.Content)
+		}
+	}
+
+	if emoji, ok := EmojiForShortcode(":package:"); !ok || emoji != "\U0001F4E6" {
+		t.Errorf("EmojiForShortcode(:package:) = %q, %v", emoji, ok)
+	}
+	if _, ok := EmojiForShortcode(":buildkite:"); ok {
+		t.Error("EmojiForShortcode(:buildkite:) should have no Unicode emoji")
+	}
+}
+
+func TestGroupLabel(t *testing.T) {
+	tests := []struct {
+		name string
+		want string
+	}{
+		{"~~~ Running global environment hook", "Running global environment hook"},
+		{"~~~ :docker: Building image", "Building image"},
+		{"--- Build job environment", "Build job environment"},
+		{"--- :package: Build job checkout directory", "Build job checkout directory"},
+		{"+++ Running tests", "Running tests"},
+		{"+++ :hammer: Running tests", "Running tests"},
+		{"+++:hammer:  Running tests ", "Running tests"},
+		{"Running tests", "Running tests"},
+		{"", ""},
+	}
+
+	for _, tt := range tests {
+		if got := GroupLabel(tt.name); got != tt.want {
+			t.Errorf("GroupLabel(%q) = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+
+	entry := &LogEntry{Group: "+++ :hammer: Example tests"}
+	if got := entry.GroupLabel(); got != "Example tests" {
+		t.Errorf("LogEntry.GroupLabel() = %q, want %q", got, "Example tests")
+	}
+}
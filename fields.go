@@ -0,0 +1,154 @@
+package buildkitelogs
+
+import (
+	"bytes"
+	"encoding/json"
+	"strconv"
+	"strings"
+)
+
+// Fields extracts the fields of a structured log line, written as a JSON object or as logfmt
+// (e.g. level=info msg="done" duration=1.2s), from the content with ANSI codes stripped.
+// It returns false for anything else. Detection is conservative: every word of a logfmt line
+// must be a key=value pair and there must be at least two, so prose such as "set FOO=bar" or a
+// lone "x=1" isn't mistaken for fields. Fields are parsed on each call and aren't stored in Parquet.
+func (entry *LogEntry) Fields() (map[string]string, bool) {
+	return parseFields(entry.CleanContent())
+}
+
+// Fields extracts the fields of a structured log line, see LogEntry.Fields
+func (entry *ParquetLogEntry) Fields() (map[string]string, bool) {
+	return parseFields(entry.CleanContent())
+}
+
+// minLogfmtPairs is the number of key=value pairs a line needs to be treated as logfmt
+const minLogfmtPairs = 2
+
+// parseFields parses content as a JSON object or a logfmt line
+func parseFields(content string) (map[string]string, bool) {
+	content = strings.TrimSpace(content)
+	if strings.HasPrefix(content, "{") {
+		return parseJSONFields(content)
+	}
+	return parseLogfmtFields(content)
+}
+
+// parseJSONFields parses a JSON object, formatting each value as a string
+// Strings are unquoted, numbers keep their original text, null is empty and nested objects and
+// arrays are kept as compact JSON.
+func parseJSONFields(content string) (map[string]string, bool) {
+	var object map[string]json.RawMessage
+	if err := json.Unmarshal([]byte(content), &object); err != nil || object == nil {
+		return nil, false
+	}
+
+	fields := make(map[string]string, len(object))
+	for key, raw := range object {
+		switch {
+		case raw[0] == '"':
+			var value string
+			if err := json.Unmarshal(raw, &value); err != nil {
+				return nil, false
+			}
+			fields[key] = value
+		case string(raw) == "null":
+			fields[key] = ""
+		case raw[0] == '{' || raw[0] == '[':
+			var compact bytes.Buffer
+			if err := json.Compact(&compact, raw); err != nil {
+				return nil, false
+			}
+			fields[key] = compact.String()
+		default:
+			fields[key] = string(raw) // Numbers and booleans
+		}
+	}
+	return fields, true
+}
+
+// parseLogfmtFields parses a line of space separated key=value pairs
+// Values may be double-quoted with Go escapes, unquoted values can't contain '"' or '='.
+// A later value for a key replaces an earlier one.
+func parseLogfmtFields(content string) (map[string]string, bool) {
+	fields := make(map[string]string)
+	pairs := 0
+
+	for i := 0; i < len(content); {
+		if isLogfmtSpace(content[i]) {
+			i++
+			continue
+		}
+
+		start := i
+		for i < len(content) && isLogfmtKeyByte(content[i], i == start) {
+			i++
+		}
+		if i == start || i == len(content) || content[i] != '=' {
+			return nil, false
+		}
+		key := content[start:i]
+		i++
+
+		var value string
+		if i < len(content) && content[i] == '"' {
+			end, ok := quotedValueEnd(content, i)
+			if !ok {
+				return nil, false
+			}
+			unquoted, err := strconv.Unquote(content[i:end])
+			if err != nil {
+				return nil, false
+			}
+			value, i = unquoted, end
+		} else {
+			valueStart := i
+			for i < len(content) && !isLogfmtSpace(content[i]) {
+				if content[i] == '"' || content[i] == '=' {
+					return nil, false
+				}
+				i++
+			}
+			value = content[valueStart:i]
+		}
+
+		if i < len(content) && !isLogfmtSpace(content[i]) {
+			return nil, false // Text straight after a quoted value
+		}
+		fields[key] = value
+		pairs++
+	}
+
+	if pairs < minLogfmtPairs {
+		return nil, false
+	}
+	return fields, true
+}
+
+// quotedValueEnd returns the offset just past the closing quote of the value starting at start
+func quotedValueEnd(content string, start int) (int, bool) {
+	for i := start + 1; i < len(content); i++ {
+		switch content[i] {
+		case '\\':
+			i++
+		case '"':
+			return i + 1, true
+		}
+	}
+	return 0, false
+}
+
+// isLogfmtKeyByte checks if a byte may appear in a logfmt key, which starts with a letter or underscore
+func isLogfmtKeyByte(c byte, first bool) bool {
+	switch {
+	case c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z'):
+		return true
+	case first:
+		return false
+	default:
+		return c == '.' || c == '-' || (c >= '0' && c <= '9')
+	}
+}
+
+func isLogfmtSpace(c byte) bool {
+	return c == ' ' || c == '\t'
+}
@@ -0,0 +1,70 @@
+package buildkitelogs
+
+import (
+	"maps"
+	"testing"
+)
+
+func TestLogEntryFields(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    map[string]string // Nil when the line isn't structured
+	}{
+		{
+			name:    "logfmt",
+			content: `level=info msg="tests passed" duration=1.2s`,
+			want:    map[string]string{"level": "info", "msg": "tests passed", "duration": "1.2s"},
+		},
+		{
+			name:    "logfmt with ANSI codes and escapes",
+			content: "\x1b[31mlevel=error\x1b[0m msg=\"said \\\"no\\\"\" err= trace.id=abc-1",
+			want:    map[string]string{"level": "error", "msg": `said "no"`, "err": "", "trace.id": "abc-1"},
+		},
+		{
+			name:    "logfmt later key wins",
+			content: "a=1 a=2",
+			want:    map[string]string{"a": "2"},
+		},
+		{
+			name:    "json",
+			content: `  {"level":"error","msg":"boom","count":3,"ok":false,"extra":null,"tags":["a", "b"],"ctx":{"id": 1}}`,
+			want: map[string]string{
+				"level": "error", "msg": "boom", "count": "3", "ok": "false", "extra": "",
+				"tags": `["a","b"]`, "ctx": `{"id":1}`,
+			},
+		},
+		{name: "prose with an assignment", content: "$ export FOO=bar"},
+		{name: "single pair", content: "x=1"},
+		{name: "sentence with pairs", content: "retrying with attempts=3 delay=5s"},
+		{name: "comparison", content: "a==b c=d"},
+		{name: "unterminated quote", content: `level=info msg="tests`},
+		{name: "text after quoted value", content: `level=info msg="a"b`},
+		{name: "quote in unquoted value", content: `level=info msg=a"b`},
+		{name: "key starting with a digit", content: "1a=b c=d"},
+		{name: "json array", content: `[{"level":"info"}]`},
+		{name: "invalid json", content: `{"level": info}`},
+		{name: "json null", content: "{} null"},
+		{name: "empty", content: ""},
+		{name: "plain", content: "Compiling 42 packages"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			entry := &LogEntry{Content: tt.content}
+			got, ok := entry.Fields()
+			if ok != (tt.want != nil) {
+				t.Fatalf("Fields() ok = %v, want %v (fields %v)", ok, tt.want != nil, got)
+			}
+			if !maps.Equal(got, tt.want) {
+				t.Errorf("Fields() = %v, want %v", got, tt.want)
+			}
+
+			parquetEntry := &ParquetLogEntry{Content: tt.content}
+			parquetGot, parquetOK := parquetEntry.Fields()
+			if parquetOK != ok || !maps.Equal(parquetGot, got) {
+				t.Errorf("ParquetLogEntry.Fields() = %v, %v, want %v, %v", parquetGot, parquetOK, got, ok)
+			}
+		})
+	}
+}
@@ -0,0 +1,55 @@
+package buildkitelogs
+
+import (
+	"fmt"
+	"iter"
+	"time"
+)
+
+// TimeGap is a pause in a log between two consecutive timestamped entries, e.g. where a build hung
+type TimeGap struct {
+	Before    ParquetLogEntry `json:"before"`     // Last timestamped entry before the gap
+	After     ParquetLogEntry `json:"after"`      // First timestamped entry after the gap
+	BeforeRow int64           `json:"before_row"` // 0-based row of Before, as used by SeekToRow
+	AfterRow  int64           `json:"after_row"`  // 0-based row of After, rows without a timestamp may lie between
+}
+
+// Duration returns the time between the entries either side of the gap
+func (g TimeGap) Duration() time.Duration {
+	return time.Duration(g.After.Timestamp-g.Before.Timestamp) * time.Millisecond
+}
+
+// gapColumns are the columns read when looking for gaps
+var gapColumns = []string{"timestamp", "has_timestamp", "content", "group", "line_number"}
+
+// GapsIter returns an iterator over the gaps longer than threshold between consecutive timestamped
+// entries, in file order. Entries without a timestamp are passed over, and timestamps that go
+// backwards, e.g. from clock skew between agents, never count as a gap. Only the columns needed
+// to describe each gap are read.
+func (pr *ParquetReader) GapsIter(threshold time.Duration) iter.Seq2[TimeGap, error] {
+	return func(yield func(TimeGap, error) bool) {
+		var previous ParquetLogEntry
+		previousRow := int64(-1)
+
+		row := int64(-1)
+		for entry, err := range pr.ReadColumnsIter(gapColumns) {
+			if err != nil {
+				yield(TimeGap{}, fmt.Errorf("error reading entries: %w", err))
+				return
+			}
+			row++
+
+			if !entry.HasTime {
+				continue
+			}
+
+			if previousRow >= 0 {
+				gap := TimeGap{Before: previous, After: entry, BeforeRow: previousRow, AfterRow: row}
+				if gap.Duration() > threshold && !yield(gap, nil) {
+					return
+				}
+			}
+			previous, previousRow = entry, row
+		}
+	}
+}
@@ -0,0 +1,90 @@
+package buildkitelogs
+
+import (
+	"bytes"
+	"testing"
+	"time"
+)
+
+func TestParquetReader_GapsIter(t *testing.T) {
+	base := time.UnixMilli(1745322209921)
+	entries := []*LogEntry{
+		{Timestamp: base, Content: "~~~ Build", Group: "~~~ Build"},
+		{Timestamp: base.Add(time.Second), Content: "$ make", Group: "~~~ Build"},
+		{Content: "no timestamp", Group: "~~~ Build"}, // Passed over, but still counts as a row
+		{Timestamp: base.Add(time.Minute), Content: "hung here", Group: "~~~ Build"},
+		{Timestamp: base.Add(30 * time.Second), Content: "clock went back", Group: "~~~ Build"},
+		{Timestamp: base.Add(2 * time.Minute), Content: "~~~ Test", Group: "~~~ Test"},
+		{Timestamp: base.Add(2*time.Minute + 10*time.Second), Content: "done", Group: "~~~ Test"},
+	}
+
+	var buf bytes.Buffer
+	if err := ExportToParquetWriter(entries, &buf, ParquetOptions{}); err != nil {
+		t.Fatalf("ExportToParquetWriter() error = %v", err)
+	}
+	reader := NewParquetReaderFromReaderAt(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+
+	tests := []struct {
+		name      string
+		threshold time.Duration
+		want      []TimeGap
+	}{
+		{
+			name:      "long gaps",
+			threshold: 30 * time.Second,
+			want: []TimeGap{
+				{BeforeRow: 1, AfterRow: 3},
+				{BeforeRow: 4, AfterRow: 5},
+			},
+		},
+		{
+			name:      "threshold is exclusive",
+			threshold: 90 * time.Second,
+			want:      []TimeGap{},
+		},
+		{
+			name:      "short threshold",
+			threshold: 5 * time.Second,
+			want: []TimeGap{
+				{BeforeRow: 1, AfterRow: 3},
+				{BeforeRow: 4, AfterRow: 5},
+				{BeforeRow: 5, AfterRow: 6},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []TimeGap
+			for gap, err := range reader.GapsIter(tt.threshold) {
+				if err != nil {
+					t.Fatalf("GapsIter() error = %v", err)
+				}
+				got = append(got, gap)
+			}
+
+			if len(got) != len(tt.want) {
+				t.Fatalf("Expected %d gaps, got %d: %+v", len(tt.want), len(got), got)
+			}
+			for i, gap := range got {
+				if gap.BeforeRow != tt.want[i].BeforeRow || gap.AfterRow != tt.want[i].AfterRow {
+					t.Errorf("Gap %d: rows %d-%d, want %d-%d", i, gap.BeforeRow, gap.AfterRow, tt.want[i].BeforeRow, tt.want[i].AfterRow)
+				}
+				if gap.Duration() <= tt.threshold {
+					t.Errorf("Gap %d: duration %s not above the threshold", i, gap.Duration())
+				}
+			}
+		})
+	}
+
+	// The first gap is described by the entries either side of it
+	for gap, err := range reader.GapsIter(30 * time.Second) {
+		if err != nil {
+			t.Fatalf("GapsIter() error = %v", err)
+		}
+		if gap.Duration() != 59*time.Second || gap.Before.Content != "$ make" || gap.After.Content != "hung here" || gap.After.Group != "~~~ Build" {
+			t.Errorf("Unexpected first gap: %+v (%s)", gap, gap.Duration())
+		}
+		break
+	}
+}
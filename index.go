@@ -0,0 +1,130 @@
+package buildkitelogs
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"iter"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// groupIndex is the sidecar index mapping each group name to the range of rows it occupies
+type groupIndex struct {
+	RowCount int64                      `json:"row_count"`
+	Groups   map[string]groupIndexEntry `json:"groups"`
+}
+
+// groupIndexEntry records the first and last row (0-based, inclusive) of a group and its entry count
+// Groups can be interleaved with others, so the range is an upper bound on the rows to scan, not a
+// contiguous run of the group's entries.
+type groupIndexEntry struct {
+	StartRow int64 `json:"start_row"`
+	EndRow   int64 `json:"end_row"`
+	Count    int64 `json:"count"`
+}
+
+// GroupIndexPath returns the path of the sidecar group index for a Parquet file
+// For "build.parquet" this is "build.idx.json" in the same directory.
+func GroupIndexPath(parquetPath string) string {
+	return strings.TrimSuffix(parquetPath, filepath.Ext(parquetPath)) + ".idx.json"
+}
+
+// BuildGroupIndex scans a Parquet file and writes a sidecar index of the row range of each group
+// FilterByGroupExactIter uses the index, when present, to seek straight to the first row of a group
+// and stop after its last row. The index records the file's row count and is ignored once that no
+// longer matches, so rewriting the Parquet file without rebuilding the index falls back to a scan.
+func BuildGroupIndex(parquetPath string) error {
+	index := groupIndex{
+		Groups: make(map[string]groupIndexEntry),
+	}
+
+	for entry, err := range readParquetFileStreamingIter(context.Background(), fileSource(parquetPath), 5000, streamOptions{columns: []string{"group"}}) {
+		if err != nil {
+			return fmt.Errorf("error reading entries: %w", err)
+		}
+
+		row := index.RowCount
+		index.RowCount++
+
+		groupEntry, exists := index.Groups[entry.Group]
+		if !exists {
+			groupEntry.StartRow = row
+		}
+		groupEntry.EndRow = row
+		groupEntry.Count++
+		index.Groups[entry.Group] = groupEntry
+	}
+
+	data, err := json.Marshal(index)
+	if err != nil {
+		return fmt.Errorf("failed to encode group index: %w", err)
+	}
+
+	if err := os.WriteFile(GroupIndexPath(parquetPath), data, 0o644); err != nil {
+		return fmt.Errorf("failed to write group index: %w", err)
+	}
+
+	return nil
+}
+
+// loadGroupIndex reads the sidecar group index for a Parquet file, returning nil if it is missing,
+// unreadable or stale
+func loadGroupIndex(parquetPath string) *groupIndex {
+	data, err := os.ReadFile(GroupIndexPath(parquetPath))
+	if err != nil {
+		return nil
+	}
+
+	var index groupIndex
+	if err := json.Unmarshal(data, &index); err != nil {
+		return nil
+	}
+
+	info, err := getParquetFileInfo(fileSource(parquetPath))
+	if err != nil || info.RowCount != index.RowCount {
+		return nil
+	}
+
+	return &index
+}
+
+// currentGroupIndex returns the sidecar group index of a file-backed reader, or nil if there is no current index
+func (pr *ParquetReader) currentGroupIndex() *groupIndex {
+	if pr.filename == "" {
+		return nil
+	}
+	return loadGroupIndex(pr.filename)
+}
+
+// indexedGroupIter returns an iterator over the rows of a group using the sidecar index
+func indexedGroupIter(src parquetSource, index *groupIndex, target string) iter.Seq2[ParquetLogEntry, error] {
+	return func(yield func(ParquetLogEntry, error) bool) {
+		groupEntry, exists := index.Groups[target]
+		if !exists {
+			return
+		}
+
+		row := groupEntry.StartRow
+		for entry, err := range readParquetFileFromRowIter(context.Background(), src, groupEntry.StartRow) {
+			if err != nil {
+				if !yield(ParquetLogEntry{}, err) {
+					return
+				}
+				continue
+			}
+
+			if row > groupEntry.EndRow {
+				return
+			}
+			row++
+
+			if entry.Group == target {
+				if !yield(entry, nil) {
+					return
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,110 @@
+package buildkitelogs
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+// copyTestParquet copies a Parquet test file into a temp directory so a sidecar index can be written next to it
+func copyTestParquet(t *testing.T, src string) string {
+	t.Helper()
+
+	data, err := os.ReadFile(src)
+	if err != nil {
+		t.Fatalf("ReadFile(%s) error = %v", src, err)
+	}
+
+	dst := filepath.Join(t.TempDir(), filepath.Base(src))
+	if err := os.WriteFile(dst, data, 0o644); err != nil {
+		t.Fatalf("WriteFile(%s) error = %v", dst, err)
+	}
+
+	return dst
+}
+
+func TestGroupIndexPath(t *testing.T) {
+	if got := GroupIndexPath("logs/build.parquet"); got != "logs/build.idx.json" {
+		t.Errorf("GroupIndexPath() = %q, want %q", got, "logs/build.idx.json")
+	}
+}
+
+func TestBuildGroupIndex(t *testing.T) {
+	testFile := copyTestParquet(t, "testdata/bazel-bazel_build_32517_rocky-rocky-linux-8.parquet")
+	reader := NewParquetReader(testFile)
+
+	groups, err := reader.GroupStats()
+	if err != nil {
+		t.Fatalf("GroupStats() error = %v", err)
+	}
+
+	names := []string{groups[0].Name, groups[len(groups)/2].Name, groups[len(groups)-1].Name}
+
+	scanned := make(map[string][]ParquetLogEntry)
+	for _, name := range names {
+		entries, err := collectEntries(reader.FilterByGroupExactIter(name))
+		if err != nil {
+			t.Fatalf("FilterByGroupExactIter(%q) error = %v", name, err)
+		}
+		scanned[name] = entries
+	}
+
+	if err := BuildGroupIndex(testFile); err != nil {
+		t.Fatalf("BuildGroupIndex() error = %v", err)
+	}
+
+	index := loadGroupIndex(testFile)
+	if index == nil {
+		t.Fatal("loadGroupIndex() returned nil after BuildGroupIndex")
+	}
+	if index.RowCount != 11029 {
+		t.Errorf("RowCount = %d, want 11029", index.RowCount)
+	}
+
+	for _, name := range names {
+		entries, err := collectEntries(reader.FilterByGroupExactIter(name))
+		if err != nil {
+			t.Fatalf("FilterByGroupExactIter(%q) with index error = %v", name, err)
+		}
+		if !slices.Equal(entries, scanned[name]) {
+			t.Errorf("FilterByGroupExactIter(%q) with index returned %d entries, want %d", name, len(entries), len(scanned[name]))
+		}
+	}
+
+	entries, err := collectEntries(reader.FilterByGroupExactIter("no such group"))
+	if err != nil {
+		t.Fatalf("FilterByGroupExactIter() error = %v", err)
+	}
+	if len(entries) != 0 {
+		t.Errorf("Expected no entries for unknown group, got %d", len(entries))
+	}
+}
+
+func TestGroupIndexStale(t *testing.T) {
+	testFile := copyTestParquet(t, "testdata/bash-example.parquet")
+
+	// An index with the wrong row count must be ignored, falling back to a scan
+	stale := `{"row_count":1,"groups":{}}`
+	if err := os.WriteFile(GroupIndexPath(testFile), []byte(stale), 0o644); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+
+	if index := loadGroupIndex(testFile); index != nil {
+		t.Error("loadGroupIndex() should ignore a stale index")
+	}
+
+	reader := NewParquetReader(testFile)
+	groups, err := reader.GroupStats()
+	if err != nil {
+		t.Fatalf("GroupStats() error = %v", err)
+	}
+
+	entries, err := collectEntries(reader.FilterByGroupExactIter(groups[0].Name))
+	if err != nil {
+		t.Fatalf("FilterByGroupExactIter() error = %v", err)
+	}
+	if len(entries) != groups[0].EntryCount {
+		t.Errorf("Fallback scan returned %d entries, want %d", len(entries), groups[0].EntryCount)
+	}
+}
@@ -151,3 +151,93 @@ func TestIterSeq2WithFiltering(t *testing.T) {
 		}
 	}
 }
+
+func TestLogIteratorSeq2(t *testing.T) {
+	parser := NewParser()
+
+	testData := "\x1b_bk;t=1745322209921\x07~~~ Running global environment hook\n" +
+		"\x1b_bk;t=1745322209922\x07$ /buildkite/agent/hooks/environment\n" +
+		"\x1b_bk;t=bad\x07Invalid timestamp"
+
+	var contents []string
+	var lastErr error
+	for entry, err := range parser.NewIterator(strings.NewReader(testData)).Seq2() {
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		contents = append(contents, entry.Content)
+	}
+
+	expected := []string{"~~~ Running global environment hook", "$ /buildkite/agent/hooks/environment"}
+	if strings.Join(contents, "|") != strings.Join(expected, "|") {
+		t.Errorf("Expected contents %q, got %q", expected, contents)
+	}
+	if lastErr == nil {
+		t.Error("Expected the parse error to be yielded")
+	}
+}
+
+func TestSeq2ToIterator(t *testing.T) {
+	parser := NewParser()
+
+	testData := "\x1b_bk;t=1745322209921\x07~~~ Running global environment hook\n" +
+		"\x1b_bk;t=1745322209922\x07$ /buildkite/agent/hooks/environment\n" +
+		"\x1b_bk;t=1745322209923\x07Some regular output"
+
+	t.Run("all entries", func(t *testing.T) {
+		iterator := Seq2ToIterator(parser.All(strings.NewReader(testData)))
+
+		var lineNumbers []int64
+		for iterator.Next() {
+			lineNumbers = append(lineNumbers, iterator.Entry().LineNumber)
+		}
+		if err := iterator.Err(); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if len(lineNumbers) != 3 || lineNumbers[0] != 1 || lineNumbers[2] != 3 {
+			t.Errorf("Expected line numbers 1-3, got %v", lineNumbers)
+		}
+		if iterator.Next() {
+			t.Error("Expected Next to keep returning false after the end")
+		}
+	})
+
+	t.Run("error ends iteration", func(t *testing.T) {
+		iterator := Seq2ToIterator(parser.All(strings.NewReader("first\n\x1b_bk;t=bad\x07broken\nafter")))
+
+		count := 0
+		for iterator.Next() {
+			count++
+		}
+		if count != 1 {
+			t.Errorf("Expected 1 entry before the error, got %d", count)
+		}
+		if iterator.Err() == nil {
+			t.Error("Expected Err to report the parse error")
+		}
+	})
+
+	t.Run("stop early", func(t *testing.T) {
+		stopped := false
+		seq := func(yield func(*LogEntry, error) bool) {
+			defer func() { stopped = true }()
+			for entry, err := range parser.All(strings.NewReader(testData)) {
+				if !yield(entry, err) {
+					return
+				}
+			}
+		}
+
+		iterator := Seq2ToIterator(seq)
+		if !iterator.Next() {
+			t.Fatal("Expected an entry")
+		}
+		iterator.Stop()
+		iterator.Stop()
+
+		if !stopped {
+			t.Error("Expected Stop to end the sequence")
+		}
+	})
+}
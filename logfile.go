@@ -0,0 +1,64 @@
+package buildkitelogs
+
+import (
+	"bufio"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// gzipMagic are the first two bytes of every gzip stream
+var gzipMagic = []byte{0x1f, 0x8b}
+
+// OpenLogFile opens a local log file for parsing, decompressing it if it is gzipped
+// Gzip is detected by a .gz extension or the gzip magic bytes, so archived logs work whatever they are named.
+// Other files are returned as they are. Closing the returned reader closes the file.
+func OpenLogFile(path string) (io.ReadCloser, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+
+	buffered := bufio.NewReader(file)
+	magic, err := buffered.Peek(len(gzipMagic))
+	if err != nil && err != io.EOF {
+		_ = file.Close()
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+
+	if !strings.EqualFold(filepath.Ext(path), ".gz") && string(magic) != string(gzipMagic) {
+		return &bufferedFile{Reader: buffered, file: file}, nil
+	}
+
+	gz, err := gzip.NewReader(buffered)
+	if err != nil {
+		_ = file.Close()
+		return nil, fmt.Errorf("failed to read gzip header of %s: %w", path, err)
+	}
+
+	return &gzipFile{Reader: gz, file: file}, nil
+}
+
+// bufferedFile reads a file through the buffer used to sniff its first bytes
+type bufferedFile struct {
+	*bufio.Reader
+	file *os.File
+}
+
+func (f *bufferedFile) Close() error {
+	return f.file.Close()
+}
+
+// gzipFile decompresses a file, closing both the gzip reader and the file
+type gzipFile struct {
+	*gzip.Reader
+	file *os.File
+}
+
+func (f *gzipFile) Close() error {
+	return errors.Join(f.Reader.Close(), f.file.Close())
+}
@@ -0,0 +1,93 @@
+package buildkitelogs
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestOpenLogFile(t *testing.T) {
+	log, err := os.ReadFile("testdata/bash-example.log")
+	if err != nil {
+		t.Fatalf("Failed to read test log: %v", err)
+	}
+
+	var expectedEntries int
+	for _, err := range NewParser().All(bytes.NewReader(log)) {
+		if err != nil {
+			t.Fatalf("Parse error: %v", err)
+		}
+		expectedEntries++
+	}
+
+	var compressed bytes.Buffer
+	gz := gzip.NewWriter(&compressed)
+	if _, err := gz.Write(log); err != nil {
+		t.Fatalf("Failed to compress test log: %v", err)
+	}
+	if err := gz.Close(); err != nil {
+		t.Fatalf("Failed to compress test log: %v", err)
+	}
+
+	dir := t.TempDir()
+	tests := []struct {
+		name string
+		file string
+		data []byte
+	}{
+		{name: "plain", file: "build.log", data: log},
+		{name: "gzip extension", file: "build.log.gz", data: compressed.Bytes()},
+		{name: "gzip without extension", file: "archived.log", data: compressed.Bytes()},
+		{name: "empty", file: "empty.log", data: nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(dir, tt.file)
+			if err := os.WriteFile(path, tt.data, 0o600); err != nil {
+				t.Fatalf("Failed to write %s: %v", path, err)
+			}
+
+			reader, err := OpenLogFile(path)
+			if err != nil {
+				t.Fatalf("OpenLogFile() error = %v", err)
+			}
+
+			var count int
+			for _, err := range NewParser().All(reader) {
+				if err != nil {
+					t.Fatalf("Parse error: %v", err)
+				}
+				count++
+			}
+			if err := reader.Close(); err != nil {
+				t.Errorf("Close() error = %v", err)
+			}
+
+			expected := 0
+			if tt.data != nil {
+				expected = expectedEntries
+			}
+			if count != expected {
+				t.Errorf("Expected %d entries, got %d", expected, count)
+			}
+		})
+	}
+}
+
+func TestOpenLogFileInvalidGzip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "build.log.gz")
+	if err := os.WriteFile(path, []byte("not gzipped\n"), 0o600); err != nil {
+		t.Fatalf("Failed to write %s: %v", path, err)
+	}
+
+	reader, err := OpenLogFile(path)
+	if err == nil {
+		_, _ = io.Copy(io.Discard, reader)
+		_ = reader.Close()
+		t.Fatal("Expected an error for a .gz file that isn't gzipped")
+	}
+}
@@ -0,0 +1,194 @@
+package buildkitelogs
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"iter"
+	"math"
+	"os"
+	"strconv"
+
+	"github.com/apache/arrow-go/v18/arrow"
+	"github.com/apache/arrow-go/v18/arrow/memory"
+	"github.com/apache/arrow-go/v18/parquet/file"
+	"github.com/apache/arrow-go/v18/parquet/pqarrow"
+)
+
+// MergeParquetFiles combines multiple Parquet log files into a single output file
+// Rows are streamed one record batch at a time in input order, so memory use is bounded
+// by the batch size rather than the size of the inputs. All inputs must share the same schema.
+func MergeParquetFiles(inputs []string, output string, opts ParquetOptions) error {
+	if len(inputs) == 0 {
+		return fmt.Errorf("no input files to merge")
+	}
+
+	// Validate every input before creating the output so a mismatch doesn't leave a partial file
+	var schema *arrow.Schema
+	for _, input := range inputs {
+		inputSchema, err := readArrowSchema(fileSource(input))
+		if err != nil {
+			return fmt.Errorf("failed to read schema from %s: %w", input, err)
+		}
+
+		if schema == nil {
+			schema = inputSchema
+			continue
+		}
+
+		if !schema.Equal(inputSchema) {
+			return fmt.Errorf("schema mismatch: %s has schema\n%s\nexpected (from %s)\n%s", input, inputSchema, inputs[0], schema)
+		}
+	}
+
+	// Create output file
+	outFile, err := os.Create(output)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = outFile.Close() }()
+
+	pool := memory.NewGoAllocator()
+
+	writer, err := createNewFileWriter(schema, outFile, pool, opts)
+	if err != nil {
+		return fmt.Errorf("failed to create Parquet writer: %w", err)
+	}
+	defer func() { _ = writer.Close() }()
+
+	for _, input := range inputs {
+		if err := copyParquetRecords(input, writer, pool, int64(opts.batchSize())); err != nil {
+			return fmt.Errorf("failed to merge %s: %w", input, err)
+		}
+	}
+
+	if err := writer.Close(); err != nil {
+		return err
+	}
+
+	return outFile.Close()
+}
+
+// copyParquetRecords streams every record batch from a Parquet file into the writer
+func copyParquetRecords(filename string, writer *pqarrow.FileWriter, pool memory.Allocator, batchSize int64) error {
+	pf, err := file.OpenParquetFile(filename, false)
+	if err != nil {
+		return fmt.Errorf("failed to open parquet file: %w", err)
+	}
+	defer pf.Close()
+
+	arrowReader, err := pqarrow.NewFileReader(pf, pqarrow.ArrowReadProperties{
+		BatchSize: batchSize,
+	}, pool)
+	if err != nil {
+		return fmt.Errorf("failed to create arrow reader: %w", err)
+	}
+
+	recordReader, err := arrowReader.GetRecordReader(context.Background(), nil, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create record reader: %w", err)
+	}
+	defer recordReader.Release()
+
+	for {
+		record, err := recordReader.Read()
+		if err != nil {
+			if err == io.EOF {
+				return nil
+			}
+			return fmt.Errorf("error reading record: %w", err)
+		}
+
+		err = writer.Write(record)
+		record.Release()
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// MergeSortedIters interleaves entries from several sequences into one timeline ordered by timestamp,
+// e.g. the logs of a build's parallel jobs. Each sequence should already be in timestamp order, and they
+// may be of any length. Exported logs keep document order, which is usually timestamp order, and an entry
+// earlier than the one before it is still yielded after it, so the timeline is only sorted where the
+// sequences are. Entries without a timestamp keep their place after the previous entry of their sequence,
+// and ties go to the earlier sequence.
+//
+// Entries are tagged with their source by the job_id column. Entries with an empty JobID are given the
+// 0-based position of their sequence instead, e.g. "1" for the second. An error from a sequence is
+// yielded as "source N: ..." and that sequence is dropped, the merge continues with the others if the
+// caller keeps iterating. Only one entry per sequence is held at a time.
+func MergeSortedIters(iters ...iter.Seq2[ParquetLogEntry, error]) iter.Seq2[ParquetLogEntry, error] {
+	return func(yield func(ParquetLogEntry, error) bool) {
+		sources := make([]*mergeSource, len(iters))
+		for i, seq := range iters {
+			next, stop := iter.Pull2(seq)
+			defer stop()
+
+			sources[i] = &mergeSource{label: strconv.Itoa(i), next: next, last: math.MinInt64}
+			sources[i].advance()
+		}
+
+		for {
+			// Pick the source with the earliest pending entry, reporting failed sources as they're found
+			best := -1
+			for i, src := range sources {
+				if src.err != nil {
+					err := src.err
+					src.err, src.done = nil, true
+					if !yield(ParquetLogEntry{}, fmt.Errorf("source %d: %w", i, err)) {
+						return
+					}
+					continue
+				}
+				if src.done {
+					continue
+				}
+				if best < 0 || src.key < sources[best].key {
+					best = i
+				}
+			}
+			if best < 0 {
+				return
+			}
+
+			src := sources[best]
+			if !yield(src.head, nil) {
+				return
+			}
+			src.advance()
+		}
+	}
+}
+
+// mergeSource is one of the sequences being merged by MergeSortedIters, with its next entry
+type mergeSource struct {
+	label string
+	next  func() (ParquetLogEntry, error, bool)
+	head  ParquetLogEntry // Next entry to yield, valid unless done or err is set
+	key   int64           // Timestamp head is ordered by
+	last  int64           // Timestamp of the latest timestamped entry, used for entries without one
+	done  bool
+	err   error
+}
+
+// advance reads the next entry into head, setting done at the end of the sequence and err on failure
+func (s *mergeSource) advance() {
+	entry, err, ok := s.next()
+	switch {
+	case err != nil:
+		s.err = err
+		return
+	case !ok:
+		s.done = true
+		return
+	}
+
+	if entry.HasTime {
+		s.last = entry.Timestamp
+	}
+	if entry.JobID == "" {
+		entry.JobID = s.label
+	}
+	s.head, s.key = entry, s.last
+}
@@ -0,0 +1,206 @@
+package buildkitelogs
+
+import (
+	"errors"
+	"iter"
+	"math"
+	"os"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestMergeParquetFiles(t *testing.T) {
+	inputs := []string{
+		"testdata/bazel-bazel_build_32517_rocky-rocky-linux-8.parquet",
+		"testdata/bun_build_19487_windows-x64-build-cpp.parquet",
+	}
+
+	var expectedRows int64
+	for _, input := range inputs {
+		info, err := NewParquetReader(input).GetFileInfo()
+		if err != nil {
+			t.Fatalf("GetFileInfo(%s) error = %v", input, err)
+		}
+		expectedRows += info.RowCount
+	}
+
+	output := "test_merged.parquet"
+	defer func() {
+		_ = os.Remove(output)
+	}()
+
+	if err := MergeParquetFiles(inputs, output, ParquetOptions{RowGroupSize: 10000}); err != nil {
+		t.Fatalf("MergeParquetFiles() error = %v", err)
+	}
+
+	info, err := NewParquetReader(output).GetFileInfo()
+	if err != nil {
+		t.Fatalf("GetFileInfo() error = %v", err)
+	}
+	if info.RowCount != expectedRows {
+		t.Errorf("RowCount = %d, want %d", info.RowCount, expectedRows)
+	}
+
+	// Order is preserved: the first row comes from the first input
+	var firstMerged, firstInput ParquetLogEntry
+	for entry, err := range ReadParquetFileIter(output) {
+		if err != nil {
+			t.Fatalf("ReadParquetFileIter() error = %v", err)
+		}
+		firstMerged = entry
+		break
+	}
+	for entry, err := range ReadParquetFileIter(inputs[0]) {
+		if err != nil {
+			t.Fatalf("ReadParquetFileIter() error = %v", err)
+		}
+		firstInput = entry
+		break
+	}
+	if firstMerged != firstInput {
+		t.Errorf("First merged entry = %+v, want %+v", firstMerged, firstInput)
+	}
+}
+
+func TestMergeParquetFilesSchemaMismatch(t *testing.T) {
+	inputs := []string{
+		"testdata/bazel-bazel_build_32517_rocky-rocky-linux-8.parquet",
+		"testdata/bash-example.parquet",
+	}
+
+	output := "test_merged_mismatch.parquet"
+	defer func() {
+		_ = os.Remove(output)
+	}()
+
+	err := MergeParquetFiles(inputs, output, ParquetOptions{})
+	if err == nil {
+		t.Fatal("Expected schema mismatch error")
+	}
+	if !strings.Contains(err.Error(), "schema mismatch") {
+		t.Errorf("Unexpected error: %v", err)
+	}
+
+	if _, statErr := os.Stat(output); !os.IsNotExist(statErr) {
+		t.Error("Output file should not be created when schemas differ")
+	}
+}
+
+func TestMergeParquetFilesNoInputs(t *testing.T) {
+	if err := MergeParquetFiles(nil, "test_merged_empty.parquet", ParquetOptions{}); err == nil {
+		t.Error("Expected error when merging no inputs")
+	}
+}
+
+// entrySeq yields entries from a slice, followed by err if it isn't nil
+func entrySeq(entries []ParquetLogEntry, err error) iter.Seq2[ParquetLogEntry, error] {
+	return func(yield func(ParquetLogEntry, error) bool) {
+		for _, entry := range entries {
+			if !yield(entry, nil) {
+				return
+			}
+		}
+		if err != nil {
+			yield(ParquetLogEntry{}, err)
+		}
+	}
+}
+
+func TestMergeSortedIters(t *testing.T) {
+	jobA := []ParquetLogEntry{
+		{Timestamp: 100, HasTime: true, Content: "a1", JobID: "job-a"},
+		{Timestamp: 300, HasTime: true, Content: "a2", JobID: "job-a"},
+		{Content: "a3", JobID: "job-a"}, // No timestamp, stays after a2
+		{Timestamp: 500, HasTime: true, Content: "a4", JobID: "job-a"},
+	}
+	jobB := []ParquetLogEntry{
+		{Content: "b0"}, // No timestamp before the first, sorts first
+		{Timestamp: 200, HasTime: true, Content: "b1"},
+		{Timestamp: 300, HasTime: true, Content: "b2"},
+	}
+	jobC := []ParquetLogEntry{
+		{Timestamp: 50, HasTime: true, Content: "c1", JobID: "job-c"},
+	}
+
+	var contents, jobs []string
+	for entry, err := range MergeSortedIters(entrySeq(jobA, nil), entrySeq(jobB, nil), entrySeq(nil, nil), entrySeq(jobC, nil)) {
+		if err != nil {
+			t.Fatalf("MergeSortedIters() error = %v", err)
+		}
+		contents = append(contents, entry.Content)
+		jobs = append(jobs, entry.JobID)
+	}
+
+	wantContents := []string{"b0", "c1", "a1", "b1", "a2", "a3", "b2", "a4"}
+	if !slices.Equal(contents, wantContents) {
+		t.Errorf("Merged order = %v, want %v", contents, wantContents)
+	}
+	wantJobs := []string{"1", "job-c", "job-a", "1", "job-a", "job-a", "1", "job-a"}
+	if !slices.Equal(jobs, wantJobs) {
+		t.Errorf("Merged sources = %v, want %v", jobs, wantJobs)
+	}
+}
+
+func TestMergeSortedIters_Error(t *testing.T) {
+	good := []ParquetLogEntry{
+		{Timestamp: 100, HasTime: true, Content: "good1"},
+		{Timestamp: 300, HasTime: true, Content: "good2"},
+	}
+	bad := []ParquetLogEntry{
+		{Timestamp: 200, HasTime: true, Content: "bad1"},
+	}
+
+	var contents []string
+	var errs []error
+	for entry, err := range MergeSortedIters(entrySeq(good, nil), entrySeq(bad, errors.New("corrupt page"))) {
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		contents = append(contents, entry.Content)
+	}
+
+	if len(errs) != 1 || errs[0].Error() != "source 1: corrupt page" {
+		t.Errorf("Expected one error from source 1, got %v", errs)
+	}
+	if want := []string{"good1", "bad1", "good2"}; !slices.Equal(contents, want) {
+		t.Errorf("Merged order = %v, want %v", contents, want)
+	}
+}
+
+func TestMergeSortedIters_Files(t *testing.T) {
+	inputs := []string{
+		"testdata/bazel-bazel_build_32517_rocky-rocky-linux-8.parquet",
+		"testdata/bun_build_19487_windows-x64-build-cpp.parquet",
+	}
+
+	var seqs []iter.Seq2[ParquetLogEntry, error]
+	var expectedRows int64
+	for _, input := range inputs {
+		info, err := NewParquetReader(input).GetFileInfo()
+		if err != nil {
+			t.Fatalf("GetFileInfo(%s) error = %v", input, err)
+		}
+		expectedRows += info.RowCount
+		seqs = append(seqs, NewParquetReader(input).ReadEntriesIter())
+	}
+
+	var rows int64
+	last := int64(math.MinInt64)
+	for entry, err := range MergeSortedIters(seqs...) {
+		if err != nil {
+			t.Fatalf("MergeSortedIters() error = %v", err)
+		}
+		rows++
+		if entry.HasTime {
+			if entry.Timestamp < last {
+				t.Fatalf("Row %d at %d is before the previous timestamp %d", rows, entry.Timestamp, last)
+			}
+			last = entry.Timestamp
+		}
+	}
+	if rows != expectedRows {
+		t.Errorf("Expected %d merged rows, got %d", expectedRows, rows)
+	}
+}
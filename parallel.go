@@ -0,0 +1,132 @@
+package buildkitelogs
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sync"
+
+	"github.com/apache/arrow-go/v18/arrow/memory"
+	"github.com/apache/arrow-go/v18/parquet/file"
+	"github.com/apache/arrow-go/v18/parquet/pqarrow"
+)
+
+// readGroupStatsParallel computes group statistics with up to workers row groups decoded concurrently
+// The row groups are split into a contiguous range per worker, each range is aggregated separately and
+// the partial statistics are merged in file order, so the result matches readGroupStats.
+func readGroupStatsParallel(src parquetSource, byJob bool, workers int) ([]GroupInfo, error) {
+	info, err := getParquetFileInfo(src)
+	if err != nil {
+		return nil, err
+	}
+
+	workers = min(workers, info.NumRowGroups)
+	if workers <= 1 {
+		return readGroupStats(src, byJob)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	partials := make([]*groupAggregator, workers)
+
+	// The first failure cancels the other workers, which then fail with the context error
+	var firstErr error
+	var failOnce sync.Once
+
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		first := w * info.NumRowGroups / workers
+		last := (w + 1) * info.NumRowGroups / workers
+
+		rowGroups := make([]int, 0, last-first)
+		for i := first; i < last; i++ {
+			rowGroups = append(rowGroups, i)
+		}
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			partial, err := aggregateRowGroups(ctx, src, byJob, rowGroups)
+			if err != nil {
+				failOnce.Do(func() {
+					firstErr = err
+					cancel()
+				})
+				return
+			}
+			partials[w] = partial
+		}()
+	}
+	wg.Wait()
+
+	if firstErr != nil {
+		return nil, firstErr
+	}
+
+	agg := newGroupAggregator(byJob)
+	for _, partial := range partials {
+		agg.merge(partial)
+	}
+
+	return agg.sorted(), nil
+}
+
+// aggregateRowGroups opens its own reader and aggregates the projected columns of the row groups
+func aggregateRowGroups(ctx context.Context, src parquetSource, byJob bool, rowGroups []int) (*groupAggregator, error) {
+	agg := newGroupAggregator(byJob)
+
+	r, _, release, err := src()
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	pf, err := file.NewParquetReader(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open parquet file: %w", err)
+	}
+	defer pf.Close()
+
+	arrowReader, err := pqarrow.NewFileReader(pf, pqarrow.ArrowReadProperties{
+		BatchSize: 5000,
+	}, memory.NewGoAllocator())
+	if err != nil {
+		return nil, fmt.Errorf("failed to create arrow reader: %w", err)
+	}
+
+	columns := groupStatsProjection(pf.MetaData().Schema.ColumnIndexByName("raw_line_size") >= 0)
+	colIndices := projectColumns(pf, columns)
+	if len(colIndices) == 0 {
+		return agg, nil // None of the columns exist in this file
+	}
+
+	recordReader, err := arrowReader.GetRecordReader(ctx, colIndices, rowGroups)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create record reader: %w", err)
+	}
+	defer recordReader.Release()
+
+	for {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		record, err := recordReader.Read()
+		if err != nil {
+			if err == io.EOF {
+				return agg, truncationWarning(r)
+			}
+			return nil, fmt.Errorf("error reading record: %w", err)
+		}
+
+		for entry, err := range convertRecordToEntriesIterStreaming(record, mapColumnIndices(record.Schema())) {
+			if err != nil {
+				record.Release()
+				return nil, err
+			}
+			agg.add(entry)
+		}
+		record.Release()
+	}
+}
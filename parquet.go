@@ -1,9 +1,16 @@
 package buildkitelogs
 
 import (
+	"errors"
 	"fmt"
+	"io"
 	"iter"
 	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
 
 	"github.com/apache/arrow-go/v18/arrow"
 	"github.com/apache/arrow-go/v18/arrow/array"
@@ -13,6 +20,15 @@ import (
 	"github.com/apache/arrow-go/v18/parquet/pqarrow"
 )
 
+// schemaVersion is the version of the log entry schema written by this package, recorded in the
+// key-value metadata under MetadataSchemaVersion. Bump it whenever a column is added, and record
+// the column's version in columnSchemaVersions.
+const schemaVersion = 2
+
+// MetadataSchemaVersion is the key-value metadata key holding the schema version of a log file
+// Files written before the version was recorded don't have it and are read as version 1.
+const MetadataSchemaVersion = "schema_version"
+
 // createArrowSchema creates the Arrow schema for log entries
 func createArrowSchema() *arrow.Schema {
 	return arrow.NewSchema([]arrow.Field{
@@ -23,11 +39,87 @@ func createArrowSchema() *arrow.Schema {
 		{Name: "is_command", Type: arrow.FixedWidthTypes.Boolean, Nullable: false},
 		{Name: "is_group", Type: arrow.FixedWidthTypes.Boolean, Nullable: false},
 		{Name: "is_progress", Type: arrow.FixedWidthTypes.Boolean, Nullable: false},
+		{Name: "is_error", Type: arrow.FixedWidthTypes.Boolean, Nullable: false},
+		{Name: "raw_line_size", Type: arrow.PrimitiveTypes.Int32, Nullable: false},
+		{Name: "line_number", Type: arrow.PrimitiveTypes.Int64, Nullable: false},
+		{Name: "job_id", Type: arrow.BinaryTypes.String, Nullable: false},
+		{Name: "job_name", Type: arrow.BinaryTypes.String, Nullable: false},
+		{Name: "group_index", Type: arrow.PrimitiveTypes.Int32, Nullable: false},
+		{Name: "parent_group", Type: arrow.BinaryTypes.String, Nullable: false},
+		{Name: "repeat_count", Type: arrow.PrimitiveTypes.Int32, Nullable: false},
 	}, nil)
 }
 
+// timestampType is the Arrow type of the timestamp column with ParquetOptions.LogicalTimestamps
+var timestampType = &arrow.TimestampType{Unit: arrow.Millisecond, TimeZone: "UTC"}
+
+// createLogicalTimestampSchema creates the log entry schema with a timestamp typed timestamp column
+func createLogicalTimestampSchema() *arrow.Schema {
+	schema := createArrowSchema()
+	fields := schema.Fields()
+	fields[0].Type = timestampType
+	return arrow.NewSchema(fields, nil)
+}
+
+// rawContentField is the column holding unstripped content with ParquetOptions.KeepRawContent
+// It isn't part of any schema version, as it is only written on request.
+var rawContentField = arrow.Field{Name: "raw_content", Type: arrow.BinaryTypes.String, Nullable: false}
+
+// withRawContentField returns the schema with rawContentField appended
+func withRawContentField(schema *arrow.Schema) *arrow.Schema {
+	return arrow.NewSchema(append(schema.Fields(), rawContentField), nil)
+}
+
+// isLogSchema returns true if the schema is the current log entry schema, in either timestamp variant
+// and with or without the raw_content column
+func isLogSchema(schema *arrow.Schema) bool {
+	if n := schema.NumFields(); n > 0 && schema.Field(n-1).Equal(rawContentField) {
+		schema = arrow.NewSchema(schema.Fields()[:n-1], nil)
+	}
+	return schema.Equal(createArrowSchema()) || schema.Equal(createLogicalTimestampSchema())
+}
+
+// withLogicalTimestamps returns the record with its int64 timestamp column retyped as timestampType
+// Both types store Unix milliseconds, so the column's buffers are shared rather than copied.
+func withLogicalTimestamps(record arrow.Record) arrow.Record {
+	fields := record.Schema().Fields()
+	fields[0].Type = timestampType
+	schema := arrow.NewSchema(fields, nil)
+
+	columns := record.Columns()
+	data := columns[0].Data()
+	retypedData := array.NewData(timestampType, data.Len(), data.Buffers(), nil, data.NullN(), data.Offset())
+	defer retypedData.Release()
+	timestamps := array.NewTimestampData(retypedData)
+	defer timestamps.Release()
+
+	retyped := make([]arrow.Array, len(columns))
+	copy(retyped, columns)
+	retyped[0] = timestamps
+
+	return array.NewRecord(schema, retyped, record.NumRows())
+}
+
+// contentEncoding controls how entry content is written to the content column
+// The zero value writes content unchanged.
+type contentEncoding struct {
+	maxBytes  int         // Truncate content longer than this when positive, see ParquetOptions.MaxContentBytes
+	stripANSI *ByteParser // Strip ANSI codes with this parser when not nil, see ParquetOptions.StripANSIOnWrite
+	keepRaw   bool        // Also write the unstripped content to raw_content, see ParquetOptions.KeepRawContent
+}
+
+// encode returns the content to store for an entry
+func (e contentEncoding) encode(content string) string {
+	if e.stripANSI != nil {
+		content = e.stripANSI.StripANSI(content)
+	}
+	return truncateContent(content, e.maxBytes)
+}
+
 // createRecordFromEntries creates an Arrow record from log entries
-func createRecordFromEntries(entries []*LogEntry, pool memory.Allocator) (arrow.Record, error) {
+// The job ID and name are written to every row, and may be empty when the job is unknown.
+// Content is written as encoding describes, adding the raw_content column if it keeps raw content.
+func createRecordFromEntries(entries []*LogEntry, jobID, jobName string, encoding contentEncoding, pool memory.Allocator) (arrow.Record, error) {
 	schema := createArrowSchema()
 
 	// Create builders for each field
@@ -38,6 +130,15 @@ func createRecordFromEntries(entries []*LogEntry, pool memory.Allocator) (arrow.
 	isCommandBuilder := array.NewBooleanBuilder(pool)
 	isGroupBuilder := array.NewBooleanBuilder(pool)
 	isProgressBuilder := array.NewBooleanBuilder(pool)
+	isErrorBuilder := array.NewBooleanBuilder(pool)
+	rawLineSizeBuilder := array.NewInt32Builder(pool)
+	lineNumberBuilder := array.NewInt64Builder(pool)
+	jobIDBuilder := array.NewStringBuilder(pool)
+	jobNameBuilder := array.NewStringBuilder(pool)
+	groupIndexBuilder := array.NewInt32Builder(pool)
+	parentGroupBuilder := array.NewStringBuilder(pool)
+	repeatCountBuilder := array.NewInt32Builder(pool)
+	rawContentBuilder := array.NewStringBuilder(pool)
 
 	defer timestampBuilder.Release()
 	defer contentBuilder.Release()
@@ -46,6 +147,15 @@ func createRecordFromEntries(entries []*LogEntry, pool memory.Allocator) (arrow.
 	defer isCommandBuilder.Release()
 	defer isGroupBuilder.Release()
 	defer isProgressBuilder.Release()
+	defer isErrorBuilder.Release()
+	defer rawLineSizeBuilder.Release()
+	defer lineNumberBuilder.Release()
+	defer jobIDBuilder.Release()
+	defer jobNameBuilder.Release()
+	defer groupIndexBuilder.Release()
+	defer parentGroupBuilder.Release()
+	defer repeatCountBuilder.Release()
+	defer rawContentBuilder.Release()
 
 	// Reserve capacity
 	numEntries := len(entries)
@@ -56,16 +166,39 @@ func createRecordFromEntries(entries []*LogEntry, pool memory.Allocator) (arrow.
 	isCommandBuilder.Resize(numEntries)
 	isGroupBuilder.Resize(numEntries)
 	isProgressBuilder.Resize(numEntries)
+	isErrorBuilder.Resize(numEntries)
+	rawLineSizeBuilder.Resize(numEntries)
+	lineNumberBuilder.Resize(numEntries)
+	jobIDBuilder.Resize(numEntries)
+	jobNameBuilder.Resize(numEntries)
+	groupIndexBuilder.Resize(numEntries)
+	parentGroupBuilder.Resize(numEntries)
+	repeatCountBuilder.Resize(numEntries)
+	if encoding.keepRaw {
+		rawContentBuilder.Resize(numEntries)
+	}
 
 	// Populate arrays
 	for _, entry := range entries {
+		// Files store milliseconds, finer timestamps from OSC unit hints are truncated here
 		timestampBuilder.Append(entry.Timestamp.UnixMilli())
-		contentBuilder.Append(entry.Content)
+		contentBuilder.Append(encoding.encode(entry.Content))
 		groupBuilder.Append(entry.Group)
 		hasTimestampBuilder.Append(entry.HasTimestamp())
 		isCommandBuilder.Append(entry.IsCommand())
 		isGroupBuilder.Append(entry.IsGroup())
 		isProgressBuilder.Append(entry.IsProgress())
+		isErrorBuilder.Append(entry.IsError())
+		rawLineSizeBuilder.Append(int32(entry.RawLineSize()))
+		lineNumberBuilder.Append(entry.LineNumber)
+		jobIDBuilder.Append(jobID)
+		jobNameBuilder.Append(jobName)
+		groupIndexBuilder.Append(int32(entry.GroupIndex))
+		parentGroupBuilder.Append(entry.ParentGroup)
+		repeatCountBuilder.Append(int32(entry.RepeatCount))
+		if encoding.keepRaw {
+			rawContentBuilder.Append(truncateContent(entry.Content, encoding.maxBytes))
+		}
 	}
 
 	// Build arrays
@@ -76,6 +209,14 @@ func createRecordFromEntries(entries []*LogEntry, pool memory.Allocator) (arrow.
 	isCommandArray := isCommandBuilder.NewArray()
 	isGroupArray := isGroupBuilder.NewArray()
 	isProgressArray := isProgressBuilder.NewArray()
+	isErrorArray := isErrorBuilder.NewArray()
+	rawLineSizeArray := rawLineSizeBuilder.NewArray()
+	lineNumberArray := lineNumberBuilder.NewArray()
+	jobIDArray := jobIDBuilder.NewArray()
+	jobNameArray := jobNameBuilder.NewArray()
+	groupIndexArray := groupIndexBuilder.NewArray()
+	parentGroupArray := parentGroupBuilder.NewArray()
+	repeatCountArray := repeatCountBuilder.NewArray()
 
 	defer timestampArray.Release()
 	defer contentArray.Release()
@@ -84,9 +225,16 @@ func createRecordFromEntries(entries []*LogEntry, pool memory.Allocator) (arrow.
 	defer isCommandArray.Release()
 	defer isGroupArray.Release()
 	defer isProgressArray.Release()
-
-	// Create record
-	return array.NewRecord(schema, []arrow.Array{
+	defer isErrorArray.Release()
+	defer rawLineSizeArray.Release()
+	defer lineNumberArray.Release()
+	defer jobIDArray.Release()
+	defer jobNameArray.Release()
+	defer groupIndexArray.Release()
+	defer parentGroupArray.Release()
+	defer repeatCountArray.Release()
+
+	columns := []arrow.Array{
 		timestampArray,
 		contentArray,
 		groupArray,
@@ -94,235 +242,422 @@ func createRecordFromEntries(entries []*LogEntry, pool memory.Allocator) (arrow.
 		isCommandArray,
 		isGroupArray,
 		isProgressArray,
-	}, int64(numEntries)), nil
-}
-
-// ExportToParquet exports log entries to a Parquet file using Apache Arrow
-func ExportToParquet(entries []*LogEntry, filename string) error {
-	// Create output file
-	file, err := os.Create(filename)
-	if err != nil {
-		return err
+		isErrorArray,
+		rawLineSizeArray,
+		lineNumberArray,
+		jobIDArray,
+		jobNameArray,
+		groupIndexArray,
+		parentGroupArray,
+		repeatCountArray,
 	}
-	defer func() { _ = file.Close() }()
 
-	// Create memory allocator
-	pool := memory.NewGoAllocator()
+	if encoding.keepRaw {
+		rawContentArray := rawContentBuilder.NewArray()
+		defer rawContentArray.Release()
 
-	// Create Arrow record
-	record, err := createRecordFromEntries(entries, pool)
-	if err != nil {
-		return err
+		schema = withRawContentField(schema)
+		columns = append(columns, rawContentArray)
 	}
-	defer record.Release()
 
-	// Create Parquet writer
-	writer, err := pqarrow.NewFileWriter(record.Schema(), file,
-		parquet.NewWriterProperties(
-			parquet.WithCompression(compress.Codecs.Zstd),
-			parquet.WithCompressionLevel(3),
-			parquet.WithSortingColumns([]parquet.SortingColumn{
-				{ColumnIdx: 0, Descending: false, NullsFirst: true}, // Timestamp
-				{ColumnIdx: 2, Descending: false, NullsFirst: true}, // Group
-			}),
-		),
-		pqarrow.NewArrowWriterProperties(
-			pqarrow.WithAllocator(pool),
-			pqarrow.WithCoerceTimestamps(arrow.Millisecond),
-		),
-	)
-	if err != nil {
-		return err
-	}
-	defer func() { _ = writer.Close() }()
+	// Create record
+	return array.NewRecord(schema, columns, int64(numEntries)), nil
+}
 
-	// Write the record
-	err = writer.Write(record)
-	if err != nil {
-		return err
+// TruncatedMarker is appended to content cut short by ParquetOptions.MaxContentBytes
+const TruncatedMarker = "…[truncated]"
+
+// truncateContent cuts content to at most maxBytes bytes plus TruncatedMarker, without splitting a UTF-8 character
+// Content within the limit, or any content when maxBytes isn't positive, is returned unchanged.
+func truncateContent(content string, maxBytes int) string {
+	if maxBytes <= 0 || len(content) <= maxBytes {
+		return content
 	}
 
-	return nil
+	cut := maxBytes
+	for cut > 0 && !utf8.RuneStart(content[cut]) {
+		cut--
+	}
+	return content[:cut] + TruncatedMarker
 }
 
-// ParquetWriter provides streaming Parquet writing capabilities
-type ParquetWriter struct {
-	file   *os.File
-	writer *pqarrow.FileWriter
-	pool   memory.Allocator
-	schema *arrow.Schema
+// bloomFilterGroupNDV is the number of distinct groups per row group the group Bloom filter is sized for
+// Logs rarely have more, and a row group with more only raises the false positive rate.
+const bloomFilterGroupNDV = 1024
+
+// DefaultRowGroupSize is the number of entries buffered per batch by the streaming exports
+const DefaultRowGroupSize = 1000
+
+// ParquetOptions configures how log entries are written to Parquet
+// The zero value writes Zstd level 3 compressed files
+type ParquetOptions struct {
+	// Compression is the codec name: zstd (default), snappy, gzip, brotli, lz4, lz4raw or none
+	Compression string
+	// CompressionLevel is passed to the codec, zero uses the codec default (3 for zstd)
+	CompressionLevel int
+	// RowGroupSize is the maximum number of rows per row group, zero uses DefaultRowGroupSize
+	// for streaming exports and a single row group for ExportToParquetWithOptions.
+	// Larger row groups compress better and scan faster, smaller row groups keep
+	// writer memory low and allow finer grained seeking and statistics based skipping.
+	RowGroupSize int
+	// Metadata is written to the file's key-value metadata, e.g. the output of JobMetadata
+	Metadata map[string]string
+	// JobID and JobName are written to the job_id and job_name columns of every row,
+	// so entries can be told apart when several jobs are combined into one file
+	JobID   string
+	JobName string
+	// CollapseProgress keeps only the last of each run of consecutive progress updates, see CollapseProgress
+	CollapseProgress bool
+	// SkipBlankLines leaves out entries whose content is empty or whitespace, see LogEntry.IsBlank
+	SkipBlankLines bool
+	// KeepTimestampedBlankLines keeps blank entries that have a timestamp when SkipBlankLines is set,
+	// so the pauses they mark still show up in timing
+	KeepTimestampedBlankLines bool
+	// LogicalTimestamps writes the timestamp column as an Arrow Timestamp(Millisecond, UTC), a Parquet
+	// TIMESTAMP logical type, instead of plain int64 milliseconds, so tools such as DuckDB and Spark read it
+	// as a timestamp. The stored values are the same and the readers in this package accept either type.
+	LogicalTimestamps bool
+	// MaxContentBytes truncates the content column to this many bytes, appending TruncatedMarker, so
+	// pathological lines such as embedded base64 don't bloat the file. The raw_line_size column still
+	// records the original size and entries are classified from their full content. Zero keeps all content.
+	MaxContentBytes int
+	// StripANSIOnWrite stores content with ANSI codes stripped, as LogEntry.CleanContent returns it, so
+	// readers that never want them don't strip every row. Entries are still classified and grouped from
+	// their original content.
+	StripANSIOnWrite bool
+	// KeepRawContent also writes the original content to a raw_content column when StripANSIOnWrite is
+	// set, see ParquetLogEntry.RawContent. It is truncated like content by MaxContentBytes.
+	KeepRawContent bool
+	// OnError is called with each error yielded by the sequence being exported, such as a malformed
+	// line, and the export skips it and continues if it returns true. Nil stops at the first error.
+	// Errors that end the sequence, such as a line longer than ParserOptions.MaxLineBytes, still end
+	// the export early, but the file is written with the entries read before them.
+	OnError func(err error) bool
+	// OnProgress is called after each batch of entries is written with the total written so far, so
+	// long exports can show a counter. Streaming exports write a batch per RowGroupSize entries.
+	// Nil disables progress reporting.
+	OnProgress func(entriesWritten int)
+	// BloomFilterGroups writes a Bloom filter of the group column for each row group, so
+	// ParquetReader.MayContainGroup and FilterByGroupExactIter can rule out files and row groups
+	// without reading them. It adds a few KB per row group.
+	BloomFilterGroups bool
 }
 
-// NewParquetWriter creates a new Parquet writer for streaming
-func NewParquetWriter(file *os.File) *ParquetWriter {
-	pool := memory.NewGoAllocator()
-	schema := createArrowSchema()
+// Key-value metadata keys describing which Buildkite job a file was exported from
+const (
+	MetadataOrganization = "organization"
+	MetadataPipeline     = "pipeline"
+	MetadataBuild        = "build"
+	MetadataJob          = "job"
+	MetadataExportedAt   = "exported_at"
+)
 
-	writer, err := pqarrow.NewFileWriter(schema, file, nil, pqarrow.DefaultWriterProps())
-	if err != nil {
-		return nil // In a real implementation, we'd want to return the error
+// JobMetadata returns key-value metadata recording the provenance of a Buildkite job export
+func JobMetadata(org, pipeline, build, job string) map[string]string {
+	return map[string]string{
+		MetadataOrganization: org,
+		MetadataPipeline:     pipeline,
+		MetadataBuild:        build,
+		MetadataJob:          job,
+		MetadataExportedAt:   time.Now().UTC().Format(time.RFC3339),
 	}
+}
 
-	return &ParquetWriter{
-		file:   file,
-		writer: writer,
-		pool:   pool,
-		schema: schema,
+// batchSize returns the number of entries the streaming exports buffer per write
+func (opts ParquetOptions) batchSize() int {
+	if opts.RowGroupSize > 0 {
+		return opts.RowGroupSize
 	}
+	return DefaultRowGroupSize
 }
 
-// WriteBatch writes a batch of log entries to the Parquet file
-func (pw *ParquetWriter) WriteBatch(entries []*LogEntry) error {
-	if len(entries) == 0 {
-		return nil
+// compressionCodecs maps the supported codec names to Parquet compression types
+var compressionCodecs = map[string]compress.Compression{
+	"zstd":         compress.Codecs.Zstd,
+	"snappy":       compress.Codecs.Snappy,
+	"gzip":         compress.Codecs.Gzip,
+	"brotli":       compress.Codecs.Brotli,
+	"lz4":          compress.Codecs.Lz4,
+	"lz4raw":       compress.Codecs.Lz4Raw,
+	"none":         compress.Codecs.Uncompressed,
+	"uncompressed": compress.Codecs.Uncompressed,
+}
+
+// writerProperties converts the options into Parquet writer properties
+func (opts ParquetOptions) writerProperties() (*parquet.WriterProperties, error) {
+	name := strings.ToLower(opts.Compression)
+	if name == "" {
+		name = "zstd"
 	}
 
-	record, err := createRecordFromEntries(entries, pw.pool)
-	if err != nil {
-		return err
+	codec, ok := compressionCodecs[name]
+	if !ok {
+		names := make([]string, 0, len(compressionCodecs))
+		for n := range compressionCodecs {
+			names = append(names, n)
+		}
+		sort.Strings(names)
+		return nil, fmt.Errorf("unknown compression codec %q (supported: %s)", opts.Compression, strings.Join(names, ", "))
 	}
-	defer record.Release()
 
-	return pw.writer.Write(record)
-}
+	level := opts.CompressionLevel
+	if level == 0 {
+		level = compress.DefaultCompressionLevel
+		if codec == compress.Codecs.Zstd {
+			level = 3
+		}
+	}
 
-// Close closes the Parquet writer
-func (pw *ParquetWriter) Close() error {
-	return pw.writer.Close()
+	// Rows are written in document order, never sorted, so no sorting columns are declared. Entries without
+	// a timestamp keep their place, and engines mustn't assume timestamp order when planning queries.
+	props := []parquet.WriterProperty{
+		parquet.WithCompression(codec),
+		parquet.WithCompressionLevel(level),
+	}
+	if opts.RowGroupSize > 0 {
+		props = append(props, parquet.WithMaxRowGroupLength(int64(opts.RowGroupSize)))
+	}
+	if opts.BloomFilterGroups {
+		// Without an expected count the filter is sized at its 1MB maximum in every row group
+		props = append(props,
+			parquet.WithBloomFilterEnabledFor("group", true),
+			parquet.WithBloomFilterNDVFor("group", bloomFilterGroupNDV),
+		)
+	}
+
+	return parquet.NewWriterProperties(props...), nil
 }
 
-// ExportIteratorToParquet exports from an iterator to Parquet using Apache Arrow
-func ExportIteratorToParquet(iterator *LogIterator, filename string) error {
-	// Create output file
-	file, err := os.Create(filename)
+// createNewFileWriter creates a Parquet file writer for the log entry schema
+func createNewFileWriter(schema *arrow.Schema, w io.Writer, pool memory.Allocator, opts ParquetOptions) (*pqarrow.FileWriter, error) {
+	props, err := opts.writerProperties()
 	if err != nil {
-		return err
+		return nil, err
 	}
-	defer func() { _ = file.Close() }()
 
-	// Create writer
-	writer := NewParquetWriter(file)
-	if writer == nil {
-		return fmt.Errorf("failed to create Parquet writer")
+	// The Parquet writer closes its sink when it is closed, w belongs to the caller
+	writer, err := pqarrow.NewFileWriter(schema, nopCloseWriter{w}, props,
+		pqarrow.NewArrowWriterProperties(
+			pqarrow.WithAllocator(pool),
+			pqarrow.WithCoerceTimestamps(arrow.Millisecond),
+		),
+	)
+	if err != nil {
+		return nil, err
 	}
-	defer func() { _ = writer.Close() }()
-
-	// Process entries in batches for memory efficiency
-	const batchSize = 1000
-	batch := make([]*LogEntry, 0, batchSize)
 
-	for iterator.Next() {
-		entry := iterator.Entry()
-		batch = append(batch, entry)
+	metadata := opts.Metadata
 
-		// Write batch when full
-		if len(batch) >= batchSize {
-			err := writer.WriteBatch(batch)
-			if err != nil {
-				return err
-			}
-			batch = batch[:0] // Reset slice
+	// Only the current schema is versioned, files copied from older inputs by MergeParquetFiles
+	// have fewer columns and keep reading as version 1
+	if isLogSchema(schema) {
+		metadata = make(map[string]string, len(opts.Metadata)+1)
+		for key, value := range opts.Metadata {
+			metadata[key] = value
 		}
+		metadata[MetadataSchemaVersion] = strconv.Itoa(schemaVersion)
 	}
 
-	// Write final batch
-	if len(batch) > 0 {
-		err := writer.WriteBatch(batch)
-		if err != nil {
-			return err
+	// Sort keys so the file footer is deterministic
+	keys := make([]string, 0, len(metadata))
+	for key := range metadata {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		if err := writer.AppendKeyValueMetadata(key, metadata[key]); err != nil {
+			_ = writer.Close()
+			return nil, fmt.Errorf("failed to write metadata %q: %w", key, err)
 		}
 	}
 
-	// Check for iterator errors
-	return iterator.Err()
+	return writer, nil
 }
 
-// ExportSeq2ToParquet exports log entries using Go 1.23+ iter.Seq2 for efficient iteration
-func ExportSeq2ToParquet(seq iter.Seq2[*LogEntry, error], filename string) error {
-	// Create output file
+// nopCloseWriter hides any Close method of the writer it wraps
+type nopCloseWriter struct {
+	io.Writer
+}
+
+// createParquetFile creates the named file and calls write with it, closing it afterwards
+// Errors closing the file are returned, as they may mean buffered data was never written.
+func createParquetFile(filename string, write func(w io.Writer) error) error {
 	file, err := os.Create(filename)
 	if err != nil {
 		return err
 	}
-	defer func() { _ = file.Close() }()
 
-	// Create writer
-	writer := NewParquetWriter(file)
-	if writer == nil {
-		return fmt.Errorf("failed to create Parquet writer")
+	if err := write(file); err != nil {
+		_ = file.Close()
+		return err
 	}
-	defer func() { _ = writer.Close() }()
 
-	// Process entries in batches for memory efficiency
-	const batchSize = 1000
-	batch := make([]*LogEntry, 0, batchSize)
+	return file.Close()
+}
 
-	for entry, err := range seq {
-		// Handle errors during iteration
-		if err != nil {
-			return fmt.Errorf("error during iteration: %w", err)
-		}
+// ExportToParquet exports log entries to a Parquet file using Apache Arrow
+func ExportToParquet(entries []*LogEntry, filename string) error {
+	return ExportToParquetWithOptions(entries, filename, ParquetOptions{})
+}
 
-		batch = append(batch, entry)
+// ExportToParquetWithOptions exports log entries to a Parquet file using the provided options
+func ExportToParquetWithOptions(entries []*LogEntry, filename string, opts ParquetOptions) error {
+	return createParquetFile(filename, func(w io.Writer) error {
+		return ExportToParquetWriter(entries, w, opts)
+	})
+}
 
-		// Write batch when full
-		if len(batch) >= batchSize {
-			err := writer.WriteBatch(batch)
-			if err != nil {
-				return err
+// ExportToParquetWriter exports log entries as Parquet to any writer, such as stdout or an upload stream
+// w is left open once the file footer has been written, closing it is up to the caller.
+func ExportToParquetWriter(entries []*LogEntry, w io.Writer, opts ParquetOptions) error {
+	// The whole slice is one batch, so it's a single row group unless RowGroupSize splits it
+	batchSize := max(len(entries), 1)
+	if opts.RowGroupSize > 0 {
+		batchSize = opts.RowGroupSize
+	}
+
+	return exportEntries(sliceSeq(entries), w, opts, batchSize, nil)
+}
+
+// sliceSeq adapts a slice of entries to iter.Seq2
+func sliceSeq(entries []*LogEntry) iter.Seq2[*LogEntry, error] {
+	return func(yield func(*LogEntry, error) bool) {
+		for _, entry := range entries {
+			if !yield(entry, nil) {
+				return
 			}
-			batch = batch[:0] // Reset slice
 		}
 	}
+}
 
-	// Write final batch
-	if len(batch) > 0 {
-		err := writer.WriteBatch(batch)
-		if err != nil {
-			return err
-		}
+// ParquetWriter provides streaming Parquet writing capabilities
+type ParquetWriter struct {
+	writer     *pqarrow.FileWriter
+	pool       memory.Allocator
+	schema     *arrow.Schema
+	jobID      string
+	jobName    string
+	batchSize  int              // Entries buffered per WriteBatch by WriteBatchSeq2
+	content    contentEncoding  // How content is written, see ParquetOptions.MaxContentBytes and StripANSIOnWrite
+	onError    func(error) bool // Decides whether WriteBatchSeq2 skips sequence errors, see ParquetOptions.OnError
+	onProgress func(int)        // Reports entries written after each batch, see ParquetOptions.OnProgress
+	written    int              // Entries written so far, only counted for onProgress
+}
+
+// NewParquetWriter creates a new Parquet writer for streaming to w
+// Closing the writer writes the file footer but leaves w open, closing it is up to the caller.
+func NewParquetWriter(w io.Writer) *ParquetWriter {
+	writer, err := NewParquetWriterWithOptions(w, ParquetOptions{})
+	if err != nil {
+		return nil // Use NewParquetWriterWithOptions to inspect the error
 	}
+	return writer
+}
 
-	return nil
+// NewParquetWriterWithOptions creates a new Parquet writer for streaming using the provided options
+func NewParquetWriterWithOptions(w io.Writer, opts ParquetOptions) (*ParquetWriter, error) {
+	pool := memory.NewGoAllocator()
+	schema := createArrowSchema()
+	if opts.LogicalTimestamps {
+		schema = createLogicalTimestampSchema()
+	}
+
+	content := contentEncoding{maxBytes: opts.MaxContentBytes}
+	if opts.StripANSIOnWrite {
+		// One parser strips every entry, rather than one per entry as LogEntry.CleanContent does
+		content.stripANSI = NewByteParser()
+		content.keepRaw = opts.KeepRawContent
+	}
+	if content.keepRaw {
+		schema = withRawContentField(schema)
+	}
+
+	writer, err := createNewFileWriter(schema, w, pool, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	return &ParquetWriter{
+		writer:     writer,
+		pool:       pool,
+		schema:     schema,
+		jobID:      opts.JobID,
+		jobName:    opts.JobName,
+		batchSize:  opts.batchSize(),
+		content:    content,
+		onError:    opts.OnError,
+		onProgress: opts.OnProgress,
+	}, nil
 }
 
-// ExportSeq2ToParquetWithFilter exports filtered log entries using iter.Seq2
-func ExportSeq2ToParquetWithFilter(seq iter.Seq2[*LogEntry, error], filename string, filterFunc func(*LogEntry) bool) error {
-	// Create output file
-	file, err := os.Create(filename)
+// WriteBatch writes a batch of log entries to the Parquet file
+// Each call starts a new row group, batches larger than ParquetOptions.RowGroupSize
+// are split across multiple row groups
+func (pw *ParquetWriter) WriteBatch(entries []*LogEntry) error {
+	if len(entries) == 0 {
+		return nil
+	}
+
+	record, err := createRecordFromEntries(entries, pw.jobID, pw.jobName, pw.content, pw.pool)
 	if err != nil {
 		return err
 	}
-	defer func() { _ = file.Close() }()
+	defer record.Release()
+
+	if !pw.schema.Equal(record.Schema()) {
+		record = withLogicalTimestamps(record)
+		defer record.Release()
+	}
+
+	if err := pw.writer.Write(record); err != nil {
+		return err
+	}
 
-	// Create writer
-	writer := NewParquetWriter(file)
-	if writer == nil {
-		return fmt.Errorf("failed to create Parquet writer")
+	if pw.onProgress != nil {
+		pw.written += len(entries)
+		pw.onProgress(pw.written)
 	}
-	defer func() { _ = writer.Close() }()
+	return nil
+}
+
+// WriteBatchSeq2 streams log entries from iter.Seq2 into the file, writing a batch per
+// ParquetOptions.RowGroupSize entries (DefaultRowGroupSize if unset)
+// It can be called more than once, e.g. with SetJob between the logs of different jobs.
+// Errors from the sequence stop the write unless ParquetOptions.OnError skips them.
+func (pw *ParquetWriter) WriteBatchSeq2(seq iter.Seq2[*LogEntry, error]) error {
+	return writeEntries(pw, seq, nil)
+}
 
-	// Process entries in batches for memory efficiency
-	const batchSize = 1000
-	batch := make([]*LogEntry, 0, batchSize)
+// writeEntries accumulates entries that pass filter into batches of w.batchSize and writes them
+// This is the batching loop shared by every export, filter may be nil to keep every entry.
+func writeEntries(w *ParquetWriter, seq iter.Seq2[*LogEntry, error], filter func(*LogEntry) bool) error {
+	batch := make([]*LogEntry, 0, w.batchSize)
 
 	for entry, err := range seq {
-		// Handle errors during iteration
+		// Handle errors during iteration, skipping them if the caller chooses to
 		if err != nil {
+			if w.onError != nil && w.onError(err) {
+				continue
+			}
 			return fmt.Errorf("error during iteration: %w", err)
 		}
 
+		// A reused entry would overwrite every row already in the batch
+		if len(batch) > 0 && batch[len(batch)-1] == entry {
+			return errReusedEntry
+		}
+
 		// Apply filter if provided
-		if filterFunc != nil && !filterFunc(entry) {
+		if filter != nil && !filter(entry) {
 			continue
 		}
 
 		batch = append(batch, entry)
 
 		// Write batch when full
-		if len(batch) >= batchSize {
-			err := writer.WriteBatch(batch)
-			if err != nil {
+		if len(batch) >= w.batchSize {
+			if err := w.WriteBatch(batch); err != nil {
 				return err
 			}
 			batch = batch[:0] // Reset slice
@@ -330,12 +665,81 @@ func ExportSeq2ToParquetWithFilter(seq iter.Seq2[*LogEntry, error], filename str
 	}
 
 	// Write final batch
-	if len(batch) > 0 {
-		err := writer.WriteBatch(batch)
-		if err != nil {
-			return err
-		}
+	return w.WriteBatch(batch)
+}
+
+// exportEntries writes a Parquet file of the entries that pass filter, batching batchSize entries at a time
+func exportEntries(seq iter.Seq2[*LogEntry, error], w io.Writer, opts ParquetOptions, batchSize int, filter func(*LogEntry) bool) error {
+	if opts.CollapseProgress {
+		seq = CollapseProgress(seq, nil)
+	}
+	if opts.SkipBlankLines {
+		seq = DropBlankLines(seq, opts.KeepTimestampedBlankLines, nil)
 	}
 
-	return nil
+	writer, err := NewParquetWriterWithOptions(w, opts)
+	if err != nil {
+		return fmt.Errorf("failed to create Parquet writer: %w", err)
+	}
+	defer func() { _ = writer.Close() }()
+
+	writer.batchSize = batchSize
+	if err := writeEntries(writer, seq, filter); err != nil {
+		return err
+	}
+
+	// Close explicitly so errors writing the footer are reported
+	return writer.Close()
+}
+
+// SetJob sets the job ID and name written with subsequent batches
+// This allows the logs of several jobs to be streamed into a single file.
+func (pw *ParquetWriter) SetJob(jobID, jobName string) {
+	pw.jobID = jobID
+	pw.jobName = jobName
+}
+
+// Close writes the Parquet file footer, the underlying writer is left open
+func (pw *ParquetWriter) Close() error {
+	return pw.writer.Close()
+}
+
+// ExportIteratorToParquet exports from an iterator to Parquet using Apache Arrow
+func ExportIteratorToParquet(iterator *LogIterator, filename string) error {
+	return ExportIteratorToParquetWithOptions(iterator, filename, ParquetOptions{})
+}
+
+// ExportIteratorToParquetWithOptions exports from an iterator to Parquet using the provided options
+func ExportIteratorToParquetWithOptions(iterator *LogIterator, filename string, opts ParquetOptions) error {
+	return createParquetFile(filename, func(w io.Writer) error {
+		return ExportSeq2ToParquetWriter(iterator.Seq2(), w, opts)
+	})
+}
+
+// errReusedEntry is returned when a sequence yields the same entry twice, as with ParserOptions.ReuseEntries
+var errReusedEntry = errors.New("sequence reuses its log entries, which can't be batched for export (disable ParserOptions.ReuseEntries)")
+
+// ExportSeq2ToParquet exports log entries using Go 1.23+ iter.Seq2 for efficient iteration
+func ExportSeq2ToParquet(seq iter.Seq2[*LogEntry, error], filename string) error {
+	return ExportSeq2ToParquetWithOptions(seq, filename, ParquetOptions{})
+}
+
+// ExportSeq2ToParquetWithOptions exports log entries using iter.Seq2 and the provided options
+func ExportSeq2ToParquetWithOptions(seq iter.Seq2[*LogEntry, error], filename string, opts ParquetOptions) error {
+	return createParquetFile(filename, func(w io.Writer) error {
+		return ExportSeq2ToParquetWriter(seq, w, opts)
+	})
+}
+
+// ExportSeq2ToParquetWriter streams log entries from iter.Seq2 as Parquet to any writer
+// w is left open once the file footer has been written, closing it is up to the caller.
+func ExportSeq2ToParquetWriter(seq iter.Seq2[*LogEntry, error], w io.Writer, opts ParquetOptions) error {
+	return exportEntries(seq, w, opts, opts.batchSize(), nil)
+}
+
+// ExportSeq2ToParquetWithFilter exports filtered log entries using iter.Seq2
+func ExportSeq2ToParquetWithFilter(seq iter.Seq2[*LogEntry, error], filename string, filterFunc func(*LogEntry) bool) error {
+	return createParquetFile(filename, func(w io.Writer) error {
+		return exportEntries(seq, w, ParquetOptions{}, DefaultRowGroupSize, filterFunc)
+	})
 }
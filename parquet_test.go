@@ -1,10 +1,21 @@
 package buildkitelogs
 
 import (
+	"bytes"
+	"fmt"
+	"io"
+	"iter"
 	"os"
+	"slices"
 	"strings"
 	"testing"
 	"time"
+
+	"github.com/apache/arrow-go/v18/arrow"
+	"github.com/apache/arrow-go/v18/arrow/array"
+	"github.com/apache/arrow-go/v18/arrow/memory"
+	"github.com/apache/arrow-go/v18/parquet/compress"
+	"github.com/apache/arrow-go/v18/parquet/file"
 )
 
 func TestParquetExport(t *testing.T) {
@@ -225,3 +236,1113 @@ func TestParquetSeq2ExportWithFilter(t *testing.T) {
 		t.Error("Parquet file is empty")
 	}
 }
+
+func TestParquetErrorColumnRoundTrip(t *testing.T) {
+	parser := NewParser()
+
+	testData := "\x1b_bk;t=1745322209921\x07~~~ Running tests\n" +
+		"\x1b_bk;t=1745322209922\x07🚨 Error: The command exited with status 1\n" +
+		"\x1b_bk;t=1745322209923\x07Some regular output"
+
+	filename := "test_error_column.parquet"
+	defer func() {
+		_ = os.Remove(filename)
+	}()
+
+	err := ExportSeq2ToParquet(parser.All(strings.NewReader(testData)), filename)
+	if err != nil {
+		t.Fatalf("ExportSeq2ToParquet() error = %v", err)
+	}
+
+	var errors []bool
+	for entry, err := range ReadParquetFileIter(filename) {
+		if err != nil {
+			t.Fatalf("ReadParquetFileIter() error = %v", err)
+		}
+		errors = append(errors, entry.IsError)
+	}
+
+	expected := []bool{false, true, false}
+	if len(errors) != len(expected) {
+		t.Fatalf("Expected %d entries, got %d", len(expected), len(errors))
+	}
+	for i, want := range expected {
+		if errors[i] != want {
+			t.Errorf("Entry %d: IsError = %v, want %v", i, errors[i], want)
+		}
+	}
+}
+
+func TestParquetCompressionOptions(t *testing.T) {
+	entries := []*LogEntry{
+		{
+			Timestamp: time.Unix(0, 1745322209921*int64(time.Millisecond)),
+			Content:   "~~~ Running global environment hook",
+			Group:     "~~~ Running global environment hook",
+		},
+	}
+
+	tests := []struct {
+		name string
+		opts ParquetOptions
+		want compress.Compression
+	}{
+		{name: "default", opts: ParquetOptions{}, want: compress.Codecs.Zstd},
+		{name: "snappy", opts: ParquetOptions{Compression: "snappy"}, want: compress.Codecs.Snappy},
+		{name: "none", opts: ParquetOptions{Compression: "none"}, want: compress.Codecs.Uncompressed},
+		{name: "zstd level 9", opts: ParquetOptions{Compression: "ZSTD", CompressionLevel: 9}, want: compress.Codecs.Zstd},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			filename := "test_compression_" + strings.ReplaceAll(tt.name, " ", "_") + ".parquet"
+			defer func() {
+				_ = os.Remove(filename)
+			}()
+
+			if err := ExportToParquetWithOptions(entries, filename, tt.opts); err != nil {
+				t.Fatalf("ExportToParquetWithOptions() error = %v", err)
+			}
+
+			osFile, err := os.Open(filename)
+			if err != nil {
+				t.Fatalf("Failed to open parquet file: %v", err)
+			}
+			defer osFile.Close()
+
+			pf, err := file.NewParquetReader(osFile)
+			if err != nil {
+				t.Fatalf("Failed to read parquet file: %v", err)
+			}
+			defer pf.Close()
+
+			chunk, err := pf.MetaData().RowGroup(0).ColumnChunk(0)
+			if err != nil {
+				t.Fatalf("Failed to read column chunk metadata: %v", err)
+			}
+			if chunk.Compression() != tt.want {
+				t.Errorf("Compression = %v, want %v", chunk.Compression(), tt.want)
+			}
+		})
+	}
+}
+
+func TestParquetCompressionOptionsUnknownCodec(t *testing.T) {
+	filename := "test_compression_unknown.parquet"
+	defer func() {
+		_ = os.Remove(filename)
+	}()
+
+	err := ExportToParquetWithOptions(nil, filename, ParquetOptions{Compression: "bzip2"})
+	if err == nil {
+		t.Fatal("Expected error for unknown compression codec")
+	}
+	if !strings.Contains(err.Error(), `unknown compression codec "bzip2"`) {
+		t.Errorf("Unexpected error message: %v", err)
+	}
+
+	file, err := os.Create(filename)
+	if err != nil {
+		t.Fatalf("Failed to create file: %v", err)
+	}
+	defer file.Close()
+
+	if _, err := NewParquetWriterWithOptions(file, ParquetOptions{Compression: "bzip2"}); err == nil {
+		t.Error("Expected NewParquetWriterWithOptions to reject unknown codec")
+	}
+}
+
+func TestParquetRowGroupSize(t *testing.T) {
+	const numLines = 2500
+	testData := generateTestData(numLines)
+
+	tests := []struct {
+		name          string
+		opts          ParquetOptions
+		wantRowGroups int
+	}{
+		{name: "default", opts: ParquetOptions{}, wantRowGroups: 3},
+		{name: "small", opts: ParquetOptions{RowGroupSize: 500}, wantRowGroups: 5},
+		{name: "large", opts: ParquetOptions{RowGroupSize: 5000}, wantRowGroups: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			filename := "test_row_group_" + tt.name + ".parquet"
+			defer func() {
+				_ = os.Remove(filename)
+			}()
+
+			parser := NewParser()
+			err := ExportSeq2ToParquetWithOptions(parser.All(strings.NewReader(testData)), filename, tt.opts)
+			if err != nil {
+				t.Fatalf("ExportSeq2ToParquetWithOptions() error = %v", err)
+			}
+
+			info, err := NewParquetReader(filename).GetFileInfo()
+			if err != nil {
+				t.Fatalf("GetFileInfo() error = %v", err)
+			}
+
+			if info.RowCount != numLines {
+				t.Errorf("RowCount = %d, want %d", info.RowCount, numLines)
+			}
+			if info.NumRowGroups != tt.wantRowGroups {
+				t.Errorf("NumRowGroups = %d, want %d", info.NumRowGroups, tt.wantRowGroups)
+			}
+		})
+	}
+
+	t.Run("slice export", func(t *testing.T) {
+		filename := "test_row_group_slice.parquet"
+		defer func() {
+			_ = os.Remove(filename)
+		}()
+
+		var entries []*LogEntry
+		for entry, err := range NewParser().All(strings.NewReader(testData)) {
+			if err != nil {
+				t.Fatalf("Parser.All() error = %v", err)
+			}
+			entries = append(entries, entry)
+		}
+
+		if err := ExportToParquetWithOptions(entries, filename, ParquetOptions{RowGroupSize: 1000}); err != nil {
+			t.Fatalf("ExportToParquetWithOptions() error = %v", err)
+		}
+
+		info, err := NewParquetReader(filename).GetFileInfo()
+		if err != nil {
+			t.Fatalf("GetFileInfo() error = %v", err)
+		}
+		if info.NumRowGroups != 3 {
+			t.Errorf("NumRowGroups = %d, want 3", info.NumRowGroups)
+		}
+	})
+}
+
+func TestParquetRawLineSizeRoundTrip(t *testing.T) {
+	parser := NewParser()
+
+	lines := []string{
+		"\x1b_bk;t=1745322209921\x07~~~ Running tests",
+		"\x1b_bk;t=1745322209922\x07[90m$[0m make test",
+		"untimestamped",
+	}
+
+	filename := "test_raw_line_size.parquet"
+	defer func() {
+		_ = os.Remove(filename)
+	}()
+
+	err := ExportSeq2ToParquet(parser.All(strings.NewReader(strings.Join(lines, "\n"))), filename)
+	if err != nil {
+		t.Fatalf("ExportSeq2ToParquet() error = %v", err)
+	}
+
+	entries := readAllParquetEntries(t, filename)
+	if len(entries) != len(lines) {
+		t.Fatalf("Expected %d entries, got %d", len(lines), len(entries))
+	}
+	for i, line := range lines {
+		if entries[i].RawLineSize != int32(len(line)) {
+			t.Errorf("Entry %d: RawLineSize = %d, want %d", i, entries[i].RawLineSize, len(line))
+		}
+	}
+
+	// Files written before the column existed read back as zero
+	for _, entry := range readAllParquetEntries(t, "testdata/bazel-bazel_build_32517_rocky-rocky-linux-8.parquet") {
+		if entry.RawLineSize != 0 {
+			t.Fatalf("Expected zero RawLineSize for legacy file, got %d", entry.RawLineSize)
+		}
+	}
+}
+
+// readAllParquetEntries collects every entry in a Parquet file, failing the test on error
+func readAllParquetEntries(t *testing.T, filename string) []ParquetLogEntry {
+	t.Helper()
+
+	var entries []ParquetLogEntry
+	for entry, err := range ReadParquetFileIter(filename) {
+		if err != nil {
+			t.Fatalf("ReadParquetFileIter(%s) error = %v", filename, err)
+		}
+		entries = append(entries, entry)
+	}
+	return entries
+}
+
+func TestParquetLineNumberRoundTrip(t *testing.T) {
+	parser := NewParser()
+
+	testData := "\x1b_bk;t=1745322209921\x07~~~ Running tests\n" +
+		"\x1b_bk;t=1745322209922\x07$ make test\n" +
+		"\x1b_bk;t=1745322209923\x07ok"
+
+	filename := "test_line_number.parquet"
+	defer func() {
+		_ = os.Remove(filename)
+	}()
+
+	err := ExportSeq2ToParquet(parser.All(strings.NewReader(testData)), filename)
+	if err != nil {
+		t.Fatalf("ExportSeq2ToParquet() error = %v", err)
+	}
+
+	for i, entry := range readAllParquetEntries(t, filename) {
+		if entry.LineNumber != int64(i+1) {
+			t.Errorf("Entry %d: LineNumber = %d, want %d", i, entry.LineNumber, i+1)
+		}
+	}
+
+	// Files written before the column existed read back as zero
+	for entry, err := range NewParquetReader("testdata/bash-example.parquet").SeekToRow(200) {
+		if err != nil {
+			t.Fatalf("SeekToRow() error = %v", err)
+		}
+		if entry.LineNumber != 0 {
+			t.Fatalf("Expected zero LineNumber for legacy file, got %d", entry.LineNumber)
+		}
+	}
+}
+
+func TestParquetMetadataRoundTrip(t *testing.T) {
+	parser := NewParser()
+	testData := "\x1b_bk;t=1745322209921\x07~~~ Running tests"
+
+	filename := "test_metadata.parquet"
+	defer func() {
+		_ = os.Remove(filename)
+	}()
+
+	metadata := JobMetadata("myorg", "mypipeline", "123", "abc-def")
+	err := ExportSeq2ToParquetWithOptions(parser.All(strings.NewReader(testData)), filename, ParquetOptions{
+		Metadata: metadata,
+	})
+	if err != nil {
+		t.Fatalf("ExportSeq2ToParquetWithOptions() error = %v", err)
+	}
+
+	info, err := NewParquetReader(filename).GetFileInfo()
+	if err != nil {
+		t.Fatalf("GetFileInfo() error = %v", err)
+	}
+
+	for _, key := range []string{MetadataOrganization, MetadataPipeline, MetadataBuild, MetadataJob, MetadataExportedAt} {
+		if info.Metadata[key] != metadata[key] {
+			t.Errorf("Metadata[%q] = %q, want %q", key, info.Metadata[key], metadata[key])
+		}
+	}
+
+	if _, err := time.Parse(time.RFC3339, info.Metadata[MetadataExportedAt]); err != nil {
+		t.Errorf("exported_at is not RFC3339: %v", err)
+	}
+
+	// Files without metadata report none
+	info, err = NewParquetReader("testdata/bash-example.parquet").GetFileInfo()
+	if err != nil {
+		t.Fatalf("GetFileInfo() error = %v", err)
+	}
+	if len(info.Metadata) != 0 {
+		t.Errorf("Expected no metadata, got %v", info.Metadata)
+	}
+}
+
+func TestParquetJobColumnsRoundTrip(t *testing.T) {
+	parser := NewParser()
+
+	testData := "\x1b_bk;t=1745322209921\x07~~~ Running tests\n" +
+		"\x1b_bk;t=1745322209922\x07$ make test"
+
+	filename := "test_job_columns.parquet"
+	defer func() {
+		_ = os.Remove(filename)
+	}()
+
+	opts := ParquetOptions{JobID: "0190-job-a", JobName: ":go: test"}
+	err := ExportSeq2ToParquetWithOptions(parser.All(strings.NewReader(testData)), filename, opts)
+	if err != nil {
+		t.Fatalf("ExportSeq2ToParquetWithOptions() error = %v", err)
+	}
+
+	for i, entry := range readAllParquetEntries(t, filename) {
+		if entry.JobID != opts.JobID || entry.JobName != opts.JobName {
+			t.Errorf("Entry %d: job = (%q, %q), want (%q, %q)", i, entry.JobID, entry.JobName, opts.JobID, opts.JobName)
+		}
+	}
+
+	// Files written before the columns existed read back as empty
+	for entry, err := range NewParquetReader("testdata/bash-example.parquet").SeekToRow(200) {
+		if err != nil {
+			t.Fatalf("SeekToRow() error = %v", err)
+		}
+		if entry.JobID != "" || entry.JobName != "" {
+			t.Fatalf("Expected empty job columns for legacy file, got (%q, %q)", entry.JobID, entry.JobName)
+		}
+	}
+}
+
+func TestParquetWriterSetJob(t *testing.T) {
+	filename := "test_writer_set_job.parquet"
+	defer func() {
+		_ = os.Remove(filename)
+	}()
+
+	file, err := os.Create(filename)
+	if err != nil {
+		t.Fatalf("Failed to create file: %v", err)
+	}
+	defer func() { _ = file.Close() }()
+
+	writer, err := NewParquetWriterWithOptions(file, ParquetOptions{})
+	if err != nil {
+		t.Fatalf("NewParquetWriterWithOptions() error = %v", err)
+	}
+
+	writer.SetJob("job-a", "build")
+	if err := writer.WriteBatch([]*LogEntry{{Content: "building"}}); err != nil {
+		t.Fatalf("WriteBatch() error = %v", err)
+	}
+
+	writer.SetJob("job-b", "test")
+	if err := writer.WriteBatch([]*LogEntry{{Content: "testing"}, {Content: "done"}}); err != nil {
+		t.Fatalf("WriteBatch() error = %v", err)
+	}
+
+	if err := writer.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+
+	expected := []string{"job-a", "job-b", "job-b"}
+	entries := readAllParquetEntries(t, filename)
+	if len(entries) != len(expected) {
+		t.Fatalf("Expected %d entries, got %d", len(expected), len(entries))
+	}
+	for i, entry := range entries {
+		if entry.JobID != expected[i] {
+			t.Errorf("Entry %d: JobID = %q, want %q", i, entry.JobID, expected[i])
+		}
+	}
+}
+
+func TestExportToParquetWriter(t *testing.T) {
+	entries := []*LogEntry{
+		{Timestamp: time.Unix(0, 1745322209921*int64(time.Millisecond)), Content: "~~~ Running tests", Group: "~~~ Running tests"},
+		{Content: "ok"},
+	}
+
+	var buf bytes.Buffer
+	if err := ExportToParquetWriter(entries, &buf, ParquetOptions{JobID: "job-1"}); err != nil {
+		t.Fatalf("ExportToParquetWriter() error = %v", err)
+	}
+
+	got, err := NewParquetReaderFromReaderAt(bytes.NewReader(buf.Bytes()), int64(buf.Len())).ReadEntries()
+	if err != nil {
+		t.Fatalf("ReadEntries() error = %v", err)
+	}
+	if len(got) != len(entries) {
+		t.Fatalf("Expected %d entries, got %d", len(entries), len(got))
+	}
+	if got[0].Group != "~~~ Running tests" || got[1].Content != "ok" || got[1].JobID != "job-1" {
+		t.Errorf("Unexpected entries: %+v", got)
+	}
+}
+
+// closeRecorder is a writer that records whether it was closed
+type closeRecorder struct {
+	bytes.Buffer
+	closed bool
+}
+
+func (c *closeRecorder) Close() error {
+	c.closed = true
+	return nil
+}
+
+func TestParquetWritersLeaveWriterOpen(t *testing.T) {
+	entries := []*LogEntry{{Content: "building"}, {Content: "done"}}
+
+	tests := []struct {
+		name  string
+		write func(w io.Writer) error
+	}{
+		{name: "ExportToParquetWriter", write: func(w io.Writer) error {
+			return ExportToParquetWriter(entries, w, ParquetOptions{})
+		}},
+		{name: "ExportSeq2ToParquetWriter", write: func(w io.Writer) error {
+			return ExportSeq2ToParquetWriter(sliceSeq(entries), w, ParquetOptions{})
+		}},
+		{name: "NewParquetWriter", write: func(w io.Writer) error {
+			writer := NewParquetWriter(w)
+			if err := writer.WriteBatch(entries); err != nil {
+				return err
+			}
+			return writer.Close()
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var w closeRecorder
+			if err := tt.write(&w); err != nil {
+				t.Fatalf("%s() error = %v", tt.name, err)
+			}
+			if w.closed {
+				t.Errorf("%s() closed the caller's writer", tt.name)
+			}
+
+			got, err := NewParquetReaderFromReaderAt(bytes.NewReader(w.Bytes()), int64(w.Len())).ReadEntries()
+			if err != nil {
+				t.Fatalf("ReadEntries() error = %v", err)
+			}
+			if len(got) != len(entries) {
+				t.Errorf("Expected %d entries, got %d", len(entries), len(got))
+			}
+		})
+	}
+}
+
+func TestWriteBatchSeq2(t *testing.T) {
+	lines := func(prefix string, n int) iter.Seq2[*LogEntry, error] {
+		return func(yield func(*LogEntry, error) bool) {
+			for i := range n {
+				if !yield(&LogEntry{Content: fmt.Sprintf("%s %d", prefix, i)}, nil) {
+					return
+				}
+			}
+		}
+	}
+
+	var buf bytes.Buffer
+	writer, err := NewParquetWriterWithOptions(&buf, ParquetOptions{RowGroupSize: 2})
+	if err != nil {
+		t.Fatalf("NewParquetWriterWithOptions() error = %v", err)
+	}
+
+	writer.SetJob("job-1", "build")
+	if err := writer.WriteBatchSeq2(lines("build", 3)); err != nil {
+		t.Fatalf("WriteBatchSeq2() error = %v", err)
+	}
+	writer.SetJob("job-2", "test")
+	if err := writer.WriteBatchSeq2(lines("test", 2)); err != nil {
+		t.Fatalf("WriteBatchSeq2() error = %v", err)
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+
+	reader := NewParquetReaderFromReaderAt(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	info, err := reader.GetFileInfo()
+	if err != nil {
+		t.Fatalf("GetFileInfo() error = %v", err)
+	}
+	// Batches of 2: build 0-1, build 2, test 0-1
+	if info.RowCount != 5 || info.NumRowGroups != 3 {
+		t.Errorf("Got %d rows in %d row groups, want 5 in 3", info.RowCount, info.NumRowGroups)
+	}
+
+	got, err := reader.ReadEntries()
+	if err != nil {
+		t.Fatalf("ReadEntries() error = %v", err)
+	}
+	if got[2].Content != "build 2" || got[2].JobID != "job-1" || got[3].Content != "test 0" || got[3].JobID != "job-2" {
+		t.Errorf("Unexpected entries: %+v", got)
+	}
+}
+
+func TestExportSeq2ToParquetWriter(t *testing.T) {
+	seq := func(yield func(*LogEntry, error) bool) {
+		for i := range 3 {
+			if !yield(&LogEntry{Content: fmt.Sprintf("line %d", i)}, nil) {
+				return
+			}
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := ExportSeq2ToParquetWriter(seq, &buf, ParquetOptions{}); err != nil {
+		t.Fatalf("ExportSeq2ToParquetWriter() error = %v", err)
+	}
+
+	got, err := NewParquetReaderFromReaderAt(bytes.NewReader(buf.Bytes()), int64(buf.Len())).ReadEntries()
+	if err != nil {
+		t.Fatalf("ReadEntries() error = %v", err)
+	}
+	if len(got) != 3 || got[2].Content != "line 2" {
+		t.Errorf("Unexpected entries: %+v", got)
+	}
+}
+
+func TestExportCollapseProgress(t *testing.T) {
+	entries := []*LogEntry{
+		{Content: "Receiving objects:  10% (1/10)\x1b[K"},
+		{Content: "Receiving objects:  50% (5/10)\x1b[K"},
+		{Content: "Receiving objects: 100% (10/10), done.\x1b[K"},
+		{Content: "HEAD is now at abc123"},
+	}
+
+	for _, collapse := range []bool{false, true} {
+		var buf bytes.Buffer
+		if err := ExportToParquetWriter(entries, &buf, ParquetOptions{CollapseProgress: collapse}); err != nil {
+			t.Fatalf("ExportToParquetWriter() error = %v", err)
+		}
+
+		got, err := NewParquetReaderFromReaderAt(bytes.NewReader(buf.Bytes()), int64(buf.Len())).ReadEntries()
+		if err != nil {
+			t.Fatalf("ReadEntries() error = %v", err)
+		}
+
+		want := len(entries)
+		if collapse {
+			want = 2
+		}
+		if len(got) != want {
+			t.Errorf("CollapseProgress = %v: got %d rows, want %d", collapse, len(got), want)
+		}
+	}
+}
+
+func TestExportSkipBlankLines(t *testing.T) {
+	entries := []*LogEntry{
+		{Content: "~~~ Build", Timestamp: time.UnixMilli(1)},
+		{Content: "", Timestamp: time.UnixMilli(2)},
+		{Content: "  "},
+		{Content: "done"},
+	}
+
+	tests := []struct {
+		opts ParquetOptions
+		want int
+	}{
+		{ParquetOptions{}, 4},
+		{ParquetOptions{SkipBlankLines: true}, 2},
+		{ParquetOptions{SkipBlankLines: true, KeepTimestampedBlankLines: true}, 3},
+	}
+
+	for _, tt := range tests {
+		var buf bytes.Buffer
+		if err := ExportToParquetWriter(entries, &buf, tt.opts); err != nil {
+			t.Fatalf("ExportToParquetWriter() error = %v", err)
+		}
+
+		got, err := NewParquetReaderFromReaderAt(bytes.NewReader(buf.Bytes()), int64(buf.Len())).ReadEntries()
+		if err != nil {
+			t.Fatalf("ReadEntries() error = %v", err)
+		}
+		if len(got) != tt.want {
+			t.Errorf("%+v: got %d rows, want %d", tt.opts, len(got), tt.want)
+		}
+	}
+}
+
+func TestParquetGroupIndexRoundTrip(t *testing.T) {
+	input := "before any group\n" +
+		"\x1b_bk;t=1\x07~~~ Setup\n" +
+		"\x1b_bk;t=2\x07--- Build\n" +
+		"\x1b_bk;t=3\x07~~~ Setup\n"
+
+	var buf bytes.Buffer
+	if err := ExportSeq2ToParquetWriter(NewParser().All(strings.NewReader(input)), &buf, ParquetOptions{}); err != nil {
+		t.Fatalf("ExportSeq2ToParquetWriter() error = %v", err)
+	}
+
+	reader := NewParquetReaderFromReaderAt(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	entries, err := reader.ReadEntries()
+	if err != nil {
+		t.Fatalf("ReadEntries() error = %v", err)
+	}
+
+	want := []int32{-1, 0, 1, 2}
+	for i, entry := range entries {
+		if entry.GroupIndex != want[i] {
+			t.Errorf("Entry %d: GroupIndex = %d, want %d", i, entry.GroupIndex, want[i])
+		}
+	}
+
+	// A repeated group name keeps the ordinal of its first header
+	groups, err := reader.GroupStats()
+	if err != nil {
+		t.Fatalf("GroupStats() error = %v", err)
+	}
+	indices := make(map[string]int32)
+	for _, group := range groups {
+		indices[group.Name] = group.Index
+	}
+	if indices["<no group>"] != -1 || indices["~~~ Setup"] != 0 || indices["--- Build"] != 1 {
+		t.Errorf("Unexpected group indices: %v", indices)
+	}
+
+	// Files written before the column existed report -1
+	old, err := ReadParquetFile("testdata/bash-example.parquet")
+	if err != nil {
+		t.Fatalf("ReadParquetFile() error = %v", err)
+	}
+	if old[0].GroupIndex != -1 {
+		t.Errorf("GroupIndex = %d for a file without the column, want -1", old[0].GroupIndex)
+	}
+}
+
+func TestParquetParentGroupRoundTrip(t *testing.T) {
+	input := "\x1b_bk;t=1\x07~~~ Running script\n" +
+		"\x1b_bk;t=2\x07--- Build\n" +
+		"\x1b_bk;t=3\x07building\n"
+
+	parser := NewParserWithOptions(ParserOptions{TrackParentGroups: true})
+
+	var buf bytes.Buffer
+	if err := ExportSeq2ToParquetWriter(parser.All(strings.NewReader(input)), &buf, ParquetOptions{}); err != nil {
+		t.Fatalf("ExportSeq2ToParquetWriter() error = %v", err)
+	}
+
+	reader := NewParquetReaderFromReaderAt(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	entries, err := reader.ReadEntries()
+	if err != nil {
+		t.Fatalf("ReadEntries() error = %v", err)
+	}
+	for i, entry := range entries {
+		if entry.ParentGroup != "~~~ Running script" {
+			t.Errorf("Entry %d: ParentGroup = %q, want %q", i, entry.ParentGroup, "~~~ Running script")
+		}
+	}
+
+	groups, err := reader.GroupStats()
+	if err != nil {
+		t.Fatalf("GroupStats() error = %v", err)
+	}
+	parents := make(map[string]string)
+	for _, group := range groups {
+		parents[group.Name] = group.Parent
+	}
+	if parents["~~~ Running script"] != "" || parents["--- Build"] != "~~~ Running script" {
+		t.Errorf("Unexpected group parents: %q", parents)
+	}
+}
+
+func TestParquetRepeatCountRoundTrip(t *testing.T) {
+	input := "\x1b_bk;t=1\x07retrying\n" +
+		"\x1b_bk;t=2\x07retrying\n" +
+		"\x1b_bk;t=3\x07retrying\n" +
+		"\x1b_bk;t=4\x07done\n"
+
+	parser := NewParserWithOptions(ParserOptions{DedupeConsecutive: true})
+
+	var buf bytes.Buffer
+	if err := ExportSeq2ToParquetWriter(parser.All(strings.NewReader(input)), &buf, ParquetOptions{}); err != nil {
+		t.Fatalf("ExportSeq2ToParquetWriter() error = %v", err)
+	}
+
+	reader := NewParquetReaderFromReaderAt(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	entries, err := reader.ReadEntries()
+	if err != nil {
+		t.Fatalf("ReadEntries() error = %v", err)
+	}
+
+	if len(entries) != 2 {
+		t.Fatalf("Expected 2 rows, got %d", len(entries))
+	}
+	if entries[0].RepeatCount != 2 || entries[1].RepeatCount != 0 {
+		t.Errorf("RepeatCount = %d, %d, want 2, 0", entries[0].RepeatCount, entries[1].RepeatCount)
+	}
+}
+
+func TestExportSeq2OnError(t *testing.T) {
+	input := "\x1b_bk;t=1\x07first\n\x1b_bk;t=bad\x07malformed\n\x1b_bk;t=3\x07last\n"
+
+	var buf bytes.Buffer
+	if err := ExportSeq2ToParquetWriter(NewParser().All(strings.NewReader(input)), &buf, ParquetOptions{}); err == nil {
+		t.Fatal("Expected the malformed line to fail the export without OnError")
+	}
+
+	var skipped []error
+	buf.Reset()
+	err := ExportSeq2ToParquetWriter(NewParser().All(strings.NewReader(input)), &buf, ParquetOptions{
+		OnError: func(err error) bool {
+			skipped = append(skipped, err)
+			return true
+		},
+	})
+	if err != nil {
+		t.Fatalf("ExportSeq2ToParquetWriter() error = %v", err)
+	}
+	if len(skipped) != 1 {
+		t.Errorf("Expected 1 skipped error, got %v", skipped)
+	}
+
+	entries, err := NewParquetReaderFromReaderAt(bytes.NewReader(buf.Bytes()), int64(buf.Len())).ReadEntries()
+	if err != nil {
+		t.Fatalf("ReadEntries() error = %v", err)
+	}
+	if len(entries) != 2 || entries[0].Content != "first" || entries[1].Content != "last" || entries[1].LineNumber != 3 {
+		t.Errorf("Unexpected entries: %+v", entries)
+	}
+
+	// Returning false stops the export with the error
+	err = ExportSeq2ToParquetWriter(NewParser().All(strings.NewReader(input)), io.Discard, ParquetOptions{
+		OnError: func(error) bool { return false },
+	})
+	if err == nil {
+		t.Error("Expected an error when OnError returns false")
+	}
+}
+
+func TestExportSeq2OnProgress(t *testing.T) {
+	var input strings.Builder
+	for i := range 120 {
+		fmt.Fprintf(&input, "\x1b_bk;t=%d\x07line %d\n", 1745322209921+i, i)
+	}
+
+	var progress []int
+	err := ExportSeq2ToParquetWriter(NewParser().All(strings.NewReader(input.String())), io.Discard, ParquetOptions{
+		RowGroupSize: 50,
+		OnProgress: func(entriesWritten int) {
+			progress = append(progress, entriesWritten)
+		},
+	})
+	if err != nil {
+		t.Fatalf("ExportSeq2ToParquetWriter() error = %v", err)
+	}
+
+	want := []int{50, 100, 120}
+	if !slices.Equal(progress, want) {
+		t.Errorf("OnProgress calls = %v, want %v", progress, want)
+	}
+}
+
+func TestWithLogicalTimestampsReleases(t *testing.T) {
+	pool := memory.NewCheckedAllocator(memory.NewGoAllocator())
+	defer pool.AssertSize(t, 0)
+
+	builder := array.NewRecordBuilder(pool, createArrowSchema())
+	defer builder.Release()
+	builder.Field(0).(*array.Int64Builder).Append(1745322209921)
+	for i := 1; i < builder.Schema().NumFields(); i++ {
+		builder.Field(i).AppendEmptyValue()
+	}
+	record := builder.NewRecord()
+
+	retyped := withLogicalTimestamps(record)
+	record.Release()
+
+	timestamps := retyped.Column(0).(*array.Timestamp)
+	if timestamps.Len() != 1 || timestamps.Value(0) != 1745322209921 {
+		t.Errorf("withLogicalTimestamps() timestamps = %v", timestamps)
+	}
+	retyped.Release()
+}
+
+func TestParquetLogicalTimestamps(t *testing.T) {
+	input := "\x1b_bk;t=1745322209921\x07~~~ Running tests\n\x1b_bk;t=1745322210921\x07$ make test\n"
+
+	var buf bytes.Buffer
+	err := ExportSeq2ToParquetWriter(NewParser().All(strings.NewReader(input)), &buf, ParquetOptions{LogicalTimestamps: true})
+	if err != nil {
+		t.Fatalf("ExportSeq2ToParquetWriter() error = %v", err)
+	}
+
+	reader := NewParquetReaderFromReaderAt(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+
+	schema, err := reader.Schema()
+	if err != nil {
+		t.Fatalf("Schema() error = %v", err)
+	}
+	if got := schema.Field(0).Type; got.ID() != arrow.TIMESTAMP {
+		t.Errorf("timestamp column type = %s, want a timestamp", got)
+	}
+	if err := reader.ValidateSchema(); err != nil {
+		t.Errorf("ValidateSchema() error = %v", err)
+	}
+	if version, err := reader.SchemaVersion(); err != nil || version != schemaVersion {
+		t.Errorf("SchemaVersion() = %d, %v, want %d", version, err, schemaVersion)
+	}
+
+	entries, err := reader.ReadEntries()
+	if err != nil {
+		t.Fatalf("ReadEntries() error = %v", err)
+	}
+	if len(entries) != 2 || entries[0].Timestamp != 1745322209921 || entries[1].Timestamp != 1745322210921 {
+		t.Errorf("Unexpected entries: %+v", entries)
+	}
+
+	// Row group statistics are still usable for time range filtering
+	var matched []ParquetLogEntry
+	for entry, err := range reader.FilterByTimeRangeIter(time.UnixMilli(1745322210000), time.UnixMilli(1745322211000)) {
+		if err != nil {
+			t.Fatalf("FilterByTimeRangeIter() error = %v", err)
+		}
+		matched = append(matched, entry)
+	}
+	if len(matched) != 1 || matched[0].Content != "$ make test" {
+		t.Errorf("Unexpected time range matches: %+v", matched)
+	}
+}
+
+func TestParquetMaxContentBytes(t *testing.T) {
+	long := "\x1b_bk;t=1745322209921\x07" + strings.Repeat("QUJD", 100) + "\n"
+	short := "\x1b_bk;t=1745322209922\x07$ make test\n"
+
+	var buf bytes.Buffer
+	err := ExportSeq2ToParquetWriter(NewParser().All(strings.NewReader(long+short)), &buf, ParquetOptions{MaxContentBytes: 16})
+	if err != nil {
+		t.Fatalf("ExportSeq2ToParquetWriter() error = %v", err)
+	}
+
+	entries, err := NewParquetReaderFromReaderAt(bytes.NewReader(buf.Bytes()), int64(buf.Len())).ReadEntries()
+	if err != nil {
+		t.Fatalf("ReadEntries() error = %v", err)
+	}
+	if len(entries) != 2 {
+		t.Fatalf("Expected 2 entries, got %d", len(entries))
+	}
+
+	if want := "QUJDQUJDQUJDQUJD" + TruncatedMarker; entries[0].Content != want {
+		t.Errorf("Long content = %q, want %q", entries[0].Content, want)
+	}
+	if entries[0].RawLineSize != int32(len(long)-1) {
+		t.Errorf("Long RawLineSize = %d, want the original %d", entries[0].RawLineSize, len(long)-1)
+	}
+
+	if entries[1].Content != "$ make test" || !entries[1].IsCommand {
+		t.Errorf("Short entry changed: %+v", entries[1])
+	}
+}
+
+func TestTruncateContent(t *testing.T) {
+	tests := []struct {
+		content  string
+		maxBytes int
+		want     string
+	}{
+		{"hello", 0, "hello"},
+		{"hello", 5, "hello"},
+		{"hello world", 5, "hello" + TruncatedMarker},
+		{"héllo", 2, "h" + TruncatedMarker}, // é is two bytes and isn't split
+	}
+
+	for _, tt := range tests {
+		if got := truncateContent(tt.content, tt.maxBytes); got != tt.want {
+			t.Errorf("truncateContent(%q, %d) = %q, want %q", tt.content, tt.maxBytes, got, tt.want)
+		}
+	}
+}
+
+func TestExportStripANSIOnWrite(t *testing.T) {
+	input := "\x1b_bk;t=1745322209921\x07\x1b[90m$\x1b[0m make test\n" +
+		"\x1b_bk;t=1745322209922\x07plain [0] output\n"
+
+	tests := []struct {
+		name        string
+		opts        ParquetOptions
+		wantContent []string
+		wantRaw     []string
+	}{
+		{
+			name:        "raw by default",
+			opts:        ParquetOptions{},
+			wantContent: []string{"\x1b[90m$\x1b[0m make test", "plain [0] output"},
+			wantRaw:     []string{"", ""},
+		},
+		{
+			name:        "stripped",
+			opts:        ParquetOptions{StripANSIOnWrite: true},
+			wantContent: []string{"$ make test", "plain [0] output"},
+			wantRaw:     []string{"", ""},
+		},
+		{
+			name:        "stripped keeping raw content",
+			opts:        ParquetOptions{StripANSIOnWrite: true, KeepRawContent: true},
+			wantContent: []string{"$ make test", "plain [0] output"},
+			wantRaw:     []string{"\x1b[90m$\x1b[0m make test", "plain [0] output"},
+		},
+		{
+			name:        "logical timestamps keeping raw content",
+			opts:        ParquetOptions{StripANSIOnWrite: true, KeepRawContent: true, LogicalTimestamps: true},
+			wantContent: []string{"$ make test", "plain [0] output"},
+			wantRaw:     []string{"\x1b[90m$\x1b[0m make test", "plain [0] output"},
+		},
+		{
+			name:        "raw content needs stripping",
+			opts:        ParquetOptions{KeepRawContent: true},
+			wantContent: []string{"\x1b[90m$\x1b[0m make test", "plain [0] output"},
+			wantRaw:     []string{"", ""},
+		},
+		{
+			name:        "both truncated",
+			opts:        ParquetOptions{StripANSIOnWrite: true, KeepRawContent: true, MaxContentBytes: 6},
+			wantContent: []string{"$ make" + TruncatedMarker, "plain " + TruncatedMarker},
+			wantRaw:     []string{"\x1b[90m$" + TruncatedMarker, "plain " + TruncatedMarker},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := ExportSeq2ToParquetWriter(NewParser().All(strings.NewReader(input)), &buf, tt.opts); err != nil {
+				t.Fatalf("ExportSeq2ToParquetWriter() error = %v", err)
+			}
+
+			reader := NewParquetReaderFromReaderAt(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+			if err := reader.ValidateSchema(); err != nil {
+				t.Errorf("ValidateSchema() error = %v", err)
+			}
+			if version, err := reader.SchemaVersion(); err != nil || version != schemaVersion {
+				t.Errorf("SchemaVersion() = %d, %v, want %d", version, err, schemaVersion)
+			}
+
+			entries, err := reader.ReadEntries()
+			if err != nil {
+				t.Fatalf("ReadEntries() error = %v", err)
+			}
+			if len(entries) != len(tt.wantContent) {
+				t.Fatalf("Expected %d entries, got %d", len(tt.wantContent), len(entries))
+			}
+			for i, entry := range entries {
+				if entry.Content != tt.wantContent[i] {
+					t.Errorf("Entry %d: Content = %q, want %q", i, entry.Content, tt.wantContent[i])
+				}
+				if entry.RawContent != tt.wantRaw[i] {
+					t.Errorf("Entry %d: RawContent = %q, want %q", i, entry.RawContent, tt.wantRaw[i])
+				}
+			}
+
+			// Classification uses the original content whichever is stored
+			if !entries[0].IsCommand {
+				t.Errorf("Entry 0 should be a command")
+			}
+		})
+	}
+}
+
+func TestParquetNullBytesRoundTrip(t *testing.T) {
+	// Arrow strings are length-prefixed and not validated, so NUL bytes and invalid UTF-8 are stored as-is
+	contents := []string{
+		"~~~ Build\x00step",
+		"before\x00after",
+		"\x00leading",
+		"trailing\x00",
+		"\x00\x00\x00",
+		"invalid \xff\xfe utf-8",
+	}
+
+	var input strings.Builder
+	for i, content := range contents {
+		fmt.Fprintf(&input, "\x1b_bk;t=%d\x07%s\n", 1745322209921+i, content)
+	}
+
+	var buf bytes.Buffer
+	if err := ExportSeq2ToParquetWriter(NewParser().All(strings.NewReader(input.String())), &buf, ParquetOptions{}); err != nil {
+		t.Fatalf("ExportSeq2ToParquetWriter() error = %v", err)
+	}
+
+	entries, err := NewParquetReaderFromReaderAt(bytes.NewReader(buf.Bytes()), int64(buf.Len())).ReadEntries()
+	if err != nil {
+		t.Fatalf("ReadEntries() error = %v", err)
+	}
+	if len(entries) != len(contents) {
+		t.Fatalf("Expected %d entries, got %d", len(contents), len(entries))
+	}
+	for i, entry := range entries {
+		if entry.Content != contents[i] {
+			t.Errorf("Entry %d: Content = %q, want %q", i, entry.Content, contents[i])
+		}
+		if entry.Group != contents[0] {
+			t.Errorf("Entry %d: Group = %q, want %q", i, entry.Group, contents[0])
+		}
+	}
+}
+
+func TestParquetUntimestampedEntriesKeepDocumentOrder(t *testing.T) {
+	// An untimestamped preamble, then timestamped lines with an untimestamped line between them
+	input := "preamble one\n" +
+		"preamble two\n" +
+		"\x1b_bk;t=1745322209921\x07~~~ Running tests\n" +
+		"no timestamp in the middle\n" +
+		"\x1b_bk;t=1745322209922\x07$ make test\n" +
+		"\x1b_bk;t=1745322209923\x07ok\n"
+
+	var buf bytes.Buffer
+	err := ExportSeq2ToParquetWriter(NewParser().All(strings.NewReader(input)), &buf, ParquetOptions{RowGroupSize: 2})
+	if err != nil {
+		t.Fatalf("ExportSeq2ToParquetWriter() error = %v", err)
+	}
+
+	// Rows aren't sorted, so no sort order is claimed for engines to rely on
+	pf, err := file.NewParquetReader(bytes.NewReader(buf.Bytes()))
+	if err != nil {
+		t.Fatalf("NewParquetReader() error = %v", err)
+	}
+	defer func() { _ = pf.Close() }()
+	for i := 0; i < pf.NumRowGroups(); i++ {
+		if sorting := pf.MetaData().RowGroup(i).SortingColumns(); len(sorting) != 0 {
+			t.Errorf("Row group %d declares sorting columns %v", i, sorting)
+		}
+	}
+
+	reader := NewParquetReaderFromReaderAt(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	entries, err := reader.ReadEntries()
+	if err != nil {
+		t.Fatalf("ReadEntries() error = %v", err)
+	}
+	if len(entries) != 6 {
+		t.Fatalf("Expected 6 entries, got %d", len(entries))
+	}
+	for i, entry := range entries {
+		if entry.LineNumber != int64(i+1) {
+			t.Errorf("Row %d: LineNumber = %d, want rows in document order", i, entry.LineNumber)
+		}
+	}
+	if entries[0].HasTime || entries[3].HasTime || !entries[2].HasTime {
+		t.Errorf("Unexpected has_timestamp values: %+v", entries)
+	}
+
+	// Seeking counts rows in document order too, so the preamble doesn't shift positions
+	var seeked []string
+	for entry, err := range reader.SeekToRow(3) {
+		if err != nil {
+			t.Fatalf("SeekToRow() error = %v", err)
+		}
+		seeked = append(seeked, entry.Content)
+	}
+	if want := []string{"no timestamp in the middle", "$ make test", "ok"}; !slices.Equal(seeked, want) {
+		t.Errorf("SeekToRow(3) = %q, want %q", seeked, want)
+	}
+}
+
+func TestExportToParquetKeepsArrivalOrder(t *testing.T) {
+	// Clock skew between agents can put timestamps out of order, the slice export mustn't reorder them
+	base := time.UnixMilli(1745322209921)
+	entries := []*LogEntry{
+		{Timestamp: base.Add(2 * time.Second), Content: "second", LineNumber: 1},
+		{Timestamp: base, Content: "first", LineNumber: 2},
+		{Content: "untimestamped", LineNumber: 3},
+		{Timestamp: base.Add(time.Second), Content: "middle", LineNumber: 4},
+	}
+
+	var buf bytes.Buffer
+	if err := ExportToParquetWriter(entries, &buf, ParquetOptions{}); err != nil {
+		t.Fatalf("ExportToParquetWriter() error = %v", err)
+	}
+
+	pf, err := file.NewParquetReader(bytes.NewReader(buf.Bytes()))
+	if err != nil {
+		t.Fatalf("NewParquetReader() error = %v", err)
+	}
+	defer func() { _ = pf.Close() }()
+	for i := 0; i < pf.NumRowGroups(); i++ {
+		if sorting := pf.MetaData().RowGroup(i).SortingColumns(); len(sorting) != 0 {
+			t.Errorf("Row group %d declares sorting columns %v", i, sorting)
+		}
+	}
+
+	rows, err := NewParquetReaderFromReaderAt(bytes.NewReader(buf.Bytes()), int64(buf.Len())).ReadEntries()
+	if err != nil {
+		t.Fatalf("ReadEntries() error = %v", err)
+	}
+	if len(rows) != len(entries) {
+		t.Fatalf("Expected %d rows, got %d", len(entries), len(rows))
+	}
+	for i, row := range rows {
+		if row.Content != entries[i].Content {
+			t.Errorf("Row %d: Content = %q, want %q in arrival order", i, row.Content, entries[i].Content)
+		}
+	}
+}
@@ -2,6 +2,8 @@ package buildkitelogs
 
 import (
 	"bufio"
+	"encoding/json"
+	"fmt"
 	"io"
 	"iter"
 	"strings"
@@ -14,12 +16,182 @@ type LogEntry struct {
 	Content   string // Parsed content after OSC processing, may still contain ANSI codes
 	RawLine   []byte // Original line bytes including all OSC sequences and formatting
 	Group     string // The current section/group this entry belongs to
+
+	LineNumber int64 // 1-based line number in the source, set by All and LogIterator (0 if unknown)
+
+	// ParentGroup is the most recent "~~~" group header when ParserOptions.TrackParentGroups is set,
+	// so "---" and "+++" sub-groups can be related to the phase they run in. Entries of the "~~~"
+	// group itself have it as their parent. Empty when tracking is off or before the first "~~~".
+	ParentGroup string
+
+	// GroupIndex is the 0-based ordinal of the group header this entry follows, counting every
+	// header in the log, or -1 for lines before the first group. Set by Parser.
+	GroupIndex int
+
+	// SyntheticTimestamp is true when Timestamp was generated by ParserOptions.SynthesizeTimestamps
+	// rather than read from the log, HasTimestamp returns false for these entries
+	SyntheticTimestamp bool
+
+	// TimestampSuspect is true when the line's OSC timestamp fell outside the plausible range set by
+	// ParserOptions.ValidateTimestamps, in which case the timestamp is dropped, leaving the entry without
+	// one. It is also set when the timestamp had an unknown unit hint and was read as milliseconds.
+	TimestampSuspect bool
+
+	// RepeatCount is how many identical lines directly after this one were collapsed into it by
+	// ParserOptions.DedupeConsecutive, zero for a line that wasn't repeated
+	RepeatCount int
+
+	rawLineSize int        // Size of the original line, kept when RawLine is dropped
+	flags       EntryFlags // Types set by the parser's Classifier, valid when classified is set
+	classified  bool       // False for entries not created by a Parser, which use DefaultClassifier
+}
+
+// SeverityPatterns controls how IsError and IsWarning classify log entries.
+// Text patterns must start the ANSI-stripped content, ignoring leading whitespace,
+// color patterns are matched as substrings of the raw content (before stripping).
+type SeverityPatterns struct {
+	Error         []string
+	Warning       []string
+	ErrorColors   []string
+	WarningColors []string
+}
+
+// DefaultSeverityPatterns returns the built-in error and warning heuristics
+//
+// Errors: lines starting with 🚨, "Error:", "error:" or "FAILED", and red ANSI colors (31, 91)
+// Warnings: lines starting with ⚠️ or "WARNING", and yellow ANSI colors (33, 93)
+func DefaultSeverityPatterns() *SeverityPatterns {
+	return &SeverityPatterns{
+		Error:         []string{"🚨", "Error:", "error:", "FAILED"},
+		Warning:       []string{"⚠️", "WARNING"},
+		ErrorColors:   []string{"[31m", "[91m", "[1;31m", "[0;31m", "[1;91m"},
+		WarningColors: []string{"[33m", "[93m", "[1;33m", "[0;33m", "[1;93m"},
+	}
+}
+
+// defaultSeverityPatterns is shared by entries parsed without custom patterns
+var defaultSeverityPatterns = DefaultSeverityPatterns()
+
+// DefaultMaxLineBytes is the longest line the parser accepts when ParserOptions.MaxLineBytes is unset
+const DefaultMaxLineBytes = 1024 * 1024
+
+// DefaultMinTimestamp and DefaultMaxTimestamp bound the plausible OSC timestamps when
+// ParserOptions.ValidateTimestamps is set without explicit bounds
+var (
+	DefaultMinTimestamp = time.Date(2000, time.January, 1, 0, 0, 0, 0, time.UTC)
+	DefaultMaxTimestamp = time.Date(2100, time.January, 1, 0, 0, 0, 0, time.UTC)
+)
+
+// ParserOptions configures optional Parser behaviour
+type ParserOptions struct {
+	// Severity overrides the patterns used by IsError/IsWarning, nil uses DefaultSeverityPatterns
+	Severity *SeverityPatterns
+
+	// Classifier decides whether each entry is a command, group header, progress update, error or
+	// warning, which the Is* methods and the Parquet boolean columns report. Nil uses DefaultClassifier
+	// with the Severity patterns, a custom Classifier ignores Severity.
+	Classifier Classifier
+
+	// MaxLineBytes is the longest line that can be scanned, longer lines fail with bufio.ErrTooLong
+	// Zero uses DefaultMaxLineBytes
+	MaxLineBytes int
+
+	// TrackParentGroups sets LogEntry.ParentGroup to the most recent "~~~" group header, modelling
+	// "---" and "+++" groups as children of the "~~~" phase they appear in
+	TrackParentGroups bool
+
+	// SynthesizeTimestamps gives lines without an OSC timestamp a synthetic one, so logs captured
+	// without Buildkite's markers still sort and filter by time in their original order. Each synthetic
+	// timestamp is the previous timestamp (real or synthetic) plus SyntheticTimestampStep, starting at
+	// SyntheticTimestampBase. They are not real times and are marked with LogEntry.SyntheticTimestamp.
+	SynthesizeTimestamps bool
+
+	// SyntheticTimestampBase is the first synthetic timestamp, zero uses the Unix epoch
+	SyntheticTimestampBase time.Time
+
+	// SyntheticTimestampStep is the increment between synthetic timestamps, zero uses one millisecond
+	SyntheticTimestampStep time.Duration
+
+	// ValidateTimestamps treats OSC and JSON timestamps outside [MinTimestamp, MaxTimestamp] as corrupt, so a
+	// single bad line can't break the time ordering of an export. Such entries have no timestamp and
+	// are marked with LogEntry.TimestampSuspect, their content is kept.
+	ValidateTimestamps bool
+
+	// MinTimestamp is the earliest plausible timestamp, zero uses DefaultMinTimestamp
+	MinTimestamp time.Time
+
+	// MaxTimestamp is the latest plausible timestamp, zero uses DefaultMaxTimestamp
+	MaxTimestamp time.Time
+
+	// DropRawLine leaves LogEntry.RawLine nil instead of keeping a copy of every line, roughly halving
+	// the memory held per entry for large streams. RawLineSize still reports the original size, so the
+	// raw_line_size column is unaffected.
+	DropRawLine bool
+
+	// ReuseEntries makes All and NewIterator yield the same *LogEntry for every line, overwriting it
+	// with the next line instead of allocating a new entry. The entry is only valid until the next
+	// iteration, so it suits consumers that handle each entry before moving on, such as counting or
+	// printing. Entries must not be retained, so these iterators can't be collected into a slice,
+	// passed to CollapseProgress or exported to Parquet (the exports report an error if they are).
+	ReuseEntries bool
+
+	// DetectPercentProgress flags a line as a progress update when it repeats the previous line in the
+	// same group with only its trailing percentage changed, such as "Downloading... 10%" followed by
+	// "Downloading... 20%", for tools that print progress without erase-in-line sequences. The first
+	// line of a run has nothing to compare with, so only the following lines are flagged.
+	DetectPercentProgress bool
+
+	// DedupeConsecutive collapses runs of lines with identical content in the same group into their
+	// first entry, counting the dropped lines in LogEntry.RepeatCount. Runs never span a group header.
+	// Entries are held while looking for repeats, so ReuseEntries has no effect when this is set.
+	DedupeConsecutive bool
+}
+
+// CommandBlock represents a `$ command` and the output it produced
+type CommandBlock struct {
+	Command string      // Command text with ANSI codes and the leading "$ " removed
+	Group   string      // Group the command was run in
+	Start   time.Time   // Timestamp of the command line, zero if it had none
+	End     time.Time   // Timestamp of the next command or group boundary, or of the last entry
+	Entries []*LogEntry // Output entries between the command and the next boundary
+}
+
+// Duration returns how long the command ran, or zero if either bound is unknown
+func (b CommandBlock) Duration() time.Duration {
+	if b.Start.IsZero() || b.End.IsZero() || b.End.Before(b.Start) {
+		return 0
+	}
+	return b.End.Sub(b.Start)
 }
 
 // Parser handles parsing of Buildkite log files
+// A Parser tracks the current group and timestamps of the log it is parsing, so it is not safe for
+// concurrent use. Use a separate Parser for each log, and each goroutine.
 type Parser struct {
 	byteParser   *ByteParser
 	currentGroup string
+	parentGroup  string
+	trackParents bool
+	groupIndex   int
+	classifier   Classifier
+	maxLineBytes int
+	dropRawLine  bool
+	reuseEntries bool
+	dedupe       bool
+
+	validateTimestamps bool
+	minTimestamp       time.Time
+	maxTimestamp       time.Time
+
+	detectPercent  bool
+	percentPrefix  string // Text before the trailing percentage of the previous line
+	percentValue   string // Trailing percentage of the previous line, empty if it had none
+	percentGroupIx int    // Group index of the previous line
+
+	synthesize    bool
+	syntheticBase time.Time
+	syntheticStep time.Duration
+	lastTimestamp time.Time
 }
 
 // LogIterator provides an iterator interface for processing log entries
@@ -27,51 +199,248 @@ type LogIterator struct {
 	scanner *bufio.Scanner
 	parser  *Parser
 	current *LogEntry
+	reused  *LogEntry // Entry overwritten by every line when ParserOptions.ReuseEntries is set
+	next    *LogEntry // Entry read past the end of a run of repeats, when ParserOptions.DedupeConsecutive is set
 	err     error
+	line    int64
+
+	// pull and stop are set instead of scanner and parser when adapting an iter.Seq2, see Seq2ToIterator
+	pull func() (*LogEntry, error, bool)
+	stop func()
 }
 
 // NewParser creates a new Buildkite log parser
 func NewParser() *Parser {
+	return NewParserWithOptions(ParserOptions{})
+}
+
+// NewParserWithOptions creates a new Buildkite log parser with the provided options
+func NewParserWithOptions(opts ParserOptions) *Parser {
+	classifier := opts.Classifier
+	if classifier == nil {
+		classifier = DefaultClassifier{Severity: opts.Severity}
+	}
+
+	maxLineBytes := opts.MaxLineBytes
+	if maxLineBytes <= 0 {
+		maxLineBytes = DefaultMaxLineBytes
+	}
+
+	syntheticBase := opts.SyntheticTimestampBase
+	if syntheticBase.IsZero() {
+		syntheticBase = time.Unix(0, 0)
+	}
+
+	syntheticStep := opts.SyntheticTimestampStep
+	if syntheticStep <= 0 {
+		syntheticStep = time.Millisecond
+	}
+
+	minTimestamp := opts.MinTimestamp
+	if minTimestamp.IsZero() {
+		minTimestamp = DefaultMinTimestamp
+	}
+
+	maxTimestamp := opts.MaxTimestamp
+	if maxTimestamp.IsZero() {
+		maxTimestamp = DefaultMaxTimestamp
+	}
+
 	return &Parser{
-		byteParser: NewByteParser(),
+		byteParser:    NewByteParser(),
+		groupIndex:    -1,
+		trackParents:  opts.TrackParentGroups,
+		classifier:    classifier,
+		maxLineBytes:  maxLineBytes,
+		dropRawLine:   opts.DropRawLine,
+		reuseEntries:  opts.ReuseEntries && !opts.DedupeConsecutive,
+		dedupe:        opts.DedupeConsecutive,
+		detectPercent: opts.DetectPercentProgress,
+
+		validateTimestamps: opts.ValidateTimestamps,
+		minTimestamp:       minTimestamp,
+		maxTimestamp:       maxTimestamp,
+
+		synthesize:    opts.SynthesizeTimestamps,
+		syntheticBase: syntheticBase,
+		syntheticStep: syntheticStep,
 	}
 }
 
 // ParseLine parses a single log line
 func (p *Parser) ParseLine(line string) (*LogEntry, error) {
-	entry, err := p.byteParser.ParseLine(line)
-	if err != nil {
+	entry := &LogEntry{}
+	if err := p.parseLineInto(entry, line); err != nil {
 		return nil, err
 	}
+	return entry, nil
+}
+
+// parseLineInto parses a single log line into entry, tracking groups and timestamps like ParseLine
+func (p *Parser) parseLineInto(entry *LogEntry, line string) error {
+	if err := p.byteParser.parseLineInto(entry, line, !p.dropRawLine); err != nil {
+		return err
+	}
+
+	p.classify(entry)
+	p.trackGroup(entry)
+	p.detectPercentProgress(entry)
+	p.validateTimestamp(entry)
+	p.synthesizeTimestamp(entry)
 
+	return nil
+}
+
+// nextEntry returns the entry to parse the next line into, reused is nil unless entries are reused
+func nextEntry(reused *LogEntry) *LogEntry {
+	if reused != nil {
+		return reused
+	}
+	return &LogEntry{}
+}
+
+// classify records the entry's types from the parser's classifier
+func (p *Parser) classify(entry *LogEntry) {
+	entry.flags = p.classifier.Classify(entry)
+	entry.classified = true
+}
+
+// trackGroup sets the group and group index of an entry, advancing them if the entry is a group header
+func (p *Parser) trackGroup(entry *LogEntry) {
 	// Update current group if this is a group header
 	if entry.IsGroup() {
 		p.currentGroup = entry.CleanContent()
+		p.groupIndex++
+
+		if p.trackParents && strings.HasPrefix(p.currentGroup, "~~~") {
+			p.parentGroup = p.currentGroup
+		}
 	}
 
 	// Set the group for this entry
 	entry.Group = p.currentGroup
+	entry.ParentGroup = p.parentGroup
+	entry.GroupIndex = p.groupIndex
+}
 
-	return entry, nil
+// detectPercentProgress flags an entry as progress if it changes only the trailing percentage of the
+// previous line in its group, if enabled
+func (p *Parser) detectPercentProgress(entry *LogEntry) {
+	if !p.detectPercent {
+		return
+	}
+
+	prefix, value, ok := splitTrailingPercent(entry.CleanContent())
+	if ok && p.percentValue != "" && entry.GroupIndex == p.percentGroupIx &&
+		prefix == p.percentPrefix && value != p.percentValue && !entry.IsGroup() {
+		entry.flags |= FlagProgress
+	}
+
+	p.percentPrefix, p.percentValue, p.percentGroupIx = prefix, value, entry.GroupIndex
+}
+
+// splitTrailingPercent splits "Downloading... 10%" into "Downloading..." and "10"
+// Carriage returns and spaces after the percentage are ignored, ok is false if there is no percentage.
+func splitTrailingPercent(content string) (prefix, value string, ok bool) {
+	content = strings.TrimRight(content, " \t\r")
+	if !strings.HasSuffix(content, "%") {
+		return "", "", false
+	}
+
+	end := len(content) - 1
+	start := end
+	for start > 0 && (content[start-1] >= '0' && content[start-1] <= '9' || content[start-1] == '.') {
+		start--
+	}
+	if start == end {
+		return "", "", false
+	}
+
+	return strings.TrimRight(content[:start], " "), content[start:end], true
+}
+
+// validateTimestamp drops an implausible timestamp, marking the entry as suspect, if enabled
+func (p *Parser) validateTimestamp(entry *LogEntry) {
+	if !p.validateTimestamps || entry.Timestamp.IsZero() {
+		return
+	}
+
+	if entry.Timestamp.Before(p.minTimestamp) || entry.Timestamp.After(p.maxTimestamp) {
+		entry.Timestamp = time.Time{}
+		entry.TimestampSuspect = true
+	}
+}
+
+// synthesizeTimestamp gives an entry without a timestamp the next synthetic one, if enabled
+func (p *Parser) synthesizeTimestamp(entry *LogEntry) {
+	if !p.synthesize {
+		return
+	}
+
+	if entry.Timestamp.IsZero() {
+		entry.Timestamp = p.syntheticBase
+		if !p.lastTimestamp.IsZero() {
+			entry.Timestamp = p.lastTimestamp.Add(p.syntheticStep)
+		}
+		entry.SyntheticTimestamp = true
+	}
+
+	p.lastTimestamp = entry.Timestamp
 }
 
 // NewIterator creates a new LogIterator for memory-efficient processing
 func (p *Parser) NewIterator(reader io.Reader) *LogIterator {
-	return &LogIterator{
-		scanner: bufio.NewScanner(reader),
+	iterator := &LogIterator{
+		scanner: newLineScanner(reader, p.maxLineBytes),
 		parser:  p,
 	}
+	if p.reuseEntries {
+		iterator.reused = &LogEntry{}
+	}
+	return iterator
+}
+
+// newLineScanner creates a scanner that splits log data into lines
+// Only \n terminates a record, a single \r directly before it (CRLF) is dropped,
+// any other \r is part of the content (e.g. carriage-return progress updates)
+// The buffer starts small and grows up to maxLineBytes as long lines are encountered
+func newLineScanner(reader io.Reader, maxLineBytes int) *bufio.Scanner {
+	scanner := bufio.NewScanner(reader)
+	scanner.Buffer(make([]byte, 0, min(bufio.MaxScanTokenSize, maxLineBytes)), maxLineBytes)
+	scanner.Split(bufio.ScanLines)
+	return scanner
 }
 
 // All returns an iterator over all log entries using Go 1.23+ iter.Seq2 pattern
-// Each iteration yields a *LogEntry and an error, following Go's idiomatic error handling
+// Each iteration yields a *LogEntry and an error, following Go's idiomatic error handling.
+// With ParserOptions.ReuseEntries the same entry is yielded for every line.
 func (p *Parser) All(reader io.Reader) iter.Seq2[*LogEntry, error] {
+	if p.dedupe {
+		return dedupeConsecutive(p.all(reader, false))
+	}
+	return p.all(reader, p.reuseEntries)
+}
+
+// all returns an iterator over all log entries, yielding a single overwritten entry when reuse is set
+func (p *Parser) all(reader io.Reader, reuse bool) iter.Seq2[*LogEntry, error] {
 	return func(yield func(*LogEntry, error) bool) {
-		scanner := bufio.NewScanner(reader)
+		scanner := newLineScanner(reader, p.maxLineBytes)
+		var lineNumber int64
+
+		var reused *LogEntry
+		if reuse {
+			reused = &LogEntry{}
+		}
 
 		for scanner.Scan() {
-			line := scanner.Text()
-			entry, err := p.ParseLine(line)
+			lineNumber++
+			entry := nextEntry(reused)
+			err := p.parseLineInto(entry, scanner.Text())
+			if err != nil {
+				entry = nil
+			} else {
+				entry.LineNumber = lineNumber
+			}
 
 			// Yield both the entry (which may be nil if err != nil) and the error
 			if !yield(entry, err) {
@@ -89,6 +458,304 @@ func (p *Parser) All(reader io.Reader) iter.Seq2[*LogEntry, error] {
 	}
 }
 
+// jsonLogLine is a pre-split log line in JSON input
+// Timestamp is either Unix milliseconds or an RFC 3339 string, and may be omitted.
+type jsonLogLine struct {
+	Timestamp json.RawMessage `json:"timestamp"`
+	Content   string          `json:"content"`
+}
+
+// ParseJSON returns an iterator over log entries from JSON objects with timestamp and content fields
+// The input is either a single JSON array of objects or newline-delimited JSON (NDJSON). Content is
+// classified and grouped the same way as lines passed to ParseLine, without needing OSC sequences.
+// An object with an invalid timestamp yields an error and parsing continues, malformed JSON ends
+// the iteration with an error.
+func (p *Parser) ParseJSON(reader io.Reader) iter.Seq2[*LogEntry, error] {
+	if p.dedupe {
+		return dedupeConsecutive(p.parseJSON(reader))
+	}
+	return p.parseJSON(reader)
+}
+
+// parseJSON returns an iterator over log entries from JSON input, see ParseJSON
+func (p *Parser) parseJSON(reader io.Reader) iter.Seq2[*LogEntry, error] {
+	return func(yield func(*LogEntry, error) bool) {
+		buffered := bufio.NewReader(reader)
+
+		isArray, err := startsWithArray(buffered)
+		if err != nil {
+			if err != io.EOF {
+				yield(nil, err)
+			}
+			return
+		}
+
+		decoder := json.NewDecoder(buffered)
+		if isArray {
+			// Consume the opening bracket
+			if _, err := decoder.Token(); err != nil {
+				yield(nil, err)
+				return
+			}
+		}
+
+		var lineNumber int64
+		for {
+			if isArray && !decoder.More() {
+				break
+			}
+
+			var line jsonLogLine
+			if err := decoder.Decode(&line); err != nil {
+				// The end of the input is only expected between NDJSON objects
+				if err != io.EOF || isArray {
+					yield(nil, fmt.Errorf("invalid JSON log line %d: %w", lineNumber+1, err))
+				}
+				return
+			}
+			lineNumber++
+
+			timestamp, err := parseJSONTimestamp(line.Timestamp)
+			if err != nil {
+				if !yield(nil, fmt.Errorf("invalid timestamp on JSON log line %d: %w", lineNumber, err)) {
+					return
+				}
+				continue
+			}
+
+			entry := &LogEntry{
+				Timestamp:   timestamp,
+				Content:     line.Content,
+				LineNumber:  lineNumber,
+				rawLineSize: len(line.Content),
+			}
+			if !p.dropRawLine {
+				entry.RawLine = []byte(line.Content)
+			}
+			p.classify(entry)
+			p.trackGroup(entry)
+			p.detectPercentProgress(entry)
+			p.validateTimestamp(entry)
+			p.synthesizeTimestamp(entry)
+
+			if !yield(entry, nil) {
+				return
+			}
+		}
+
+		// Consume the closing bracket so a truncated array is reported
+		if _, err := decoder.Token(); err != nil {
+			yield(nil, fmt.Errorf("invalid JSON log array: %w", err))
+		}
+	}
+}
+
+// startsWithArray reports whether the first non-whitespace byte of the input opens a JSON array
+func startsWithArray(reader *bufio.Reader) (bool, error) {
+	for {
+		b, err := reader.ReadByte()
+		if err != nil {
+			return false, err
+		}
+		switch b {
+		case ' ', '\t', '\r', '\n':
+			continue
+		}
+		return b == '[', reader.UnreadByte()
+	}
+}
+
+// parseJSONTimestamp parses Unix milliseconds or an RFC 3339 string, a missing or null timestamp is zero
+func parseJSONTimestamp(raw json.RawMessage) (time.Time, error) {
+	if len(raw) == 0 || string(raw) == "null" {
+		return time.Time{}, nil
+	}
+
+	var ms int64
+	if err := json.Unmarshal(raw, &ms); err == nil {
+		return time.Unix(0, ms*int64(time.Millisecond)), nil
+	}
+
+	var text string
+	if err := json.Unmarshal(raw, &text); err != nil {
+		return time.Time{}, fmt.Errorf("expected Unix milliseconds or RFC 3339 string, got %s", raw)
+	}
+
+	return time.Parse(time.RFC3339Nano, text)
+}
+
+// CommandBlocks returns an iterator over the command blocks in a log
+// A block starts at a command entry and ends at the next command or group header,
+// the final block ends at the timestamp of the last timestamped entry
+// Blocks keep their entries, so ParserOptions.ReuseEntries doesn't apply.
+func (p *Parser) CommandBlocks(reader io.Reader) iter.Seq2[CommandBlock, error] {
+	return func(yield func(CommandBlock, error) bool) {
+		var current *CommandBlock
+		var lastTimestamp time.Time
+
+		for entry, err := range p.all(reader, false) {
+			if err != nil {
+				if !yield(CommandBlock{}, err) {
+					return
+				}
+				continue
+			}
+
+			isCommand := entry.IsCommand()
+
+			// Close the current block at a command or group boundary
+			if current != nil && (isCommand || entry.IsGroup()) {
+				current.End = entry.Timestamp
+				if !entry.HasTimestamp() {
+					current.End = lastTimestamp
+				}
+				if !yield(*current, nil) {
+					return
+				}
+				current = nil
+			}
+
+			if entry.HasTimestamp() {
+				lastTimestamp = entry.Timestamp
+			}
+
+			if isCommand {
+				current = &CommandBlock{
+					Command: strings.TrimPrefix(entry.CleanContent(), "$ "),
+					Group:   entry.Group,
+				}
+				if entry.HasTimestamp() {
+					current.Start = entry.Timestamp
+				}
+				continue
+			}
+
+			if current != nil {
+				current.Entries = append(current.Entries, entry)
+			}
+		}
+
+		// Final block has no following boundary, end it at the last entry
+		if current != nil {
+			current.End = lastTimestamp
+			yield(*current, nil)
+		}
+	}
+}
+
+// dedupeConsecutive collapses runs of repeated lines into their first entry, see ParserOptions.DedupeConsecutive
+func dedupeConsecutive(seq iter.Seq2[*LogEntry, error]) iter.Seq2[*LogEntry, error] {
+	return func(yield func(*LogEntry, error) bool) {
+		var pending *LogEntry
+
+		for entry, err := range seq {
+			if err == nil && pending != nil && isRepeat(pending, entry) {
+				pending.RepeatCount++
+				continue
+			}
+
+			if pending != nil {
+				if !yield(pending, nil) {
+					return
+				}
+				pending = nil
+			}
+
+			if err != nil {
+				if !yield(nil, err) {
+					return
+				}
+				continue
+			}
+			pending = entry
+		}
+
+		if pending != nil {
+			yield(pending, nil)
+		}
+	}
+}
+
+// isRepeat returns true if entry repeats the content of previous within the same group
+func isRepeat(previous, entry *LogEntry) bool {
+	return entry.Content == previous.Content && entry.GroupIndex == previous.GroupIndex
+}
+
+// CollapseProgress returns an iterator that keeps only the final state of runs of progress updates
+// A run is consecutive IsProgress entries in the same group whose text before the percentage matches,
+// such as successive "Receiving objects:  45% (9/20)" lines, and only its last entry is yielded.
+// onCollapse, if not nil, is called with each entry that is dropped.
+func CollapseProgress(seq iter.Seq2[*LogEntry, error], onCollapse func(*LogEntry)) iter.Seq2[*LogEntry, error] {
+	return func(yield func(*LogEntry, error) bool) {
+		var pending *LogEntry
+		var pendingPrefix string
+
+		for entry, err := range seq {
+			if err != nil || !entry.IsProgress() {
+				// Anything other than a progress update ends the current run
+				if pending != nil {
+					if !yield(pending, nil) {
+						return
+					}
+					pending = nil
+				}
+				if !yield(entry, err) {
+					return
+				}
+				continue
+			}
+
+			prefix := progressPrefix(entry)
+			if pending != nil {
+				if pending.Group == entry.Group && pendingPrefix == prefix {
+					if onCollapse != nil {
+						onCollapse(pending)
+					}
+				} else if !yield(pending, nil) {
+					return
+				}
+			}
+			pending, pendingPrefix = entry, prefix
+		}
+
+		if pending != nil {
+			yield(pending, nil)
+		}
+	}
+}
+
+// DropBlankLines returns an iterator that leaves out blank entries, see LogEntry.IsBlank
+// Blank entries with a timestamp are kept when keepTimestamped is set, as they still mark when output
+// paused or resumed. onDrop, if not nil, is called with each entry that is dropped. Errors pass through.
+func DropBlankLines(seq iter.Seq2[*LogEntry, error], keepTimestamped bool, onDrop func(*LogEntry)) iter.Seq2[*LogEntry, error] {
+	return func(yield func(*LogEntry, error) bool) {
+		for entry, err := range seq {
+			if err == nil && entry.IsBlank() && !(keepTimestamped && entry.HasTimestamp()) {
+				if onDrop != nil {
+					onDrop(entry)
+				}
+				continue
+			}
+			if !yield(entry, err) {
+				return
+			}
+		}
+	}
+}
+
+// progressPrefix returns the text of a progress update before its percentage, e.g. "Receiving objects:"
+// Only the final carriage-return separated state of the line is considered.
+func progressPrefix(entry *LogEntry) string {
+	content := strings.TrimRight(entry.CleanContent(), "\r")
+	if i := strings.LastIndexByte(content, '\r'); i >= 0 {
+		content = content[i+1:]
+	}
+	if i := strings.IndexByte(content, '%'); i >= 0 {
+		content = strings.TrimRight(content[:i], "0123456789. ")
+	}
+	return strings.TrimSpace(content)
+}
+
 // Next advances the iterator to the next log entry
 // Returns true if there is a next entry, false if EOF or error
 func (iter *LogIterator) Next() bool {
@@ -96,16 +763,63 @@ func (iter *LogIterator) Next() bool {
 		return false
 	}
 
+	if iter.pull != nil {
+		return iter.nextPulled()
+	}
+
+	if iter.parser.dedupe {
+		return iter.nextDeduped()
+	}
+
+	entry, ok := iter.scanEntry()
+	if !ok {
+		return false
+	}
+
+	iter.current = entry
+	return true
+}
+
+// scanEntry parses the next line, returning false and recording any error at the end of the input or on failure
+func (iter *LogIterator) scanEntry() (*LogEntry, bool) {
 	if !iter.scanner.Scan() {
 		iter.err = iter.scanner.Err()
-		return false
+		return nil, false
 	}
 
-	line := iter.scanner.Text()
-	entry, err := iter.parser.ParseLine(line)
-	if err != nil {
+	iter.line++
+	entry := nextEntry(iter.reused)
+	if err := iter.parser.parseLineInto(entry, iter.scanner.Text()); err != nil {
 		iter.err = err
-		return false
+		return nil, false
+	}
+	entry.LineNumber = iter.line
+
+	return entry, true
+}
+
+// nextDeduped advances to the next entry, reading ahead to collapse the lines that repeat it
+// An error found while reading ahead is reported by the following call to Next.
+func (iter *LogIterator) nextDeduped() bool {
+	entry := iter.next
+	iter.next = nil
+	if entry == nil {
+		var ok bool
+		if entry, ok = iter.scanEntry(); !ok {
+			return false
+		}
+	}
+
+	for {
+		next, ok := iter.scanEntry()
+		if !ok {
+			break
+		}
+		if !isRepeat(entry, next) {
+			iter.next = next
+			break
+		}
+		entry.RepeatCount++
 	}
 
 	iter.current = entry
@@ -113,7 +827,7 @@ func (iter *LogIterator) Next() bool {
 }
 
 // Entry returns the current log entry
-// Only valid after a successful call to Next()
+// Only valid after a successful call to Next(), and only until the next call with ParserOptions.ReuseEntries
 func (iter *LogIterator) Entry() *LogEntry {
 	return iter.current
 }
@@ -123,6 +837,58 @@ func (iter *LogIterator) Err() error {
 	return iter.err
 }
 
+// nextPulled advances an iterator created by Seq2ToIterator, stopping the sequence at its end or first error
+func (iter *LogIterator) nextPulled() bool {
+	entry, err, ok := iter.pull()
+	if !ok {
+		iter.Stop()
+		return false
+	}
+	if err != nil {
+		iter.err = err
+		iter.Stop()
+		return false
+	}
+
+	iter.current = entry
+	return true
+}
+
+// Stop releases the sequence of an iterator created by Seq2ToIterator when it isn't read to the end
+// It is safe to call more than once, and does nothing for iterators created by Parser.NewIterator.
+func (iter *LogIterator) Stop() {
+	if iter.stop != nil {
+		iter.stop()
+	}
+}
+
+// Seq2 adapts the iterator to iter.Seq2 for use with range-over-func
+// Iteration continues from the iterator's current position, and Err is yielded as the final error
+// if iteration failed. Like the iterator itself, the sequence can only be consumed once.
+func (iter *LogIterator) Seq2() iter.Seq2[*LogEntry, error] {
+	return func(yield func(*LogEntry, error) bool) {
+		for iter.Next() {
+			if !yield(iter.Entry(), nil) {
+				return
+			}
+		}
+		if err := iter.Err(); err != nil {
+			yield(nil, err)
+		}
+	}
+}
+
+// Seq2ToIterator adapts an iter.Seq2, such as Parser.All, to the Next/Entry/Err style of LogIterator
+// The first error from the sequence ends iteration and is reported by Err. Call Stop if the iterator
+// is abandoned before Next returns false, to release the sequence.
+func Seq2ToIterator(seq iter.Seq2[*LogEntry, error]) *LogIterator {
+	pull, stop := iter.Pull2(seq)
+	return &LogIterator{
+		pull: pull,
+		stop: stop,
+	}
+}
+
 // StripANSI removes ANSI escape sequences from content
 func (p *Parser) StripANSI(content string) string {
 	return p.byteParser.StripANSI(content)
@@ -134,43 +900,76 @@ func (entry *LogEntry) CleanContent() string {
 	return parser.StripANSI(entry.Content)
 }
 
-// HasTimestamp returns true if the log entry has a valid timestamp
+// HasTimestamp returns true if the log entry has a valid timestamp read from the log
 func (entry *LogEntry) HasTimestamp() bool {
-	return !entry.Timestamp.IsZero()
+	return !entry.Timestamp.IsZero() && !entry.SyntheticTimestamp
+}
+
+// IsBlank returns true if the entry's content is empty or only whitespace once ANSI codes are stripped
+// Codes that lost their ESC, such as "[0m", are stripped too.
+func (entry *LogEntry) IsBlank() bool {
+	content := entry.Content
+	if strings.ContainsAny(content, "\x1b[") {
+		content = entry.CleanContent()
+	}
+	return strings.TrimSpace(content) == ""
+}
+
+// RawLineSize returns the size in bytes of the original line, including OSC sequences
+// It is available even when RawLine was dropped with ParserOptions.DropRawLine.
+func (entry *LogEntry) RawLineSize() int {
+	if entry.RawLine != nil {
+		return len(entry.RawLine)
+	}
+	return entry.rawLineSize
+}
+
+// Flags returns the types the entry was classified as
+// Entries from a Parser use its Classifier, others, such as from ByteParser, use DefaultClassifier.
+func (entry *LogEntry) Flags() EntryFlags {
+	if entry.classified {
+		return entry.flags
+	}
+	return DefaultClassifier{}.Classify(entry)
 }
 
 // IsCommand returns true if the log entry appears to be a command execution
 func (entry *LogEntry) IsCommand() bool {
-	clean := entry.CleanContent()
-	return strings.HasPrefix(clean, "$ ")
+	if entry.classified {
+		return entry.flags.Has(FlagCommand)
+	}
+	return isCommandContent(entry.CleanContent())
 }
 
 // IsProgress returns true if the log entry appears to be a progress update
-// Progress lines are identified by [K (erase-in-line) sequences anywhere in the content,
-// but more conservatively than before - looking for the specific pattern where [K
-// appears in contexts that indicate terminal progress updates
 func (entry *LogEntry) IsProgress() bool {
-	content := entry.Content
-
-	// Look for [K sequences in the content
-	if !strings.Contains(content, "[K") {
-		return false
+	if entry.classified {
+		return entry.flags.Has(FlagProgress)
 	}
-
-	// Additional validation: should be git progress-related content
-	cleanContent := entry.CleanContent()
-	return strings.Contains(cleanContent, "objects") ||
-		strings.Contains(cleanContent, "deltas") ||
-		strings.Contains(cleanContent, "%")
+	return isProgressContent(entry.Content, entry.CleanContent())
 }
 
 // IsGroup returns true if the log entry appears to be a group header
 func (entry *LogEntry) IsGroup() bool {
-	content := entry.CleanContent()
-	return strings.HasPrefix(content, "~~~") || strings.HasPrefix(content, "---") || strings.HasPrefix(content, "+++")
+	if entry.classified {
+		return entry.flags.Has(FlagGroup)
+	}
+	return isGroupContent(entry.CleanContent())
 }
 
 // IsSection is deprecated, use IsGroup instead
 func (entry *LogEntry) IsSection() bool {
 	return entry.IsGroup()
 }
+
+// IsError returns true if the log entry looks like an error message
+func (entry *LogEntry) IsError() bool {
+	return entry.Flags().Has(FlagError)
+}
+
+// IsWarning returns true if the log entry looks like a warning message
+// Entries that are classified as errors are never reported as warnings
+func (entry *LogEntry) IsWarning() bool {
+	flags := entry.Flags()
+	return flags.Has(FlagWarning) && !flags.Has(FlagError)
+}
@@ -233,6 +233,27 @@ func BenchmarkMemoryUsage(b *testing.B) {
 			}
 		}
 	})
+
+	b.Run("collect_all_entries_drop_raw_line", func(b *testing.B) {
+		dropParser := NewParserWithOptions(ParserOptions{DropRawLine: true})
+
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			reader := strings.NewReader(data)
+
+			var entries []*LogEntry
+			for entry, err := range dropParser.All(reader) {
+				if err != nil {
+					b.Fatal(err)
+				}
+				entries = append(entries, entry)
+			}
+
+			for _, entry := range entries {
+				_ = entry
+			}
+		}
+	})
 }
 
 // BenchmarkSeq2Iterator tests the performance of the Go 1.23+ Seq2 iterator
@@ -265,6 +286,36 @@ func BenchmarkSeq2Iterator(b *testing.B) {
 	}
 }
 
+// BenchmarkSeq2IteratorReuseEntries tests the Seq2 iterator when a single entry is reused for every line
+func BenchmarkSeq2IteratorReuseEntries(b *testing.B) {
+	sizes := []int{100, 1000, 10000, 100000}
+
+	for _, size := range sizes {
+		b.Run(fmt.Sprintf("lines_%d", size), func(b *testing.B) {
+			data := generateTestData(size)
+			parser := NewParserWithOptions(ParserOptions{ReuseEntries: true})
+
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				reader := strings.NewReader(data)
+
+				count := 0
+				for entry, err := range parser.All(reader) {
+					if err != nil {
+						b.Fatal(err)
+					}
+					count++
+					_ = entry // Prevent optimization
+				}
+
+				if count != size {
+					b.Fatalf("Expected %d entries, got %d", size, count)
+				}
+			}
+		})
+	}
+}
+
 // BenchmarkSeq2WithFiltering tests Seq2 iterator performance with filtering
 func BenchmarkSeq2WithFiltering(b *testing.B) {
 	data := generateTestData(10000)
@@ -337,6 +388,41 @@ func BenchmarkIteratorComparison(b *testing.B) {
 			}
 		}
 	})
+	reuseParser := NewParserWithOptions(ParserOptions{ReuseEntries: true})
+
+	b.Run("traditional_iterator_reuse_entries", func(b *testing.B) {
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			reader := strings.NewReader(data)
+			iterator := reuseParser.NewIterator(reader)
+
+			count := 0
+			for iterator.Next() {
+				count++
+				_ = iterator.Entry()
+			}
+
+			if err := iterator.Err(); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+
+	b.Run("seq2_iterator_reuse_entries", func(b *testing.B) {
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			reader := strings.NewReader(data)
+
+			count := 0
+			for entry, err := range reuseParser.All(reader) {
+				if err != nil {
+					b.Fatal(err)
+				}
+				count++
+				_ = entry
+			}
+		}
+	})
 }
 
 // BenchmarkParquetExport tests Parquet export performance
@@ -1,6 +1,13 @@
 package buildkitelogs
 
 import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"iter"
+	"os"
+	"slices"
 	"strings"
 	"testing"
 	"time"
@@ -44,6 +51,34 @@ func TestParseLine(t *testing.T) {
 			wantContent: "",
 			wantHasTs:   true,
 		},
+		{
+			name:        "OSC sequence after ANSI reset",
+			input:       "\x1b[0m\x1b_bk;t=1745322209921\x07~~~ Running tests",
+			wantTs:      1745322209921,
+			wantContent: "\x1b[0m~~~ Running tests",
+			wantHasTs:   true,
+		},
+		{
+			name:        "OSC sequence after whitespace and ANSI",
+			input:       " \x1b[0m\x1b[K\x1b_bk;t=1745322209921\x07done",
+			wantTs:      1745322209921,
+			wantContent: " \x1b[0m\x1b[Kdone",
+			wantHasTs:   true,
+		},
+		{
+			name:        "OSC sequence after text",
+			input:       "text \x1b_bk;t=1745322209921\x07done",
+			wantTs:      0,
+			wantContent: "text \x1b_bk;t=1745322209921\x07done",
+			wantHasTs:   false,
+		},
+		{
+			name:        "OSC sequence beyond leading offset",
+			input:       strings.Repeat("\x1b[0m", 20) + "\x1b_bk;t=1745322209921\x07done",
+			wantTs:      0,
+			wantContent: strings.Repeat("\x1b[0m", 20) + "\x1b_bk;t=1745322209921\x07done",
+			wantHasTs:   false,
+		},
 	}
 
 	for _, tt := range tests {
@@ -103,6 +138,31 @@ func TestStripANSI(t *testing.T) {
 			input: "remote: Counting objects: 100% (54/54)[K",
 			want:  "remote: Counting objects: 100% (54/54)",
 		},
+		{
+			name:  "Array index preserved",
+			input: "array[0] = 1",
+			want:  "array[0] = 1",
+		},
+		{
+			name:  "Bracketed words preserved",
+			input: "TOKEN=[REDACTED] [Killed] [mypkg] see [A] array[i]",
+			want:  "TOKEN=[REDACTED] [Killed] [mypkg] see [A] array[i]",
+		},
+		{
+			name:  "Bracketed code preserved",
+			input: "[1m] [42] [K]",
+			want:  "[1m] [42] [K]",
+		},
+		{
+			name:  "Parameterless sequences at word boundaries",
+			input: "[1mbold[m done[K",
+			want:  "bold done",
+		},
+		{
+			name:  "Cursor movement",
+			input: "[2K[1Gstep 2/3",
+			want:  "step 2/3",
+		},
 	}
 
 	for _, tt := range tests {
@@ -348,3 +408,1040 @@ func TestLogIteratorError(t *testing.T) {
 		t.Error("Expected error for invalid timestamp")
 	}
 }
+
+func TestLogEntrySeverity(t *testing.T) {
+	parser := NewParser()
+
+	tests := []struct {
+		name        string
+		input       string
+		wantError   bool
+		wantWarning bool
+	}{
+		{
+			name:      "Buildkite error emoji",
+			input:     "\x1b_bk;t=1745322210701\x07🚨 Error: The command exited with status 1",
+			wantError: true,
+		},
+		{
+			name:      "Lowercase error prefix",
+			input:     "\x1b_bk;t=1745322210701\x07error: pathspec 'main' did not match",
+			wantError: true,
+		},
+		{
+			name:      "Failed test summary",
+			input:     "\x1b_bk;t=1745322210701\x07FAILED (2 failures)",
+			wantError: true,
+		},
+		{
+			name:      "Indented error prefix",
+			input:     "\x1b_bk;t=1745322210701\x07  \x1b[1merror:\x1b[0m linker command failed",
+			wantError: true,
+		},
+		{
+			name:  "Error text mid-line",
+			input: "\x1b_bk;t=1745322210701\x070 error: none",
+		},
+		{
+			name:  "Failed count mid-line",
+			input: "\x1b_bk;t=1745322210701\x07tests FAILED=0",
+		},
+		{
+			name:  "Warning text mid-line",
+			input: "\x1b_bk;t=1745322210701\x07-WARNING flag",
+		},
+		{
+			name:      "Red ANSI color",
+			input:     "\x1b_bk;t=1745322210701\x07[31mcompilation failed[0m",
+			wantError: true,
+		},
+		{
+			name:        "Warning emoji",
+			input:       "\x1b_bk;t=1745322210701\x07⚠️ Retrying upload",
+			wantWarning: true,
+		},
+		{
+			name:        "Warning text",
+			input:       "\x1b_bk;t=1745322210701\x07WARNING: deprecated flag used",
+			wantWarning: true,
+		},
+		{
+			name:        "Yellow ANSI color",
+			input:       "\x1b_bk;t=1745322210701\x07[33mCongratulations![0m You've successfully run your first build!",
+			wantWarning: true,
+		},
+		{
+			name:  "Regular output",
+			input: "\x1b_bk;t=1745322210701\x07Cloning into '.'...",
+		},
+		{
+			name:  "Grey command",
+			input: "\x1b_bk;t=1745322209921\x07[90m$[0m /buildkite/agent/hooks/environment",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			entry, err := parser.ParseLine(tt.input)
+			if err != nil {
+				t.Fatalf("ParseLine() error = %v", err)
+			}
+
+			if entry.IsError() != tt.wantError {
+				t.Errorf("IsError() = %v, want %v", entry.IsError(), tt.wantError)
+			}
+
+			if entry.IsWarning() != tt.wantWarning {
+				t.Errorf("IsWarning() = %v, want %v", entry.IsWarning(), tt.wantWarning)
+			}
+		})
+	}
+}
+
+func TestParserSeverityOptions(t *testing.T) {
+	parser := NewParserWithOptions(ParserOptions{
+		Severity: &SeverityPatterns{
+			Error:   []string{"panic:"},
+			Warning: []string{"DEPRECATED"},
+		},
+	})
+
+	entry, err := parser.ParseLine("\x1b_bk;t=1745322210701\x07panic: runtime error")
+	if err != nil {
+		t.Fatalf("ParseLine() error = %v", err)
+	}
+	if !entry.IsError() {
+		t.Error("Expected custom error pattern to match")
+	}
+
+	entry, err = parser.ParseLine("\x1b_bk;t=1745322210701\x07DEPRECATED: use --foo")
+	if err != nil {
+		t.Fatalf("ParseLine() error = %v", err)
+	}
+	if !entry.IsWarning() {
+		t.Error("Expected custom warning pattern to match")
+	}
+
+	// Default patterns are replaced, not extended
+	entry, err = parser.ParseLine("\x1b_bk;t=1745322210701\x07Error: something broke")
+	if err != nil {
+		t.Fatalf("ParseLine() error = %v", err)
+	}
+	if entry.IsError() {
+		t.Error("Expected default error pattern to be overridden")
+	}
+}
+
+func TestCommandBlocks(t *testing.T) {
+	parser := NewParser()
+
+	input := "\x1b_bk;t=1745322209921\x07~~~ Running global environment hook\n" +
+		"\x1b_bk;t=1745322209921\x07[90m$[0m /buildkite/agent/hooks/environment\n" +
+		"\x1b_bk;t=1745322209940\x07exporting environment\n" +
+		"\x1b_bk;t=1745322209948\x07~~~ Running script\n" +
+		"\x1b_bk;t=1745322210692\x07[90m$[0m ./script.sh\n" +
+		"\x1b_bk;t=1745322210700\x07building\n" +
+		"untimestamped output\n" +
+		"\x1b_bk;t=1745322211692\x07[90m$[0m make test\n" +
+		"\x1b_bk;t=1745322213692\x07ok"
+
+	var blocks []CommandBlock
+	for block, err := range parser.CommandBlocks(strings.NewReader(input)) {
+		if err != nil {
+			t.Fatalf("CommandBlocks() error = %v", err)
+		}
+		blocks = append(blocks, block)
+	}
+
+	if len(blocks) != 3 {
+		t.Fatalf("CommandBlocks() got %d blocks, want 3", len(blocks))
+	}
+
+	tests := []struct {
+		command  string
+		group    string
+		entries  int
+		duration time.Duration
+	}{
+		{"/buildkite/agent/hooks/environment", "~~~ Running global environment hook", 1, 27 * time.Millisecond},
+		{"./script.sh", "~~~ Running script", 2, 1000 * time.Millisecond},
+		{"make test", "~~~ Running script", 1, 2000 * time.Millisecond},
+	}
+
+	for i, tt := range tests {
+		block := blocks[i]
+		if block.Command != tt.command {
+			t.Errorf("Block %d: Command = %q, want %q", i, block.Command, tt.command)
+		}
+		if block.Group != tt.group {
+			t.Errorf("Block %d: Group = %q, want %q", i, block.Group, tt.group)
+		}
+		if len(block.Entries) != tt.entries {
+			t.Errorf("Block %d: got %d entries, want %d", i, len(block.Entries), tt.entries)
+		}
+		if block.Duration() != tt.duration {
+			t.Errorf("Block %d: Duration() = %v, want %v", i, block.Duration(), tt.duration)
+		}
+	}
+}
+
+func TestCommandBlocksWithoutTimestamps(t *testing.T) {
+	parser := NewParser()
+
+	input := "$ echo hello\n" +
+		"hello\n" +
+		"$ echo world\n" +
+		"world"
+
+	count := 0
+	for block, err := range parser.CommandBlocks(strings.NewReader(input)) {
+		if err != nil {
+			t.Fatalf("CommandBlocks() error = %v", err)
+		}
+		count++
+
+		if block.Duration() != 0 {
+			t.Errorf("Expected zero duration for untimestamped block, got %v", block.Duration())
+		}
+		if len(block.Entries) != 1 {
+			t.Errorf("Expected 1 output entry, got %d", len(block.Entries))
+		}
+	}
+
+	if count != 2 {
+		t.Errorf("Expected 2 blocks, got %d", count)
+	}
+}
+
+func TestLineEndings(t *testing.T) {
+	parser := NewParser()
+
+	tests := []struct {
+		name         string
+		input        string
+		wantContents []string
+	}{
+		{
+			name: "CRLF terminated lines",
+			input: "\x1b_bk;t=1745322209921\x07~~~ Running global environment hook\r\n" +
+				"\x1b_bk;t=1745322209922\x07$ /buildkite/agent/hooks/environment\r\n" +
+				"regular line\r\n",
+			wantContents: []string{
+				"~~~ Running global environment hook",
+				"$ /buildkite/agent/hooks/environment",
+				"regular line",
+			},
+		},
+		{
+			name: "Embedded bare CR stays in content",
+			input: "\x1b_bk;t=1745322210213\x07Receiving objects:  50% (131/263)\rReceiving objects: 100% (263/263)\r\n" +
+				"\x1b_bk;t=1745322210340\x07done",
+			wantContents: []string{
+				"Receiving objects:  50% (131/263)\rReceiving objects: 100% (263/263)",
+				"done",
+			},
+		},
+		{
+			name:         "Mixed LF and CRLF",
+			input:        "first\nsecond\r\nthird",
+			wantContents: []string{"first", "second", "third"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var contents []string
+			for entry, err := range parser.All(strings.NewReader(tt.input)) {
+				if err != nil {
+					t.Fatalf("Parser.All() error = %v", err)
+				}
+				contents = append(contents, entry.Content)
+			}
+
+			if len(contents) != len(tt.wantContents) {
+				t.Fatalf("Parser.All() got %d entries, want %d", len(contents), len(tt.wantContents))
+			}
+			for i, want := range tt.wantContents {
+				if contents[i] != want {
+					t.Errorf("Entry %d: content = %q, want %q", i, contents[i], want)
+				}
+			}
+
+			// The legacy iterator must agree with the Seq2 path
+			iterator := parser.NewIterator(strings.NewReader(tt.input))
+			count := 0
+			for iterator.Next() {
+				if iterator.Entry().Content != tt.wantContents[count] {
+					t.Errorf("Iterator entry %d: content = %q, want %q", count, iterator.Entry().Content, tt.wantContents[count])
+				}
+				count++
+			}
+			if iterator.Err() != nil {
+				t.Fatalf("Iterator error: %v", iterator.Err())
+			}
+			if count != len(tt.wantContents) {
+				t.Errorf("Iterator got %d entries, want %d", count, len(tt.wantContents))
+			}
+		})
+	}
+}
+
+func TestLongLines(t *testing.T) {
+	longContent := strings.Repeat("A", 80*1024)
+	input := "\x1b_bk;t=1745322209921\x07~~~ Bundling\n" +
+		"\x1b_bk;t=1745322209922\x07" + longContent + "\n" +
+		"\x1b_bk;t=1745322209923\x07done"
+
+	t.Run("DefaultLimit", func(t *testing.T) {
+		parser := NewParser()
+
+		var entries []*LogEntry
+		for entry, err := range parser.All(strings.NewReader(input)) {
+			if err != nil {
+				t.Fatalf("Parser.All() error = %v", err)
+			}
+			entries = append(entries, entry)
+		}
+
+		if len(entries) != 3 {
+			t.Fatalf("Parser.All() got %d entries, want 3", len(entries))
+		}
+		if entries[1].Content != longContent {
+			t.Errorf("Long line content length = %d, want %d", len(entries[1].Content), len(longContent))
+		}
+	})
+
+	t.Run("CustomLimit", func(t *testing.T) {
+		parser := NewParserWithOptions(ParserOptions{MaxLineBytes: 1024})
+		iterator := parser.NewIterator(strings.NewReader(input))
+
+		for iterator.Next() {
+		}
+
+		if !errors.Is(iterator.Err(), bufio.ErrTooLong) {
+			t.Errorf("Expected bufio.ErrTooLong, got %v", iterator.Err())
+		}
+	})
+}
+
+func TestLineNumbers(t *testing.T) {
+	parser := NewParser()
+
+	input := "\x1b_bk;t=1745322209921\x07~~~ Running global environment hook\n" +
+		"\x1b_bk;t=1745322209921\x07[90m$[0m /buildkite/agent/hooks/environment\n" +
+		"regular log line without OSC\n" +
+		"\x1b_bk;t=1745322209948\x07~~~ Running global pre-checkout hook"
+
+	var lineNumbers []int64
+	for entry, err := range parser.All(strings.NewReader(input)) {
+		if err != nil {
+			t.Fatalf("Parser.All() error = %v", err)
+		}
+		lineNumbers = append(lineNumbers, entry.LineNumber)
+	}
+
+	iterator := parser.NewIterator(strings.NewReader(input))
+	var iteratorLineNumbers []int64
+	for iterator.Next() {
+		iteratorLineNumbers = append(iteratorLineNumbers, iterator.Entry().LineNumber)
+	}
+	if iterator.Err() != nil {
+		t.Fatalf("Iterator error: %v", iterator.Err())
+	}
+
+	expected := []int64{1, 2, 3, 4}
+	for i, want := range expected {
+		if lineNumbers[i] != want {
+			t.Errorf("All() entry %d: LineNumber = %d, want %d", i, lineNumbers[i], want)
+		}
+		if iteratorLineNumbers[i] != want {
+			t.Errorf("Iterator entry %d: LineNumber = %d, want %d", i, iteratorLineNumbers[i], want)
+		}
+	}
+}
+
+func TestParseJSON(t *testing.T) {
+	array := `[
+		{"timestamp": 1745322209921, "content": "~~~ Running global environment hook"},
+		{"timestamp": 1745322209922, "content": "$ /buildkite/agent/hooks/environment"},
+		{"timestamp": "2025-04-22T11:43:30.5Z", "content": "--- :package: Build"},
+		{"content": "building"}
+	]`
+
+	ndjson := `{"timestamp": 1745322209921, "content": "~~~ Running global environment hook"}
+{"timestamp": 1745322209922, "content": "$ /buildkite/agent/hooks/environment"}
+{"timestamp": "2025-04-22T11:43:30.5Z", "content": "--- :package: Build"}
+
+{"content": "building"}
+`
+
+	for name, input := range map[string]string{"array": array, "ndjson": ndjson} {
+		t.Run(name, func(t *testing.T) {
+			var entries []*LogEntry
+			for entry, err := range NewParser().ParseJSON(strings.NewReader(input)) {
+				if err != nil {
+					t.Fatalf("ParseJSON() error = %v", err)
+				}
+				entries = append(entries, entry)
+			}
+
+			if len(entries) != 4 {
+				t.Fatalf("Expected 4 entries, got %d", len(entries))
+			}
+
+			if !entries[0].IsGroup() || entries[0].Group != "~~~ Running global environment hook" {
+				t.Errorf("Entry 0: IsGroup = %v, Group = %q", entries[0].IsGroup(), entries[0].Group)
+			}
+			if !entries[1].IsCommand() || entries[1].Group != "~~~ Running global environment hook" {
+				t.Errorf("Entry 1: IsCommand = %v, Group = %q", entries[1].IsCommand(), entries[1].Group)
+			}
+			if entries[1].Timestamp.UnixMilli() != 1745322209922 {
+				t.Errorf("Entry 1: Timestamp = %d, want 1745322209922", entries[1].Timestamp.UnixMilli())
+			}
+
+			want := time.Date(2025, 4, 22, 11, 43, 30, 500000000, time.UTC)
+			if !entries[2].Timestamp.Equal(want) {
+				t.Errorf("Entry 2: Timestamp = %v, want %v", entries[2].Timestamp, want)
+			}
+
+			if entries[3].HasTimestamp() || entries[3].Group != "--- :package: Build" || entries[3].LineNumber != 4 {
+				t.Errorf("Entry 3: HasTimestamp = %v, Group = %q, LineNumber = %d", entries[3].HasTimestamp(), entries[3].Group, entries[3].LineNumber)
+			}
+		})
+	}
+}
+
+func TestParseJSONErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		entries int
+		errors  int
+	}{
+		{"empty", "", 0, 0},
+		{"empty array", "[]", 0, 0},
+		{"invalid timestamp continues", `[{"timestamp": true, "content": "a"}, {"content": "b"}]`, 1, 1},
+		{"truncated array", `[{"content": "a"}`, 1, 1},
+		{"malformed ndjson", "{\"content\": \"a\"}\n{\"content\": ", 1, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var entries, errs int
+			for entry, err := range NewParser().ParseJSON(strings.NewReader(tt.input)) {
+				if err != nil {
+					errs++
+					continue
+				}
+				if entry != nil {
+					entries++
+				}
+			}
+
+			if entries != tt.entries || errs != tt.errors {
+				t.Errorf("Got %d entries and %d errors, want %d and %d", entries, errs, tt.entries, tt.errors)
+			}
+		})
+	}
+}
+
+func TestSynthesizeTimestamps(t *testing.T) {
+	input := "plain line\n" +
+		"another line\n" +
+		"\x1b_bk;t=1745322209921\x07~~~ Running tests\n" +
+		"after a real timestamp\n"
+
+	parser := NewParserWithOptions(ParserOptions{SynthesizeTimestamps: true})
+
+	var entries []*LogEntry
+	for entry, err := range parser.All(strings.NewReader(input)) {
+		if err != nil {
+			t.Fatalf("All() error = %v", err)
+		}
+		entries = append(entries, entry)
+	}
+
+	want := []struct {
+		ms        int64
+		synthetic bool
+	}{
+		{0, true},
+		{1, true},
+		{1745322209921, false},
+		{1745322209922, true},
+	}
+
+	if len(entries) != len(want) {
+		t.Fatalf("Expected %d entries, got %d", len(want), len(entries))
+	}
+	for i, entry := range entries {
+		if entry.Timestamp.UnixMilli() != want[i].ms {
+			t.Errorf("Entry %d: Timestamp = %d, want %d", i, entry.Timestamp.UnixMilli(), want[i].ms)
+		}
+		if entry.SyntheticTimestamp != want[i].synthetic {
+			t.Errorf("Entry %d: SyntheticTimestamp = %v, want %v", i, entry.SyntheticTimestamp, want[i].synthetic)
+		}
+		if entry.HasTimestamp() == want[i].synthetic {
+			t.Errorf("Entry %d: HasTimestamp = %v, want %v", i, entry.HasTimestamp(), !want[i].synthetic)
+		}
+	}
+}
+
+func TestSynthesizeTimestampsBaseAndStep(t *testing.T) {
+	base := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	parser := NewParserWithOptions(ParserOptions{
+		SynthesizeTimestamps:   true,
+		SyntheticTimestampBase: base,
+		SyntheticTimestampStep: time.Second,
+	})
+
+	var got []time.Time
+	for entry, err := range parser.All(strings.NewReader("a\nb\nc\n")) {
+		if err != nil {
+			t.Fatalf("All() error = %v", err)
+		}
+		got = append(got, entry.Timestamp)
+	}
+
+	for i, ts := range got {
+		if want := base.Add(time.Duration(i) * time.Second); !ts.Equal(want) {
+			t.Errorf("Entry %d: Timestamp = %v, want %v", i, ts, want)
+		}
+	}
+
+	// Without the option lines keep a zero timestamp
+	entry, err := NewParser().ParseLine("plain line")
+	if err != nil {
+		t.Fatalf("ParseLine() error = %v", err)
+	}
+	if !entry.Timestamp.IsZero() || entry.SyntheticTimestamp {
+		t.Errorf("Timestamp = %v, SyntheticTimestamp = %v, want zero and false", entry.Timestamp, entry.SyntheticTimestamp)
+	}
+}
+
+func TestCollapseProgress(t *testing.T) {
+	input := "\x1b_bk;t=1\x07~~~ Checkout\n" +
+		"\x1b_bk;t=2\x07Receiving objects:  10% (1/10)\x1b[K\n" +
+		"\x1b_bk;t=3\x07Receiving objects:  50% (5/10)\x1b[K\n" +
+		"\x1b_bk;t=4\x07Receiving objects: 100% (10/10), done.\x1b[K\n" +
+		"\x1b_bk;t=5\x07Resolving deltas:  50% (1/2)\x1b[K\n" +
+		"\x1b_bk;t=6\x07Resolving deltas: 100% (2/2), done.\x1b[K\n" +
+		"\x1b_bk;t=7\x07HEAD is now at abc123\n" +
+		"\x1b_bk;t=8\x07Receiving objects:  50% (1/2)\x1b[K\n" +
+		"\x1b_bk;t=9\x07~~~ Build\n" +
+		"\x1b_bk;t=10\x07Receiving objects: 100% (2/2)\x1b[K\n"
+
+	var dropped []string
+	var got []string
+	for entry, err := range CollapseProgress(NewParser().All(strings.NewReader(input)), func(entry *LogEntry) {
+		dropped = append(dropped, entry.CleanContent())
+	}) {
+		if err != nil {
+			t.Fatalf("CollapseProgress() error = %v", err)
+		}
+		got = append(got, entry.CleanContent())
+	}
+
+	want := []string{
+		"~~~ Checkout",
+		"Receiving objects: 100% (10/10), done.",
+		"Resolving deltas: 100% (2/2), done.",
+		"HEAD is now at abc123",
+		"Receiving objects:  50% (1/2)",
+		"~~~ Build",
+		"Receiving objects: 100% (2/2)",
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("CollapseProgress() = %q, want %q", got, want)
+	}
+	if len(dropped) != 3 {
+		t.Errorf("Expected 3 collapsed entries, got %d: %q", len(dropped), dropped)
+	}
+}
+
+func TestLogEntryIsBlank(t *testing.T) {
+	tests := []struct {
+		content string
+		want    bool
+	}{
+		{"", true},
+		{"   \t", true},
+		{"\r", true},
+		{"\x1b[0m", true},
+		{"\x1b[32m  \x1b[0m", true},
+		{"[0m", true},
+		{"[K ", true},
+		{"[32m  [0m", true},
+		{"ok", false},
+		{"[ok]", false},
+		{"\x1b[32mok\x1b[0m", false},
+		{"  -", false},
+	}
+
+	for _, tt := range tests {
+		entry := &LogEntry{Content: tt.content}
+		if got := entry.IsBlank(); got != tt.want {
+			t.Errorf("IsBlank(%q) = %v, want %v", tt.content, got, tt.want)
+		}
+	}
+}
+
+func TestDropBlankLines(t *testing.T) {
+	input := "\x1b_bk;t=1745322209921\x07~~~ Build\n" +
+		"\x1b_bk;t=1745322209922\x07\n" +
+		"\n" +
+		"\x1b[0m   \n" +
+		"[K \n" +
+		"\x1b_bk;t=1745322209923\x07done\n"
+
+	tests := []struct {
+		name            string
+		keepTimestamped bool
+		want            []string
+		dropped         int
+	}{
+		{"drop all", false, []string{"~~~ Build", "done"}, 4},
+		{"keep timestamped", true, []string{"~~~ Build", "", "done"}, 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dropped := 0
+			var got []string
+			for entry, err := range DropBlankLines(NewParser().All(strings.NewReader(input)), tt.keepTimestamped, func(*LogEntry) {
+				dropped++
+			}) {
+				if err != nil {
+					t.Fatalf("DropBlankLines() error = %v", err)
+				}
+				got = append(got, entry.Content)
+			}
+
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("DropBlankLines() = %q, want %q", got, tt.want)
+			}
+			if dropped != tt.dropped {
+				t.Errorf("Expected %d dropped entries, got %d", tt.dropped, dropped)
+			}
+		})
+	}
+}
+
+func TestCollapseProgressStopsEarly(t *testing.T) {
+	input := "\x1b_bk;t=1\x07Receiving objects:  10% (1/10)\x1b[K\n" +
+		"\x1b_bk;t=2\x07done\n" +
+		"\x1b_bk;t=3\x07more\n"
+
+	var count int
+	for range CollapseProgress(NewParser().All(strings.NewReader(input)), nil) {
+		count++
+		break
+	}
+	if count != 1 {
+		t.Errorf("Expected 1 entry before break, got %d", count)
+	}
+}
+
+func TestGroupIndex(t *testing.T) {
+	input := "before any group\n" +
+		"\x1b_bk;t=1\x07~~~ Setup\n" +
+		"\x1b_bk;t=2\x07setting up\n" +
+		"\x1b_bk;t=3\x07--- Build\n" +
+		"\x1b_bk;t=4\x07~~~ Setup\n" +
+		"\x1b_bk;t=5\x07setting up again\n"
+
+	want := []int{-1, 0, 0, 1, 2, 2}
+
+	var got []int
+	for entry, err := range NewParser().All(strings.NewReader(input)) {
+		if err != nil {
+			t.Fatalf("All() error = %v", err)
+		}
+		got = append(got, entry.GroupIndex)
+	}
+
+	if len(got) != len(want) {
+		t.Fatalf("Expected %d entries, got %d", len(want), len(got))
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("Entry %d: GroupIndex = %d, want %d", i, got[i], want[i])
+		}
+	}
+}
+
+func TestParentGroups(t *testing.T) {
+	input := "before any group\n" +
+		"\x1b_bk;t=1\x07~~~ Running script\n" +
+		"\x1b_bk;t=2\x07--- Build\n" +
+		"\x1b_bk;t=3\x07building\n" +
+		"\x1b_bk;t=4\x07+++ Test\n" +
+		"\x1b_bk;t=5\x07~~~ Uploading artifacts\n"
+
+	tests := []struct {
+		name  string
+		track bool
+		want  []string
+	}{
+		{"default", false, []string{"", "", "", "", "", ""}},
+		{"tracked", true, []string{"", "~~~ Running script", "~~~ Running script", "~~~ Running script", "~~~ Running script", "~~~ Uploading artifacts"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			parser := NewParserWithOptions(ParserOptions{TrackParentGroups: tt.track})
+
+			var got []string
+			for entry, err := range parser.All(strings.NewReader(input)) {
+				if err != nil {
+					t.Fatalf("All() error = %v", err)
+				}
+				got = append(got, entry.ParentGroup)
+			}
+
+			if strings.Join(got, "|") != strings.Join(tt.want, "|") {
+				t.Errorf("ParentGroup = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDedupeConsecutive(t *testing.T) {
+	input := "\x1b_bk;t=1\x07~~~ Retrying\n" +
+		"\x1b_bk;t=2\x07waiting for lock\n" +
+		"\x1b_bk;t=3\x07waiting for lock\n" +
+		"\x1b_bk;t=4\x07waiting for lock\n" +
+		"\x1b_bk;t=5\x07got lock\n" +
+		"\x1b_bk;t=6\x07~~~ Retrying\n" +
+		"\x1b_bk;t=7\x07~~~ Retrying\n" +
+		"\x1b_bk;t=8\x07got lock\n" +
+		"\x1b_bk;t=9\x07got lock\n"
+
+	// Repeated group headers start new groups, so they are never collapsed
+	want := []string{"~~~ Retrying x0", "waiting for lock x2", "got lock x0", "~~~ Retrying x0", "~~~ Retrying x0", "got lock x1"}
+
+	collect := func(entries iter.Seq2[*LogEntry, error]) []string {
+		var got []string
+		for entry, err := range entries {
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			got = append(got, fmt.Sprintf("%s x%d", entry.Content, entry.RepeatCount))
+		}
+		return got
+	}
+
+	opts := ParserOptions{DedupeConsecutive: true, ReuseEntries: true}
+
+	t.Run("All", func(t *testing.T) {
+		got := collect(NewParserWithOptions(opts).All(strings.NewReader(input)))
+		if !slices.Equal(got, want) {
+			t.Errorf("All() = %q, want %q", got, want)
+		}
+	})
+
+	t.Run("NewIterator", func(t *testing.T) {
+		got := collect(NewParserWithOptions(opts).NewIterator(strings.NewReader(input)).Seq2())
+		if !slices.Equal(got, want) {
+			t.Errorf("NewIterator() = %q, want %q", got, want)
+		}
+	})
+
+	t.Run("disabled", func(t *testing.T) {
+		got := collect(NewParser().All(strings.NewReader(input)))
+		if len(got) != 9 {
+			t.Errorf("Expected every line without DedupeConsecutive, got %q", got)
+		}
+	})
+}
+
+func TestDedupeConsecutiveIteratorError(t *testing.T) {
+	// The malformed timestamp is reported after the run before it
+	input := "\x1b_bk;t=1\x07same\n\x1b_bk;t=2\x07same\n\x1b_bk;t=bad\x07broken\n"
+
+	iterator := NewParserWithOptions(ParserOptions{DedupeConsecutive: true}).NewIterator(strings.NewReader(input))
+
+	if !iterator.Next() {
+		t.Fatalf("Next() = false, err = %v", iterator.Err())
+	}
+	if entry := iterator.Entry(); entry.Content != "same" || entry.RepeatCount != 1 {
+		t.Errorf("Entry() = %q x%d, want \"same\" x1", entry.Content, entry.RepeatCount)
+	}
+
+	if iterator.Next() {
+		t.Error("Expected Next() to stop at the malformed line")
+	}
+	if iterator.Err() == nil {
+		t.Error("Expected Err() to report the malformed timestamp")
+	}
+}
+
+func TestDropRawLine(t *testing.T) {
+	input := "\x1b_bk;t=1745322209921\x07~~~ Running script\n" +
+		"plain line\n" +
+		"\x1b_bk;t=1745322209922\x07$ make test\n"
+	lines := strings.Split(strings.TrimSuffix(input, "\n"), "\n")
+
+	keep, err := collectAll(NewParser(), input)
+	if err != nil {
+		t.Fatalf("All() error = %v", err)
+	}
+	drop, err := collectAll(NewParserWithOptions(ParserOptions{DropRawLine: true}), input)
+	if err != nil {
+		t.Fatalf("All() with DropRawLine error = %v", err)
+	}
+
+	if len(drop) != len(lines) {
+		t.Fatalf("Expected %d entries, got %d", len(lines), len(drop))
+	}
+	for i, entry := range drop {
+		if entry.RawLine != nil {
+			t.Errorf("Entry %d: RawLine = %q, want nil", i, entry.RawLine)
+		}
+		if entry.RawLineSize() != len(lines[i]) {
+			t.Errorf("Entry %d: RawLineSize() = %d, want %d", i, entry.RawLineSize(), len(lines[i]))
+		}
+		if entry.RawLineSize() != keep[i].RawLineSize() {
+			t.Errorf("Entry %d: RawLineSize() = %d, want %d as without DropRawLine", i, entry.RawLineSize(), keep[i].RawLineSize())
+		}
+		if entry.Content != keep[i].Content || !entry.Timestamp.Equal(keep[i].Timestamp) || entry.Group != keep[i].Group {
+			t.Errorf("Entry %d: got %+v, want same fields as %+v", i, entry, keep[i])
+		}
+	}
+}
+
+func TestReuseEntries(t *testing.T) {
+	valid := "\x1b_bk;t=1745322209921\x07~~~ Running script\n" +
+		"plain line\n" +
+		"\x1b_bk;t=1745322209922\x07$ make test\n"
+	input := valid + "\x1b_bk;t=bad\x07broken timestamp\n"
+
+	want, err := collectAll(NewParser(), valid)
+	if err != nil {
+		t.Fatalf("All() error = %v", err)
+	}
+
+	parser := NewParserWithOptions(ParserOptions{ReuseEntries: true})
+
+	t.Run("all", func(t *testing.T) {
+		var first *LogEntry
+		i := 0
+		for entry, err := range parser.All(strings.NewReader(input)) {
+			if i == len(want) {
+				if err == nil || entry != nil {
+					t.Fatalf("Expected a nil entry and an error for the invalid timestamp, got %v, %v", entry, err)
+				}
+				break
+			}
+			if err != nil {
+				t.Fatalf("All() error = %v", err)
+			}
+			if first == nil {
+				first = entry
+			} else if entry != first {
+				t.Errorf("Entry %d: expected the reused entry %p, got %p", i, first, entry)
+			}
+			if entry.Content != want[i].Content || string(entry.RawLine) != string(want[i].RawLine) ||
+				entry.Group != want[i].Group || entry.LineNumber != want[i].LineNumber || !entry.Timestamp.Equal(want[i].Timestamp) {
+				t.Errorf("Entry %d: got %+v, want %+v", i, entry, want[i])
+			}
+			i++
+		}
+	})
+
+	t.Run("iterator", func(t *testing.T) {
+		iterator := parser.NewIterator(strings.NewReader(input))
+		var first *LogEntry
+		for i := 0; iterator.Next(); i++ {
+			entry := iterator.Entry()
+			if first == nil {
+				first = entry
+			} else if entry != first {
+				t.Errorf("Entry %d: expected the reused entry %p, got %p", i, first, entry)
+			}
+			if entry.Content != want[i].Content || entry.LineNumber != want[i].LineNumber {
+				t.Errorf("Entry %d: got %+v, want %+v", i, entry, want[i])
+			}
+		}
+		if iterator.Err() == nil {
+			t.Error("Expected an error for the invalid timestamp")
+		}
+	})
+
+	t.Run("command blocks keep their entries", func(t *testing.T) {
+		blocks := "\x1b_bk;t=1745322209921\x07$ make\nfirst\nsecond\n"
+		for block, err := range parser.CommandBlocks(strings.NewReader(blocks)) {
+			if err != nil {
+				t.Fatalf("CommandBlocks() error = %v", err)
+			}
+			if len(block.Entries) != 2 || block.Entries[0].Content != "first" || block.Entries[1].Content != "second" {
+				t.Errorf("Block entries = %+v, want first and second", block.Entries)
+			}
+		}
+	})
+
+	t.Run("export rejects reused entries", func(t *testing.T) {
+		err := ExportSeq2ToParquetWriter(parser.All(strings.NewReader(valid)), io.Discard, ParquetOptions{})
+		if !errors.Is(err, errReusedEntry) {
+			t.Errorf("ExportSeq2ToParquetWriter() error = %v, want %v", err, errReusedEntry)
+		}
+	})
+}
+
+// collectAll parses input with parser.All and collects the entries
+func collectAll(parser *Parser, input string) ([]*LogEntry, error) {
+	var entries []*LogEntry
+	for entry, err := range parser.All(strings.NewReader(input)) {
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, entry)
+	}
+	return entries, nil
+}
+
+func TestValidateTimestamps(t *testing.T) {
+	input := "\x1b_bk;t=1745322209921\x07~~~ Running tests\n" +
+		"\x1b_bk;t=1703462400000000\x07corrupt far future\n" +
+		"\x1b_bk;t=12\x07corrupt near the epoch\n" +
+		"\x1b_bk;t=1745322209922\x07$ make test\n"
+
+	parser := NewParserWithOptions(ParserOptions{ValidateTimestamps: true})
+
+	var entries []*LogEntry
+	for entry, err := range parser.All(strings.NewReader(input)) {
+		if err != nil {
+			t.Fatalf("All() error = %v", err)
+		}
+		entries = append(entries, entry)
+	}
+
+	wantSuspect := []bool{false, true, true, false}
+	wantContent := []string{"~~~ Running tests", "corrupt far future", "corrupt near the epoch", "$ make test"}
+	for i, entry := range entries {
+		if entry.TimestampSuspect != wantSuspect[i] {
+			t.Errorf("Entry %d: TimestampSuspect = %v, want %v", i, entry.TimestampSuspect, wantSuspect[i])
+		}
+		if entry.HasTimestamp() == wantSuspect[i] {
+			t.Errorf("Entry %d: HasTimestamp = %v, want %v", i, entry.HasTimestamp(), !wantSuspect[i])
+		}
+		if entry.Content != wantContent[i] {
+			t.Errorf("Entry %d: Content = %q, want %q", i, entry.Content, wantContent[i])
+		}
+	}
+
+	// Custom bounds, with suspect lines given synthetic timestamps following the last real one
+	parser = NewParserWithOptions(ParserOptions{
+		ValidateTimestamps:   true,
+		MinTimestamp:         time.UnixMilli(1745322209921),
+		MaxTimestamp:         time.UnixMilli(1745322209921),
+		SynthesizeTimestamps: true,
+	})
+	entries = entries[:0]
+	for entry, err := range parser.All(strings.NewReader(input)) {
+		if err != nil {
+			t.Fatalf("All() error = %v", err)
+		}
+		entries = append(entries, entry)
+	}
+	if !entries[3].TimestampSuspect || !entries[3].SyntheticTimestamp || entries[3].Timestamp.UnixMilli() != 1745322209924 {
+		t.Errorf("Entry 3: got suspect %v, synthetic %v at %d", entries[3].TimestampSuspect, entries[3].SyntheticTimestamp, entries[3].Timestamp.UnixMilli())
+	}
+
+	// JSON input is validated the same way
+	jsonInput := `{"timestamp":1745322209921,"content":"~~~ Running tests"}
+{"timestamp":1703462400000000,"content":"corrupt far future"}
+{"timestamp":"1970-01-01T00:00:00.012Z","content":"corrupt near the epoch"}
+{"timestamp":1745322209922,"content":"$ make test"}`
+	entries = entries[:0]
+	for entry, err := range NewParserWithOptions(ParserOptions{ValidateTimestamps: true}).ParseJSON(strings.NewReader(jsonInput)) {
+		if err != nil {
+			t.Fatalf("ParseJSON() error = %v", err)
+		}
+		entries = append(entries, entry)
+	}
+	for i, entry := range entries {
+		if entry.TimestampSuspect != wantSuspect[i] || entry.HasTimestamp() == wantSuspect[i] {
+			t.Errorf("JSON entry %d: TimestampSuspect = %v, HasTimestamp = %v, want suspect %v", i, entry.TimestampSuspect, entry.HasTimestamp(), wantSuspect[i])
+		}
+	}
+
+	// Without the option any parseable timestamp is kept
+	entry, err := NewParser().ParseLine("\x1b_bk;t=12\x07early")
+	if err != nil {
+		t.Fatalf("ParseLine() error = %v", err)
+	}
+	if entry.TimestampSuspect || entry.Timestamp.UnixMilli() != 12 {
+		t.Errorf("Timestamp = %d, TimestampSuspect = %v, want 12 and false", entry.Timestamp.UnixMilli(), entry.TimestampSuspect)
+	}
+}
+
+func TestDetectPercentProgress(t *testing.T) {
+	input := "\x1b_bk;t=1745322209921\x07~~~ Fetching artifacts\n" +
+		"\x1b_bk;t=1745322209922\x07Downloading... 10%\n" +
+		"\x1b_bk;t=1745322209923\x07Downloading... 20%\n" +
+		"\x1b_bk;t=1745322209924\x07Downloading... 100%\r\n" +
+		"\x1b_bk;t=1745322209925\x07Downloading... 100%\n" +
+		"\x1b_bk;t=1745322209926\x07Extracting 12.5%\n" +
+		"\x1b_bk;t=1745322209927\x07\x1b[32mExtracting\x1b[0m 50.0%\n" +
+		"\x1b_bk;t=1745322209928\x07coverage: 81%\n" +
+		"\x1b_bk;t=1745322209929\x07--- 90%\n" +
+		"\x1b_bk;t=1745322209930\x07--- 95%\n" +
+		"\x1b_bk;t=1745322209931\x07100% done"
+
+	wantProgress := []bool{
+		false, // group header
+		false, // first line of a run has nothing to compare with
+		true,
+		true,  // trailing carriage return ignored
+		false, // percentage unchanged
+		false, // different prefix
+		true,  // same prefix once ANSI is stripped
+		false,
+		false, // group headers are never progress, and start a new group
+		false,
+		false, // percentage isn't trailing
+	}
+
+	parser := NewParserWithOptions(ParserOptions{DetectPercentProgress: true})
+
+	var entries []*LogEntry
+	for entry, err := range parser.All(strings.NewReader(input)) {
+		if err != nil {
+			t.Fatalf("All() error = %v", err)
+		}
+		entries = append(entries, entry)
+	}
+	if len(entries) != len(wantProgress) {
+		t.Fatalf("Expected %d entries, got %d", len(wantProgress), len(entries))
+	}
+	for i, entry := range entries {
+		if entry.IsProgress() != wantProgress[i] {
+			t.Errorf("Entry %d (%q): IsProgress = %v, want %v", i, entry.Content, entry.IsProgress(), wantProgress[i])
+		}
+	}
+
+	// The flag is persisted in the is_progress column
+	filename := "test_percent_progress.parquet"
+	defer func() {
+		_ = os.Remove(filename)
+	}()
+	if err := ExportToParquet(entries, filename); err != nil {
+		t.Fatalf("ExportToParquet() error = %v", err)
+	}
+	for i, entry := range readAllParquetEntries(t, filename) {
+		if entry.IsProgress != wantProgress[i] {
+			t.Errorf("Row %d: is_progress = %v, want %v", i, entry.IsProgress, wantProgress[i])
+		}
+	}
+
+	// Off by default
+	for entry, err := range NewParser().All(strings.NewReader(input)) {
+		if err != nil {
+			t.Fatalf("All() error = %v", err)
+		}
+		if entry.IsProgress() {
+			t.Errorf("Unexpected progress without the option: %q", entry.Content)
+		}
+	}
+}
@@ -1,40 +1,74 @@
 package buildkitelogs
 
 import (
+	"bytes"
 	"context"
 	"fmt"
 	"io"
 	"iter"
 	"os"
+	"regexp"
+	"runtime"
+	"slices"
+	"sort"
 	"strings"
 	"time"
 
 	"github.com/apache/arrow-go/v18/arrow"
 	"github.com/apache/arrow-go/v18/arrow/array"
 	"github.com/apache/arrow-go/v18/arrow/memory"
+	"github.com/apache/arrow-go/v18/parquet"
 	"github.com/apache/arrow-go/v18/parquet/file"
+	"github.com/apache/arrow-go/v18/parquet/metadata"
 	"github.com/apache/arrow-go/v18/parquet/pqarrow"
 )
 
 // ParquetLogEntry represents a log entry read from a Parquet file
 type ParquetLogEntry struct {
-	Timestamp  int64  `json:"timestamp"`
-	Content    string `json:"content"`
-	Group      string `json:"group"`
-	HasTime    bool   `json:"has_timestamp"`
-	IsCommand  bool   `json:"is_command"`
-	IsGroup    bool   `json:"is_group"`
-	IsProgress bool   `json:"is_progress"`
+	Timestamp   int64  `json:"timestamp"`
+	Content     string `json:"content"`
+	Group       string `json:"group"`
+	HasTime     bool   `json:"has_timestamp"`
+	IsCommand   bool   `json:"is_command"`
+	IsGroup     bool   `json:"is_group"`
+	IsProgress  bool   `json:"is_progress"`
+	IsError     bool   `json:"is_error"`
+	RawLineSize int32  `json:"raw_line_size"`
+	LineNumber  int64  `json:"line_number"`
+	JobID       string `json:"job_id"`
+	JobName     string `json:"job_name"`
+	GroupIndex  int32  `json:"group_index"`           // Ordinal of the group header, -1 before the first group or if not recorded
+	ParentGroup string `json:"parent_group"`          // Enclosing "~~~" group, empty unless the parser tracked parent groups
+	RepeatCount int32  `json:"repeat_count"`          // Identical lines collapsed into this entry by ParserOptions.DedupeConsecutive
+	RawContent  string `json:"raw_content,omitempty"` // Content before ANSI codes were stripped, only with ParquetOptions.KeepRawContent
+}
+
+// CleanContent returns the content with ANSI codes stripped
+func (entry *ParquetLogEntry) CleanContent() string {
+	return NewByteParser().StripANSI(entry.Content)
 }
 
 // GroupInfo contains statistical information about a log group
 type GroupInfo struct {
+	JobID      string    `json:"job_id,omitempty"`
+	Index      int32     `json:"index"` // Ordinal of the group's first header, -1 for "<no group>" or if not recorded
 	Name       string    `json:"name"`
+	Parent     string    `json:"parent,omitempty"` // Enclosing "~~~" group of a sub-group when parent groups were tracked
 	EntryCount int       `json:"entry_count"`
 	FirstSeen  time.Time `json:"first_seen"`
 	LastSeen   time.Time `json:"last_seen"`
 	Commands   int       `json:"commands"`
 	Progress   int       `json:"progress"`
+	TotalBytes int64     `json:"total_bytes"` // Size of the group's original lines, or of their content in files without raw_line_size
+}
+
+// Duration returns the elapsed time between the first and last entry of the group,
+// or zero if either bound is unknown
+func (g GroupInfo) Duration() time.Duration {
+	if g.FirstSeen.IsZero() || g.LastSeen.IsZero() || g.LastSeen.Before(g.FirstSeen) {
+		return 0
+	}
+	return g.LastSeen.Sub(g.FirstSeen)
 }
 
 // QueryStats contains performance and result statistics for queries
@@ -58,23 +92,84 @@ type ParquetFileInfo struct {
 	ColumnCount  int   `json:"column_count"`
 	FileSize     int64 `json:"file_size_bytes"`
 	NumRowGroups int   `json:"num_row_groups"`
+	// SchemaVersion is the log schema version from the metadata, 1 for files written before it was recorded
+	SchemaVersion int               `json:"schema_version"`
+	Metadata      map[string]string `json:"metadata,omitempty"`
 }
 
 // ParquetReader provides functionality to read and query Parquet log files
+// It holds no state between calls, each iterator and query opens its own handle to the data, so a
+// single reader is safe for concurrent use by multiple goroutines.
 type ParquetReader struct {
 	filename string
+	source   parquetSource
 }
 
 // NewParquetReader creates a new ParquetReader for the specified file
 func NewParquetReader(filename string) *ParquetReader {
 	return &ParquetReader{
 		filename: filename,
+		source:   fileSource(filename),
+	}
+}
+
+// ReaderOptions configures a ParquetReader
+type ReaderOptions struct {
+	// TolerateTruncated reads the complete row groups of a file whose footer is missing or corrupt, such
+	// as an export that was killed part way through, instead of failing. Reads of a truncated file yield
+	// its rows and then an error wrapping ErrTruncated. The file's page headers are scanned on each read,
+	// so this is slower for truncated files, complete files are read as usual.
+	TolerateTruncated bool
+}
+
+// NewParquetReaderWithOptions creates a new ParquetReader for the specified file using the provided options
+func NewParquetReaderWithOptions(filename string, opts ReaderOptions) *ParquetReader {
+	pr := NewParquetReader(filename)
+	if opts.TolerateTruncated {
+		pr.source = tolerantSource(pr.source)
+	}
+	return pr
+}
+
+// NewParquetReaderFromReaderAt creates a new ParquetReader over Parquet data of the given size in bytes
+// This reads from object storage clients, or in-memory data via bytes.NewReader, without a local file.
+// Each read uses an independent section of r, so r must support concurrent ReadAt calls if iterators
+// are consumed concurrently. The sidecar group index is not used with this reader.
+func NewParquetReaderFromReaderAt(r io.ReaderAt, size int64) *ParquetReader {
+	return &ParquetReader{
+		source: readerAtSource(r, size),
 	}
 }
 
 // ReadEntriesIter returns an iterator over log entries from the Parquet file
 func (pr *ParquetReader) ReadEntriesIter() iter.Seq2[ParquetLogEntry, error] {
-	return readParquetFileIter(pr.filename)
+	return pr.ReadEntriesIterContext(context.Background())
+}
+
+// ReadEntriesIterContext returns an iterator over log entries that stops when ctx is done
+// The context is checked between record batches, a cancelled read yields an error wrapping ctx.Err().
+func (pr *ParquetReader) ReadEntriesIterContext(ctx context.Context) iter.Seq2[ParquetLogEntry, error] {
+	return readParquetFileStreamingIter(ctx, pr.source, 5000, streamOptions{})
+}
+
+// ReadColumnsIter returns an iterator over log entries decoding only the named columns
+// Fields for columns that are not requested, or not present in older files, are left as zero values.
+// Skipping the content column in particular makes aggregate queries much cheaper.
+// An empty column list reads every column.
+func (pr *ParquetReader) ReadColumnsIter(columns []string) iter.Seq2[ParquetLogEntry, error] {
+	if len(columns) == 0 {
+		return pr.ReadEntriesIter()
+	}
+
+	for _, column := range columns {
+		if !isLogColumn(column) {
+			return func(yield func(ParquetLogEntry, error) bool) {
+				yield(ParquetLogEntry{}, fmt.Errorf("unknown column %q", column))
+			}
+		}
+	}
+
+	return readParquetFileStreamingIter(context.Background(), pr.source, 5000, streamOptions{columns: columns})
 }
 
 // FilterByGroupIter returns an iterator over entries that belong to groups matching the specified name pattern
@@ -82,14 +177,296 @@ func (pr *ParquetReader) FilterByGroupIter(groupPattern string) iter.Seq2[Parque
 	return FilterByGroupIter(pr.ReadEntriesIter(), groupPattern)
 }
 
+// FilterByGroupsIter returns an iterator over entries whose group matches any of the name patterns
+func (pr *ParquetReader) FilterByGroupsIter(groupPatterns []string) iter.Seq2[ParquetLogEntry, error] {
+	return FilterByGroupsIter(pr.ReadEntriesIter(), groupPatterns)
+}
+
+// FilterByTimeRangeIter returns an iterator over entries with timestamps within [start, end]
+// Row groups whose timestamp statistics fall entirely outside the range are skipped without being decoded.
+func (pr *ParquetReader) FilterByTimeRangeIter(start, end time.Time) iter.Seq2[ParquetLogEntry, error] {
+	entries := readParquetFileStreamingIter(context.Background(), pr.source, 5000, streamOptions{
+		rowGroups: func(pf *file.Reader) ([]int, error) {
+			return rowGroupsInTimeRange(pf, start, end)
+		},
+	})
+	return FilterByTimeRangeIter(entries, start, end)
+}
+
+// FilterByGroupExactIter returns an iterator over entries whose group name equals groupName exactly
+// Unlike FilterByGroupIter this can use the row group statistics of the group column to skip row groups
+// whose min/max range can't contain the name, as well as any Bloom filters written with
+// ParquetOptions.BloomFilterGroups. This pays off on files with many row groups where a group
+// is confined to a few of them. Use "<no group>" to select entries outside any group.
+// If a current sidecar index built by BuildGroupIndex exists, the rows are read by seeking to the
+// group's first row instead.
+func (pr *ParquetReader) FilterByGroupExactIter(groupName string) iter.Seq2[ParquetLogEntry, error] {
+	target := groupName
+	if target == "<no group>" {
+		target = ""
+	}
+
+	return func(yield func(ParquetLogEntry, error) bool) {
+		if index := pr.currentGroupIndex(); index != nil {
+			for entry, err := range indexedGroupIter(pr.source, index, target) {
+				if !yield(entry, err) {
+					return
+				}
+			}
+			return
+		}
+
+		entries := readParquetFileStreamingIter(context.Background(), pr.source, 5000, streamOptions{
+			rowGroups: func(pf *file.Reader) ([]int, error) {
+				return rowGroupsContainingGroup(pf, target)
+			},
+		})
+
+		for entry, err := range entries {
+			if err != nil {
+				if !yield(ParquetLogEntry{}, err) {
+					return
+				}
+				continue
+			}
+
+			if entry.Group == target {
+				if !yield(entry, nil) {
+					return
+				}
+			}
+		}
+	}
+}
+
+// MayContainGroup reports whether the file may have entries in the group named groupName, reading only
+// the footer and the group column's Bloom filters. False means the group is definitely absent, so files
+// can be ruled out cheaply before querying them. Without Bloom filters, written when
+// ParquetOptions.BloomFilterGroups is set, only the row group min/max statistics are checked and true
+// is common. Use "<no group>" to check for entries outside any group.
+func (pr *ParquetReader) MayContainGroup(groupName string) (bool, error) {
+	target := groupName
+	if target == "<no group>" {
+		target = ""
+	}
+
+	r, _, release, err := pr.source()
+	if err != nil {
+		return false, err
+	}
+	defer release()
+
+	pf, err := file.NewParquetReader(r)
+	if err != nil {
+		return false, fmt.Errorf("failed to open parquet file: %w", err)
+	}
+	defer pf.Close()
+
+	rowGroups, err := rowGroupsContainingGroup(pf, target)
+	if err != nil {
+		return false, err
+	}
+	return len(rowGroups) > 0, nil
+}
+
+// FilterByContentIter returns an iterator over entries whose ANSI-stripped content matches the pattern
+// Substring matching is case-insensitive, mirroring FilterByGroupIter. An invalid regular expression
+// is yielded as a single error before the file is read.
+func (pr *ParquetReader) FilterByContentIter(pattern string, useRegex bool) iter.Seq2[ParquetLogEntry, error] {
+	filtered, err := FilterByContentIter(pr.ReadEntriesIter(), pattern, useRegex, true)
+	if err != nil {
+		return func(yield func(ParquetLogEntry, error) bool) {
+			yield(ParquetLogEntry{}, err)
+		}
+	}
+	return filtered
+}
+
+// groupHeaderColumns are the columns decoded by GroupHeadersIter
+var groupHeaderColumns = []string{"timestamp", "content", "group", "has_timestamp", "is_group", "line_number", "job_id", "job_name", "group_index", "parent_group"}
+
+// GroupHeadersIter returns an iterator over the group header entries in file order
+// Only the columns describing a header are decoded and row groups whose is_group statistics show
+// no headers are skipped, making this a cheap way to list the phases of a build. Unlike ListGroups
+// the header entries themselves are yielded rather than aggregated statistics.
+func (pr *ParquetReader) GroupHeadersIter() iter.Seq2[ParquetLogEntry, error] {
+	return func(yield func(ParquetLogEntry, error) bool) {
+		entries := readParquetFileStreamingIter(context.Background(), pr.source, 5000, streamOptions{
+			columns:   groupHeaderColumns,
+			rowGroups: rowGroupsWithGroupHeaders,
+		})
+
+		for entry, err := range entries {
+			if err != nil {
+				if !yield(ParquetLogEntry{}, err) {
+					return
+				}
+				continue
+			}
+
+			if entry.IsGroup {
+				if !yield(entry, nil) {
+					return
+				}
+			}
+		}
+	}
+}
+
 // SeekToRow returns an iterator starting from the specified row number (0-based)
 func (pr *ParquetReader) SeekToRow(startRow int64) iter.Seq2[ParquetLogEntry, error] {
-	return readParquetFileFromRowIter(pr.filename, startRow)
+	return pr.SeekToRowContext(context.Background(), startRow)
+}
+
+// SeekToRowContext returns an iterator starting from the specified row number that stops when ctx is done
+func (pr *ParquetReader) SeekToRowContext(ctx context.Context, startRow int64) iter.Seq2[ParquetLogEntry, error] {
+	return readParquetFileFromRowIter(ctx, pr.source, startRow)
+}
+
+// SeekToTimestamp returns an iterator starting from the first row with a timestamp at or after ts
+// Rows are in document order, which is usually but not always timestamp order, so rows after the start
+// may still be before ts. The timestamp statistics of the row groups narrow the search to the first row
+// group that can hold a later timestamp, whose timestamps are scanned to find the starting row. A time
+// before the first timestamp starts at the first timestamped row, a time after every timestamp yields nothing.
+func (pr *ParquetReader) SeekToTimestamp(ts time.Time) iter.Seq2[ParquetLogEntry, error] {
+	return pr.SeekToTimestampContext(context.Background(), ts)
+}
+
+// SeekToTimestampContext returns an iterator starting from the first row at or after ts that stops when ctx is done
+func (pr *ParquetReader) SeekToTimestampContext(ctx context.Context, ts time.Time) iter.Seq2[ParquetLogEntry, error] {
+	return func(yield func(ParquetLogEntry, error) bool) {
+		startRow, found, err := findTimestampRow(ctx, pr.source, ts)
+		if err != nil {
+			yield(ParquetLogEntry{}, err)
+			return
+		}
+		if !found {
+			return // Every row is before ts
+		}
+
+		for entry, err := range readParquetFileFromRowIter(ctx, pr.source, startRow) {
+			if !yield(entry, err) {
+				return
+			}
+		}
+	}
+}
+
+// DistinctGroups returns the unique group names in the order they first appear in the file
+// Only the group column is read. Entries outside any group are reported as "<no group>", the name
+// accepted by FilterByGroupExactIter.
+func (pr *ParquetReader) DistinctGroups() ([]string, error) {
+	var names []string
+	seen := make(map[string]bool)
+
+	for entry, err := range readParquetFileStreamingIter(context.Background(), pr.source, 5000, streamOptions{columns: []string{"group"}}) {
+		if err != nil {
+			return nil, err
+		}
+
+		name := entry.Group
+		if name == "" {
+			name = "<no group>"
+		}
+		if !seen[name] {
+			seen[name] = true
+			names = append(names, name)
+		}
+	}
+
+	return names, nil
+}
+
+// GroupStats returns statistics for each group, sorted by first seen time
+// Only the columns needed for the statistics are read, the content column is skipped.
+func (pr *ParquetReader) GroupStats() ([]GroupInfo, error) {
+	return readGroupStats(pr.source, false)
+}
+
+// GroupStatsParallel returns the same statistics as GroupStats, decoding row groups concurrently
+// Up to GOMAXPROCS row groups are read at once, each into partial statistics that are merged once
+// all are done. This speeds up files with many row groups, files with a single row group are read
+// sequentially.
+func (pr *ParquetReader) GroupStatsParallel() ([]GroupInfo, error) {
+	return readGroupStatsParallel(pr.source, false, runtime.GOMAXPROCS(0))
+}
+
+// GroupStatsByJob returns statistics for each group within each job, sorted by first seen time
+// Groups with the same name in different jobs are reported separately with their JobID set.
+func (pr *ParquetReader) GroupStatsByJob() ([]GroupInfo, error) {
+	return readGroupStats(pr.source, true)
+}
+
+// ReadEntries reads all log entries from the Parquet file into memory
+// Prefer ReadEntriesIter for large files, which streams with constant memory.
+func (pr *ParquetReader) ReadEntries() ([]ParquetLogEntry, error) {
+	return collectEntries(pr.ReadEntriesIter())
+}
+
+// ListGroups returns statistics for each group, sorted by first seen time
+func (pr *ParquetReader) ListGroups() ([]GroupInfo, error) {
+	return pr.GroupStats()
+}
+
+// FilterByGroup reads the entries in groups matching the name pattern into memory
+func (pr *ParquetReader) FilterByGroup(groupPattern string) ([]ParquetLogEntry, error) {
+	return collectEntries(pr.FilterByGroupIter(groupPattern))
+}
+
+// Query runs a list-groups or by-group operation, returning the results with query statistics
+// groupPattern is only used by the by-group operation.
+func (pr *ParquetReader) Query(operation, groupPattern string) (*QueryResult, error) {
+	start := time.Now()
+	result := &QueryResult{}
+
+	switch operation {
+	case "list-groups":
+		groups, err := pr.ListGroups()
+		if err != nil {
+			return nil, err
+		}
+		result.Groups = groups
+		result.Stats.TotalGroups = len(groups)
+		for _, group := range groups {
+			result.Stats.TotalEntries += group.EntryCount
+		}
+		result.Stats.MatchedEntries = result.Stats.TotalEntries
+	case "by-group":
+		if groupPattern == "" {
+			return nil, fmt.Errorf("group pattern is required for by-group operation")
+		}
+
+		// Count every entry while filtering so the total needs no second pass
+		total := 0
+		counted := func(yield func(ParquetLogEntry, error) bool) {
+			for entry, err := range pr.ReadEntriesIter() {
+				if err == nil {
+					total++
+				}
+				if !yield(entry, err) {
+					return
+				}
+			}
+		}
+
+		entries, err := collectEntries(FilterByGroupIter(counted, groupPattern))
+		if err != nil {
+			return nil, err
+		}
+		result.Entries = entries
+		result.Stats.TotalEntries = total
+		result.Stats.MatchedEntries = len(entries)
+	default:
+		return nil, fmt.Errorf("unknown operation: %s", operation)
+	}
+
+	result.Stats.QueryTime = float64(time.Since(start).Nanoseconds()) / 1e6
+	return result, nil
 }
 
 // GetFileInfo returns metadata about the Parquet file
 func (pr *ParquetReader) GetFileInfo() (*ParquetFileInfo, error) {
-	return getParquetFileInfo(pr.filename)
+	return getParquetFileInfo(pr.source)
 }
 
 // ReadParquetFileIter is a convenience function to get an iterator over entries from a Parquet file
@@ -97,13 +474,475 @@ func ReadParquetFileIter(filename string) iter.Seq2[ParquetLogEntry, error] {
 	return readParquetFileIter(filename)
 }
 
+// collectEntries drains an entry iterator into a slice, stopping at the first error
+func collectEntries(entries iter.Seq2[ParquetLogEntry, error]) ([]ParquetLogEntry, error) {
+	var result []ParquetLogEntry
+	for entry, err := range entries {
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, entry)
+	}
+	return result, nil
+}
+
 // readParquetFileIter reads a Parquet file and returns an iterator over log entries using streaming
 func readParquetFileIter(filename string) iter.Seq2[ParquetLogEntry, error] {
-	return readParquetFileStreamingIter(filename, 5000) // Use 5000 as default batch size
+	return readParquetFileStreamingIter(context.Background(), fileSource(filename), 5000, streamOptions{}) // Use 5000 as default batch size
+}
+
+// groupStatsColumns are the only columns decoded when computing group statistics
+var groupStatsColumns = []string{"timestamp", "has_timestamp", "group", "is_command", "is_progress", "raw_line_size", "job_id", "group_index", "parent_group"}
+
+// groupStatsProjection returns the columns to decode for group statistics
+// Files written before raw_line_size existed need the content column to total each group's bytes.
+func groupStatsProjection(hasRawLineSize bool) []string {
+	if hasRawLineSize {
+		return groupStatsColumns
+	}
+	return append(slices.Clone(groupStatsColumns), "content")
+}
+
+// readGroupStats streams the projected columns of a Parquet file, building statistics for each group
+func readGroupStats(src parquetSource, byJob bool) ([]GroupInfo, error) {
+	schema, err := readArrowSchema(src)
+	if err != nil {
+		return nil, err
+	}
+	columns := groupStatsProjection(len(schema.FieldIndices("raw_line_size")) > 0)
+
+	return aggregateGroups(readParquetFileStreamingIter(context.Background(), src, 5000, streamOptions{columns: columns}), byJob)
+}
+
+// aggregateGroups computes statistics for each group in the entries, sorted by first seen time
+func aggregateGroups(entries iter.Seq2[ParquetLogEntry, error], byJob bool) ([]GroupInfo, error) {
+	agg := newGroupAggregator(byJob)
+
+	for entry, err := range entries {
+		if err != nil {
+			return nil, fmt.Errorf("error reading entries: %w", err)
+		}
+		agg.add(entry)
+	}
+
+	return agg.sorted(), nil
+}
+
+// groupAggregator accumulates statistics for each group, keyed by group name and optionally job
+type groupAggregator struct {
+	byJob  bool
+	groups map[string]*GroupInfo
+}
+
+// newGroupAggregator creates an empty groupAggregator
+func newGroupAggregator(byJob bool) *groupAggregator {
+	return &groupAggregator{
+		byJob:  byJob,
+		groups: make(map[string]*GroupInfo),
+	}
+}
+
+// add counts an entry towards its group
+func (a *groupAggregator) add(entry ParquetLogEntry) {
+	groupName := entry.Group
+	if groupName == "" {
+		groupName = "<no group>"
+	}
+
+	key := groupName
+	var jobID string
+	if a.byJob {
+		jobID = entry.JobID
+		key = jobID + "\x00" + groupName
+	}
+
+	info, exists := a.groups[key]
+	if !exists {
+		info = &GroupInfo{
+			JobID: jobID,
+			Index: entry.GroupIndex,
+			Name:  groupName,
+		}
+		a.groups[key] = info
+
+		// A "~~~" group is its own entries' parent, but it is a top level group
+		if entry.ParentGroup != entry.Group {
+			info.Parent = entry.ParentGroup
+		}
+	}
+
+	// A name repeated in later headers keeps the ordinal of its first header
+	if entry.GroupIndex >= 0 && (info.Index < 0 || entry.GroupIndex < info.Index) {
+		info.Index = entry.GroupIndex
+	}
+
+	info.EntryCount++
+
+	// Entries without a timestamp don't move the group's time bounds
+	if entry.HasTime {
+		entryTime := time.Unix(0, entry.Timestamp*int64(time.Millisecond))
+		if info.FirstSeen.IsZero() || entryTime.Before(info.FirstSeen) {
+			info.FirstSeen = entryTime
+		}
+		if entryTime.After(info.LastSeen) {
+			info.LastSeen = entryTime
+		}
+	}
+
+	if entry.IsCommand {
+		info.Commands++
+	}
+	if entry.IsProgress {
+		info.Progress++
+	}
+
+	// Files without the raw_line_size column fall back to the size of the content
+	if entry.RawLineSize > 0 {
+		info.TotalBytes += int64(entry.RawLineSize)
+	} else {
+		info.TotalBytes += int64(len(entry.Content))
+	}
+}
+
+// merge folds the statistics of entries that came after this aggregator's entries into it
+// Merging in file order keeps the parent recorded from each group's first entry.
+func (a *groupAggregator) merge(later *groupAggregator) {
+	for key, other := range later.groups {
+		info, exists := a.groups[key]
+		if !exists {
+			copied := *other
+			a.groups[key] = &copied
+			continue
+		}
+
+		if other.Index >= 0 && (info.Index < 0 || other.Index < info.Index) {
+			info.Index = other.Index
+		}
+		info.EntryCount += other.EntryCount
+		if !other.FirstSeen.IsZero() && (info.FirstSeen.IsZero() || other.FirstSeen.Before(info.FirstSeen)) {
+			info.FirstSeen = other.FirstSeen
+		}
+		if other.LastSeen.After(info.LastSeen) {
+			info.LastSeen = other.LastSeen
+		}
+		info.Commands += other.Commands
+		info.Progress += other.Progress
+		info.TotalBytes += other.TotalBytes
+	}
+}
+
+// sorted returns the group statistics sorted by first seen time
+func (a *groupAggregator) sorted() []GroupInfo {
+	groups := make([]GroupInfo, 0, len(a.groups))
+	for _, info := range a.groups {
+		groups = append(groups, *info)
+	}
+
+	sort.Slice(groups, func(i, j int) bool {
+		if !groups[i].FirstSeen.Equal(groups[j].FirstSeen) {
+			return groups[i].FirstSeen.Before(groups[j].FirstSeen)
+		}
+		if groups[i].JobID != groups[j].JobID {
+			return groups[i].JobID < groups[j].JobID
+		}
+		return groups[i].Name < groups[j].Name
+	})
+
+	return groups
+}
+
+// isLogColumn returns true if the name is a column of the log entry schema
+func isLogColumn(name string) bool {
+	return createArrowSchema().HasField(name)
+}
+
+// projectColumns resolves column names to Parquet column indices, skipping columns the file doesn't have
+func projectColumns(pf *file.Reader, columns []string) []int {
+	indices := make([]int, 0, len(columns))
+	for _, column := range columns {
+		if idx := pf.MetaData().Schema.ColumnIndexByName(column); idx >= 0 {
+			indices = append(indices, idx)
+		}
+	}
+	return indices
+}
+
+// rowGroupsInTimeRange returns the row groups whose timestamp statistics overlap [start, end]
+// Row groups without usable statistics are always included.
+func rowGroupsInTimeRange(pf *file.Reader, start, end time.Time) ([]int, error) {
+	rowGroups := make([]int, 0, pf.NumRowGroups())
+
+	timestampIdx := pf.MetaData().Schema.ColumnIndexByName("timestamp")
+	for i := 0; i < pf.NumRowGroups(); i++ {
+		if timestampIdx < 0 {
+			rowGroups = append(rowGroups, i)
+			continue
+		}
+
+		chunk, err := pf.MetaData().RowGroup(i).ColumnChunk(timestampIdx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read row group %d metadata: %w", i, err)
+		}
+
+		stats, err := chunk.Statistics()
+		if err != nil {
+			return nil, fmt.Errorf("failed to read row group %d statistics: %w", i, err)
+		}
+
+		tsStats, ok := stats.(*metadata.Int64Statistics)
+		if !ok || !tsStats.HasMinMax() {
+			rowGroups = append(rowGroups, i)
+			continue
+		}
+
+		if time.UnixMilli(tsStats.Max()).Before(start) || time.UnixMilli(tsStats.Min()).After(end) {
+			continue // Entirely outside the range
+		}
+
+		rowGroups = append(rowGroups, i)
+	}
+
+	return rowGroups, nil
+}
+
+// findTimestampRow returns the first row with a timestamp at or after ts, and false if there is none
+func findTimestampRow(ctx context.Context, src parquetSource, ts time.Time) (int64, bool, error) {
+	r, _, release, err := src()
+	if err != nil {
+		return 0, false, err
+	}
+	defer release()
+
+	pf, err := file.NewParquetReader(r)
+	if err != nil {
+		return 0, false, fmt.Errorf("failed to open parquet file: %w", err)
+	}
+	defer pf.Close()
+
+	first, err := firstRowGroupAtOrAfter(pf, ts)
+	if err != nil {
+		return 0, false, err
+	}
+	if first == pf.NumRowGroups() {
+		return 0, false, nil
+	}
+
+	var startRow int64
+	rowGroups := make([]int, 0, pf.NumRowGroups()-first)
+	for i := range pf.NumRowGroups() {
+		if i < first {
+			startRow += pf.MetaData().RowGroup(i).NumRows()
+		} else {
+			rowGroups = append(rowGroups, i)
+		}
+	}
+
+	// Scan the timestamps from the row group, continuing past it in case its statistics were missing
+	target := ts.UnixMilli()
+	entries := readParquetFileStreamingIter(ctx, src, 5000, streamOptions{
+		columns:   []string{"timestamp", "has_timestamp"},
+		rowGroups: func(*file.Reader) ([]int, error) { return rowGroups, nil },
+	})
+	for entry, err := range entries {
+		if err != nil {
+			return 0, false, err
+		}
+		if entry.HasTime && entry.Timestamp >= target {
+			return startRow, true, nil
+		}
+		startRow++
+	}
+
+	return 0, false, nil
+}
+
+// firstRowGroupAtOrAfter returns the first row group that may contain a timestamp at or after ts,
+// or the number of row groups if none can. Row groups are binary searched on their maximum timestamp
+// when the maximums never decrease, and scanned in order otherwise or if any lack usable statistics.
+func firstRowGroupAtOrAfter(pf *file.Reader, ts time.Time) (int, error) {
+	timestampIdx := pf.MetaData().Schema.ColumnIndexByName("timestamp")
+	if timestampIdx < 0 {
+		return 0, nil
+	}
+
+	// Maximum timestamp of each row group, false when unknown
+	maxes := make([]int64, pf.NumRowGroups())
+	known := make([]bool, pf.NumRowGroups())
+	for i := range pf.NumRowGroups() {
+		chunk, err := pf.MetaData().RowGroup(i).ColumnChunk(timestampIdx)
+		if err != nil {
+			return 0, fmt.Errorf("failed to read row group %d metadata: %w", i, err)
+		}
+
+		stats, err := chunk.Statistics()
+		if err != nil {
+			return 0, fmt.Errorf("failed to read row group %d statistics: %w", i, err)
+		}
+
+		if tsStats, ok := stats.(*metadata.Int64Statistics); ok && tsStats.HasMinMax() {
+			maxes[i], known[i] = tsStats.Max(), true
+		}
+	}
+
+	target := ts.UnixMilli()
+	mayContain := func(i int) bool {
+		return !known[i] || maxes[i] >= target
+	}
+
+	// Rows are in document order, so the maximums can only be binary searched if they happen to be sorted
+	if slices.Contains(known, false) || !slices.IsSorted(maxes) {
+		for i := range maxes {
+			if mayContain(i) {
+				return i, nil
+			}
+		}
+		return len(maxes), nil
+	}
+	return sort.Search(len(maxes), mayContain), nil
+}
+
+// rowGroupsContainingGroup returns the row groups whose group column statistics could contain groupName
+// Row groups without usable statistics are always included.
+func rowGroupsContainingGroup(pf *file.Reader, groupName string) ([]int, error) {
+	rowGroups := make([]int, 0, pf.NumRowGroups())
+	target := []byte(groupName)
+
+	groupIdx := pf.MetaData().Schema.ColumnIndexByName("group")
+	for i := 0; i < pf.NumRowGroups(); i++ {
+		if groupIdx < 0 {
+			rowGroups = append(rowGroups, i)
+			continue
+		}
+
+		chunk, err := pf.MetaData().RowGroup(i).ColumnChunk(groupIdx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read row group %d metadata: %w", i, err)
+		}
+
+		stats, err := chunk.Statistics()
+		if err != nil {
+			return nil, fmt.Errorf("failed to read row group %d statistics: %w", i, err)
+		}
+
+		groupStats, ok := stats.(*metadata.ByteArrayStatistics)
+		if !ok || !groupStats.HasMinMax() {
+			rowGroups = append(rowGroups, i)
+			continue
+		}
+
+		if bytes.Compare(target, groupStats.Min()) < 0 || bytes.Compare(target, groupStats.Max()) > 0 {
+			continue // Outside the row group's range of group names
+		}
+
+		mayContain, err := bloomFilterMayContain(pf, i, groupIdx, target)
+		if err != nil {
+			return nil, err
+		}
+		if mayContain {
+			rowGroups = append(rowGroups, i)
+		}
+	}
+
+	return rowGroups, nil
+}
+
+// bloomFilterMayContain checks value against a column's Bloom filter in a row group
+// It returns true when the column chunk has no Bloom filter, see ParquetOptions.BloomFilterGroups.
+func bloomFilterMayContain(pf *file.Reader, rowGroup, column int, value []byte) (bool, error) {
+	rgFilters, err := pf.GetBloomFilterReader().RowGroup(rowGroup)
+	if err != nil {
+		return false, fmt.Errorf("failed to read row group %d bloom filters: %w", rowGroup, err)
+	}
+
+	filter, err := rgFilters.GetColumnBloomFilter(column)
+	if err != nil {
+		return false, fmt.Errorf("failed to read row group %d bloom filter: %w", rowGroup, err)
+	}
+	if filter == nil {
+		return true, nil
+	}
+
+	return filter.CheckHash(metadata.GetHash(filter.Hasher(), parquet.ByteArray(value))), nil
+}
+
+// rowGroupsWithGroupHeaders returns the row groups whose is_group statistics show they may contain a header
+// Row groups without usable statistics are always included.
+func rowGroupsWithGroupHeaders(pf *file.Reader) ([]int, error) {
+	rowGroups := make([]int, 0, pf.NumRowGroups())
+
+	isGroupIdx := pf.MetaData().Schema.ColumnIndexByName("is_group")
+	for i := 0; i < pf.NumRowGroups(); i++ {
+		if isGroupIdx < 0 {
+			rowGroups = append(rowGroups, i)
+			continue
+		}
+
+		chunk, err := pf.MetaData().RowGroup(i).ColumnChunk(isGroupIdx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read row group %d metadata: %w", i, err)
+		}
+
+		stats, err := chunk.Statistics()
+		if err != nil {
+			return nil, fmt.Errorf("failed to read row group %d statistics: %w", i, err)
+		}
+
+		boolStats, ok := stats.(*metadata.BooleanStatistics)
+		if !ok || !boolStats.HasMinMax() {
+			rowGroups = append(rowGroups, i)
+			continue
+		}
+
+		if !boolStats.Max() {
+			continue // No group headers in this row group
+		}
+
+		rowGroups = append(rowGroups, i)
+	}
+
+	return rowGroups, nil
+}
+
+// streamOptions controls which parts of a Parquet file are decoded when streaming
+type streamOptions struct {
+	// columns limits decoding to the named columns, nil reads every column
+	columns []string
+	// rowGroups selects the row groups to read, nil reads every row group
+	rowGroups func(pf *file.Reader) ([]int, error)
+}
+
+// parquetSource opens the underlying Parquet data for a single read
+// It returns the reader, the size of the data in bytes and a function releasing the reader.
+type parquetSource func() (parquet.ReaderAtSeeker, int64, func(), error)
+
+// fileSource returns a parquetSource that opens the named file for each read
+func fileSource(filename string) parquetSource {
+	return func() (parquet.ReaderAtSeeker, int64, func(), error) {
+		osFile, err := os.Open(filename)
+		if err != nil {
+			return nil, 0, nil, fmt.Errorf("failed to open file: %w", err)
+		}
+
+		fileInfo, err := osFile.Stat()
+		if err != nil {
+			_ = osFile.Close()
+			return nil, 0, nil, fmt.Errorf("failed to get file info: %w", err)
+		}
+
+		return osFile, fileInfo.Size(), func() { _ = osFile.Close() }, nil
+	}
+}
+
+// readerAtSource returns a parquetSource reading from r
+// Each read gets its own section reader so seeks don't interfere, and the Parquet reader can't close r.
+func readerAtSource(r io.ReaderAt, size int64) parquetSource {
+	return func() (parquet.ReaderAtSeeker, int64, func(), error) {
+		return io.NewSectionReader(r, 0, size), size, func() {}, nil
+	}
 }
 
 // readParquetFileStreamingIter reads a Parquet file using GetRecordReader for true streaming
-func readParquetFileStreamingIter(filename string, batchSize int64) iter.Seq2[ParquetLogEntry, error] {
+func readParquetFileStreamingIter(ctx context.Context, src parquetSource, batchSize int64, opts streamOptions) iter.Seq2[ParquetLogEntry, error] {
 	return func(yield func(ParquetLogEntry, error) bool) {
 		// Resource management with proper cleanup order
 		resources := make([]func(), 0)
@@ -113,19 +952,19 @@ func readParquetFileStreamingIter(filename string, batchSize int64) iter.Seq2[Pa
 			}
 		}()
 
-		// Open the Parquet file
-		osFile, err := os.Open(filename)
+		// Open the Parquet data
+		r, _, release, err := src()
 		if err != nil {
-			yield(ParquetLogEntry{}, fmt.Errorf("failed to open file: %w", err))
+			yield(ParquetLogEntry{}, err)
 			return
 		}
-		resources = append(resources, func() { _ = osFile.Close() })
+		resources = append(resources, release)
 
 		// Create a memory pool
 		pool := memory.NewGoAllocator()
 
 		// Create a Parquet file reader using Arrow v18 API
-		pf, err := file.NewParquetReader(osFile)
+		pf, err := file.NewParquetReader(r)
 		if err != nil {
 			yield(ParquetLogEntry{}, fmt.Errorf("failed to open parquet file: %w", err))
 			return
@@ -133,7 +972,6 @@ func readParquetFileStreamingIter(filename string, batchSize int64) iter.Seq2[Pa
 		resources = append(resources, func() { _ = pf.Close() })
 
 		// Create an Arrow file reader with streaming configuration
-		ctx := context.Background()
 		arrowReader, err := pqarrow.NewFileReader(pf, pqarrow.ArrowReadProperties{
 			BatchSize: batchSize, // Configure batch size for streaming
 		}, pool)
@@ -142,8 +980,28 @@ func readParquetFileStreamingIter(filename string, batchSize int64) iter.Seq2[Pa
 			return
 		}
 
-		// Get record reader for true streaming (all columns, all row groups)
-		recordReader, err := arrowReader.GetRecordReader(ctx, nil, nil)
+		// Get record reader for true streaming (projected columns and row groups if requested)
+		var colIndices []int
+		if opts.columns != nil {
+			colIndices = projectColumns(pf, opts.columns)
+			if len(colIndices) == 0 {
+				return // None of the requested columns exist in this file
+			}
+		}
+
+		var rowGroups []int
+		if opts.rowGroups != nil {
+			rowGroups, err = opts.rowGroups(pf)
+			if err != nil {
+				yield(ParquetLogEntry{}, err)
+				return
+			}
+			if len(rowGroups) == 0 {
+				return // No row groups can contain matching entries
+			}
+		}
+
+		recordReader, err := arrowReader.GetRecordReader(ctx, colIndices, rowGroups)
 		if err != nil {
 			yield(ParquetLogEntry{}, fmt.Errorf("failed to create record reader: %w", err))
 			return
@@ -155,9 +1013,18 @@ func readParquetFileStreamingIter(filename string, batchSize int64) iter.Seq2[Pa
 
 		// Stream records in batches
 		for {
+			if err := ctx.Err(); err != nil {
+				yield(ParquetLogEntry{}, fmt.Errorf("read cancelled: %w", err))
+				return
+			}
+
 			record, err := recordReader.Read()
 			if err != nil {
 				if err == io.EOF {
+					// Reads of a truncated file end with a warning after its rows
+					if warning := truncationWarning(r); warning != nil {
+						yield(ParquetLogEntry{}, warning)
+					}
 					break // Normal end of file
 				}
 				yield(ParquetLogEntry{}, fmt.Errorf("error reading record: %w", err))
@@ -166,7 +1033,11 @@ func readParquetFileStreamingIter(filename string, batchSize int64) iter.Seq2[Pa
 
 			// Initialize column mapping on first record
 			if columnIndices == nil {
-				columnIndices, err = mapColumns(record.Schema())
+				if opts.columns != nil {
+					columnIndices = mapColumnIndices(record.Schema())
+				} else {
+					columnIndices, err = mapColumns(record.Schema())
+				}
 				if err != nil {
 					record.Release()
 					yield(ParquetLogEntry{}, err)
@@ -196,14 +1067,26 @@ func readParquetFileStreamingIter(filename string, batchSize int64) iter.Seq2[Pa
 
 // columnMapping holds column indices for efficient access
 type columnMapping struct {
-	timestampIdx, contentIdx, groupIdx, hasTimeIdx, isCmdIdx, isGroupIdx, isProgIdx int
+	timestampIdx, contentIdx, groupIdx, hasTimeIdx, isCmdIdx, isGroupIdx, isProgIdx, isErrorIdx, rawLineSizeIdx, lineNumberIdx, jobIDIdx, jobNameIdx, groupIndexIdx, parentGroupIdx, repeatCountIdx, rawContentIdx int
 }
 
-// mapColumns maps column names to indices from schema
+// mapColumns maps column names to indices from schema, requiring timestamp and content
 func mapColumns(schema *arrow.Schema) (*columnMapping, error) {
+	mapping := mapColumnIndices(schema)
+
+	if mapping.timestampIdx == -1 || mapping.contentIdx == -1 {
+		return nil, fmt.Errorf("required columns 'timestamp' and 'content' not found")
+	}
+
+	return mapping, nil
+}
+
+// mapColumnIndices maps column names to indices from schema, using -1 for missing columns
+func mapColumnIndices(schema *arrow.Schema) *columnMapping {
 	mapping := &columnMapping{
 		timestampIdx: -1, contentIdx: -1, groupIdx: -1, hasTimeIdx: -1,
-		isCmdIdx: -1, isGroupIdx: -1, isProgIdx: -1,
+		isCmdIdx: -1, isGroupIdx: -1, isProgIdx: -1, isErrorIdx: -1, rawLineSizeIdx: -1, lineNumberIdx: -1,
+		jobIDIdx: -1, jobNameIdx: -1, groupIndexIdx: -1, parentGroupIdx: -1, repeatCountIdx: -1, rawContentIdx: -1,
 	}
 
 	for i, field := range schema.Fields() {
@@ -222,14 +1105,28 @@ func mapColumns(schema *arrow.Schema) (*columnMapping, error) {
 			mapping.isGroupIdx = i
 		case "is_progress":
 			mapping.isProgIdx = i
+		case "is_error":
+			mapping.isErrorIdx = i
+		case "raw_line_size":
+			mapping.rawLineSizeIdx = i
+		case "line_number":
+			mapping.lineNumberIdx = i
+		case "job_id":
+			mapping.jobIDIdx = i
+		case "job_name":
+			mapping.jobNameIdx = i
+		case "group_index":
+			mapping.groupIndexIdx = i
+		case "parent_group":
+			mapping.parentGroupIdx = i
+		case "repeat_count":
+			mapping.repeatCountIdx = i
+		case "raw_content":
+			mapping.rawContentIdx = i
 		}
 	}
 
-	if mapping.timestampIdx == -1 || mapping.contentIdx == -1 {
-		return nil, fmt.Errorf("required columns 'timestamp' and 'content' not found")
-	}
-
-	return mapping, nil
+	return mapping
 }
 
 // convertRecordToEntriesIterStreaming converts an Arrow record to an iterator over ParquetLogEntry with column mapping
@@ -238,10 +1135,15 @@ func convertRecordToEntriesIterStreaming(record arrow.Record, mapping *columnMap
 		numRows := int(record.NumRows())
 
 		// Get column arrays
-		timestampCol := record.Column(mapping.timestampIdx)
-		contentCol := record.Column(mapping.contentIdx)
+		var timestampCol, contentCol arrow.Array
+		if mapping.timestampIdx >= 0 {
+			timestampCol = record.Column(mapping.timestampIdx)
+		}
+		if mapping.contentIdx >= 0 {
+			contentCol = record.Column(mapping.contentIdx)
+		}
 
-		var groupCol, hasTimeCol, isCmdCol, isGroupCol, isProgCol arrow.Array
+		var groupCol, hasTimeCol, isCmdCol, isGroupCol, isProgCol, isErrorCol, rawLineSizeCol, lineNumberCol, jobIDCol, jobNameCol, groupIndexCol, parentGroupCol, repeatCountCol, rawContentCol arrow.Array
 		if mapping.groupIdx >= 0 {
 			groupCol = record.Column(mapping.groupIdx)
 		}
@@ -257,47 +1159,68 @@ func convertRecordToEntriesIterStreaming(record arrow.Record, mapping *columnMap
 		if mapping.isProgIdx >= 0 {
 			isProgCol = record.Column(mapping.isProgIdx)
 		}
+		if mapping.isErrorIdx >= 0 {
+			isErrorCol = record.Column(mapping.isErrorIdx)
+		}
+		if mapping.rawLineSizeIdx >= 0 {
+			rawLineSizeCol = record.Column(mapping.rawLineSizeIdx)
+		}
+		if mapping.lineNumberIdx >= 0 {
+			lineNumberCol = record.Column(mapping.lineNumberIdx)
+		}
+		if mapping.jobIDIdx >= 0 {
+			jobIDCol = record.Column(mapping.jobIDIdx)
+		}
+		if mapping.jobNameIdx >= 0 {
+			jobNameCol = record.Column(mapping.jobNameIdx)
+		}
+		if mapping.groupIndexIdx >= 0 {
+			groupIndexCol = record.Column(mapping.groupIndexIdx)
+		}
+		if mapping.parentGroupIdx >= 0 {
+			parentGroupCol = record.Column(mapping.parentGroupIdx)
+		}
+		if mapping.repeatCountIdx >= 0 {
+			repeatCountCol = record.Column(mapping.repeatCountIdx)
+		}
+		if mapping.rawContentIdx >= 0 {
+			rawContentCol = record.Column(mapping.rawContentIdx)
+		}
 
 		// Convert each row
 		for i := 0; i < numRows; i++ {
-			entry := ParquetLogEntry{}
+			entry := ParquetLogEntry{GroupIndex: -1}
 
-			// Timestamp (required)
-			if timestampCol.IsNull(i) {
+			// Timestamp (required unless projected out)
+			if timestampCol == nil || timestampCol.IsNull(i) {
 				entry.Timestamp = 0
 			} else {
 				switch ts := timestampCol.(type) {
 				case *array.Int64:
 					entry.Timestamp = ts.Value(i)
+				case *array.Timestamp:
+					entry.Timestamp = timestampMillis(ts.Value(i), ts.DataType().(*arrow.TimestampType).Unit)
 				default:
 					yield(ParquetLogEntry{}, fmt.Errorf("unexpected timestamp column type: %T", timestampCol))
 					return
 				}
 			}
 
-			// Content (required)
-			if contentCol.IsNull(i) {
+			// Content (required unless projected out)
+			if contentCol == nil || contentCol.IsNull(i) {
 				entry.Content = ""
 			} else {
-				switch content := contentCol.(type) {
-				case *array.String:
-					entry.Content = content.Value(i)
-				case *array.Binary:
-					entry.Content = string(content.Value(i))
-				default:
+				content, ok := stringValue(contentCol, i)
+				if !ok {
 					yield(ParquetLogEntry{}, fmt.Errorf("unexpected content column type: %T", contentCol))
 					return
 				}
+				entry.Content = content
 			}
 
 			// Group (optional)
 			if groupCol != nil && !groupCol.IsNull(i) {
-				switch group := groupCol.(type) {
-				case *array.String:
-					entry.Group = group.Value(i)
-				case *array.Binary:
-					entry.Group = string(group.Value(i))
-				}
+				entry.Group, _ = stringValue(groupCol, i)
 			}
 
 			// Boolean fields (optional)
@@ -321,7 +1244,125 @@ func convertRecordToEntriesIterStreaming(record arrow.Record, mapping *columnMap
 					entry.IsProgress = boolCol.Value(i)
 				}
 			}
+			if isErrorCol != nil && !isErrorCol.IsNull(i) {
+				if boolCol, ok := isErrorCol.(*array.Boolean); ok {
+					entry.IsError = boolCol.Value(i)
+				}
+			}
+
+			// Raw line size (optional, missing in files written before it was added)
+			if rawLineSizeCol != nil && !rawLineSizeCol.IsNull(i) {
+				if sizeCol, ok := rawLineSizeCol.(*array.Int32); ok {
+					entry.RawLineSize = sizeCol.Value(i)
+				}
+			}
+
+			// Line number (optional, missing in files written before it was added)
+			if lineNumberCol != nil && !lineNumberCol.IsNull(i) {
+				if numCol, ok := lineNumberCol.(*array.Int64); ok {
+					entry.LineNumber = numCol.Value(i)
+				}
+			}
+
+			// Job ID and name (optional, missing in files written before they were added)
+			if jobIDCol != nil && !jobIDCol.IsNull(i) {
+				entry.JobID, _ = stringValue(jobIDCol, i)
+			}
+			if jobNameCol != nil && !jobNameCol.IsNull(i) {
+				entry.JobName, _ = stringValue(jobNameCol, i)
+			}
+
+			// Group index (optional, missing in files written before it was added)
+			if groupIndexCol != nil && !groupIndexCol.IsNull(i) {
+				if indexCol, ok := groupIndexCol.(*array.Int32); ok {
+					entry.GroupIndex = indexCol.Value(i)
+				}
+			}
 
+			// Parent group (optional, missing in files written before it was added)
+			if parentGroupCol != nil && !parentGroupCol.IsNull(i) {
+				entry.ParentGroup, _ = stringValue(parentGroupCol, i)
+			}
+
+			// Repeat count (optional, missing in files written before it was added)
+			if repeatCountCol != nil && !repeatCountCol.IsNull(i) {
+				if countCol, ok := repeatCountCol.(*array.Int32); ok {
+					entry.RepeatCount = countCol.Value(i)
+				}
+			}
+
+			// Raw content (optional, only written with ParquetOptions.KeepRawContent)
+			if rawContentCol != nil && !rawContentCol.IsNull(i) {
+				entry.RawContent, _ = stringValue(rawContentCol, i)
+			}
+
+			if !yield(entry, nil) {
+				return
+			}
+		}
+	}
+}
+
+// timestampMillis converts a timestamp in the given unit to Unix milliseconds
+func timestampMillis(value arrow.Timestamp, unit arrow.TimeUnit) int64 {
+	switch unit {
+	case arrow.Second:
+		return int64(value) * 1000
+	case arrow.Microsecond:
+		return int64(value) / 1000
+	case arrow.Nanosecond:
+		return int64(value) / int64(time.Millisecond)
+	default:
+		return int64(value)
+	}
+}
+
+// stringValue returns row i of a string-like column, resolving dictionary encoded values
+// Files written by other tools may use binary, large or dictionary encoded types for string columns.
+// It returns false if the column isn't a string-like type.
+func stringValue(col arrow.Array, i int) (string, bool) {
+	switch col := col.(type) {
+	case *array.String:
+		return col.Value(i), true
+	case *array.LargeString:
+		return col.Value(i), true
+	case *array.Binary:
+		return string(col.Value(i)), true
+	case *array.LargeBinary:
+		return string(col.Value(i)), true
+	case *array.Dictionary:
+		return stringValue(col.Dictionary(), col.GetValueIndex(i))
+	default:
+		return "", false
+	}
+}
+
+// ReadParquetFile reads all log entries from a Parquet file into memory
+// Prefer ReadParquetFileIter for large files, which streams with constant memory.
+func ReadParquetFile(filename string) ([]ParquetLogEntry, error) {
+	return collectEntries(ReadParquetFileIter(filename))
+}
+
+// ListGroups computes statistics for each group in the entries, sorted by first seen time
+func ListGroups(entries []ParquetLogEntry) []GroupInfo {
+	// The slice iterator never yields an error
+	groups, _ := aggregateGroups(sliceEntriesIter(entries), false)
+	return groups
+}
+
+// FilterByGroup returns the entries that belong to groups matching the specified pattern
+func FilterByGroup(entries []ParquetLogEntry, groupPattern string) []ParquetLogEntry {
+	var result []ParquetLogEntry
+	for entry := range FilterByGroupIter(sliceEntriesIter(entries), groupPattern) {
+		result = append(result, entry)
+	}
+	return result
+}
+
+// sliceEntriesIter adapts a slice of entries to an entry iterator
+func sliceEntriesIter(entries []ParquetLogEntry) iter.Seq2[ParquetLogEntry, error] {
+	return func(yield func(ParquetLogEntry, error) bool) {
+		for _, entry := range entries {
 			if !yield(entry, nil) {
 				return
 			}
@@ -331,6 +1372,18 @@ func convertRecordToEntriesIterStreaming(record arrow.Record, mapping *columnMap
 
 // FilterByGroupIter returns an iterator over entries that belong to groups matching the specified pattern
 func FilterByGroupIter(entries iter.Seq2[ParquetLogEntry, error], groupPattern string) iter.Seq2[ParquetLogEntry, error] {
+	return FilterByGroupsIter(entries, []string{groupPattern})
+}
+
+// FilterByGroupsIter returns an iterator over entries whose group matches any of the patterns
+// Patterns are case-insensitive substrings as in FilterByGroupIter, so several groups can be
+// selected in a single pass. An empty pattern list matches nothing.
+func FilterByGroupsIter(entries iter.Seq2[ParquetLogEntry, error], groupPatterns []string) iter.Seq2[ParquetLogEntry, error] {
+	lowerPatterns := make([]string, len(groupPatterns))
+	for i, pattern := range groupPatterns {
+		lowerPatterns[i] = strings.ToLower(pattern)
+	}
+
 	return func(yield func(ParquetLogEntry, error) bool) {
 		for entry, err := range entries {
 			if err != nil {
@@ -344,33 +1397,96 @@ func FilterByGroupIter(entries iter.Seq2[ParquetLogEntry, error], groupPattern s
 			if entryGroup == "" {
 				entryGroup = "<no group>"
 			}
+			entryGroup = strings.ToLower(entryGroup)
+
+			for _, pattern := range lowerPatterns {
+				if strings.Contains(entryGroup, pattern) {
+					if !yield(entry, nil) {
+						return
+					}
+					break
+				}
+			}
+		}
+	}
+}
+
+// FilterByContentIter returns an iterator over entries whose content matches the pattern
+// The pattern is a case-insensitive substring unless useRegex is set, in which case it is compiled
+// once up front and an invalid expression is returned as an error. When stripANSI is set, escape
+// sequences are removed from the content before matching.
+func FilterByContentIter(entries iter.Seq2[ParquetLogEntry, error], pattern string, useRegex bool, stripANSI bool) (iter.Seq2[ParquetLogEntry, error], error) {
+	var matches func(string) bool
+	if useRegex {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid content pattern %q: %w", pattern, err)
+		}
+		matches = re.MatchString
+	} else {
+		lowerPattern := strings.ToLower(pattern)
+		matches = func(content string) bool {
+			return strings.Contains(strings.ToLower(content), lowerPattern)
+		}
+	}
+
+	return func(yield func(ParquetLogEntry, error) bool) {
+		for entry, err := range entries {
+			if err != nil {
+				if !yield(ParquetLogEntry{}, err) {
+					return
+				}
+				continue
+			}
 
-			if strings.Contains(strings.ToLower(entryGroup), strings.ToLower(groupPattern)) {
+			content := entry.Content
+			if stripANSI {
+				content = entry.CleanContent()
+			}
+
+			if matches(content) {
 				if !yield(entry, nil) {
 					return
 				}
 			}
 		}
-	}
+	}, nil
 }
 
-// getParquetFileInfo returns metadata about the Parquet file
-func getParquetFileInfo(filename string) (*ParquetFileInfo, error) {
-	// Open the file to get file size
-	osFile, err := os.Open(filename)
-	if err != nil {
-		return nil, fmt.Errorf("failed to open file: %w", err)
+// FilterByTimeRangeIter returns an iterator over entries with timestamps within [start, end]
+func FilterByTimeRangeIter(entries iter.Seq2[ParquetLogEntry, error], start, end time.Time) iter.Seq2[ParquetLogEntry, error] {
+	return func(yield func(ParquetLogEntry, error) bool) {
+		for entry, err := range entries {
+			if err != nil {
+				if !yield(ParquetLogEntry{}, err) {
+					return
+				}
+				continue
+			}
+
+			ts := time.Unix(0, entry.Timestamp*int64(time.Millisecond))
+			if ts.Before(start) || ts.After(end) {
+				continue
+			}
+
+			if !yield(entry, nil) {
+				return
+			}
+		}
 	}
-	defer osFile.Close()
+}
 
-	// Get file size
-	fileInfo, err := osFile.Stat()
+// getParquetFileInfo returns metadata about the Parquet file
+func getParquetFileInfo(src parquetSource) (*ParquetFileInfo, error) {
+	// Open the Parquet data, which also reports its size
+	r, size, release, err := src()
 	if err != nil {
-		return nil, fmt.Errorf("failed to get file info: %w", err)
+		return nil, err
 	}
+	defer release()
 
 	// Create Parquet file reader
-	pf, err := file.NewParquetReader(osFile)
+	pf, err := file.NewParquetReader(r)
 	if err != nil {
 		return nil, fmt.Errorf("failed to open parquet file: %w", err)
 	}
@@ -388,15 +1504,33 @@ func getParquetFileInfo(filename string) (*ParquetFileInfo, error) {
 	info := &ParquetFileInfo{
 		RowCount:     metadata.GetNumRows(),
 		ColumnCount:  columnCount,
-		FileSize:     fileInfo.Size(),
+		FileSize:     size,
 		NumRowGroups: metadata.NumRowGroups(),
 	}
 
+	// Key-value metadata, skipping the serialized Arrow schema if present
+	kv := metadata.KeyValueMetadata()
+	info.SchemaVersion, err = parseSchemaVersion(kv)
+	if err != nil {
+		return nil, err
+	}
+
+	if kv.Len() > 0 {
+		info.Metadata = make(map[string]string, kv.Len())
+		keys, values := kv.Keys(), kv.Values()
+		for i, key := range keys {
+			if key == "ARROW:schema" {
+				continue
+			}
+			info.Metadata[key] = values[i]
+		}
+	}
+
 	return info, nil
 }
 
 // readParquetFileFromRowIter reads a Parquet file starting from a specific row
-func readParquetFileFromRowIter(filename string, startRow int64) iter.Seq2[ParquetLogEntry, error] {
+func readParquetFileFromRowIter(ctx context.Context, src parquetSource, startRow int64) iter.Seq2[ParquetLogEntry, error] {
 	return func(yield func(ParquetLogEntry, error) bool) {
 		// Resource management with proper cleanup order
 		resources := make([]func(), 0)
@@ -406,19 +1540,19 @@ func readParquetFileFromRowIter(filename string, startRow int64) iter.Seq2[Parqu
 			}
 		}()
 
-		// Open the Parquet file
-		osFile, err := os.Open(filename)
+		// Open the Parquet data
+		r, _, release, err := src()
 		if err != nil {
-			yield(ParquetLogEntry{}, fmt.Errorf("failed to open file: %w", err))
+			yield(ParquetLogEntry{}, err)
 			return
 		}
-		resources = append(resources, func() { _ = osFile.Close() })
+		resources = append(resources, release)
 
 		// Create a memory pool
 		pool := memory.NewGoAllocator()
 
 		// Create a Parquet file reader using Arrow v18 API
-		pf, err := file.NewParquetReader(osFile)
+		pf, err := file.NewParquetReader(r)
 		if err != nil {
 			yield(ParquetLogEntry{}, fmt.Errorf("failed to open parquet file: %w", err))
 			return
@@ -433,7 +1567,6 @@ func readParquetFileFromRowIter(filename string, startRow int64) iter.Seq2[Parqu
 		}
 
 		// Create an Arrow file reader
-		ctx := context.Background()
 		arrowReader, err := pqarrow.NewFileReader(pf, pqarrow.ArrowReadProperties{
 			BatchSize: 5000, // Default batch size
 		}, pool)
@@ -463,9 +1596,18 @@ func readParquetFileFromRowIter(filename string, startRow int64) iter.Seq2[Parqu
 
 		// Stream records in batches starting from the seek position
 		for {
+			if err := ctx.Err(); err != nil {
+				yield(ParquetLogEntry{}, fmt.Errorf("read cancelled: %w", err))
+				return
+			}
+
 			record, err := recordReader.Read()
 			if err != nil {
 				if err == io.EOF {
+					// Reads of a truncated file end with a warning after its rows
+					if warning := truncationWarning(r); warning != nil {
+						yield(ParquetLogEntry{}, warning)
+					}
 					break // Normal end of file
 				}
 				yield(ParquetLogEntry{}, fmt.Errorf("error reading record: %w", err))
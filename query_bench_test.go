@@ -359,3 +359,81 @@ func BenchmarkStreamingScalability(b *testing.B) {
 		}
 	})
 }
+
+// BenchmarkReadColumnsIter compares a full read against a projection that skips the content column
+func BenchmarkReadColumnsIter(b *testing.B) {
+	testFile := "testdata/bun_build_19487_windows-x64-build-cpp.parquet"
+	if _, err := os.Stat(testFile); os.IsNotExist(err) {
+		b.Skip("test data not found")
+	}
+
+	reader := NewParquetReader(testFile)
+
+	b.Run("AllColumns", func(b *testing.B) {
+		b.ReportAllocs()
+		for b.Loop() {
+			for _, err := range reader.ReadEntriesIter() {
+				if err != nil {
+					b.Fatalf("ReadEntriesIter failed: %v", err)
+				}
+			}
+		}
+	})
+
+	b.Run("GroupColumns", func(b *testing.B) {
+		columns := []string{"timestamp", "group", "is_command", "is_progress"}
+		b.ReportAllocs()
+		for b.Loop() {
+			for _, err := range reader.ReadColumnsIter(columns) {
+				if err != nil {
+					b.Fatalf("ReadColumnsIter failed: %v", err)
+				}
+			}
+		}
+	})
+}
+
+// BenchmarkGroupStats measures computing group statistics from the projected columns
+func BenchmarkGroupStats(b *testing.B) {
+	testFile := "testdata/bun_build_19487_windows-x64-build-cpp.parquet"
+	if _, err := os.Stat(testFile); os.IsNotExist(err) {
+		b.Skip("test data not found")
+	}
+
+	reader := NewParquetReader(testFile)
+
+	b.ReportAllocs()
+	for b.Loop() {
+		if _, err := reader.GroupStats(); err != nil {
+			b.Fatalf("GroupStats failed: %v", err)
+		}
+	}
+}
+
+// BenchmarkGroupStatsParallel compares sequential and concurrent row group decoding for group statistics
+func BenchmarkGroupStatsParallel(b *testing.B) {
+	testFile := "testdata/bun_build_19487_windows-x64-build-cpp.parquet"
+	if _, err := os.Stat(testFile); os.IsNotExist(err) {
+		b.Skip("test data not found")
+	}
+
+	reader := NewParquetReader(testFile)
+
+	b.Run("sequential", func(b *testing.B) {
+		b.ReportAllocs()
+		for b.Loop() {
+			if _, err := reader.GroupStats(); err != nil {
+				b.Fatalf("GroupStats failed: %v", err)
+			}
+		}
+	})
+
+	b.Run("parallel", func(b *testing.B) {
+		b.ReportAllocs()
+		for b.Loop() {
+			if _, err := reader.GroupStatsParallel(); err != nil {
+				b.Fatalf("GroupStatsParallel failed: %v", err)
+			}
+		}
+	})
+}
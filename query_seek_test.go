@@ -1,8 +1,12 @@
 package buildkitelogs
 
 import (
+	"context"
+	"fmt"
 	"os"
+	"strings"
 	"testing"
+	"time"
 )
 
 func TestParquetReader_GetFileInfo(t *testing.T) {
@@ -149,7 +153,7 @@ func TestReadParquetFileFromRowIter_Standalone(t *testing.T) {
 
 	// Test seeking to row 0
 	entryCount := 0
-	for entry, err := range readParquetFileFromRowIter(testFile, 0) {
+	for entry, err := range readParquetFileFromRowIter(context.Background(), fileSource(testFile), 0) {
 		if err != nil {
 			t.Fatalf("readParquetFileFromRowIter failed: %v", err)
 		}
@@ -178,7 +182,7 @@ func TestGetParquetFileInfo_Standalone(t *testing.T) {
 		t.Skip("test data not found")
 	}
 
-	info, err := getParquetFileInfo(testFile)
+	info, err := getParquetFileInfo(fileSource(testFile))
 	if err != nil {
 		t.Fatalf("getParquetFileInfo failed: %v", err)
 	}
@@ -198,8 +202,98 @@ func TestGetParquetFileInfo_Standalone(t *testing.T) {
 }
 
 func TestGetParquetFileInfo_NonExistent(t *testing.T) {
-	_, err := getParquetFileInfo("nonexistent.parquet")
+	_, err := getParquetFileInfo(fileSource("nonexistent.parquet"))
 	if err == nil {
 		t.Error("Expected error for non-existent file")
 	}
 }
+
+func TestParquetReader_SeekToTimestamp(t *testing.T) {
+	// 100 lines 10ms apart, with untimestamped lines after every tenth, over row groups of 16 rows
+	var testData strings.Builder
+	for i := range 100 {
+		fmt.Fprintf(&testData, "\x1b_bk;t=%d\x07line %d\n", 1745322200000+int64(i)*10, i)
+		if i%10 == 0 {
+			fmt.Fprintf(&testData, "continued %d\n", i)
+		}
+	}
+
+	filename := "test_seek_to_timestamp.parquet"
+	defer func() {
+		_ = os.Remove(filename)
+	}()
+	if err := ExportSeq2ToParquetWithOptions(NewParser().All(strings.NewReader(testData.String())), filename, ParquetOptions{RowGroupSize: 16}); err != nil {
+		t.Fatalf("ExportSeq2ToParquetWithOptions() error = %v", err)
+	}
+
+	reader := NewParquetReader(filename)
+	if info, err := reader.GetFileInfo(); err != nil || info.NumRowGroups < 5 {
+		t.Fatalf("GetFileInfo() = %+v, %v, want several row groups", info, err)
+	}
+
+	tests := []struct {
+		name        string
+		ts          time.Time
+		wantFirst   string
+		wantEntries int
+	}{
+		{name: "exact", ts: time.UnixMilli(1745322200500), wantFirst: "line 50", wantEntries: 55},
+		{name: "between rows", ts: time.UnixMilli(1745322200505), wantFirst: "line 51", wantEntries: 53},
+		{name: "row group boundary", ts: time.UnixMilli(1745322200150), wantFirst: "line 15", wantEntries: 93},
+		{name: "before first", ts: time.UnixMilli(1745322100000), wantFirst: "line 0", wantEntries: 110},
+		{name: "last", ts: time.UnixMilli(1745322200990), wantFirst: "line 99", wantEntries: 1},
+		{name: "after last", ts: time.UnixMilli(1745322201000), wantEntries: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var entries []ParquetLogEntry
+			for entry, err := range reader.SeekToTimestamp(tt.ts) {
+				if err != nil {
+					t.Fatalf("SeekToTimestamp() error = %v", err)
+				}
+				entries = append(entries, entry)
+			}
+
+			if len(entries) != tt.wantEntries {
+				t.Fatalf("SeekToTimestamp() returned %d entries, want %d", len(entries), tt.wantEntries)
+			}
+			if len(entries) > 0 && entries[0].Content != tt.wantFirst {
+				t.Errorf("SeekToTimestamp() first entry = %q, want %q", entries[0].Content, tt.wantFirst)
+			}
+		})
+	}
+}
+
+func TestParquetReader_SeekToTimestampUnsorted(t *testing.T) {
+	// Row groups of 4 rows in document order, where the second ends with a late timestamp so the maximum
+	// timestamps of the row groups aren't sorted
+	offsets := []int64{0, 10, 20, 100, 110, 120, 130, 500, 140, 150, 160, 200, 210, 220, 230, 300}
+	var testData strings.Builder
+	for i, offset := range offsets {
+		fmt.Fprintf(&testData, "\x1b_bk;t=%d\x07line %d\n", 1745322200000+offset, i)
+	}
+
+	filename := "test_seek_to_timestamp_unsorted.parquet"
+	defer func() {
+		_ = os.Remove(filename)
+	}()
+	if err := ExportSeq2ToParquetWithOptions(NewParser().All(strings.NewReader(testData.String())), filename, ParquetOptions{RowGroupSize: 4}); err != nil {
+		t.Fatalf("ExportSeq2ToParquetWithOptions() error = %v", err)
+	}
+
+	var entries []ParquetLogEntry
+	for entry, err := range NewParquetReader(filename).SeekToTimestamp(time.UnixMilli(1745322200250)) {
+		if err != nil {
+			t.Fatalf("SeekToTimestamp() error = %v", err)
+		}
+		entries = append(entries, entry)
+	}
+
+	if len(entries) != 9 {
+		t.Fatalf("SeekToTimestamp() returned %d entries, want 9", len(entries))
+	}
+	if entries[0].Content != "line 7" {
+		t.Errorf("SeekToTimestamp() first entry = %q, want %q", entries[0].Content, "line 7")
+	}
+}
@@ -1,9 +1,22 @@
 package buildkitelogs
 
 import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
 	"os"
+	"reflect"
+	"slices"
+	"strings"
+	"sync"
 	"testing"
 	"time"
+
+	"github.com/apache/arrow-go/v18/arrow"
+	"github.com/apache/arrow-go/v18/arrow/array"
+	"github.com/apache/arrow-go/v18/arrow/memory"
+	"github.com/apache/arrow-go/v18/parquet/file"
 )
 
 func TestParquetReader(t *testing.T) {
@@ -291,6 +304,43 @@ func TestFilterByGroupIter_Standalone(t *testing.T) {
 	}
 }
 
+func TestFilterByGroupsIter(t *testing.T) {
+	testEntries := []ParquetLogEntry{
+		{Content: "Cloning", Group: "~~~ Preparing working directory"},
+		{Content: "Outside any group"},
+		{Content: "go test ./...", Group: "+++ Running TESTS"},
+		{Content: "Uploading", Group: "~~~ Uploading artifacts"},
+	}
+
+	tests := []struct {
+		name     string
+		patterns []string
+		want     []string
+	}{
+		{"single pattern", []string{"working"}, []string{"Cloning"}},
+		{"any of several patterns", []string{"working", "tests"}, []string{"Cloning", "go test ./..."}},
+		{"entry matching two patterns is yielded once", []string{"running", "tests"}, []string{"go test ./..."}},
+		{"no group", []string{"<no group>", "upload"}, []string{"Outside any group", "Uploading"}},
+		{"no patterns", nil, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			for entry, err := range FilterByGroupsIter(sliceEntriesIter(testEntries), tt.patterns) {
+				if err != nil {
+					t.Fatalf("FilterByGroupsIter failed: %v", err)
+				}
+				got = append(got, entry.Content)
+			}
+
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("FilterByGroupsIter(%q) = %q, want %q", tt.patterns, got, tt.want)
+			}
+		})
+	}
+}
+
 func TestReadParquetFileIter(t *testing.T) {
 	testFile := "test_logs.parquet"
 	if _, err := os.Stat(testFile); os.IsNotExist(err) {
@@ -431,3 +481,972 @@ func indexOf(s, substr string) int {
 	}
 	return -1
 }
+
+func TestReadColumnsIter(t *testing.T) {
+	testFile := "testdata/bash-example.parquet"
+	reader := NewParquetReader(testFile)
+
+	var full []ParquetLogEntry
+	for entry, err := range reader.ReadEntriesIter() {
+		if err != nil {
+			t.Fatalf("ReadEntriesIter failed: %v", err)
+		}
+		full = append(full, entry)
+	}
+
+	var projected []ParquetLogEntry
+	for entry, err := range reader.ReadColumnsIter([]string{"timestamp", "group", "is_command"}) {
+		if err != nil {
+			t.Fatalf("ReadColumnsIter failed: %v", err)
+		}
+		projected = append(projected, entry)
+	}
+
+	if len(projected) != len(full) {
+		t.Fatalf("Expected %d projected entries, got %d", len(full), len(projected))
+	}
+
+	for i := range full {
+		if projected[i].Timestamp != full[i].Timestamp {
+			t.Errorf("Entry %d: Timestamp = %d, want %d", i, projected[i].Timestamp, full[i].Timestamp)
+		}
+		if projected[i].Group != full[i].Group {
+			t.Errorf("Entry %d: Group = %q, want %q", i, projected[i].Group, full[i].Group)
+		}
+		if projected[i].IsCommand != full[i].IsCommand {
+			t.Errorf("Entry %d: IsCommand = %v, want %v", i, projected[i].IsCommand, full[i].IsCommand)
+		}
+
+		// Columns that were not requested come back as zero values
+		if projected[i].Content != "" || projected[i].IsGroup || projected[i].RawLineSize != 0 {
+			t.Fatalf("Entry %d: expected unrequested columns to be zero, got %+v", i, projected[i])
+		}
+	}
+}
+
+func TestReadColumnsIterUnknownColumn(t *testing.T) {
+	reader := NewParquetReader("testdata/bash-example.parquet")
+
+	for _, err := range reader.ReadColumnsIter([]string{"timestamp", "not_a_column"}) {
+		if err == nil {
+			t.Fatal("Expected error for unknown column")
+		}
+		if !strings.Contains(err.Error(), `unknown column "not_a_column"`) {
+			t.Errorf("Unexpected error: %v", err)
+		}
+		return
+	}
+	t.Fatal("Expected an error to be yielded")
+}
+
+func TestFilterByTimeRangeIter(t *testing.T) {
+	testFile := "testdata/bazel-bazel_build_32517_rocky-rocky-linux-8.parquet"
+	reader := NewParquetReader(testFile)
+
+	// Window sits strictly inside the timestamp range of the fourth row group
+	start := time.UnixMilli(1751321971326)
+	end := time.UnixMilli(1751322618522)
+
+	var expected int
+	for entry, err := range reader.ReadEntriesIter() {
+		if err != nil {
+			t.Fatalf("ReadEntriesIter failed: %v", err)
+		}
+		if entry.Timestamp >= start.UnixMilli() && entry.Timestamp <= end.UnixMilli() {
+			expected++
+		}
+	}
+	if expected == 0 {
+		t.Fatal("Expected test window to contain entries")
+	}
+
+	var matched int
+	for entry, err := range reader.FilterByTimeRangeIter(start, end) {
+		if err != nil {
+			t.Fatalf("FilterByTimeRangeIter failed: %v", err)
+		}
+		ts := time.UnixMilli(entry.Timestamp)
+		if ts.Before(start) || ts.After(end) {
+			t.Errorf("Entry timestamp %v outside range [%v, %v]", ts, start, end)
+		}
+		matched++
+	}
+
+	if matched != expected {
+		t.Errorf("Expected %d entries in range, got %d", expected, matched)
+	}
+}
+
+func TestRowGroupsInTimeRange(t *testing.T) {
+	pf, err := file.OpenParquetFile("testdata/bazel-bazel_build_32517_rocky-rocky-linux-8.parquet", false)
+	if err != nil {
+		t.Fatalf("Failed to open parquet file: %v", err)
+	}
+	defer pf.Close()
+
+	tests := []struct {
+		name       string
+		start, end time.Time
+		expected   []int
+	}{
+		{
+			name:     "single row group",
+			start:    time.UnixMilli(1751321971326),
+			end:      time.UnixMilli(1751322618522),
+			expected: []int{3},
+		},
+		{
+			name:     "spans row groups",
+			start:    time.UnixMilli(1751325833632),
+			end:      time.UnixMilli(1751325996720),
+			expected: []int{9, 10},
+		},
+		{
+			name:     "before file",
+			start:    time.UnixMilli(0),
+			end:      time.UnixMilli(1751321141257),
+			expected: []int{},
+		},
+		{
+			name:     "after file",
+			start:    time.UnixMilli(1751325997971),
+			end:      time.UnixMilli(1751325999999),
+			expected: []int{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rowGroups, err := rowGroupsInTimeRange(pf, tt.start, tt.end)
+			if err != nil {
+				t.Fatalf("rowGroupsInTimeRange failed: %v", err)
+			}
+			if !slices.Equal(rowGroups, tt.expected) {
+				t.Errorf("Expected row groups %v, got %v", tt.expected, rowGroups)
+			}
+		})
+	}
+}
+
+func TestFilterByContentIter(t *testing.T) {
+	entries := []ParquetLogEntry{
+		{Content: "Running tests"},
+		{Content: "\x1b[31mError:\x1b[0m build failed"},
+		{Content: "ERROR: exit status 1"},
+		{Content: "all good"},
+	}
+	seq := func(yield func(ParquetLogEntry, error) bool) {
+		for _, entry := range entries {
+			if !yield(entry, nil) {
+				return
+			}
+		}
+	}
+
+	tests := []struct {
+		name      string
+		pattern   string
+		useRegex  bool
+		stripANSI bool
+		expected  int
+	}{
+		{name: "substring is case-insensitive", pattern: "error:", expected: 2},
+		{name: "substring across ANSI codes", pattern: "error: build", stripANSI: true, expected: 1},
+		{name: "substring without stripping", pattern: "error: build", expected: 0},
+		{name: "regex", pattern: `^ERROR: exit status \d+$`, useRegex: true, expected: 1},
+		{name: "regex is case-sensitive", pattern: `error`, useRegex: true, expected: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			filtered, err := FilterByContentIter(seq, tt.pattern, tt.useRegex, tt.stripANSI)
+			if err != nil {
+				t.Fatalf("FilterByContentIter failed: %v", err)
+			}
+
+			count := 0
+			for _, err := range filtered {
+				if err != nil {
+					t.Fatalf("Unexpected error: %v", err)
+				}
+				count++
+			}
+
+			if count != tt.expected {
+				t.Errorf("Expected %d matches, got %d", tt.expected, count)
+			}
+		})
+	}
+}
+
+func TestFilterByContentIterInvalidRegex(t *testing.T) {
+	if _, err := FilterByContentIter(nil, "(unclosed", true, false); err == nil {
+		t.Error("Expected error for invalid regex")
+	}
+
+	reader := NewParquetReader("testdata/bash-example.parquet")
+
+	count := 0
+	for _, err := range reader.FilterByContentIter("(unclosed", true) {
+		count++
+		if err == nil || !strings.Contains(err.Error(), "invalid content pattern") {
+			t.Errorf("Expected invalid content pattern error, got %v", err)
+		}
+	}
+	if count != 1 {
+		t.Errorf("Expected a single error, got %d results", count)
+	}
+}
+
+func TestParquetReaderFilterByContentIter(t *testing.T) {
+	reader := NewParquetReader("testdata/bash-example.parquet")
+
+	var expected int
+	for entry, err := range reader.ReadEntriesIter() {
+		if err != nil {
+			t.Fatalf("ReadEntriesIter failed: %v", err)
+		}
+		if strings.Contains(strings.ToLower(entry.CleanContent()), "docker") {
+			expected++
+		}
+	}
+	if expected == 0 {
+		t.Fatal("Expected test file to contain matching entries")
+	}
+
+	var matched int
+	for entry, err := range reader.FilterByContentIter("DOCKER", false) {
+		if err != nil {
+			t.Fatalf("FilterByContentIter failed: %v", err)
+		}
+		if !strings.Contains(strings.ToLower(entry.CleanContent()), "docker") {
+			t.Errorf("Entry does not match pattern: %q", entry.Content)
+		}
+		matched++
+	}
+
+	if matched != expected {
+		t.Errorf("Expected %d matches, got %d", expected, matched)
+	}
+}
+
+func TestGroupInfoDuration(t *testing.T) {
+	start := time.UnixMilli(1745322209921)
+
+	tests := []struct {
+		name     string
+		group    GroupInfo
+		expected time.Duration
+	}{
+		{
+			name:     "elapsed",
+			group:    GroupInfo{FirstSeen: start, LastSeen: start.Add(1500 * time.Millisecond)},
+			expected: 1500 * time.Millisecond,
+		},
+		{
+			name:     "no timestamps",
+			group:    GroupInfo{},
+			expected: 0,
+		},
+		{
+			name:     "reversed bounds",
+			group:    GroupInfo{FirstSeen: start, LastSeen: start.Add(-time.Second)},
+			expected: 0,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.group.Duration(); got != tt.expected {
+				t.Errorf("Duration() = %v, want %v", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestGroupStats(t *testing.T) {
+	reader := NewParquetReader("testdata/bash-example.parquet")
+
+	groups, err := reader.GroupStats()
+	if err != nil {
+		t.Fatalf("GroupStats failed: %v", err)
+	}
+
+	// Compute the expected counts from a full read
+	expected := make(map[string]int)
+	total := 0
+	for entry, err := range reader.ReadEntriesIter() {
+		if err != nil {
+			t.Fatalf("ReadEntriesIter failed: %v", err)
+		}
+		name := entry.Group
+		if name == "" {
+			name = "<no group>"
+		}
+		expected[name]++
+		total++
+	}
+
+	if len(groups) != len(expected) {
+		t.Fatalf("Expected %d groups, got %d", len(expected), len(groups))
+	}
+
+	sum := 0
+	for i, group := range groups {
+		if group.EntryCount != expected[group.Name] {
+			t.Errorf("Group %q: EntryCount = %d, want %d", group.Name, group.EntryCount, expected[group.Name])
+		}
+		if i > 0 && group.FirstSeen.Before(groups[i-1].FirstSeen) {
+			t.Errorf("Groups not sorted by first seen at index %d", i)
+		}
+		sum += group.EntryCount
+	}
+
+	if sum != total {
+		t.Errorf("Expected group entry counts to sum to %d, got %d", total, sum)
+	}
+}
+
+func TestGroupStatsParallel(t *testing.T) {
+	// Many row groups, so workers each aggregate several and the partials must be merged
+	testFile := "testdata/bun_build_19487_windows-x64-build-cpp.parquet"
+	src := fileSource(testFile)
+
+	for _, byJob := range []bool{false, true} {
+		expected, err := readGroupStats(src, byJob)
+		if err != nil {
+			t.Fatalf("readGroupStats failed: %v", err)
+		}
+
+		for _, workers := range []int{1, 4, 200} {
+			groups, err := readGroupStatsParallel(src, byJob, workers)
+			if err != nil {
+				t.Fatalf("readGroupStatsParallel(%d) failed: %v", workers, err)
+			}
+			if !slices.EqualFunc(groups, expected, func(a, b GroupInfo) bool {
+				return a.JobID == b.JobID && a.Index == b.Index && a.Name == b.Name && a.Parent == b.Parent &&
+					a.EntryCount == b.EntryCount && a.FirstSeen.Equal(b.FirstSeen) && a.LastSeen.Equal(b.LastSeen) &&
+					a.Commands == b.Commands && a.Progress == b.Progress
+			}) {
+				t.Errorf("readGroupStatsParallel(byJob=%v, workers=%d) = %+v, want %+v", byJob, workers, groups, expected)
+			}
+		}
+	}
+
+	if _, err := NewParquetReader("testdata/missing.parquet").GroupStatsParallel(); err == nil {
+		t.Error("Expected an error for a missing file")
+	}
+}
+
+func TestFilterByGroupExactIter(t *testing.T) {
+	testFile := "testdata/bazel-bazel_build_32517_rocky-rocky-linux-8.parquet"
+	reader := NewParquetReader(testFile)
+
+	// Group names in this file carry the carriage return from the original CRLF log
+	groupName := "--- :gcloud: Uploading JSON Profile\r"
+
+	var expected int
+	for entry, err := range reader.ReadEntriesIter() {
+		if err != nil {
+			t.Fatalf("ReadEntriesIter failed: %v", err)
+		}
+		if entry.Group == groupName {
+			expected++
+		}
+	}
+	if expected == 0 {
+		t.Fatal("Expected test file to contain the group")
+	}
+
+	var matched int
+	for entry, err := range reader.FilterByGroupExactIter(groupName) {
+		if err != nil {
+			t.Fatalf("FilterByGroupExactIter failed: %v", err)
+		}
+		if entry.Group != groupName {
+			t.Errorf("Unexpected group %q", entry.Group)
+		}
+		matched++
+	}
+
+	if matched != expected {
+		t.Errorf("Expected %d entries, got %d", expected, matched)
+	}
+}
+
+func TestParquetReader_MayContainGroup(t *testing.T) {
+	export := func(t *testing.T, filename string, bloom bool) *ParquetReader {
+		logFile, err := os.Open("testdata/bash-example.log")
+		if err != nil {
+			t.Fatalf("Failed to open log: %v", err)
+		}
+		defer logFile.Close()
+
+		opts := ParquetOptions{RowGroupSize: 50, BloomFilterGroups: bloom}
+		if err := ExportSeq2ToParquetWithOptions(NewParser().All(logFile), filename, opts); err != nil {
+			t.Fatalf("ExportSeq2ToParquetWithOptions() error = %v", err)
+		}
+		return NewParquetReader(filename)
+	}
+
+	defer func() {
+		_ = os.Remove("test_bloom.parquet")
+		_ = os.Remove("test_no_bloom.parquet")
+	}()
+	withBloom := export(t, "test_bloom.parquet", true)
+	withoutBloom := export(t, "test_no_bloom.parquet", false)
+
+	groups, err := withBloom.DistinctGroups()
+	if err != nil {
+		t.Fatalf("DistinctGroups() error = %v", err)
+	}
+	if len(groups) < 2 {
+		t.Fatalf("Expected several groups, got %v", groups)
+	}
+
+	for _, group := range groups {
+		for _, reader := range []*ParquetReader{withBloom, withoutBloom} {
+			ok, err := reader.MayContainGroup(group)
+			if err != nil {
+				t.Fatalf("MayContainGroup() error = %v", err)
+			}
+			if !ok {
+				t.Errorf("MayContainGroup(%q) = false for a group in the file", group)
+			}
+		}
+	}
+
+	// Sorts between existing groups, so min/max statistics alone can't rule it out
+	absent := groups[0] + " (not run)"
+	ok, err := withBloom.MayContainGroup(absent)
+	if err != nil {
+		t.Fatalf("MayContainGroup() error = %v", err)
+	}
+	if ok {
+		t.Errorf("MayContainGroup(%q) = true, expected the Bloom filter to rule it out", absent)
+	}
+
+	ok, err = withBloom.MayContainGroup("~~~~ after everything")
+	if err != nil || ok {
+		t.Errorf("MayContainGroup() = %v, %v for a group outside every range", ok, err)
+	}
+
+	// Exact filtering gives the same entries whether or not row groups were skipped by Bloom filters
+	for _, group := range groups {
+		count := func(reader *ParquetReader) int {
+			n := 0
+			for _, err := range reader.FilterByGroupExactIter(group) {
+				if err != nil {
+					t.Fatalf("FilterByGroupExactIter() error = %v", err)
+				}
+				n++
+			}
+			return n
+		}
+		if got, want := count(withBloom), count(withoutBloom); got != want {
+			t.Errorf("FilterByGroupExactIter(%q) = %d entries with Bloom filters, %d without", group, got, want)
+		}
+	}
+}
+
+func TestRowGroupsContainingGroup(t *testing.T) {
+	pf, err := file.OpenParquetFile("testdata/bazel-bazel_build_32517_rocky-rocky-linux-8.parquet", false)
+	if err != nil {
+		t.Fatalf("Failed to open parquet file: %v", err)
+	}
+	defer pf.Close()
+
+	tests := []struct {
+		name      string
+		groupName string
+		expected  []int
+	}{
+		{
+			// Row groups 2-10 only contain the long dashed separator group, so they are skipped
+			name:      "pushdown skips row groups",
+			groupName: "--- :gcloud: Uploading JSON Profile\r",
+			expected:  []int{0, 1, 11},
+		},
+		{
+			name:      "outside every range",
+			groupName: "~~~~ after everything",
+			expected:  []int{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rowGroups, err := rowGroupsContainingGroup(pf, tt.groupName)
+			if err != nil {
+				t.Fatalf("rowGroupsContainingGroup failed: %v", err)
+			}
+			if !slices.Equal(rowGroups, tt.expected) {
+				t.Errorf("Expected row groups %v, got %v", tt.expected, rowGroups)
+			}
+		})
+	}
+}
+
+func TestGroupHeadersIter(t *testing.T) {
+	testFile := "testdata/bazel-bazel_build_32517_rocky-rocky-linux-8.parquet"
+	reader := NewParquetReader(testFile)
+
+	var expected []ParquetLogEntry
+	for entry, err := range reader.ReadEntriesIter() {
+		if err != nil {
+			t.Fatalf("ReadEntriesIter failed: %v", err)
+		}
+		if entry.IsGroup {
+			expected = append(expected, entry)
+		}
+	}
+	if len(expected) < 3 {
+		t.Fatalf("Expected test file to contain several group headers, got %d", len(expected))
+	}
+
+	var headers []ParquetLogEntry
+	for entry, err := range reader.GroupHeadersIter() {
+		if err != nil {
+			t.Fatalf("GroupHeadersIter failed: %v", err)
+		}
+		headers = append(headers, entry)
+	}
+
+	if len(headers) != len(expected) {
+		t.Fatalf("Expected %d headers, got %d", len(expected), len(headers))
+	}
+	for i, header := range headers {
+		want := expected[i]
+		if header.Content != want.Content || header.Timestamp != want.Timestamp || header.LineNumber != want.LineNumber {
+			t.Errorf("Header %d: got %q at %d, want %q at %d", i, header.Content, header.Timestamp, want.Content, want.Timestamp)
+		}
+	}
+
+	// Stopping early yields just the first few phases
+	var first []string
+	for entry, err := range reader.GroupHeadersIter() {
+		if err != nil {
+			t.Fatalf("GroupHeadersIter failed: %v", err)
+		}
+		first = append(first, entry.Content)
+		if len(first) == 2 {
+			break
+		}
+	}
+	if !slices.Equal(first, []string{expected[0].Content, expected[1].Content}) {
+		t.Errorf("Expected first headers %q, got %q", []string{expected[0].Content, expected[1].Content}, first)
+	}
+}
+
+func TestParquetReaderSliceMethods(t *testing.T) {
+	reader := NewParquetReader("testdata/bash-example.parquet")
+
+	entries, err := reader.ReadEntries()
+	if err != nil {
+		t.Fatalf("ReadEntries() error = %v", err)
+	}
+	if len(entries) != 212 {
+		t.Errorf("ReadEntries() returned %d entries, want 212", len(entries))
+	}
+
+	groups, err := reader.ListGroups()
+	if err != nil {
+		t.Fatalf("ListGroups() error = %v", err)
+	}
+	if len(groups) == 0 {
+		t.Error("ListGroups() returned no groups")
+	}
+
+	filtered, err := reader.FilterByGroup("environment hook")
+	if err != nil {
+		t.Fatalf("FilterByGroup() error = %v", err)
+	}
+	if len(filtered) != 2 {
+		t.Errorf("FilterByGroup() returned %d entries, want 2", len(filtered))
+	}
+}
+
+func TestParquetReaderQuery(t *testing.T) {
+	reader := NewParquetReader("testdata/bash-example.parquet")
+
+	result, err := reader.Query("list-groups", "")
+	if err != nil {
+		t.Fatalf("Query(list-groups) error = %v", err)
+	}
+	if result.Stats.TotalGroups != len(result.Groups) {
+		t.Errorf("TotalGroups = %d, want %d", result.Stats.TotalGroups, len(result.Groups))
+	}
+	if result.Stats.TotalEntries != 212 {
+		t.Errorf("TotalEntries = %d, want 212", result.Stats.TotalEntries)
+	}
+
+	result, err = reader.Query("by-group", "environment hook")
+	if err != nil {
+		t.Fatalf("Query(by-group) error = %v", err)
+	}
+	if result.Stats.MatchedEntries != 2 || len(result.Entries) != 2 {
+		t.Errorf("MatchedEntries = %d, entries = %d, want 2", result.Stats.MatchedEntries, len(result.Entries))
+	}
+	if result.Stats.TotalEntries != 212 {
+		t.Errorf("TotalEntries = %d, want 212", result.Stats.TotalEntries)
+	}
+
+	if _, err := reader.Query("by-group", ""); err == nil {
+		t.Error("Expected error for by-group without a pattern")
+	}
+	if _, err := reader.Query("bogus", ""); err == nil {
+		t.Error("Expected error for unknown operation")
+	}
+}
+
+func TestSliceFunctions(t *testing.T) {
+	entries, err := ReadParquetFile("testdata/bash-example.parquet")
+	if err != nil {
+		t.Fatalf("ReadParquetFile() error = %v", err)
+	}
+	if len(entries) != 212 {
+		t.Fatalf("ReadParquetFile() returned %d entries, want 212", len(entries))
+	}
+
+	groups := ListGroups(entries)
+	stats, err := NewParquetReader("testdata/bash-example.parquet").GroupStats()
+	if err != nil {
+		t.Fatalf("GroupStats() error = %v", err)
+	}
+	if !slices.Equal(groups, stats) {
+		t.Errorf("ListGroups() = %+v, want %+v", groups, stats)
+	}
+
+	filtered := FilterByGroup(entries, "ENVIRONMENT HOOK")
+	if len(filtered) != 2 {
+		t.Errorf("FilterByGroup() returned %d entries, want 2", len(filtered))
+	}
+}
+
+func TestReadParquetFileNotFound(t *testing.T) {
+	if _, err := ReadParquetFile("testdata/does-not-exist.parquet"); err == nil {
+		t.Error("Expected error for missing file")
+	}
+}
+
+func TestNewParquetReaderFromReaderAt(t *testing.T) {
+	testFile := "testdata/bazel-bazel_build_32517_rocky-rocky-linux-8.parquet"
+	data, err := os.ReadFile(testFile)
+	if err != nil {
+		t.Fatalf("ReadFile() error = %v", err)
+	}
+
+	fileReader := NewParquetReader(testFile)
+	memReader := NewParquetReaderFromReaderAt(bytes.NewReader(data), int64(len(data)))
+
+	want, err := fileReader.ReadEntries()
+	if err != nil {
+		t.Fatalf("ReadEntries() error = %v", err)
+	}
+	got, err := memReader.ReadEntries()
+	if err != nil {
+		t.Fatalf("ReadEntries() from ReaderAt error = %v", err)
+	}
+	if !slices.Equal(got, want) {
+		t.Errorf("ReadEntries() from ReaderAt returned %d entries, want %d", len(got), len(want))
+	}
+
+	info, err := memReader.GetFileInfo()
+	if err != nil {
+		t.Fatalf("GetFileInfo() error = %v", err)
+	}
+	if info.FileSize != int64(len(data)) || info.RowCount != int64(len(want)) {
+		t.Errorf("GetFileInfo() = %+v, want size %d and %d rows", info, len(data), len(want))
+	}
+
+	// The reader can be iterated repeatedly, including seeking and row group pushdown
+	var seeked int
+	for _, err := range memReader.SeekToRow(11000) {
+		if err != nil {
+			t.Fatalf("SeekToRow() error = %v", err)
+		}
+		seeked++
+	}
+	if seeked != len(want)-11000 {
+		t.Errorf("SeekToRow() returned %d entries, want %d", seeked, len(want)-11000)
+	}
+
+	groupName := "--- :gcloud: Uploading JSON Profile\r"
+	wantGroup, err := collectEntries(fileReader.FilterByGroupExactIter(groupName))
+	if err != nil {
+		t.Fatalf("FilterByGroupExactIter() error = %v", err)
+	}
+	gotGroup, err := collectEntries(memReader.FilterByGroupExactIter(groupName))
+	if err != nil {
+		t.Fatalf("FilterByGroupExactIter() from ReaderAt error = %v", err)
+	}
+	if !slices.Equal(gotGroup, wantGroup) {
+		t.Errorf("FilterByGroupExactIter() from ReaderAt returned %d entries, want %d", len(gotGroup), len(wantGroup))
+	}
+}
+
+func TestConvertRecordStringColumnTypes(t *testing.T) {
+	pool := memory.NewGoAllocator()
+	values := []string{"first line", "second line", "first line"}
+
+	buildColumn := func(dt arrow.DataType) arrow.Array {
+		builder := array.NewBuilder(pool, dt)
+		defer builder.Release()
+
+		for _, value := range values {
+			switch b := builder.(type) {
+			case *array.StringBuilder:
+				b.Append(value)
+			case *array.LargeStringBuilder:
+				b.Append(value)
+			case *array.BinaryBuilder:
+				b.AppendString(value)
+			case *array.BinaryDictionaryBuilder:
+				if err := b.AppendString(value); err != nil {
+					t.Fatalf("AppendString() error = %v", err)
+				}
+			default:
+				t.Fatalf("Unexpected builder %T", builder)
+			}
+		}
+		return builder.NewArray()
+	}
+
+	tests := []struct {
+		name string
+		dt   arrow.DataType
+	}{
+		{"String", arrow.BinaryTypes.String},
+		{"LargeString", arrow.BinaryTypes.LargeString},
+		{"Binary", arrow.BinaryTypes.Binary},
+		{"LargeBinary", arrow.BinaryTypes.LargeBinary},
+		{"Dictionary", &arrow.DictionaryType{IndexType: arrow.PrimitiveTypes.Int32, ValueType: arrow.BinaryTypes.String}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			timestamps := array.NewInt64Builder(pool)
+			defer timestamps.Release()
+			timestamps.AppendValues([]int64{1, 2, 3}, nil)
+			timestampCol := timestamps.NewArray()
+			defer timestampCol.Release()
+
+			contentCol := buildColumn(tt.dt)
+			defer contentCol.Release()
+			groupCol := buildColumn(tt.dt)
+			defer groupCol.Release()
+
+			schema := arrow.NewSchema([]arrow.Field{
+				{Name: "timestamp", Type: arrow.PrimitiveTypes.Int64},
+				{Name: "content", Type: tt.dt},
+				{Name: "group", Type: tt.dt},
+			}, nil)
+			record := array.NewRecord(schema, []arrow.Array{timestampCol, contentCol, groupCol}, int64(len(values)))
+			defer record.Release()
+
+			if !compatibleType(tt.dt, arrow.BinaryTypes.String) {
+				t.Errorf("compatibleType(%s) = false, want true", tt.dt)
+			}
+
+			mapping, err := mapColumns(schema)
+			if err != nil {
+				t.Fatalf("mapColumns() error = %v", err)
+			}
+
+			var got []string
+			for entry, err := range convertRecordToEntriesIterStreaming(record, mapping) {
+				if err != nil {
+					t.Fatalf("convertRecordToEntriesIterStreaming() error = %v", err)
+				}
+				if entry.Group != entry.Content {
+					t.Errorf("Group = %q, want %q", entry.Group, entry.Content)
+				}
+				got = append(got, entry.Content)
+			}
+
+			if !slices.Equal(got, values) {
+				t.Errorf("Content = %q, want %q", got, values)
+			}
+		})
+	}
+}
+
+func TestReadEntriesIterContext(t *testing.T) {
+	reader := NewParquetReader("testdata/bazel-bazel_build_32517_rocky-rocky-linux-8.parquet")
+
+	info, err := reader.GetFileInfo()
+	if err != nil {
+		t.Fatalf("GetFileInfo failed: %v", err)
+	}
+
+	// Cancelling after the first entry stops the read at the next record batch
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	read := 0
+	var readErr error
+	for _, err := range reader.ReadEntriesIterContext(ctx) {
+		if err != nil {
+			readErr = err
+			break
+		}
+		read++
+		cancel()
+	}
+
+	if !errors.Is(readErr, context.Canceled) {
+		t.Fatalf("Expected a context.Canceled error, got %v", readErr)
+	}
+	if int64(read) >= info.RowCount {
+		t.Errorf("Expected the read to stop early, read %d of %d entries", read, info.RowCount)
+	}
+
+	// An already cancelled context fails before any entries are read
+	for entry, err := range reader.SeekToRowContext(ctx, 10) {
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("Expected a context.Canceled error, got entry %+v, error %v", entry, err)
+		}
+		break
+	}
+}
+
+func TestDistinctGroups(t *testing.T) {
+	input := "before any group\n" +
+		"\x1b_bk;t=1\x07~~~ Setup\n" +
+		"\x1b_bk;t=2\x07--- Build\n" +
+		"\x1b_bk;t=3\x07building\n" +
+		"\x1b_bk;t=4\x07~~~ Setup\n" +
+		"\x1b_bk;t=5\x07+++ Annotate\n"
+
+	var buf bytes.Buffer
+	if err := ExportSeq2ToParquetWriter(NewParser().All(strings.NewReader(input)), &buf, ParquetOptions{}); err != nil {
+		t.Fatalf("ExportSeq2ToParquetWriter() error = %v", err)
+	}
+
+	reader := NewParquetReaderFromReaderAt(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	groups, err := reader.DistinctGroups()
+	if err != nil {
+		t.Fatalf("DistinctGroups() error = %v", err)
+	}
+
+	want := []string{"<no group>", "~~~ Setup", "--- Build", "+++ Annotate"}
+	if !slices.Equal(groups, want) {
+		t.Errorf("DistinctGroups() = %q, want %q", groups, want)
+	}
+}
+
+func TestGroupStatsTotalBytes(t *testing.T) {
+	tests := []struct {
+		name string
+		file string
+		size func(entry ParquetLogEntry) int64
+	}{
+		{
+			name: "raw_line_size column",
+			file: "testdata/bash-example.parquet",
+			size: func(entry ParquetLogEntry) int64 { return int64(entry.RawLineSize) },
+		},
+		{
+			// Written before raw_line_size existed, so the content is totalled instead
+			name: "content fallback",
+			file: "testdata/bazel-bazel_build_32517_rocky-rocky-linux-8.parquet",
+			size: func(entry ParquetLogEntry) int64 { return int64(len(entry.Content)) },
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reader := NewParquetReader(tt.file)
+
+			expected := make(map[string]int64)
+			for entry, err := range reader.ReadEntriesIter() {
+				if err != nil {
+					t.Fatalf("ReadEntriesIter failed: %v", err)
+				}
+				name := entry.Group
+				if name == "" {
+					name = "<no group>"
+				}
+				expected[name] += tt.size(entry)
+			}
+
+			sequential, err := reader.GroupStats()
+			if err != nil {
+				t.Fatalf("GroupStats failed: %v", err)
+			}
+			parallel, err := reader.GroupStatsParallel()
+			if err != nil {
+				t.Fatalf("GroupStatsParallel failed: %v", err)
+			}
+
+			for _, groups := range [][]GroupInfo{sequential, parallel} {
+				for _, group := range groups {
+					if group.TotalBytes == 0 || group.TotalBytes != expected[group.Name] {
+						t.Errorf("Group %q: TotalBytes = %d, want %d", group.Name, group.TotalBytes, expected[group.Name])
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestParquetReaderConcurrentUse(t *testing.T) {
+	// Run with -race: one reader shared by goroutines querying it at the same time, as a query server would
+	testFile := "testdata/bash-example.parquet"
+	data, err := os.ReadFile(testFile)
+	if err != nil {
+		t.Fatalf("Failed to read %s: %v", testFile, err)
+	}
+
+	readers := map[string]*ParquetReader{
+		"file":      NewParquetReader(testFile),
+		"reader at": NewParquetReaderFromReaderAt(bytes.NewReader(data), int64(len(data))),
+	}
+
+	for name, reader := range readers {
+		t.Run(name, func(t *testing.T) {
+			expectedEntries, err := collectEntries(reader.ReadEntriesIter())
+			if err != nil {
+				t.Fatalf("ReadEntriesIter failed: %v", err)
+			}
+			expectedGroups, err := reader.GroupStats()
+			if err != nil {
+				t.Fatalf("GroupStats failed: %v", err)
+			}
+
+			var wg sync.WaitGroup
+			errs := make(chan error, 16)
+			for i := range 8 {
+				wg.Add(2)
+				go func() {
+					defer wg.Done()
+					entries, err := collectEntries(reader.SeekToRow(int64(i)))
+					if err != nil {
+						errs <- err
+						return
+					}
+					if !slices.Equal(entries, expectedEntries[i:]) {
+						errs <- fmt.Errorf("SeekToRow(%d) returned different entries", i)
+					}
+				}()
+				go func() {
+					defer wg.Done()
+					groups, err := reader.GroupStats()
+					if err != nil {
+						errs <- err
+						return
+					}
+					if !reflect.DeepEqual(groups, expectedGroups) {
+						errs <- fmt.Errorf("GroupStats returned different groups")
+					}
+				}()
+			}
+			wg.Wait()
+			close(errs)
+
+			for err := range errs {
+				t.Error(err)
+			}
+		})
+	}
+}
@@ -0,0 +1,43 @@
+package buildkitelogs
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestRateLimiter(t *testing.T) {
+	limiter := newRateLimiter(20, 2)
+
+	// The burst is available straight away, later requests wait 50ms each
+	start := time.Now()
+	for range 4 {
+		if err := limiter.wait(context.Background()); err != nil {
+			t.Fatalf("wait() error = %v", err)
+		}
+	}
+	if elapsed := time.Since(start); elapsed < 90*time.Millisecond {
+		t.Errorf("4 requests at 20/s with a burst of 2 took %v, want at least 100ms", elapsed)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := limiter.wait(ctx); !errors.Is(err, context.Canceled) {
+		t.Errorf("wait() error = %v, want %v", err, context.Canceled)
+	}
+}
+
+func TestRateLimiterNil(t *testing.T) {
+	var limiter *rateLimiter
+
+	start := time.Now()
+	for range 100 {
+		if err := limiter.wait(context.Background()); err != nil {
+			t.Fatalf("wait() error = %v", err)
+		}
+	}
+	if elapsed := time.Since(start); elapsed > 50*time.Millisecond {
+		t.Errorf("unlimited requests took %v", elapsed)
+	}
+}
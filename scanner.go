@@ -1,8 +1,8 @@
 package buildkitelogs
 
 import (
-	"bytes"
 	"strconv"
+	"strings"
 	"time"
 )
 
@@ -16,82 +16,146 @@ func NewByteParser() *ByteParser {
 
 // ParseLine parses a single log line using byte scanning
 func (p *ByteParser) ParseLine(line string) (*LogEntry, error) {
-	data := []byte(line)
+	return p.parseLine(line, true)
+}
+
+// parseLine parses a single log line, copying it into RawLine only when keepRaw is set
+// Without the copy, Content shares the line's memory and the entry records just its size.
+func (p *ByteParser) parseLine(line string, keepRaw bool) (*LogEntry, error) {
+	entry := &LogEntry{}
+	if err := p.parseLineInto(entry, line, keepRaw); err != nil {
+		return nil, err
+	}
+	return entry, nil
+}
+
+// parseLineInto parses a single log line into entry, overwriting every field
+// A RawLine buffer already held by the entry is reused for the copy, so reused entries don't allocate it per line.
+func (p *ByteParser) parseLineInto(entry *LogEntry, line string, keepRaw bool) error {
+	var raw []byte
+	if keepRaw {
+		if entry.RawLine == nil {
+			raw = []byte(line)
+		} else {
+			raw = append(entry.RawLine[:0], line...)
+		}
+	}
+
+	*entry = LogEntry{
+		Content:     line,
+		RawLine:     raw,
+		rawLineSize: len(line),
+	}
 
 	// Check for OSC sequence: ESC_bk;t=timestamp BEL content
-	if len(data) < 10 { // Minimum: \x1b_bk;t=1\x07
-		return &LogEntry{
-			Timestamp: time.Time{},
-			Content:   line,
-			RawLine:   data,
-			Group:     "",
-		}, nil
+	if len(line) < 10 { // Minimum: \x1b_bk;t=1\x07
+		return nil
 	}
 
-	// Look for OSC start sequence: \x1b_bk;t=
-	if !hasOSCStart(data) {
-		return &LogEntry{
-			Timestamp: time.Time{},
-			Content:   line,
-			RawLine:   data,
-			Group:     "",
-		}, nil
+	// Look for OSC start sequence: \x1b_bk;t=, usually at the start of the line
+	offset := 0
+	if !strings.HasPrefix(line, oscStart) {
+		var ok bool
+		if offset, ok = leadingOSCOffset(line); !ok {
+			return nil
+		}
 	}
 
 	// Find the timestamp and content
-	timestampStart := 7 // After \x1b_bk;t=
-	timestampEnd := findBEL(data, timestampStart)
+	timestampStart := offset + len(oscStart) // After \x1b_bk;t=
+	timestampEnd := strings.IndexByte(line[timestampStart:], 0x07)
 	if timestampEnd == -1 {
-		return &LogEntry{
-			Timestamp: time.Time{},
-			Content:   line,
-			RawLine:   data,
-			Group:     "",
-		}, nil
+		return nil
 	}
+	timestampEnd += timestampStart
 
 	// Extract timestamp
-	timestampBytes := data[timestampStart:timestampEnd]
-	timestampMs, err := strconv.ParseInt(string(timestampBytes), 10, 64)
+	timestamp, suspect, err := parseOSCTimestamp(line[timestampStart:timestampEnd])
 	if err != nil {
-		return nil, err
+		return err
 	}
 
-	timestamp := time.Unix(0, timestampMs*int64(time.Millisecond))
+	entry.Timestamp = timestamp
+	entry.TimestampSuspect = suspect
 
-	// Extract content (after BEL)
-	content := string(data[timestampEnd+1:])
+	// Extract content (after BEL), keeping any sequences that preceded the OSC
+	entry.Content = line[timestampEnd+1:]
+	if offset > 0 {
+		entry.Content = line[:offset] + entry.Content
+	}
+
+	return nil
+}
 
-	return &LogEntry{
-		Timestamp: timestamp,
-		Content:   content,
-		RawLine:   data,
-		Group:     "",
-	}, nil
+// oscTimestampUnits maps the unit hints custom agents append to the OSC timestamp, as in "t=...;u=ns"
+var oscTimestampUnits = map[string]time.Duration{
+	"u=ms": time.Millisecond,
+	"u=us": time.Microsecond,
+	"u=µs": time.Microsecond,
+	"u=ns": time.Nanosecond,
 }
 
-// hasOSCStart checks if data starts with \x1b_bk;t=
-func hasOSCStart(data []byte) bool {
-	if len(data) < 7 {
-		return false
+// parseOSCTimestamp parses the payload of a timestamp OSC sequence, the text between "t=" and BEL
+// Buildkite writes Unix milliseconds, which is assumed unless a unit hint follows the value. An
+// unknown hint is read as milliseconds too, but reported as suspect. Finer units are only kept in
+// memory, Parquet exports truncate timestamps to milliseconds.
+func parseOSCTimestamp(payload string) (time.Time, bool, error) {
+	value, hint, hasHint := strings.Cut(payload, ";")
+
+	n, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		return time.Time{}, false, err
+	}
+
+	unit, suspect := time.Millisecond, false
+	if hasHint {
+		hinted, ok := oscTimestampUnits[hint]
+		if ok {
+			unit = hinted
+		}
+		suspect = !ok
 	}
 
-	return data[0] == 0x1b && // ESC
-		bytes.HasPrefix(data[1:], []byte("_bk;t="))
+	return time.Unix(0, n*int64(unit)), suspect, nil
 }
 
-// findBEL finds the position of the BEL character (\x07) starting from offset
-func findBEL(data []byte, start int) int {
-	for i := start; i < len(data); i++ {
-		if data[i] == 0x07 {
-			return i
+// maxOSCOffset is how far into a line the OSC start is looked for after leading ANSI sequences
+const maxOSCOffset = 64
+
+// leadingOSCOffset returns the offset of an OSC start that follows leading whitespace and ANSI
+// sequences, such as a stray "\x1b[0m" reset printed before the timestamp
+func leadingOSCOffset(line string) (int, bool) {
+	i := 0
+	for i < len(line) && i <= maxOSCOffset {
+		switch {
+		case strings.HasPrefix(line[i:], oscStart):
+			return i, true
+		case line[i] == ' ' || line[i] == '\t':
+			i++
+		case line[i] == 0x1b && i+1 < len(line) && line[i+1] == '[':
+			// Skip ESC[ and the parameters up to the final character
+			i += 2
+			for i < len(line) && !isANSIFinalChar(line[i]) {
+				i++
+			}
+			i++
+		default:
+			return 0, false
 		}
 	}
-	return -1
+	return 0, false
 }
 
+// oscStart is the prefix of a Buildkite timestamp OSC sequence
+const oscStart = "\x1b_bk;t="
+
 // StripANSI removes ANSI escape sequences using byte scanning
+// Content without an ESC or "[" has no sequences and is returned as is, without allocating.
 func (p *ByteParser) StripANSI(content string) string {
+	if !strings.ContainsAny(content, "\x1b[") {
+		return content
+	}
+
 	data := []byte(content)
 	result := make([]byte, 0, len(data))
 
@@ -109,31 +173,9 @@ func (p *ByteParser) StripANSI(content string) string {
 			if i < len(data) {
 				i++
 			}
-		} else if i < len(data)-1 && data[i] == '[' {
-			// Handle sequences that might be missing ESC
-			j := i + 1
-			hasValidANSI := false
-
-			// Look ahead to see if this looks like an ANSI sequence
-			for j < len(data) && j < i+10 { // Limit lookahead
-				if data[j] >= '0' && data[j] <= '9' || data[j] == ';' {
-					j++
-				} else if isANSIFinalChar(data[j]) {
-					hasValidANSI = true
-					break
-				} else {
-					break
-				}
-			}
-
-			if hasValidANSI {
-				// Skip the ANSI sequence
-				i = j + 1
-			} else {
-				// Not an ANSI sequence, keep the character
-				result = append(result, data[i])
-				i++
-			}
+		} else if end, ok := escLessSequenceEnd(data, i); ok {
+			// Sequence stored without its ESC, as Buildkite sometimes does
+			i = end
 		} else {
 			// Regular character
 			result = append(result, data[i])
@@ -144,6 +186,47 @@ func (p *ByteParser) StripANSI(content string) string {
 	return string(result)
 }
 
+// escLessSequenceEnd returns the end of a control sequence missing its ESC at data[i], such as "[90m"
+// Only digits and semicolons followed by a common final byte are recognised (colors, erasing and cursor
+// movement), and not when a "]" follows. Without digits the sequence must also end a word, as in a
+// trailing "[K". So bracketed text like "[0]", "array[i]", "[REDACTED]" or "[Killed]" is kept.
+func escLessSequenceEnd(data []byte, i int) (int, bool) {
+	if data[i] != '[' {
+		return 0, false
+	}
+
+	j := i + 1
+	for j < len(data) && j < i+10 && (data[j] >= '0' && data[j] <= '9' || data[j] == ';') { // Limit lookahead
+		j++
+	}
+	if j >= len(data) || !isEscLessFinalChar(data[j]) {
+		return 0, false
+	}
+	if j+1 < len(data) && data[j+1] == ']' {
+		return 0, false
+	}
+	if j == i+1 && j+1 < len(data) && !isSequenceBoundary(data[j+1]) {
+		return 0, false
+	}
+
+	return j + 1, true
+}
+
+// isEscLessFinalChar checks if a byte ends a sequence that is stripped without its ESC
+// These are SGR (m), erase in line (K) and display (J), and cursor movement (A-D, G, H).
+func isEscLessFinalChar(b byte) bool {
+	switch b {
+	case 'm', 'K', 'J', 'A', 'B', 'C', 'D', 'G', 'H':
+		return true
+	}
+	return false
+}
+
+// isSequenceBoundary checks if a byte can follow a parameterless sequence stored without its ESC
+func isSequenceBoundary(b byte) bool {
+	return b == ' ' || b == '\t' || b == '\r' || b == '\n' || b == '[' || b == 0x1b
+}
+
 // isANSIFinalChar checks if a byte is a valid ANSI sequence final character
 func isANSIFinalChar(b byte) bool {
 	// ANSI sequences end with letters, typically m, K, H, etc.
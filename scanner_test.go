@@ -89,6 +89,47 @@ func TestByteParserBasic(t *testing.T) {
 	}
 }
 
+func TestByteParserTimestampUnits(t *testing.T) {
+	parser := NewByteParser()
+
+	tests := []struct {
+		name        string
+		input       string
+		wantNanos   int64
+		wantSuspect bool
+	}{
+		{name: "milliseconds by default", input: "\x1b_bk;t=1745322209921\x07done", wantNanos: 1745322209921000000},
+		{name: "milliseconds hint", input: "\x1b_bk;t=1745322209921;u=ms\x07done", wantNanos: 1745322209921000000},
+		{name: "microseconds", input: "\x1b_bk;t=1745322209921123;u=us\x07done", wantNanos: 1745322209921123000},
+		{name: "micro sign", input: "\x1b_bk;t=1745322209921123;u=µs\x07done", wantNanos: 1745322209921123000},
+		{name: "nanoseconds", input: "\x1b_bk;t=1745322209921123456;u=ns\x07done", wantNanos: 1745322209921123456},
+		{name: "unknown unit", input: "\x1b_bk;t=1745322209921;u=ps\x07done", wantNanos: 1745322209921000000, wantSuspect: true},
+		{name: "unknown hint", input: "\x1b_bk;t=1745322209921;x\x07done", wantNanos: 1745322209921000000, wantSuspect: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			entry, err := parser.ParseLine(tt.input)
+			if err != nil {
+				t.Fatalf("ParseLine() error = %v", err)
+			}
+			if got := entry.Timestamp.UnixNano(); got != tt.wantNanos {
+				t.Errorf("Timestamp = %d ns, want %d", got, tt.wantNanos)
+			}
+			if entry.TimestampSuspect != tt.wantSuspect {
+				t.Errorf("TimestampSuspect = %v, want %v", entry.TimestampSuspect, tt.wantSuspect)
+			}
+			if entry.Content != "done" {
+				t.Errorf("Content = %q, want %q", entry.Content, "done")
+			}
+		})
+	}
+
+	if _, err := parser.ParseLine("\x1b_bk;t=12x;u=ns\x07done"); err == nil {
+		t.Error("Expected an invalid value with a unit hint to fail")
+	}
+}
+
 func TestByteParserStripANSI(t *testing.T) {
 	parser := NewByteParser()
 
@@ -0,0 +1,178 @@
+package buildkitelogs
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/apache/arrow-go/v18/arrow"
+	"github.com/apache/arrow-go/v18/arrow/memory"
+	"github.com/apache/arrow-go/v18/parquet/file"
+	"github.com/apache/arrow-go/v18/parquet/metadata"
+	"github.com/apache/arrow-go/v18/parquet/pqarrow"
+)
+
+// columnSchemaVersions records the schema version that added each column after the original schema
+// Version 1 files were written before versions were recorded and may lack any of these columns, later
+// versions must have every column up to their own version. Missing columns read as the zero value,
+// except group_index which reads as -1.
+var columnSchemaVersions = map[string]int{
+	"is_error":      2,
+	"raw_line_size": 2,
+	"line_number":   2,
+	"job_id":        2,
+	"job_name":      2,
+	"group_index":   2,
+	"parent_group":  2,
+	"repeat_count":  2,
+}
+
+// originalArrowSchema creates the schema of a version 1 file, with the columns of the original schema and
+// the named columns of columnSchemaVersions
+func originalArrowSchema(added ...string) *arrow.Schema {
+	var fields []arrow.Field
+	for _, field := range createArrowSchema().Fields() {
+		if _, ok := columnSchemaVersions[field.Name]; !ok || slices.Contains(added, field.Name) {
+			fields = append(fields, field)
+		}
+	}
+	return arrow.NewSchema(fields, nil)
+}
+
+// Schema returns the Arrow schema of the Parquet file
+func (pr *ParquetReader) Schema() (*arrow.Schema, error) {
+	return readArrowSchema(pr.source)
+}
+
+// SchemaVersion returns the log schema version recorded in the file's metadata, 1 if none was recorded
+func (pr *ParquetReader) SchemaVersion() (int, error) {
+	_, version, err := readArrowSchemaVersion(pr.source)
+	return version, err
+}
+
+// ValidateSchema checks that the Parquet file has the columns and types of a log file
+// Columns added in later versions of the schema than the file's version may be missing, but every
+// column present must have the expected type. The error lists all missing and mismatched columns,
+// so pointing the reader at an unrelated Parquet file fails clearly instead of yielding empty entries.
+func (pr *ParquetReader) ValidateSchema() error {
+	schema, version, err := readArrowSchemaVersion(pr.source)
+	if err != nil {
+		return err
+	}
+	return validateSchema(schema, version)
+}
+
+// validateSchema compares a schema of the given version against the log entry schema
+func validateSchema(schema *arrow.Schema, version int) error {
+	var problems []string
+
+	for _, expected := range createArrowSchema().Fields() {
+		indices := schema.FieldIndices(expected.Name)
+		if len(indices) == 0 {
+			if !columnOptional(expected.Name, version) {
+				problems = append(problems, fmt.Sprintf("missing column %q (%s)", expected.Name, expected.Type))
+			}
+			continue
+		}
+
+		actual := schema.Field(indices[0])
+		if expected.Name == "timestamp" && actual.Type.ID() == arrow.TIMESTAMP {
+			continue // Written with ParquetOptions.LogicalTimestamps
+		}
+		if !compatibleType(actual.Type, expected.Type) {
+			problems = append(problems, fmt.Sprintf("column %q has type %s, expected %s", expected.Name, actual.Type, expected.Type))
+		}
+	}
+
+	if len(problems) > 0 {
+		return fmt.Errorf("parquet file is not a compatible log file: %s", strings.Join(problems, "; "))
+	}
+
+	return nil
+}
+
+// columnOptional returns true if a file of the given schema version may lack the column
+func columnOptional(name string, version int) bool {
+	added, ok := columnSchemaVersions[name]
+	if !ok {
+		return false // In the original schema
+	}
+	return version == 1 || added > version
+}
+
+// parseSchemaVersion reads the schema version from key-value metadata, 1 when it isn't recorded
+func parseSchemaVersion(kv metadata.KeyValueMetadata) (int, error) {
+	value := kv.FindValue(MetadataSchemaVersion)
+	if value == nil {
+		return 1, nil
+	}
+
+	version, err := strconv.Atoi(*value)
+	if err != nil || version < 1 {
+		return 0, fmt.Errorf("invalid %s metadata %q", MetadataSchemaVersion, *value)
+	}
+	return version, nil
+}
+
+// compatibleType returns true if values of the actual type can be read as the expected type
+// String columns may also be binary, large or dictionary encoded, as written by other tools.
+func compatibleType(actual, expected arrow.DataType) bool {
+	if arrow.TypeEqual(actual, expected) {
+		return true
+	}
+	if expected.ID() != arrow.STRING {
+		return false
+	}
+	return isStringLike(actual)
+}
+
+// isStringLike returns true for the types stringValue can read
+func isStringLike(dt arrow.DataType) bool {
+	switch dt := dt.(type) {
+	case *arrow.StringType, *arrow.LargeStringType, *arrow.BinaryType, *arrow.LargeBinaryType:
+		return true
+	case *arrow.DictionaryType:
+		return isStringLike(dt.ValueType)
+	default:
+		return false
+	}
+}
+
+// readArrowSchema returns the Arrow schema of Parquet data
+func readArrowSchema(src parquetSource) (*arrow.Schema, error) {
+	schema, _, err := readArrowSchemaVersion(src)
+	return schema, err
+}
+
+// readArrowSchemaVersion returns the Arrow schema and log schema version of Parquet data
+func readArrowSchemaVersion(src parquetSource) (*arrow.Schema, int, error) {
+	r, _, release, err := src()
+	if err != nil {
+		return nil, 0, err
+	}
+	defer release()
+
+	pf, err := file.NewParquetReader(r)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to open parquet file: %w", err)
+	}
+	defer pf.Close()
+
+	version, err := parseSchemaVersion(pf.MetaData().KeyValueMetadata())
+	if err != nil {
+		return nil, 0, err
+	}
+
+	arrowReader, err := pqarrow.NewFileReader(pf, pqarrow.ArrowReadProperties{}, memory.NewGoAllocator())
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to create arrow reader: %w", err)
+	}
+
+	schema, err := arrowReader.Schema()
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return schema, version, nil
+}
@@ -0,0 +1,167 @@
+package buildkitelogs
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/apache/arrow-go/v18/arrow"
+	"github.com/apache/arrow-go/v18/arrow/array"
+	"github.com/apache/arrow-go/v18/arrow/memory"
+)
+
+func TestValidateSchema(t *testing.T) {
+	for _, testFile := range []string{
+		"testdata/bash-example.parquet",
+		"testdata/bazel-bazel_build_32517_rocky-rocky-linux-8.parquet",
+	} {
+		if err := NewParquetReader(testFile).ValidateSchema(); err != nil {
+			t.Errorf("ValidateSchema(%s) error = %v", testFile, err)
+		}
+	}
+}
+
+func TestValidateSchemaIncompatible(t *testing.T) {
+	schema := arrow.NewSchema([]arrow.Field{
+		{Name: "timestamp", Type: arrow.PrimitiveTypes.Int64},
+		{Name: "content", Type: arrow.PrimitiveTypes.Int64},
+		{Name: "value", Type: arrow.PrimitiveTypes.Float64},
+	}, nil)
+
+	var buf bytes.Buffer
+	writer, err := createNewFileWriter(schema, &buf, memory.NewGoAllocator(), ParquetOptions{})
+	if err != nil {
+		t.Fatalf("createNewFileWriter() error = %v", err)
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+
+	reader := NewParquetReaderFromReaderAt(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	err = reader.ValidateSchema()
+	if err == nil {
+		t.Fatal("Expected error for incompatible schema")
+	}
+
+	for _, want := range []string{
+		`column "content" has type int64, expected utf8`,
+		`missing column "group"`,
+		`missing column "is_progress"`,
+	} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("Error %q does not mention %q", err, want)
+		}
+	}
+	if strings.Contains(err.Error(), "job_id") {
+		t.Errorf("Optional columns should not be reported missing: %v", err)
+	}
+}
+
+// writeV1Parquet writes entries with only the columns of the original, unversioned schema
+func writeV1Parquet(t *testing.T, entries []*LogEntry, metadata map[string]string) []byte {
+	t.Helper()
+
+	pool := memory.NewGoAllocator()
+	record, err := createRecordFromEntries(entries, "", "", contentEncoding{}, pool)
+	if err != nil {
+		t.Fatalf("createRecordFromEntries() error = %v", err)
+	}
+	defer record.Release()
+
+	var fields []arrow.Field
+	var columns []arrow.Array
+	for i, field := range record.Schema().Fields() {
+		if _, added := columnSchemaVersions[field.Name]; added {
+			continue
+		}
+		fields = append(fields, field)
+		columns = append(columns, record.Column(i))
+	}
+	v1 := array.NewRecord(arrow.NewSchema(fields, nil), columns, record.NumRows())
+	defer v1.Release()
+
+	var buf bytes.Buffer
+	writer, err := createNewFileWriter(v1.Schema(), &buf, pool, ParquetOptions{Metadata: metadata})
+	if err != nil {
+		t.Fatalf("createNewFileWriter() error = %v", err)
+	}
+	if err := writer.Write(v1); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+	return buf.Bytes()
+}
+
+func TestSchemaVersionV1ReadByCurrent(t *testing.T) {
+	parser := NewParser()
+	var entries []*LogEntry
+	for entry, err := range parser.All(strings.NewReader("\x1b_bk;t=1\x07~~~ Build\n\x1b_bk;t=2\x07$ make\n")) {
+		if err != nil {
+			t.Fatalf("All() error = %v", err)
+		}
+		entries = append(entries, entry)
+	}
+
+	data := writeV1Parquet(t, entries, nil)
+	reader := NewParquetReaderFromReaderAt(bytes.NewReader(data), int64(len(data)))
+
+	info, err := reader.GetFileInfo()
+	if err != nil {
+		t.Fatalf("GetFileInfo() error = %v", err)
+	}
+	if info.SchemaVersion != 1 {
+		t.Errorf("SchemaVersion = %d, want 1", info.SchemaVersion)
+	}
+
+	if err := reader.ValidateSchema(); err != nil {
+		t.Errorf("ValidateSchema() error = %v", err)
+	}
+
+	read, err := reader.ReadEntries()
+	if err != nil {
+		t.Fatalf("ReadEntries() error = %v", err)
+	}
+	if len(read) != 2 {
+		t.Fatalf("Expected 2 entries, got %d", len(read))
+	}
+
+	// Columns added in version 2 read as their defaults
+	want := ParquetLogEntry{Timestamp: 2, Content: "$ make", Group: "~~~ Build", HasTime: true, IsCommand: true, GroupIndex: -1}
+	if read[1] != want {
+		t.Errorf("Entry = %+v, want %+v", read[1], want)
+	}
+}
+
+func TestSchemaVersionCurrent(t *testing.T) {
+	var buf bytes.Buffer
+	if err := ExportSeq2ToParquetWriter(NewParser().All(strings.NewReader("line\n")), &buf, ParquetOptions{
+		Metadata: map[string]string{MetadataSchemaVersion: "99"},
+	}); err != nil {
+		t.Fatalf("ExportSeq2ToParquetWriter() error = %v", err)
+	}
+
+	version, err := NewParquetReaderFromReaderAt(bytes.NewReader(buf.Bytes()), int64(buf.Len())).SchemaVersion()
+	if err != nil {
+		t.Fatalf("SchemaVersion() error = %v", err)
+	}
+	if version != schemaVersion {
+		t.Errorf("SchemaVersion() = %d, want %d", version, schemaVersion)
+	}
+}
+
+func TestSchemaVersionMissingColumns(t *testing.T) {
+	// A file claiming version 2 must have the version 2 columns
+	data := writeV1Parquet(t, []*LogEntry{{Content: "line"}}, map[string]string{MetadataSchemaVersion: "2"})
+
+	err := NewParquetReaderFromReaderAt(bytes.NewReader(data), int64(len(data))).ValidateSchema()
+	if err == nil || !strings.Contains(err.Error(), `missing column "line_number"`) {
+		t.Errorf("ValidateSchema() error = %v, want missing line_number", err)
+	}
+
+	data = writeV1Parquet(t, []*LogEntry{{Content: "line"}}, map[string]string{MetadataSchemaVersion: "two"})
+	if _, err := NewParquetReaderFromReaderAt(bytes.NewReader(data), int64(len(data))).GetFileInfo(); err == nil {
+		t.Error("Expected an error for an invalid schema version")
+	}
+}
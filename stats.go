@@ -0,0 +1,127 @@
+package buildkitelogs
+
+import (
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"unicode/utf8"
+
+	"github.com/apache/arrow-go/v18/arrow/memory"
+	"github.com/apache/arrow-go/v18/parquet/file"
+	"github.com/apache/arrow-go/v18/parquet/metadata"
+)
+
+// ColumnStats summarises a Parquet column from the row group statistics in the file footer
+type ColumnStats struct {
+	Name             string `json:"name"`
+	PhysicalType     string `json:"physical_type"`
+	LogicalType      string `json:"logical_type,omitempty"`
+	Values           int64  `json:"values"`
+	NullCount        int64  `json:"null_count"`
+	HasNullCount     bool   `json:"has_null_count"` // False when any row group omitted the null count
+	HasMinMax        bool   `json:"has_min_max"`    // False when no row group recorded min/max statistics
+	Min              string `json:"min,omitempty"`  // Smallest value across row groups
+	Max              string `json:"max,omitempty"`  // Largest value across row groups
+	CompressedSize   int64  `json:"compressed_size"`
+	UncompressedSize int64  `json:"uncompressed_size"`
+}
+
+// ColumnStats returns the statistics of every column in the file, merged across row groups
+// Only the footer is read, so this is cheap even for large files. Some writers omit statistics,
+// leaving HasMinMax or HasNullCount false.
+func (pr *ParquetReader) ColumnStats() ([]ColumnStats, error) {
+	r, _, release, err := pr.source()
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	pf, err := file.NewParquetReader(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open parquet file: %w", err)
+	}
+	defer pf.Close()
+
+	return readColumnStats(pf)
+}
+
+// readColumnStats merges the column chunk statistics of each column across row groups
+func readColumnStats(pf *file.Reader) ([]ColumnStats, error) {
+	meta := pf.MetaData()
+	columns := make([]ColumnStats, meta.Schema.NumColumns())
+
+	for i := range columns {
+		descr := meta.Schema.Column(i)
+		columns[i] = ColumnStats{
+			Name:         descr.Path(),
+			PhysicalType: descr.PhysicalType().String(),
+			HasNullCount: true,
+		}
+		if logical := descr.LogicalType(); logical != nil && !logical.IsNone() {
+			columns[i].LogicalType = logical.String()
+		}
+
+		merged := metadata.NewStatistics(descr, memory.DefaultAllocator)
+
+		for rg := 0; rg < meta.NumRowGroups(); rg++ {
+			chunk, err := meta.RowGroup(rg).ColumnChunk(i)
+			if err != nil {
+				return nil, fmt.Errorf("failed to read row group %d metadata: %w", rg, err)
+			}
+
+			columns[i].Values += chunk.NumValues()
+			columns[i].CompressedSize += chunk.TotalCompressedSize()
+			columns[i].UncompressedSize += chunk.TotalUncompressedSize()
+
+			stats, err := chunk.Statistics()
+			if err != nil {
+				return nil, fmt.Errorf("failed to read row group %d statistics: %w", rg, err)
+			}
+			if stats == nil || !stats.HasNullCount() {
+				columns[i].HasNullCount = false
+			} else {
+				columns[i].NullCount += stats.NullCount()
+			}
+			if stats != nil && stats.HasMinMax() {
+				merged.Merge(stats)
+				columns[i].HasMinMax = true
+			}
+		}
+
+		if columns[i].HasMinMax {
+			columns[i].Min, columns[i].Max = formatMinMax(merged)
+		}
+	}
+
+	return columns, nil
+}
+
+// formatMinMax formats the min and max of statistics as strings
+func formatMinMax(stats metadata.TypedStatistics) (string, string) {
+	switch s := stats.(type) {
+	case *metadata.BooleanStatistics:
+		return strconv.FormatBool(s.Min()), strconv.FormatBool(s.Max())
+	case *metadata.Int32Statistics:
+		return strconv.FormatInt(int64(s.Min()), 10), strconv.FormatInt(int64(s.Max()), 10)
+	case *metadata.Int64Statistics:
+		return strconv.FormatInt(s.Min(), 10), strconv.FormatInt(s.Max(), 10)
+	case *metadata.Float32Statistics:
+		return strconv.FormatFloat(float64(s.Min()), 'g', -1, 32), strconv.FormatFloat(float64(s.Max()), 'g', -1, 32)
+	case *metadata.Float64Statistics:
+		return strconv.FormatFloat(s.Min(), 'g', -1, 64), strconv.FormatFloat(s.Max(), 'g', -1, 64)
+	case *metadata.ByteArrayStatistics:
+		return formatBytes(s.Min()), formatBytes(s.Max())
+	case *metadata.FixedLenByteArrayStatistics:
+		return formatBytes(s.Min()), formatBytes(s.Max())
+	default:
+		return hex.EncodeToString(stats.EncodeMin()), hex.EncodeToString(stats.EncodeMax())
+	}
+}
+
+// formatBytes returns b as a string if it is valid UTF-8, otherwise hex encoded with a 0x prefix
+func formatBytes(b []byte) string {
+	if utf8.Valid(b) {
+		return string(b)
+	}
+	return "0x" + hex.EncodeToString(b)
+}
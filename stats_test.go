@@ -0,0 +1,51 @@
+package buildkitelogs
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestColumnStats(t *testing.T) {
+	input := "preamble\n" +
+		"\x1b_bk;t=1745322209921\x07~~~ Running tests\n" +
+		"\x1b_bk;t=1745322209925\x07$ make test\n"
+
+	var buf bytes.Buffer
+	err := ExportSeq2ToParquetWriter(NewParser().All(strings.NewReader(input)), &buf, ParquetOptions{RowGroupSize: 2})
+	if err != nil {
+		t.Fatalf("ExportSeq2ToParquetWriter() error = %v", err)
+	}
+
+	stats, err := NewParquetReaderFromReaderAt(bytes.NewReader(buf.Bytes()), int64(buf.Len())).ColumnStats()
+	if err != nil {
+		t.Fatalf("ColumnStats() error = %v", err)
+	}
+
+	byName := make(map[string]ColumnStats, len(stats))
+	for _, column := range stats {
+		byName[column.Name] = column
+	}
+	if len(stats) != len(createArrowSchema().Fields()) {
+		t.Errorf("Expected an entry per column, got %d", len(stats))
+	}
+
+	// Merged across both row groups, the preamble holds the zero time
+	timestamp := byName["timestamp"]
+	if timestamp.PhysicalType != "INT64" || !timestamp.HasMinMax || timestamp.Min != "-62135596800000" || timestamp.Max != "1745322209925" {
+		t.Errorf("Unexpected timestamp stats: %+v", timestamp)
+	}
+	if timestamp.Values != 3 || !timestamp.HasNullCount || timestamp.NullCount != 0 {
+		t.Errorf("Unexpected timestamp counts: %+v", timestamp)
+	}
+
+	group := byName["group"]
+	if group.PhysicalType != "BYTE_ARRAY" || group.LogicalType != "String" || group.Min != "" || group.Max != "~~~ Running tests" {
+		t.Errorf("Unexpected group stats: %+v", group)
+	}
+
+	isCommand := byName["is_command"]
+	if isCommand.Min != "false" || isCommand.Max != "true" || isCommand.CompressedSize <= 0 {
+		t.Errorf("Unexpected is_command stats: %+v", isCommand)
+	}
+}
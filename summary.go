@@ -0,0 +1,59 @@
+package buildkitelogs
+
+import "iter"
+
+// Summary holds counts of the kinds of entries in a log
+type Summary struct {
+	TotalEntries    int   `json:"total_entries"`
+	EntriesWithTime int   `json:"entries_with_time"`
+	Commands        int   `json:"commands"`
+	Sections        int   `json:"sections"`
+	Progress        int   `json:"progress"`
+	Errors          int   `json:"errors"`
+	RawBytes        int64 `json:"raw_bytes"` // Total size of the original lines
+
+	SuspectTimestamps int `json:"suspect_timestamps"` // Timestamps dropped by ParserOptions.ValidateTimestamps or with an unknown unit
+}
+
+// Add counts a single entry, for tallying a summary while processing entries in another loop
+func (s *Summary) Add(entry *LogEntry) {
+	s.TotalEntries++
+	s.RawBytes += int64(entry.RawLineSize())
+
+	if entry.HasTimestamp() {
+		s.EntriesWithTime++
+	}
+	if entry.TimestampSuspect {
+		s.SuspectTimestamps++
+	}
+	if entry.IsCommand() {
+		s.Commands++
+	}
+	if entry.IsGroup() {
+		s.Sections++
+	}
+	if entry.IsProgress() {
+		s.Progress++
+	}
+	if entry.IsError() {
+		s.Errors++
+	}
+}
+
+// Regular returns the number of entries that are not commands, sections or progress updates
+func (s Summary) Regular() int {
+	return s.TotalEntries - s.Commands - s.Sections - s.Progress
+}
+
+// Summarize counts the kinds of entries in a log
+// It stops at the first error, returning the counts up to that point along with the error.
+func Summarize(seq iter.Seq2[*LogEntry, error]) (Summary, error) {
+	var summary Summary
+	for entry, err := range seq {
+		if err != nil {
+			return summary, err
+		}
+		summary.Add(entry)
+	}
+	return summary, nil
+}
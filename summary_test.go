@@ -0,0 +1,58 @@
+package buildkitelogs
+
+import (
+	"errors"
+	"os"
+	"testing"
+)
+
+func TestSummarize(t *testing.T) {
+	file, err := os.Open("testdata/bash-example.log")
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	defer func() { _ = file.Close() }()
+
+	summary, err := Summarize(NewParser().All(file))
+	if err != nil {
+		t.Fatalf("Summarize() error = %v", err)
+	}
+
+	// Matches the counts reported by bklog parse -summary for this log
+	want := Summary{
+		TotalEntries:    212,
+		EntriesWithTime: 212,
+		Commands:        15,
+		Sections:        13,
+		Progress:        4,
+		Errors:          1,
+		RawBytes:        summary.RawBytes,
+	}
+	if summary != want {
+		t.Errorf("Summarize() = %+v, want %+v", summary, want)
+	}
+	if summary.RawBytes == 0 {
+		t.Error("Expected RawBytes to be counted")
+	}
+	if summary.Regular() != 180 {
+		t.Errorf("Regular() = %d, want 180", summary.Regular())
+	}
+}
+
+func TestSummarizeError(t *testing.T) {
+	wantErr := errors.New("read failed")
+	seq := func(yield func(*LogEntry, error) bool) {
+		if !yield(&LogEntry{Content: "$ make"}, nil) {
+			return
+		}
+		yield(nil, wantErr)
+	}
+
+	summary, err := Summarize(seq)
+	if !errors.Is(err, wantErr) {
+		t.Errorf("Summarize() error = %v, want %v", err, wantErr)
+	}
+	if summary.TotalEntries != 1 || summary.Commands != 1 {
+		t.Errorf("Summarize() = %+v, want the entry before the error counted", summary)
+	}
+}
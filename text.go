@@ -0,0 +1,61 @@
+package buildkitelogs
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"time"
+)
+
+// TextTimestampFormat is the layout of timestamps written by ParquetReader.WriteText
+const TextTimestampFormat = "2006-01-02 15:04:05.000"
+
+// TextOptions configures how ParquetReader.WriteText formats each line
+type TextOptions struct {
+	// Timestamps prefixes each line with its timestamp as "[2006-01-02 15:04:05.000] ".
+	// Lines without a timestamp are written without the prefix.
+	Timestamps bool
+
+	// Location is the time zone of the timestamps, nil uses UTC
+	Location *time.Location
+
+	// StripANSI removes ANSI escape sequences from the content, leaving plain text
+	StripANSI bool
+}
+
+// WriteText writes the log as plain text, one line per entry, streaming rows from the file
+// Lines collapsed by ParserOptions.DedupeConsecutive are written out again, so the text has the
+// original line count. Content truncated by ParquetOptions.MaxContentBytes can't be restored.
+func (pr *ParquetReader) WriteText(w io.Writer, opts TextOptions) error {
+	location := opts.Location
+	if location == nil {
+		location = time.UTC
+	}
+
+	buf := bufio.NewWriter(w)
+	stripper := NewByteParser()
+
+	for entry, err := range pr.ReadEntriesIter() {
+		if err != nil {
+			return err
+		}
+
+		content := entry.Content
+		if opts.StripANSI {
+			content = stripper.StripANSI(content)
+		}
+
+		prefix := ""
+		if opts.Timestamps && entry.HasTime {
+			prefix = "[" + time.UnixMilli(entry.Timestamp).In(location).Format(TextTimestampFormat) + "] "
+		}
+
+		for range 1 + max(entry.RepeatCount, 0) {
+			if _, err := fmt.Fprintf(buf, "%s%s\n", prefix, content); err != nil {
+				return err
+			}
+		}
+	}
+
+	return buf.Flush()
+}
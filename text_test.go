@@ -0,0 +1,96 @@
+package buildkitelogs
+
+import (
+	"bytes"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestWriteText(t *testing.T) {
+	testData := "\x1b_bk;t=1745322209921\x07~~~ Running tests\n" +
+		"no timestamp\n" +
+		"\x1b_bk;t=1745322209922\x07\x1b[90m$\x1b[0m make test\n" +
+		"\x1b_bk;t=1745322209923\x07waiting\n" +
+		"\x1b_bk;t=1745322209924\x07waiting\n" +
+		"\x1b_bk;t=1745322209925\x07ok"
+
+	parser := NewParserWithOptions(ParserOptions{DedupeConsecutive: true})
+
+	filename := "test_write_text.parquet"
+	defer func() {
+		_ = os.Remove(filename)
+	}()
+	if err := ExportSeq2ToParquet(parser.All(strings.NewReader(testData)), filename); err != nil {
+		t.Fatalf("ExportSeq2ToParquet() error = %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		opts     TextOptions
+		expected string
+	}{
+		{
+			name: "content only",
+			expected: "~~~ Running tests\n" +
+				"no timestamp\n" +
+				"\x1b[90m$\x1b[0m make test\n" +
+				"waiting\n" +
+				"waiting\n" +
+				"ok\n",
+		},
+		{
+			name: "timestamps and stripped ANSI",
+			opts: TextOptions{Timestamps: true, StripANSI: true},
+			expected: "[2025-04-22 11:43:29.921] ~~~ Running tests\n" +
+				"no timestamp\n" +
+				"[2025-04-22 11:43:29.922] $ make test\n" +
+				"[2025-04-22 11:43:29.923] waiting\n" +
+				"[2025-04-22 11:43:29.923] waiting\n" +
+				"[2025-04-22 11:43:29.925] ok\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out bytes.Buffer
+			if err := NewParquetReader(filename).WriteText(&out, tt.opts); err != nil {
+				t.Fatalf("WriteText() error = %v", err)
+			}
+			if out.String() != tt.expected {
+				t.Errorf("WriteText() =\n%q\nwant\n%q", out.String(), tt.expected)
+			}
+		})
+	}
+}
+
+func TestWriteTextRoundTrip(t *testing.T) {
+	log, err := os.ReadFile("testdata/bash-example.log")
+	if err != nil {
+		t.Fatalf("Failed to read test log: %v", err)
+	}
+
+	var expected strings.Builder
+	for entry, err := range NewParser().All(bytes.NewReader(log)) {
+		if err != nil {
+			t.Fatalf("Parse error: %v", err)
+		}
+		expected.WriteString(entry.Content + "\n")
+	}
+
+	filename := "test_write_text_round_trip.parquet"
+	defer func() {
+		_ = os.Remove(filename)
+	}()
+	if err := ExportSeq2ToParquet(NewParser().All(bytes.NewReader(log)), filename); err != nil {
+		t.Fatalf("ExportSeq2ToParquet() error = %v", err)
+	}
+
+	var out bytes.Buffer
+	if err := NewParquetReader(filename).WriteText(&out, TextOptions{}); err != nil {
+		t.Fatalf("WriteText() error = %v", err)
+	}
+	if out.String() != expected.String() {
+		t.Errorf("WriteText() doesn't reproduce the parsed content")
+	}
+}
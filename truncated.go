@@ -0,0 +1,626 @@
+package buildkitelogs
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+
+	"github.com/apache/arrow-go/v18/arrow"
+	"github.com/apache/arrow-go/v18/parquet"
+	"github.com/apache/arrow-go/v18/parquet/compress"
+	"github.com/apache/arrow-go/v18/parquet/file"
+	"github.com/apache/arrow-go/v18/parquet/metadata"
+	"github.com/apache/arrow-go/v18/parquet/pqarrow"
+	"github.com/apache/arrow-go/v18/parquet/schema"
+)
+
+// ErrTruncated is wrapped by the error ending reads of a truncated file opened with ReaderOptions.TolerateTruncated
+var ErrTruncated = errors.New("parquet file is truncated")
+
+// parquetMagic starts and ends every Parquet file
+const parquetMagic = "PAR1"
+
+// Parquet page types, from the PageType enum of the Parquet format
+const (
+	pageTypeData       = 0
+	pageTypeIndex      = 1
+	pageTypeDictionary = 2
+	pageTypeDataV2     = 3
+)
+
+// tolerantSource returns a parquetSource that recovers the complete row groups of a file whose footer is
+// missing, as happens when an export is killed, and otherwise reads the file unchanged
+func tolerantSource(src parquetSource) parquetSource {
+	return func() (parquet.ReaderAtSeeker, int64, func(), error) {
+		r, size, release, err := src()
+		if err != nil {
+			return nil, 0, nil, err
+		}
+
+		// The footer is probed through a section of r, so closing the probe leaves r open for release
+		probe, footerErr := file.NewParquetReader(io.NewSectionReader(r, 0, size))
+		if footerErr == nil {
+			_ = probe.Close()
+			return r, size, release, nil
+		}
+
+		recovered, err := recoverTruncatedFile(r, size, footerErr)
+		if err != nil {
+			release()
+			return nil, 0, nil, err
+		}
+		return recovered, recovered.size(), release, nil
+	}
+}
+
+// truncationWarning returns the error to end a read of r with, or nil if r is a complete file
+func truncationWarning(r parquet.ReaderAtSeeker) error {
+	if recovered, ok := r.(*recoveredFile); ok {
+		return recovered.warning
+	}
+	return nil
+}
+
+// recoveredFile presents the complete row groups of a truncated file followed by a rebuilt footer
+type recoveredFile struct {
+	data    io.ReaderAt
+	dataEnd int64 // End of the last complete row group
+	footer  []byte
+	offset  int64
+	warning error
+}
+
+// size returns the length of the recovered file
+func (f *recoveredFile) size() int64 {
+	return f.dataEnd + int64(len(f.footer))
+}
+
+// ReadAt reads the row group data from the original file and the footer from memory
+func (f *recoveredFile) ReadAt(p []byte, off int64) (int, error) {
+	if off < 0 {
+		return 0, fmt.Errorf("negative offset %d", off)
+	}
+
+	n := 0
+	if off < f.dataEnd {
+		want := min(int64(len(p)), f.dataEnd-off)
+		read, err := f.data.ReadAt(p[:want], off)
+		n += read
+		if err != nil && !(errors.Is(err, io.EOF) && int64(read) == want) {
+			return n, err
+		}
+	}
+
+	if n < len(p) {
+		footerOff := off + int64(n) - f.dataEnd
+		if footerOff < int64(len(f.footer)) {
+			n += copy(p[n:], f.footer[footerOff:])
+		}
+	}
+
+	if n < len(p) {
+		return n, io.EOF
+	}
+	return n, nil
+}
+
+// Seek sets the offset used by the Parquet reader to find the end of the file
+func (f *recoveredFile) Seek(offset int64, whence int) (int64, error) {
+	switch whence {
+	case io.SeekStart:
+	case io.SeekCurrent:
+		offset += f.offset
+	case io.SeekEnd:
+		offset += f.size()
+	default:
+		return 0, fmt.Errorf("invalid whence %d", whence)
+	}
+	if offset < 0 {
+		return 0, fmt.Errorf("negative offset %d", offset)
+	}
+	f.offset = offset
+	return offset, nil
+}
+
+// recoveredChunk locates a column chunk found by scanning page headers
+type recoveredChunk struct {
+	dictOffset       int64 // 0 when the chunk has no dictionary page
+	dataOffset       int64
+	numValues        int64
+	compressedSize   int64
+	uncompressedSize int64
+}
+
+// recoveredRowGroup is a complete row group found by scanning page headers
+type recoveredRowGroup struct {
+	chunks []recoveredChunk
+	rows   int64
+}
+
+// Errors ending a scan of row groups
+var (
+	errPagesEnd       = errors.New("pages end")
+	errLayoutMismatch = errors.New("pages don't match the column layout")
+)
+
+// recoveryLayout is a column layout written by this package, with the schema version of files that have it
+type recoveryLayout struct {
+	schema  *arrow.Schema
+	version int
+}
+
+// recoveryLayouts returns the layouts a truncated file is matched against, as its schema is lost with
+// the footer. Ties go to the earlier layout. Version 1 files have the original columns, some also raw_line_size.
+// Files written with ParquetOptions.LogicalTimestamps match the current layouts, as their timestamp pages
+// are the same INT64 milliseconds and only the lost footer annotates them, so they recover as plain int64.
+func recoveryLayouts() []recoveryLayout {
+	return []recoveryLayout{
+		{createArrowSchema(), schemaVersion},
+		{withRawContentField(createArrowSchema()), schemaVersion},
+		{originalArrowSchema("raw_line_size"), 1},
+		{originalArrowSchema(), 1},
+	}
+}
+
+// recoverTruncatedFile rebuilds the footer of a file written with a log layout from its page headers
+// Column chunks are written one after another, each starting with a dictionary page unless it's boolean,
+// so the first column ends where the second column's dictionary page starts, which gives the number of
+// rows, and every other column ends once its pages hold that many values. The file is scanned with each
+// layout until its pages run out at the truncation, and the layout recovering the most rows is used.
+// Layouts whose columns don't match the pages are skipped, footerErr is returned if there are no pages.
+func recoverTruncatedFile(r io.ReaderAt, size int64, footerErr error) (*recoveredFile, error) {
+	magic := make([]byte, len(parquetMagic))
+	if _, err := r.ReadAt(magic, 0); err != nil || string(magic) != parquetMagic {
+		return nil, footerErr
+	}
+
+	codec, err := detectPageCodec(r, size)
+	if err != nil {
+		return nil, footerErr
+	}
+
+	props := parquet.NewWriterProperties(parquet.WithCompression(codec))
+
+	var sc *schema.Schema
+	var layout recoveryLayout
+	var rowGroups []recoveredRowGroup
+	var dataEnd int64
+	var totalRows int64
+	for _, candidate := range recoveryLayouts() {
+		candidateSchema, err := pqarrow.ToParquet(candidate.schema, props, pqarrow.DefaultWriterProps())
+		if err != nil {
+			return nil, err
+		}
+
+		groups, end, err := scanRowGroups(r, size, candidateSchema)
+		if errors.Is(err, errLayoutMismatch) {
+			continue
+		}
+
+		var rows int64
+		for _, group := range groups {
+			rows += group.rows
+		}
+		if sc == nil || rows > totalRows {
+			sc, layout, rowGroups, dataEnd, totalRows = candidateSchema, candidate, groups, end, rows
+		}
+	}
+	if sc == nil {
+		return nil, fmt.Errorf("truncated parquet file has an unsupported schema: its columns don't match any log file layout")
+	}
+
+	kv := metadata.NewKeyValueMetadata()
+	if err := kv.Append(MetadataSchemaVersion, strconv.Itoa(layout.version)); err != nil {
+		return nil, err
+	}
+	builder := metadata.NewFileMetadataBuilder(sc, props, kv)
+
+	for i, group := range rowGroups {
+		rg := builder.AppendRowGroup()
+		rg.SetNumRows(int(group.rows))
+		for _, chunk := range group.chunks {
+			err := rg.NextColumnChunk().Finish(metadata.ChunkMetaInfo{
+				NumValues:        chunk.numValues,
+				DictPageOffset:   chunk.dictOffset,
+				IndexPageOffset:  -1,
+				DataPageOffset:   chunk.dataOffset,
+				CompressedSize:   chunk.compressedSize,
+				UncompressedSize: chunk.uncompressedSize,
+			}, chunk.dictOffset > 0, false, metadata.EncodingStats{})
+			if err != nil {
+				return nil, err
+			}
+		}
+		if err := rg.Finish(0, int16(i)); err != nil {
+			return nil, err
+		}
+	}
+
+	meta, err := builder.Finish()
+	if err != nil {
+		return nil, err
+	}
+
+	var footer bytes.Buffer
+	if _, err := meta.WriteTo(&footer, nil); err != nil {
+		return nil, fmt.Errorf("failed to write recovered footer: %w", err)
+	}
+	footerLen := footer.Len()
+	_ = binary.Write(&footer, binary.LittleEndian, uint32(footerLen))
+	footer.WriteString(parquetMagic)
+
+	return &recoveredFile{
+		data:    r,
+		dataEnd: dataEnd,
+		footer:  footer.Bytes(),
+		warning: fmt.Errorf("%w: read the %d complete row groups (%d rows)", ErrTruncated, len(rowGroups), totalRows),
+	}, nil
+}
+
+// scanRowGroups scans the complete row groups of a file written with the schema, returning them and the
+// offset after the last. The error is errPagesEnd once the pages run out at the truncation, or
+// errLayoutMismatch if they don't fit the schema's columns.
+func scanRowGroups(r io.ReaderAt, size int64, sc *schema.Schema) ([]recoveredRowGroup, int64, error) {
+	var groups []recoveredRowGroup
+	pos := int64(len(parquetMagic))
+	for {
+		chunks, rows, end, err := scanRowGroup(r, size, pos, sc)
+		if err != nil {
+			return groups, pos, err
+		}
+		groups = append(groups, recoveredRowGroup{chunks: chunks, rows: rows})
+		pos = end
+	}
+}
+
+// scanRowGroup scans the column chunks of the row group starting at pos, returning the chunks, the number
+// of rows and the offset after the row group
+// Each chunk must start with a dictionary page unless its column is boolean, and a fixed width column's
+// dictionary must hold values of its width, which tells apart layouts whose columns differ.
+func scanRowGroup(r io.ReaderAt, size, pos int64, sc *schema.Schema) ([]recoveredChunk, int64, int64, error) {
+	chunks := make([]recoveredChunk, sc.NumColumns())
+	rows := int64(-1)
+
+	for c := range chunks {
+		chunk := &chunks[c]
+		column := sc.Column(c)
+		for rows < 0 || chunk.numValues < rows {
+			header, headerLen, err := readPageHeader(r, size, pos)
+			if err != nil {
+				return nil, 0, 0, errPagesEnd
+			}
+
+			if header.pageType == pageTypeDictionary {
+				if c == 0 && chunk.numValues > 0 {
+					rows = chunk.numValues // The second column's dictionary ends the first column
+					break
+				}
+				if chunk.dictOffset > 0 || chunk.numValues > 0 || !dictionaryFits(column.PhysicalType(), header) {
+					return nil, 0, 0, errLayoutMismatch
+				}
+				chunk.dictOffset = pos
+			} else if chunk.dictOffset == 0 && chunk.numValues == 0 && column.PhysicalType() != parquet.Types.Boolean {
+				return nil, 0, 0, errLayoutMismatch // Only boolean chunks start without a dictionary
+			}
+
+			end := pos + headerLen + int64(header.compressedSize)
+			if header.compressedSize < 0 || end > size {
+				return nil, 0, 0, errPagesEnd
+			}
+
+			switch header.pageType {
+			case pageTypeData, pageTypeDataV2:
+				if chunk.numValues == 0 {
+					chunk.dataOffset = pos
+				}
+				chunk.numValues += int64(header.numValues)
+			case pageTypeDictionary, pageTypeIndex:
+			default:
+				return nil, 0, 0, errPagesEnd
+			}
+
+			chunk.compressedSize += headerLen + int64(header.compressedSize)
+			chunk.uncompressedSize += headerLen + int64(header.uncompressedSize)
+			pos = end
+		}
+
+		if chunk.numValues == 0 || chunk.numValues != rows {
+			return nil, 0, 0, errLayoutMismatch
+		}
+	}
+
+	return chunks, rows, pos, nil
+}
+
+// dictionaryFits returns true if a dictionary page can hold the plain encoded values of the physical type
+// Booleans are never dictionary encoded, fixed width values fill the page exactly and byte arrays start
+// with a 4 byte length.
+func dictionaryFits(typ parquet.Type, header pageHeader) bool {
+	size := int64(header.uncompressedSize)
+	values := int64(header.numValues)
+	switch typ {
+	case parquet.Types.Boolean:
+		return false
+	case parquet.Types.Int32, parquet.Types.Float:
+		return size == 4*values
+	case parquet.Types.Int64, parquet.Types.Double:
+		return size == 8*values
+	case parquet.Types.ByteArray:
+		return size >= 4*values
+	default:
+		return true
+	}
+}
+
+// pageHeader holds the fields of a Parquet page header needed to find column chunks
+type pageHeader struct {
+	pageType         int32
+	uncompressedSize int32
+	compressedSize   int32
+	numValues        int32
+}
+
+// maxPageHeaderBytes bounds how far a page header is read, page statistics make up most of it
+const maxPageHeaderBytes = 1 << 20
+
+// readPageHeader decodes the page header at pos, returning it and its length in bytes
+func readPageHeader(r io.ReaderAt, size, pos int64) (pageHeader, int64, error) {
+	for limit := int64(256); ; limit *= 4 {
+		n := min(limit, size-pos)
+		if n <= 0 {
+			return pageHeader{}, 0, io.ErrUnexpectedEOF
+		}
+
+		buf := make([]byte, n)
+		if _, err := r.ReadAt(buf, pos); err != nil && !errors.Is(err, io.EOF) {
+			return pageHeader{}, 0, err
+		}
+
+		dec := &thriftDecoder{buf: buf}
+		header, err := dec.pageHeader()
+		if errors.Is(err, io.ErrUnexpectedEOF) && n == limit && limit < maxPageHeaderBytes {
+			continue // The header is longer than the bytes read
+		}
+		return header, int64(dec.pos), err
+	}
+}
+
+// detectPageCodec works out the file's compression codec by decompressing its first page with each codec
+func detectPageCodec(r io.ReaderAt, size int64) (compress.Compression, error) {
+	pos := int64(len(parquetMagic))
+	header, headerLen, err := readPageHeader(r, size, pos)
+	if err != nil {
+		return 0, err
+	}
+	if header.compressedSize < 0 || header.uncompressedSize < 0 || pos+headerLen+int64(header.compressedSize) > size {
+		return 0, io.ErrUnexpectedEOF
+	}
+
+	page := make([]byte, header.compressedSize)
+	if _, err := r.ReadAt(page, pos+headerLen); err != nil && !errors.Is(err, io.EOF) {
+		return 0, err
+	}
+
+	candidates := []compress.Compression{
+		compress.Codecs.Zstd, compress.Codecs.Snappy, compress.Codecs.Gzip,
+		compress.Codecs.Lz4Raw, compress.Codecs.Lz4, compress.Codecs.Brotli,
+	}
+	for _, candidate := range candidates {
+		if decodesTo(candidate, page, int(header.uncompressedSize)) {
+			return candidate, nil
+		}
+	}
+	if header.compressedSize == header.uncompressedSize {
+		return compress.Codecs.Uncompressed, nil
+	}
+
+	return 0, fmt.Errorf("unknown page compression")
+}
+
+// decodesTo returns true if page decompresses with the codec to exactly size bytes
+func decodesTo(codec compress.Compression, page []byte, size int) (ok bool) {
+	c, err := compress.GetCodec(codec)
+	if err != nil {
+		return false
+	}
+
+	// Codecs panic on corrupt input
+	defer func() {
+		if recover() != nil {
+			ok = false
+		}
+	}()
+
+	return len(c.Decode(make([]byte, size), page)) == size
+}
+
+// thriftDecoder reads the Thrift compact protocol encoding used by Parquet page headers
+type thriftDecoder struct {
+	buf []byte
+	pos int
+}
+
+// Thrift compact protocol field types
+const (
+	thriftBoolTrue  = 1
+	thriftBoolFalse = 2
+	thriftByte      = 3
+	thriftI16       = 4
+	thriftI32       = 5
+	thriftI64       = 6
+	thriftDouble    = 7
+	thriftBinary    = 8
+	thriftList      = 9
+	thriftSet       = 10
+	thriftMap       = 11
+	thriftStruct    = 12
+)
+
+// pageHeader decodes a PageHeader struct, keeping the fields in pageHeader
+func (d *thriftDecoder) pageHeader() (pageHeader, error) {
+	var header pageHeader
+	err := d.fields(func(id int16, typ byte) error {
+		var err error
+		switch {
+		case id == 1 && typ == thriftI32:
+			header.pageType, err = d.i32()
+		case id == 2 && typ == thriftI32:
+			header.uncompressedSize, err = d.i32()
+		case id == 3 && typ == thriftI32:
+			header.compressedSize, err = d.i32()
+		case (id == 5 || id == 7 || id == 8) && typ == thriftStruct:
+			// Data, dictionary and v2 data page headers all start with num_values
+			err = d.fields(func(id int16, typ byte) error {
+				if id == 1 && typ == thriftI32 {
+					header.numValues, err = d.i32()
+					return err
+				}
+				return d.skip(typ)
+			})
+		default:
+			err = d.skip(typ)
+		}
+		return err
+	})
+	return header, err
+}
+
+// fields calls field for each field of a struct until its stop field
+func (d *thriftDecoder) fields(field func(id int16, typ byte) error) error {
+	var id int16
+	for {
+		b, err := d.byte()
+		if err != nil {
+			return err
+		}
+		if b == 0 {
+			return nil
+		}
+
+		typ := b & 0x0f
+		if delta := int16(b >> 4); delta != 0 {
+			id += delta
+		} else {
+			v, err := d.varint()
+			if err != nil {
+				return err
+			}
+			id = int16(zigzag(v))
+		}
+
+		if err := field(id, typ); err != nil {
+			return err
+		}
+	}
+}
+
+// skip skips a value of the given type
+func (d *thriftDecoder) skip(typ byte) error {
+	switch typ {
+	case thriftBoolTrue, thriftBoolFalse:
+		return nil
+	case thriftByte:
+		_, err := d.byte()
+		return err
+	case thriftI16, thriftI32, thriftI64:
+		_, err := d.varint()
+		return err
+	case thriftDouble:
+		return d.advance(8)
+	case thriftBinary:
+		n, err := d.varint()
+		if err != nil {
+			return err
+		}
+		return d.advance(n)
+	case thriftList, thriftSet:
+		b, err := d.byte()
+		if err != nil {
+			return err
+		}
+		n := uint64(b >> 4)
+		if n == 15 {
+			if n, err = d.varint(); err != nil {
+				return err
+			}
+		}
+		for range n {
+			// Booleans in lists take a byte each
+			elem := b & 0x0f
+			if elem == thriftBoolTrue || elem == thriftBoolFalse {
+				elem = thriftByte
+			}
+			if err := d.skip(elem); err != nil {
+				return err
+			}
+		}
+		return nil
+	case thriftMap:
+		n, err := d.varint()
+		if err != nil || n == 0 {
+			return err
+		}
+		types, err := d.byte()
+		if err != nil {
+			return err
+		}
+		for range n {
+			if err := d.skip(types >> 4); err != nil {
+				return err
+			}
+			if err := d.skip(types & 0x0f); err != nil {
+				return err
+			}
+		}
+		return nil
+	case thriftStruct:
+		return d.fields(func(_ int16, typ byte) error { return d.skip(typ) })
+	default:
+		return fmt.Errorf("invalid thrift type %d", typ)
+	}
+}
+
+// i32 reads a zigzag encoded 32-bit integer
+func (d *thriftDecoder) i32() (int32, error) {
+	v, err := d.varint()
+	return int32(zigzag(v)), err
+}
+
+// varint reads an unsigned LEB128 varint
+func (d *thriftDecoder) varint() (uint64, error) {
+	v, n := binary.Uvarint(d.buf[d.pos:])
+	if n == 0 {
+		return 0, io.ErrUnexpectedEOF
+	}
+	if n < 0 {
+		return 0, fmt.Errorf("invalid varint")
+	}
+	d.pos += n
+	return v, nil
+}
+
+// byte reads a single byte
+func (d *thriftDecoder) byte() (byte, error) {
+	if d.pos >= len(d.buf) {
+		return 0, io.ErrUnexpectedEOF
+	}
+	d.pos++
+	return d.buf[d.pos-1], nil
+}
+
+// advance skips n bytes
+func (d *thriftDecoder) advance(n uint64) error {
+	if n > uint64(len(d.buf)-d.pos) {
+		return io.ErrUnexpectedEOF
+	}
+	d.pos += int(n)
+	return nil
+}
+
+// zigzag decodes a zigzag encoded integer
+func zigzag(v uint64) int64 {
+	return int64(v>>1) ^ -int64(v&1)
+}
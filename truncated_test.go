@@ -0,0 +1,275 @@
+package buildkitelogs
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/apache/arrow-go/v18/arrow"
+	"github.com/apache/arrow-go/v18/arrow/array"
+	"github.com/apache/arrow-go/v18/arrow/memory"
+	"github.com/apache/arrow-go/v18/parquet/file"
+)
+
+func TestTolerateTruncated(t *testing.T) {
+	log, err := os.ReadFile("testdata/bash-example.log")
+	if err != nil {
+		t.Fatalf("Failed to read test log: %v", err)
+	}
+
+	for _, codec := range []string{"zstd", "snappy", "gzip", "none"} {
+		t.Run(codec, func(t *testing.T) {
+			var buf bytes.Buffer
+			opts := ParquetOptions{Compression: codec, RowGroupSize: 50}
+			if err := ExportSeq2ToParquetWriter(NewParser().All(bytes.NewReader(log)), &buf, opts); err != nil {
+				t.Fatalf("ExportSeq2ToParquetWriter() error = %v", err)
+			}
+
+			complete, err := NewParquetReaderFromReaderAt(bytes.NewReader(buf.Bytes()), int64(buf.Len())).ReadEntries()
+			if err != nil {
+				t.Fatalf("ReadEntries() error = %v", err)
+			}
+			if len(complete) <= 150 {
+				t.Fatalf("Expected more than 150 entries, got %d", len(complete))
+			}
+
+			// Cut the file part way through its fourth row group
+			info, err := NewParquetReaderFromReaderAt(bytes.NewReader(buf.Bytes()), int64(buf.Len())).GetFileInfo()
+			if err != nil {
+				t.Fatalf("GetFileInfo() error = %v", err)
+			}
+			if info.NumRowGroups < 4 {
+				t.Fatalf("Expected at least 4 row groups, got %d", info.NumRowGroups)
+			}
+			cut := rowGroupOffset(t, buf.Bytes(), 3) + 20
+
+			filename := "test_truncated_" + codec + ".parquet"
+			defer func() {
+				_ = os.Remove(filename)
+			}()
+			if err := os.WriteFile(filename, buf.Bytes()[:cut], 0o644); err != nil {
+				t.Fatalf("Failed to write truncated file: %v", err)
+			}
+
+			if _, err := NewParquetReader(filename).ReadEntries(); err == nil {
+				t.Fatal("Expected reading a truncated file to fail without TolerateTruncated")
+			}
+
+			var entries []ParquetLogEntry
+			var warning error
+			for entry, err := range NewParquetReaderWithOptions(filename, ReaderOptions{TolerateTruncated: true}).ReadEntriesIter() {
+				if err != nil {
+					warning = err
+					break
+				}
+				entries = append(entries, entry)
+			}
+
+			if !errors.Is(warning, ErrTruncated) {
+				t.Errorf("Expected the read to end with ErrTruncated, got %v", warning)
+			}
+			if len(entries) != 150 {
+				t.Fatalf("Expected the 150 entries of the complete row groups, got %d", len(entries))
+			}
+			for i, entry := range entries {
+				if entry != complete[i] {
+					t.Fatalf("Entry %d = %+v, want %+v", i, entry, complete[i])
+				}
+			}
+		})
+	}
+}
+
+func TestTolerateTruncatedCompleteFile(t *testing.T) {
+	reader := NewParquetReaderWithOptions("testdata/bash-example.parquet", ReaderOptions{TolerateTruncated: true})
+
+	entries, err := reader.ReadEntries()
+	if err != nil {
+		t.Fatalf("ReadEntries() error = %v", err)
+	}
+	expected, err := NewParquetReader("testdata/bash-example.parquet").ReadEntries()
+	if err != nil {
+		t.Fatalf("ReadEntries() error = %v", err)
+	}
+	if len(entries) != len(expected) {
+		t.Errorf("Expected %d entries, got %d", len(expected), len(entries))
+	}
+}
+
+func TestTolerateTruncatedNotParquet(t *testing.T) {
+	reader := NewParquetReaderWithOptions("testdata/bash-example.log", ReaderOptions{TolerateTruncated: true})
+
+	for _, err := range reader.ReadEntriesIter() {
+		if err == nil || errors.Is(err, ErrTruncated) {
+			t.Fatalf("Expected a file that isn't Parquet to fail, got %v", err)
+		}
+		break
+	}
+}
+
+func TestTolerateTruncatedLayouts(t *testing.T) {
+	log, err := os.ReadFile("testdata/bash-example.log")
+	if err != nil {
+		t.Fatalf("Failed to read test log: %v", err)
+	}
+	export := func(opts ParquetOptions) []byte {
+		var buf bytes.Buffer
+		opts.RowGroupSize = 50
+		if err := ExportSeq2ToParquetWriter(NewParser().All(bytes.NewReader(log)), &buf, opts); err != nil {
+			t.Fatalf("ExportSeq2ToParquetWriter() error = %v", err)
+		}
+		return buf.Bytes()
+	}
+	v1, err := os.ReadFile("testdata/bazel-bazel_build_32517_rocky-rocky-linux-8.parquet")
+	if err != nil {
+		t.Fatalf("Failed to read test file: %v", err)
+	}
+
+	tests := []struct {
+		name        string
+		data        []byte
+		wantVersion int
+	}{
+		{name: "version 1", data: v1, wantVersion: 1},
+		{name: "raw content", data: export(ParquetOptions{StripANSIOnWrite: true, KeepRawContent: true}), wantVersion: schemaVersion},
+		{name: "logical timestamps", data: export(ParquetOptions{LogicalTimestamps: true}), wantVersion: schemaVersion},
+		{
+			name:        "logical timestamps with raw content",
+			data:        export(ParquetOptions{LogicalTimestamps: true, StripANSIOnWrite: true, KeepRawContent: true}),
+			wantVersion: schemaVersion,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			complete, err := NewParquetReaderFromReaderAt(bytes.NewReader(tt.data), int64(len(tt.data))).ReadEntries()
+			if err != nil {
+				t.Fatalf("ReadEntries() error = %v", err)
+			}
+
+			filename := writeTruncated(t, tt.data, 3)
+			reader := NewParquetReaderWithOptions(filename, ReaderOptions{TolerateTruncated: true})
+
+			var entries []ParquetLogEntry
+			var warning error
+			for entry, err := range reader.ReadEntriesIter() {
+				if err != nil {
+					warning = err
+					break
+				}
+				entries = append(entries, entry)
+			}
+
+			if !errors.Is(warning, ErrTruncated) {
+				t.Errorf("Expected the read to end with ErrTruncated, got %v", warning)
+			}
+			if want := rowsBefore(t, tt.data, 3); int64(len(entries)) != want {
+				t.Fatalf("Expected the %d entries of the complete row groups, got %d", want, len(entries))
+			}
+			for i, entry := range entries {
+				if entry != complete[i] {
+					t.Fatalf("Entry %d = %+v, want %+v", i, entry, complete[i])
+				}
+			}
+
+			version, err := reader.SchemaVersion()
+			if err != nil {
+				t.Fatalf("SchemaVersion() error = %v", err)
+			}
+			if version != tt.wantVersion {
+				t.Errorf("SchemaVersion() = %d, want %d", version, tt.wantVersion)
+			}
+		})
+	}
+}
+
+func TestTolerateTruncatedUnsupportedSchema(t *testing.T) {
+	schema := arrow.NewSchema([]arrow.Field{
+		{Name: "timestamp", Type: arrow.PrimitiveTypes.Int64},
+		{Name: "value", Type: arrow.PrimitiveTypes.Float64},
+	}, nil)
+
+	pool := memory.NewGoAllocator()
+	builder := array.NewRecordBuilder(pool, schema)
+	defer builder.Release()
+	for i := range 200 {
+		builder.Field(0).(*array.Int64Builder).Append(1745322209921 + int64(i))
+		builder.Field(1).(*array.Float64Builder).Append(float64(i % 7))
+	}
+	record := builder.NewRecord()
+	defer record.Release()
+
+	var buf bytes.Buffer
+	writer, err := createNewFileWriter(schema, &buf, pool, ParquetOptions{RowGroupSize: 50})
+	if err != nil {
+		t.Fatalf("createNewFileWriter() error = %v", err)
+	}
+	if err := writer.Write(record); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+
+	filename := writeTruncated(t, buf.Bytes(), 3)
+	for _, err := range NewParquetReaderWithOptions(filename, ReaderOptions{TolerateTruncated: true}).ReadEntriesIter() {
+		if err == nil || errors.Is(err, ErrTruncated) || !strings.Contains(err.Error(), "unsupported schema") {
+			t.Fatalf("Expected an unsupported schema error, got %v", err)
+		}
+		break
+	}
+}
+
+// writeTruncated writes a complete Parquet file cut part way through a row group to a temporary file
+func writeTruncated(t *testing.T, data []byte, rowGroup int) string {
+	t.Helper()
+
+	filename := filepath.Join(t.TempDir(), "truncated.parquet")
+	if err := os.WriteFile(filename, data[:rowGroupOffset(t, data, rowGroup)+20], 0o644); err != nil {
+		t.Fatalf("Failed to write truncated file: %v", err)
+	}
+	return filename
+}
+
+// rowsBefore returns the number of rows in the row groups before a row group of a complete Parquet file
+func rowsBefore(t *testing.T, data []byte, rowGroup int) int64 {
+	t.Helper()
+
+	pf, err := file.NewParquetReader(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("Failed to open parquet file: %v", err)
+	}
+	var rows int64
+	for i := range rowGroup {
+		rows += pf.MetaData().RowGroup(i).NumRows()
+	}
+	return rows
+}
+
+// rowGroupOffset returns the file offset of a row group in a complete Parquet file
+func rowGroupOffset(t *testing.T, data []byte, rowGroup int) int64 {
+	t.Helper()
+
+	src := readerAtSource(bytes.NewReader(data), int64(len(data)))
+	r, _, release, err := src()
+	if err != nil {
+		t.Fatalf("Failed to open file: %v", err)
+	}
+	defer release()
+
+	pf, err := file.NewParquetReader(r)
+	if err != nil {
+		t.Fatalf("Failed to open parquet file: %v", err)
+	}
+	chunk, err := pf.MetaData().RowGroup(rowGroup).ColumnChunk(0)
+	if err != nil {
+		t.Fatalf("Failed to read row group metadata: %v", err)
+	}
+	if chunk.HasDictionaryPage() {
+		return chunk.DictionaryPageOffset()
+	}
+	return chunk.DataPageOffset()
+}
@@ -0,0 +1,129 @@
+package buildkitelogs
+
+import (
+	"fmt"
+	"io"
+	"iter"
+	"os"
+	"strconv"
+)
+
+// VerifyMismatch is the first difference found between a parsed log and its Parquet round trip
+type VerifyMismatch struct {
+	Entry      int64  `json:"entry"`       // 1-based position of the entry in the log
+	LineNumber int64  `json:"line_number"` // Line number of the parsed entry, 0 if the Parquet file has extra rows
+	Field      string `json:"field"`       // "content", "timestamp", "group", or "entry" when one side has no entry
+	Parsed     string `json:"parsed"`
+	RoundTrip  string `json:"round_trip"`
+}
+
+// VerifyResult is the outcome of VerifyRoundTrip
+type VerifyResult struct {
+	Entries  int64           `json:"entries"`            // Entries compared, up to and including any mismatch
+	Mismatch *VerifyMismatch `json:"mismatch,omitempty"` // Nil if the round trip is lossless
+}
+
+// VerifyRoundTrip checks that a log survives export to Parquet unchanged
+// The log at path is parsed and exported to a temporary Parquet file, which is read back and compared
+// entry by entry with a second parse of the log. Content, timestamp and group must match, and the
+// comparison stops at the first mismatch. Both the export and the comparison stream, so large logs
+// aren't held in memory. The log is read twice, so path must be a file rather than a pipe.
+func VerifyRoundTrip(path string) (*VerifyResult, error) {
+	tmp, err := os.CreateTemp("", "bklog-verify-*.parquet")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create temporary file: %w", err)
+	}
+	defer func() { _ = os.Remove(tmp.Name()) }()
+
+	err = withLogFile(path, func(r io.Reader) error {
+		return ExportSeq2ToParquetWriter(NewParser().All(r), tmp, ParquetOptions{})
+	})
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to export to Parquet: %w", err)
+	}
+
+	var result *VerifyResult
+	err = withLogFile(path, func(r io.Reader) error {
+		result, err = compareRoundTrip(NewParser().All(r), NewParquetReader(tmp.Name()).ReadEntriesIter())
+		return err
+	})
+	return result, err
+}
+
+// withLogFile calls fn with the opened log file at path, closing it afterwards
+func withLogFile(path string, fn func(r io.Reader) error) error {
+	file, err := OpenLogFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to open log file: %w", err)
+	}
+	defer func() { _ = file.Close() }()
+
+	return fn(file)
+}
+
+// compareRoundTrip compares parsed entries with the rows read back from Parquet, stopping at the first mismatch
+func compareRoundTrip(parsed iter.Seq2[*LogEntry, error], rows iter.Seq2[ParquetLogEntry, error]) (*VerifyResult, error) {
+	next, stop := iter.Pull2(rows)
+	defer stop()
+
+	result := &VerifyResult{}
+	for entry, err := range parsed {
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse log: %w", err)
+		}
+		result.Entries++
+
+		row, err, ok := next()
+		if err != nil {
+			return nil, fmt.Errorf("failed to read Parquet file: %w", err)
+		}
+		if !ok {
+			result.Mismatch = &VerifyMismatch{Entry: result.Entries, LineNumber: entry.LineNumber, Field: "entry", Parsed: entry.Content, RoundTrip: "<missing>"}
+			return result, nil
+		}
+
+		if mismatch := compareEntry(entry, row); mismatch != nil {
+			mismatch.Entry = result.Entries
+			result.Mismatch = mismatch
+			return result, nil
+		}
+	}
+
+	row, err, ok := next()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read Parquet file: %w", err)
+	}
+	if ok {
+		result.Entries++
+		result.Mismatch = &VerifyMismatch{Entry: result.Entries, Field: "entry", Parsed: "<missing>", RoundTrip: row.Content}
+	}
+	return result, nil
+}
+
+// compareEntry returns the first field that differs between a parsed entry and its Parquet row, or nil
+func compareEntry(entry *LogEntry, row ParquetLogEntry) *VerifyMismatch {
+	mismatch := func(field, parsed, roundTrip string) *VerifyMismatch {
+		return &VerifyMismatch{LineNumber: entry.LineNumber, Field: field, Parsed: parsed, RoundTrip: roundTrip}
+	}
+
+	switch {
+	case entry.Content != row.Content:
+		return mismatch("content", entry.Content, row.Content)
+	case entry.HasTimestamp() != row.HasTime || (row.HasTime && entry.Timestamp.UnixMilli() != row.Timestamp):
+		return mismatch("timestamp", formatVerifyTimestamp(entry.HasTimestamp(), entry.Timestamp.UnixMilli()), formatVerifyTimestamp(row.HasTime, row.Timestamp))
+	case entry.Group != row.Group:
+		return mismatch("group", entry.Group, row.Group)
+	}
+	return nil
+}
+
+// formatVerifyTimestamp formats a millisecond timestamp for a mismatch, or "<none>" if there isn't one
+func formatVerifyTimestamp(hasTime bool, ms int64) string {
+	if !hasTime {
+		return "<none>"
+	}
+	return strconv.FormatInt(ms, 10)
+}
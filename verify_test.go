@@ -0,0 +1,83 @@
+package buildkitelogs
+
+import (
+	"iter"
+	"strings"
+	"testing"
+)
+
+func TestVerifyRoundTrip(t *testing.T) {
+	result, err := VerifyRoundTrip("testdata/bash-example.log")
+	if err != nil {
+		t.Fatalf("VerifyRoundTrip() error = %v", err)
+	}
+	if result.Mismatch != nil {
+		t.Errorf("Expected a lossless round trip, got mismatch %+v", result.Mismatch)
+	}
+	if result.Entries != 212 {
+		t.Errorf("Expected 212 entries checked, got %d", result.Entries)
+	}
+}
+
+func TestCompareRoundTrip(t *testing.T) {
+	log := "\x1b_bk;t=1745322209921\x07~~~ Running tests\n\x1b_bk;t=1745322209922\x07$ make test\nok\n"
+
+	rowsOf := func(t *testing.T, modify func([]ParquetLogEntry) []ParquetLogEntry) iter.Seq2[ParquetLogEntry, error] {
+		var rows []ParquetLogEntry
+		for entry, err := range NewParser().All(strings.NewReader(log)) {
+			if err != nil {
+				t.Fatalf("All() error = %v", err)
+			}
+			rows = append(rows, ParquetLogEntry{
+				Timestamp: entry.Timestamp.UnixMilli(),
+				Content:   entry.Content,
+				Group:     entry.Group,
+				HasTime:   entry.HasTimestamp(),
+			})
+		}
+		rows = modify(rows)
+		return func(yield func(ParquetLogEntry, error) bool) {
+			for _, row := range rows {
+				if !yield(row, nil) {
+					return
+				}
+			}
+		}
+	}
+
+	tests := []struct {
+		name      string
+		modify    func([]ParquetLogEntry) []ParquetLogEntry
+		wantField string
+		wantEntry int64
+	}{
+		{"lossless", func(rows []ParquetLogEntry) []ParquetLogEntry { return rows }, "", 3},
+		{"content", func(rows []ParquetLogEntry) []ParquetLogEntry { rows[1].Content = "$ make"; return rows }, "content", 2},
+		{"timestamp", func(rows []ParquetLogEntry) []ParquetLogEntry { rows[0].Timestamp++; return rows }, "timestamp", 1},
+		{"lost timestamp", func(rows []ParquetLogEntry) []ParquetLogEntry { rows[1].HasTime = false; return rows }, "timestamp", 2},
+		{"group", func(rows []ParquetLogEntry) []ParquetLogEntry { rows[2].Group = ""; return rows }, "group", 3},
+		{"missing row", func(rows []ParquetLogEntry) []ParquetLogEntry { return rows[:2] }, "entry", 3},
+		{"extra row", func(rows []ParquetLogEntry) []ParquetLogEntry { return append(rows, ParquetLogEntry{Content: "extra"}) }, "entry", 4},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := compareRoundTrip(NewParser().All(strings.NewReader(log)), rowsOf(t, tt.modify))
+			if err != nil {
+				t.Fatalf("compareRoundTrip() error = %v", err)
+			}
+			if result.Entries != tt.wantEntry {
+				t.Errorf("Expected %d entries, got %d", tt.wantEntry, result.Entries)
+			}
+			if tt.wantField == "" {
+				if result.Mismatch != nil {
+					t.Errorf("Expected no mismatch, got %+v", result.Mismatch)
+				}
+				return
+			}
+			if result.Mismatch == nil || result.Mismatch.Field != tt.wantField || result.Mismatch.Entry != tt.wantEntry {
+				t.Errorf("Expected %s mismatch at entry %d, got %+v", tt.wantField, tt.wantEntry, result.Mismatch)
+			}
+		})
+	}
+}